@@ -0,0 +1,35 @@
+// Command migrate rewrites the documents of the orders service stored in
+// earlier formats. It takes the configuration of the server.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	firebase "github.com/leta/order-management-system/orders/db/firebase"
+	"github.com/leta/order-management-system/orders/internal/config"
+	"github.com/leta/order-management-system/orders/internal/migrate"
+	pkgconfig "github.com/leta/order-management-system/orders/pkg/config"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+func main() {
+	ctx := context.Background()
+
+	conf := config.Default()
+	pkgconfig.MustLoad(utils.SERVICE_NAME, conf, os.Args[1:])
+
+	firestoreClient, err := firebase.NewFirebaseService(conf.Firebase).GetApp().Firestore(ctx)
+	if err != nil {
+		log.Fatalf("failed to create firestore client: %v", err)
+	}
+	defer firestoreClient.Close()
+
+	n, err := migrate.Money(ctx, firestoreClient)
+	if err != nil {
+		log.Fatalf("migration failed after rewriting %d documents: %v", n, err)
+	}
+
+	log.Printf("Rewrote %d documents", n)
+}
@@ -4,49 +4,76 @@ import (
 	"context"
 	db "github.com/leta/order-management-system/orders/db/firebase"
 	firebase2 "github.com/leta/order-management-system/orders/db/firebase"
+	cartapi "github.com/leta/order-management-system/orders/internal/api/carts"
+	categoryapi "github.com/leta/order-management-system/orders/internal/api/categories"
 	"github.com/leta/order-management-system/orders/internal/api/customers"
+	notificationapi "github.com/leta/order-management-system/orders/internal/api/notifications"
 	"github.com/leta/order-management-system/orders/internal/api/orders"
+	pricelistapi "github.com/leta/order-management-system/orders/internal/api/pricelists"
 	"github.com/leta/order-management-system/orders/internal/api/product"
+	promotionapi "github.com/leta/order-management-system/orders/internal/api/promotions"
+	webhookapi "github.com/leta/order-management-system/orders/internal/api/webhooks"
 	"log"
 	"os"
+	"time"
 
 	"github.com/leta/order-management-system/orders/internal/checkout"
+	"github.com/leta/order-management-system/orders/internal/config"
+	"github.com/leta/order-management-system/orders/internal/expiry"
 	"github.com/leta/order-management-system/orders/internal/handlers"
+	"github.com/leta/order-management-system/orders/internal/handlers/http"
+	"github.com/leta/order-management-system/orders/internal/notifications"
+	"github.com/leta/order-management-system/orders/internal/purge"
+	"github.com/leta/order-management-system/orders/internal/refunds"
+	"github.com/leta/order-management-system/orders/internal/subscribers"
+	"github.com/leta/order-management-system/orders/internal/tax"
+	"github.com/leta/order-management-system/orders/internal/webhooks"
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	pkgconfig "github.com/leta/order-management-system/orders/pkg/config"
+	"github.com/leta/order-management-system/orders/pkg/events"
+	"github.com/leta/order-management-system/orders/pkg/health"
+	"github.com/leta/order-management-system/orders/pkg/lifecycle"
+	"github.com/leta/order-management-system/orders/pkg/metrics"
+	"github.com/leta/order-management-system/orders/pkg/outbox"
+	"github.com/leta/order-management-system/orders/pkg/tracing"
+	"github.com/leta/order-management-system/orders/pkg/utils"
 	p "github.com/leta/order-management-system/payments/pkg/client"
 )
 
 const (
-	BIND_ADDRESS = "BIND_ADDRESS"
-	PORT         = "PORT"
-
-	DEFAULT_BIND_ADDRESS = "localhost"
-	DEFAULT_PORT         = "50051"
+	ORDER_METRICS_INTERVAL = time.Minute
 )
 
 func main() {
 
 	ctx := context.Background()
 
+	conf := config.Default()
+	pkgconfig.MustLoad(utils.SERVICE_NAME, conf, os.Args[1:])
+
 	log.Printf("Starting server")
 
-	bindAddress := os.Getenv(BIND_ADDRESS)
-	if bindAddress == "" {
-		bindAddress = DEFAULT_BIND_ADDRESS
-	}
+	bindAddress := conf.Server.BindAddress
+	port := conf.Server.Port
 
-	port := os.Getenv(PORT)
-	if port == "" {
-		port = DEFAULT_PORT
+	manager := lifecycle.NewManager()
+	manager.GracePeriod = conf.Server.ShutdownGracePeriod
+
+	shutdownTracing, err := tracing.Setup(ctx, utils.SERVICE_NAME, conf.Tracing.Exporter)
+	if err != nil {
+		log.Fatalf("failed to setup tracing: %v", err)
 	}
+	manager.OnShutdown("tracing", shutdownTracing)
 
 	s := handlers.NewGRPCServer()
 
-	firebase := firebase2.NewFirebaseService()
+	firebase := firebase2.NewFirebaseService(conf.Firebase,
+		append(metrics.FirestoreClientOptions(), tracing.FirestoreClientOptions()...)...)
 	firestoreClient, err := firebase.GetApp().Firestore(ctx)
 	if err != nil {
 		log.Fatalf("failed to create firestore client: %v", err)
 	}
-	defer firestoreClient.Close()
+	manager.OnShutdown("firestore", func(context.Context) error { return firestoreClient.Close() })
 
 	firestoreService := db.NewFirestoreService(firestoreClient)
 
@@ -59,21 +86,128 @@ func main() {
 	orderRepository := orders.NewOrderRepository(firestoreService)
 	orderSvc := orders.NewOrdersService(*orderRepository)
 	// Setup payments service client
-	conn, err := p.ConnectToPaymentService("localhost:50051")
+	conn, err := p.ConnectToPaymentService(conf.Payments.Addr, conf.Payments.Client)
 	if err != nil {
-		log.Fatalf("Failed to connect to orders service: %v", err)
+		log.Fatalf("Failed to connect to payments service: %v", err)
 	}
+	manager.OnShutdown("payments client", func(context.Context) error { return conn.Close() })
 	paymentsClient := p.NewGrpcPaymentsClient(conn)
 
-	checkoutService := checkout.NewCheckoutService(
-		productRepository, customerRepository, orderRepository, paymentsClient)
+	promotionRepository := promotionapi.NewPromotionRepository(firestoreService)
+	priceListRepository := pricelistapi.NewPriceListRepository(firestoreService)
+	checkoutService := checkout.NewCheckoutService(productRepository, customerRepository, orderRepository,
+		promotionRepository, priceListRepository, tax.NewCalculator(conf.Tax), paymentsClient)
 
 	s.ProductRepository = productRepository
+	s.CategoryRepository = categoryapi.NewCategoryRepository(firestoreService)
 	s.CustomerService = customerSvc
+	s.CustomerRepository = customerRepository
 	s.OrderService = orderSvc
+	s.OrderRepository = orderRepository
 	s.CheckoutService = checkoutService
+	s.PromotionRepository = promotionRepository
+	s.PriceListRepository = priceListRepository
+
+	// Shipments are written along with the fulfilment status of their order,
+	// and returns are checked against its shipments.
+	s.ShipmentRepository = orderRepository
+	s.ReturnRepository = orderRepository
+	s.RefundService = refunds.NewRefundService(orderRepository, paymentsClient)
+
+	// Carts are ordered through the order repository, which empties them.
+	cartRepository := cartapi.NewCartRepository(firestoreService, conf.Carts)
+	s.CartRepository = cartRepository
+	manager.AddJob("cart expiry", func(ctx context.Context) {
+		cartapi.RunCartExpiry(ctx, cartRepository)
+	})
+
+	webhookRepository := webhookapi.NewWebhookRepository(firestoreService)
+	s.WebhookRepository = webhookRepository
+
+	notificationRepository := notificationapi.NewNotificationRepository(firestoreService)
+	s.NotificationRepository = notificationRepository
+
+	s.AuditLog = audit.NewFirestoreLog(firestoreClient, utils.SERVICE_NAME)
+
+	notificationTemplates, err := notifications.LoadTemplates(conf.Notifications.TemplatesDir)
+	if err != nil {
+		log.Fatalf("failed to load notification templates: %v", err)
+	}
+
+	// Readiness checks for the standard gRPC health service
+	healthService := health.NewService()
+	healthService.AddCheck("firestore", firestoreService.Ping)
+	healthService.AddCheck("payments", health.GRPCCheck(conn, health.LivenessService))
+	manager.AddJob("health checks", healthService.Run)
+
+	s.Health = healthService
+	s.EnableReflection = conf.Server.Reflection
+
+	// Deleted entities can be restored until they are purged.
+	purger := purge.NewPurger(productRepository, customerRepository, orderRepository, conf.Purge)
+	manager.AddJob("purge", purger.Run)
+
+	// Orders not paid in time are expired, which releases their coupon.
+	expirer := expiry.NewExpirer(orderRepository, conf.OrderExpiry)
+	manager.AddJob("order expiry", expirer.Run)
+
+	manager.AddJob("order status metrics", func(ctx context.Context) {
+		orders.RunOrderStatusMetrics(ctx, orderRepository, ORDER_METRICS_INTERVAL)
+	})
+
+	// Order events are delivered to handlers in this process and, with
+	// Pub/Sub, to other services. Payment events arrive from Pub/Sub, or
+	// through UpdateOrderStatus when the payments service has no broker.
+	bus := events.NewInProcessBus()
+	publishers := events.Publishers{bus}
+
+	// Partners are notified of order events through their webhooks.
+	webhooks.NewDispatcher(webhookRepository).Register(bus.Router)
+	manager.AddJob("webhook sender", webhooks.NewSender(webhookRepository, conf.Webhooks).Run)
+
+	// Customers are told of their orders by SMS and email.
+	notifier := notifications.NewNotifier(orderRepository, customerRepository, notificationRepository,
+		notificationTemplates, notifications.NewSenders(conf.Notifications))
+	notifier.MaxAttempts = conf.Notifications.MaxAttempts
+	notifier.Register(bus.Router)
+
+	if conf.Events.Broker == events.BrokerPubSub {
+		pubsubClient, err := events.NewPubSubClient(ctx,
+			conf.Firebase.ProjectID, conf.Firebase.CredentialsFile, conf.Events.EmulatorHost)
+		if err != nil {
+			log.Fatalf("failed to setup events broker: %v", err)
+		}
+		manager.OnShutdown("pubsub", func(context.Context) error { return pubsubClient.Close() })
+
+		publisher := events.NewPubSubPublisher(pubsubClient, conf.Events.Topic)
+		manager.OnShutdown("pubsub publisher", publisher.Stop)
+		publishers = append(publishers, publisher)
+
+		if conf.Events.Subscription != "" {
+			subscriber := events.NewPubSubSubscriber(pubsubClient, conf.Events.Subscription)
+			subscribers.NewPaymentEvents(orderRepository).Register(subscriber.Router)
+			manager.AddJob("payment events", subscriber.Run)
+		}
+	}
+
+	relay := outbox.NewRelay(outbox.NewFirestoreStore(firestoreClient, utils.SERVICE_NAME), publishers)
+	manager.AddJob("outbox relay", relay.Run)
+
+	// Debug server exposing /metrics
+	manager.AddHTTPServer("debug server", http.NewDebugServer(bindAddress+":"+conf.Server.DebugPort))
+
+	manager.Add("grpc server", func(ctx context.Context) error {
+		return s.Run(ctx, bindAddress, port)
+	}, s.Shutdown)
+
+	// REST/JSON gateway in front of the gRPC server. Added last so it is
+	// drained before the gRPC server it forwards requests to.
+	gateway := http.NewHTTPServer()
+	gateway.Addr = bindAddress + ":" + conf.Server.HTTPPort
+	gateway.GRPCAddr = bindAddress + ":" + port
+	manager.Add("http gateway", gateway.Open, gateway.Shutdown)
 
-	if err := s.Run(ctx, bindAddress, port); err != nil {
-		log.Fatalf("failed to serve: %v", err)
+	if err := manager.Run(ctx); err != nil {
+		log.Fatalf("server stopped: %v", err)
 	}
 }
@@ -0,0 +1,10 @@
+package generated
+
+import _ "embed"
+
+// OrdersOpenAPI is the OpenAPI (Swagger 2.0) document describing the REST
+// gateway, generated by protoc-gen-openapiv2 from orders.proto and
+// proto/orders_gateway.yaml.
+//
+//go:embed orders.swagger.json
+var OrdersOpenAPI []byte
@@ -0,0 +1,37 @@
+package carts
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/leta/order-management-system/orders/pkg/audit"
+)
+
+// ExpiryActor is the audit actor of the removal of expired carts.
+const ExpiryActor = "system:cart-expiry"
+
+// RunCartExpiry removes the expired carts every Options.Interval until the
+// context is cancelled. Expired carts are already read as empty; removing
+// them only reclaims their storage.
+func RunCartExpiry(ctx context.Context, r *CartRepository) {
+	ticker := time.NewTicker(r.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		ctx := audit.WithRequestID(audit.WithActor(ctx, ExpiryActor), audit.NewRequestID())
+
+		n, err := r.PurgeExpiredCarts(ctx, time.Now())
+		if err != nil {
+			log.Printf("[carts] failed to remove expired carts: %v", err)
+		} else if n > 0 {
+			log.Printf("[carts] removed %d expired carts", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
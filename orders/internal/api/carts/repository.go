@@ -0,0 +1,542 @@
+package carts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/leta/order-management-system/orders/db/firebase"
+	pricelistapi "github.com/leta/order-management-system/orders/internal/api/pricelists"
+	productapi "github.com/leta/order-management-system/orders/internal/api/product"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/carts"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/pricelists"
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/models"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// Collection holds the carts, keyed by customer ID.
+const Collection = "carts"
+
+// Options tune the expiry of carts.
+type Options struct {
+	// How long a cart is kept after its last change.
+	TTL time.Duration `yaml:"ttl" env:"TTL"`
+
+	// How often expired carts are looked for.
+	Interval time.Duration `yaml:"interval" env:"INTERVAL"`
+}
+
+// DefaultOptions returns the options used when nothing else is specified.
+func DefaultOptions() Options {
+	return Options{
+		TTL:      7 * 24 * time.Hour,
+		Interval: time.Hour,
+	}
+}
+
+// Validate reports every invalid option. name prefixes the error messages.
+func (o *Options) Validate(name string) error {
+	var errs []error
+
+	if o.TTL <= 0 {
+		errs = append(errs, fmt.Errorf("%s.ttl must be positive", name))
+	}
+	if o.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("%s.interval must be positive", name))
+	}
+
+	return errors.Join(errs...)
+}
+
+type CartRepository struct {
+	db      *firebase.FirestoreService
+	options Options
+}
+
+var _ carts.CartRepository = (*CartRepository)(nil)
+
+func NewCartRepository(db *firebase.FirestoreService, options Options) *CartRepository {
+	return &CartRepository{
+		db:      db,
+		options: options,
+	}
+}
+
+func (r *CartRepository) CheckPreconditions() {
+	if r.db == nil {
+		panic("no DB service provided")
+	}
+}
+
+func (r *CartRepository) cartCollection() *firestore.CollectionRef {
+	r.CheckPreconditions()
+
+	return r.db.Client.Collection(Collection)
+}
+
+func (r *CartRepository) record(
+	ctx context.Context, tx *firestore.Transaction, customerId string, action string, before, after *carts.Cart) error {
+
+	event, err := audit.New(ctx, audit.EntityCart, customerId, action, before, after)
+	if err != nil {
+		return err
+	}
+
+	return audit.NewFirestoreLog(r.db.Client, utils.SERVICE_NAME).Record(tx, event)
+}
+
+func (r *CartRepository) GetCart(ctx context.Context, customerId string) (*carts.Cart, error) {
+	r.CheckPreconditions()
+
+	if customerId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "customer id is required")
+	}
+
+	doc, err := r.cartCollection().Doc(customerId).Get(ctx)
+	if err != nil && status.Code(err) != codes.NotFound {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get cart: %v", err)
+	}
+
+	cart, err := ReadCart(doc, customerId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cart.Items) == 0 {
+		return cart, nil
+	}
+
+	products, err := r.db.Client.GetAll(ctx, ProductRefs(r.db.Client, cart))
+	if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get products: %v", err)
+	}
+
+	pricing, err := pricelistapi.ReadPricing(ctx, r.db.Client, customerId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := PriceCart(cart, products, pricing); err != nil {
+		return nil, err
+	}
+
+	return cart, nil
+}
+
+func (r *CartRepository) AddCartItem(
+	ctx context.Context, customerId string, productId string, variantId string, quantity uint) (*carts.Cart, error) {
+
+	r.CheckPreconditions()
+
+	if customerId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "customer id is required")
+	}
+
+	added := &carts.CartItem{ProductId: productId, VariantId: variantId, Quantity: quantity}
+	if err := added.Validate(); err != nil {
+		return nil, err
+	}
+
+	err := r.update(ctx, customerId, func(cart *carts.Cart) error {
+		if item := cart.Item(productId, variantId); item != nil {
+			item.Quantity += quantity
+			return nil
+		}
+
+		added.AddedAt = time.Now().Format(time.RFC3339)
+		cart.Items = append(cart.Items, added)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetCart(ctx, customerId)
+}
+
+func (r *CartRepository) UpdateCartItem(
+	ctx context.Context, customerId string, productId string, variantId string, quantity uint, etag string,
+) (*carts.Cart, error) {
+
+	r.CheckPreconditions()
+
+	if customerId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "customer id is required")
+	}
+
+	err := r.update(ctx, customerId, func(cart *carts.Cart) error {
+		if err := utils.CheckETag(etag, cart.Version); err != nil {
+			return err
+		}
+
+		item := cart.Item(productId, variantId)
+		if item == nil {
+			return notInCart(productId, variantId)
+		}
+
+		item.Quantity = quantity
+		return item.Validate()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetCart(ctx, customerId)
+}
+
+func (r *CartRepository) RemoveCartItem(
+	ctx context.Context, customerId string, productId string, variantId string) (*carts.Cart, error) {
+
+	r.CheckPreconditions()
+
+	if customerId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "customer id is required")
+	}
+
+	err := r.update(ctx, customerId, func(cart *carts.Cart) error {
+		removed := cart.Item(productId, variantId)
+		if removed == nil {
+			return notInCart(productId, variantId)
+		}
+
+		items := cart.Items[:0]
+		for _, item := range cart.Items {
+			if item != removed {
+				items = append(items, item)
+			}
+		}
+		cart.Items = items
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetCart(ctx, customerId)
+}
+
+func (r *CartRepository) ClearCart(ctx context.Context, customerId string) error {
+	r.CheckPreconditions()
+
+	if customerId == "" {
+		return utils.Errorf(utils.INVALID_ERROR, "customer id is required")
+	}
+
+	docRef := r.cartCollection().Doc(customerId)
+
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		cart, err := ReadCart(doc, customerId, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Delete(docRef); err != nil {
+			return err
+		}
+
+		return r.record(ctx, tx, customerId, audit.ActionDelete, cart, nil)
+	})
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return err
+	} else if err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "failed to clear cart: %v", err)
+	}
+
+	return nil
+}
+
+// PurgeExpiredCarts removes the carts that expired before now, checking
+// again in a transaction that they were not changed since they were listed.
+func (r *CartRepository) PurgeExpiredCarts(ctx context.Context, now time.Time) (int, error) {
+	r.CheckPreconditions()
+
+	cutoff := carts.ExpiresAt(now)
+
+	docs, err := r.cartCollection().Where("expires_at", "<=", cutoff).Documents(ctx).GetAll()
+	if err != nil {
+		return 0, utils.Errorf(utils.INTERNAL_ERROR, "failed to list expired carts: %v", err)
+	}
+
+	purged := 0
+	for _, doc := range docs {
+		removed := false
+
+		err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			removed = false
+
+			doc, err := tx.Get(doc.Ref)
+			if status.Code(err) == codes.NotFound {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			cartModel := &models.CartModel{}
+			if err := doc.DataTo(cartModel); err != nil {
+				return err
+			}
+
+			// Changed since it was listed.
+			if cartModel.ExpiresAt > cutoff {
+				return nil
+			}
+
+			if err := tx.Delete(doc.Ref); err != nil {
+				return err
+			}
+
+			removed = true
+
+			return r.record(ctx, tx, doc.Ref.ID, audit.ActionPurge, UnmarshallCart(doc.Ref.ID, cartModel), nil)
+		})
+		if err != nil {
+			return purged, utils.Errorf(utils.INTERNAL_ERROR, "failed to purge cart %s: %v", doc.Ref.ID, err)
+		}
+
+		if removed {
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// update applies change to the cart of a customer in a transaction, then
+// checks that its products are available and priced in a single currency.
+func (r *CartRepository) update(ctx context.Context, customerId string, change func(cart *carts.Cart) error) error {
+	docRef := r.cartCollection().Doc(customerId)
+	customerRef := r.db.Client.Collection("customers").Doc(customerId)
+
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if err := checkCustomer(tx, customerRef); err != nil {
+			return err
+		}
+
+		doc, err := tx.Get(docRef)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+
+		now := time.Now()
+
+		before, err := ReadCart(doc, customerId, now)
+		if err != nil {
+			return err
+		}
+
+		cart, err := ReadCart(doc, customerId, now)
+		if err != nil {
+			return err
+		}
+
+		if err := change(cart); err != nil {
+			return err
+		}
+
+		if len(cart.Items) > 0 {
+			products, err := tx.GetAll(ProductRefs(r.db.Client, cart))
+			if err != nil {
+				return err
+			}
+
+			pricing, err := pricelistapi.ReadPricing(ctx, r.db.Client, customerId, now)
+			if err != nil {
+				return err
+			}
+
+			if err := PriceCart(cart, products, pricing); err != nil {
+				return err
+			}
+		}
+
+		for _, item := range cart.Items {
+			if !item.Available {
+				return utils.Errorf(utils.INVALID_ERROR, "product %s is not available", item.ProductId)
+			}
+		}
+
+		if _, err := cart.Subtotal(); err != nil {
+			return utils.Errorf(utils.INVALID_ERROR, "items of a cart must be in a single currency: %v", err)
+		}
+
+		currentTime := now.Format(time.RFC3339)
+		if cart.CreatedAt == "" {
+			cart.CreatedAt = currentTime
+		}
+		cart.UpdatedAt = currentTime
+		cart.ExpiresAt = carts.ExpiresAt(now.Add(r.options.TTL))
+		cart.Version++
+
+		if err := tx.Set(docRef, MarshallCart(cart)); err != nil {
+			return err
+		}
+
+		action := audit.ActionUpdate
+		if before.Version == 0 {
+			action, before = audit.ActionCreate, nil
+		}
+
+		return r.record(ctx, tx, customerId, action, before, cart)
+	})
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return err
+	} else if err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "failed to update cart: %v", err)
+	}
+
+	return nil
+}
+
+// notInCart returns the NOT_FOUND_ERROR of a product or variant missing from
+// a cart.
+func notInCart(productId string, variantId string) error {
+	if variantId != "" {
+		return utils.Errorf(utils.NOT_FOUND_ERROR, "variant %s of product %s is not in the cart", variantId, productId)
+	}
+
+	return utils.Errorf(utils.NOT_FOUND_ERROR, "product %s is not in the cart", productId)
+}
+
+// checkCustomer returns a NOT_FOUND_ERROR unless the customer exists, and an
+// INVALID_ERROR if they were deleted.
+func checkCustomer(tx *firestore.Transaction, customerRef *firestore.DocumentRef) error {
+	doc, err := tx.Get(customerRef)
+	if status.Code(err) == codes.NotFound {
+		return utils.Errorf(utils.NOT_FOUND_ERROR, "customer not found")
+	} else if err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "failed to get customer: %v", err)
+	}
+
+	customerModel := &models.CustomerModel{}
+	if err := doc.DataTo(customerModel); err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall customer: %v", err)
+	}
+
+	if customerModel.DeletedAt != "" {
+		return utils.Errorf(utils.INVALID_ERROR, "customer is deleted")
+	}
+
+	return nil
+}
+
+// ReadCart returns the cart of a customer stored as doc, which may not
+// exist, as of now: an expired cart has no items.
+func ReadCart(doc *firestore.DocumentSnapshot, customerId string, now time.Time) (*carts.Cart, error) {
+	if doc == nil || !doc.Exists() {
+		return &carts.Cart{CustomerId: customerId}, nil
+	}
+
+	cartModel := &models.CartModel{}
+	if err := doc.DataTo(cartModel); err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall cart: %v", err)
+	}
+
+	cart := UnmarshallCart(customerId, cartModel)
+	if cart.Expired(now) {
+		cart.Items = nil
+		cart.CreatedAt = ""
+	}
+
+	return cart, nil
+}
+
+// ProductRefs returns the documents of the products of the items of cart.
+func ProductRefs(client *firestore.Client, cart *carts.Cart) []*firestore.DocumentRef {
+	refs := make([]*firestore.DocumentRef, len(cart.Items))
+	for i, item := range cart.Items {
+		refs[i] = client.Collection(productapi.Collection).Doc(item.ProductId)
+	}
+	return refs
+}
+
+// PriceCart sets the unit prices of the items of cart to the prices the
+// customer pays now for their products or variants, read as products in the
+// order of ProductRefs, see pricelists.Pricing. Items that cannot be ordered,
+// see product.Product.OrderPrice, are not available. The components of
+// bundles are checked, and the stock of the items reserved, when the cart is
+// converted to an order.
+func PriceCart(cart *carts.Cart, products []*firestore.DocumentSnapshot, pricing *pricelists.Pricing) error {
+	for i, item := range cart.Items {
+		item.UnitPrice = money.Money{}
+		item.Available = false
+
+		if !products[i].Exists() {
+			continue
+		}
+
+		product, err := productapi.UnmarshallProductDoc(products[i])
+		if err != nil {
+			return err
+		}
+
+		base, err := product.OrderPrice(item.VariantId, item.Quantity)
+		if err != nil {
+			continue
+		}
+
+		item.UnitPrice = pricing.UnitPrice(item.ProductId, item.VariantId, item.Quantity, base)
+		item.Available = true
+	}
+
+	return nil
+}
+
+// MarshallCart returns the document of cart.
+func MarshallCart(cart *carts.Cart) *models.CartModel {
+	items := make([]*models.CartItemModel, len(cart.Items))
+	for i, item := range cart.Items {
+		items[i] = &models.CartItemModel{
+			ProductId: item.ProductId,
+			VariantId: item.VariantId,
+			Quantity:  int(item.Quantity),
+			AddedAt:   item.AddedAt,
+		}
+	}
+
+	return &models.CartModel{
+		Items:     items,
+		CreatedAt: cart.CreatedAt,
+		UpdatedAt: cart.UpdatedAt,
+		ExpiresAt: cart.ExpiresAt,
+		Version:   cart.Version,
+	}
+}
+
+// UnmarshallCart returns the cart of a customer stored as cartModel, without
+// prices.
+func UnmarshallCart(customerId string, cartModel *models.CartModel) *carts.Cart {
+	items := make([]*carts.CartItem, len(cartModel.Items))
+	for i, item := range cartModel.Items {
+		items[i] = &carts.CartItem{
+			ProductId: item.ProductId,
+			VariantId: item.VariantId,
+			Quantity:  uint(item.Quantity),
+			AddedAt:   item.AddedAt,
+		}
+	}
+
+	return &carts.Cart{
+		CustomerId: customerId,
+		Items:      items,
+		CreatedAt:  cartModel.CreatedAt,
+		UpdatedAt:  cartModel.UpdatedAt,
+		ExpiresAt:  cartModel.ExpiresAt,
+		Version:    cartModel.Version,
+	}
+}
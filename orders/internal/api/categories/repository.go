@@ -0,0 +1,315 @@
+package categories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/leta/order-management-system/orders/db/firebase"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/categories"
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/models"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// Collection holds the categories, keyed by ID.
+const Collection = "categories"
+
+type CategoryRepository struct {
+	db *firebase.FirestoreService
+}
+
+var _ categories.CategoryRepository = (*CategoryRepository)(nil)
+
+func NewCategoryRepository(db *firebase.FirestoreService) *CategoryRepository {
+	return &CategoryRepository{
+		db: db,
+	}
+}
+
+func (r *CategoryRepository) CheckPreconditions() {
+	if r.db == nil {
+		panic("no DB service provided")
+	}
+}
+
+func (r *CategoryRepository) categoryCollection() *firestore.CollectionRef {
+	r.CheckPreconditions()
+
+	return r.db.Client.Collection(Collection)
+}
+
+func (r *CategoryRepository) record(
+	ctx context.Context, tx *firestore.Transaction, id string, action string, before, after *categories.Category) error {
+
+	event, err := audit.New(ctx, audit.EntityCategory, id, action, before, after)
+	if err != nil {
+		return err
+	}
+
+	return audit.NewFirestoreLog(r.db.Client, utils.SERVICE_NAME).Record(tx, event)
+}
+
+// CreateCategory reads the other categories in the same transaction, so that
+// the parent cannot be deleted meanwhile.
+func (r *CategoryRepository) CreateCategory(
+	ctx context.Context, category *categories.Category) (*categories.Category, error) {
+
+	r.CheckPreconditions()
+
+	currentTime := time.Now().Format(time.RFC3339)
+
+	category.CreatedAt = currentTime
+	category.UpdatedAt = currentTime
+	category.Version = 1
+
+	if err := category.Validate(); err != nil {
+		return nil, err
+	}
+
+	docRef := r.categoryCollection().NewDoc()
+
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		all, err := ListCategoriesTx(tx, r.db.Client)
+		if err != nil {
+			return err
+		}
+
+		if err := categories.CheckParent(all, docRef.ID, category.ParentId); err != nil {
+			return err
+		}
+
+		category.Id = docRef.ID
+
+		if err := tx.Create(docRef, marshallCategory(category)); err != nil {
+			return err
+		}
+
+		return r.record(ctx, tx, category.Id, audit.ActionCreate, nil, category)
+	})
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return nil, err
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to create category: %v", err)
+	}
+
+	return category, nil
+}
+
+func (r *CategoryRepository) GetCategory(ctx context.Context, id string) (*categories.Category, error) {
+	r.CheckPreconditions()
+
+	if id == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "id is required")
+	}
+
+	doc, err := r.categoryCollection().Doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, utils.Errorf(utils.NOT_FOUND_ERROR, "category not found")
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get category: %v", err)
+	}
+
+	return UnmarshallCategoryDoc(doc)
+}
+
+func (r *CategoryRepository) ListCategories(ctx context.Context) ([]*categories.Category, error) {
+	r.CheckPreconditions()
+
+	iter := r.categoryCollection().OrderBy("name", firestore.Asc).Documents(ctx)
+	defer iter.Stop()
+
+	var list []*categories.Category
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to iterate categories: %v", err)
+		}
+
+		category, err := UnmarshallCategoryDoc(doc)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, category)
+	}
+
+	return list, nil
+}
+
+func (r *CategoryRepository) UpdateCategory(
+	ctx context.Context, id string, update *categories.CategoryUpdate) (*categories.Category, error) {
+
+	r.CheckPreconditions()
+
+	if id == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "id is required")
+	}
+
+	var updated *categories.Category
+
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		all, err := ListCategoriesTx(tx, r.db.Client)
+		if err != nil {
+			return err
+		}
+
+		var before *categories.Category
+		for _, category := range all {
+			if category.Id == id {
+				before = category
+			}
+		}
+		if before == nil {
+			return utils.Errorf(utils.NOT_FOUND_ERROR, "category not found")
+		}
+
+		if err := utils.CheckETag(update.ETag, before.Version); err != nil {
+			return err
+		}
+
+		category := *before
+
+		if p := update.Name; p != nil {
+			category.Name = *p
+		}
+
+		if p := update.ParentId; p != nil {
+			if err := categories.CheckParent(all, id, *p); err != nil {
+				return err
+			}
+			category.ParentId = *p
+		}
+
+		if err := category.Validate(); err != nil {
+			return err
+		}
+
+		category.UpdatedAt = time.Now().Format(time.RFC3339)
+		category.Version++
+
+		if err := tx.Set(r.categoryCollection().Doc(id), marshallCategory(&category)); err != nil {
+			return err
+		}
+
+		updated = &category
+
+		return r.record(ctx, tx, id, audit.ActionUpdate, before, &category)
+	})
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return nil, err
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to update category: %v", err)
+	}
+
+	return updated, nil
+}
+
+func (r *CategoryRepository) DeleteCategory(ctx context.Context, id string) error {
+	r.CheckPreconditions()
+
+	if id == "" {
+		return utils.Errorf(utils.INVALID_ERROR, "id is required")
+	}
+
+	docRef := r.categoryCollection().Doc(id)
+
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		before, err := UnmarshallCategoryDoc(doc)
+		if err != nil {
+			return err
+		}
+
+		children, err := tx.Documents(r.categoryCollection().Where("parent_id", "==", id).Limit(1)).GetAll()
+		if err != nil {
+			return err
+		} else if len(children) > 0 {
+			return utils.Errorf(utils.INVALID_ERROR, "category %s has subcategories", id)
+		}
+
+		products, err := tx.Documents(r.db.Client.Collection("products").Where("category_id", "==", id).Limit(1)).GetAll()
+		if err != nil {
+			return err
+		} else if len(products) > 0 {
+			return utils.Errorf(utils.INVALID_ERROR, "category %s has products", id)
+		}
+
+		if err := tx.Delete(docRef); err != nil {
+			return err
+		}
+
+		return r.record(ctx, tx, id, audit.ActionDelete, before, nil)
+	})
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return err
+	} else if err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "failed to delete category: %v", err)
+	}
+
+	return nil
+}
+
+// ListCategoriesTx reads all the categories in tx. Catalogs have few enough
+// categories to check their tree in memory.
+func ListCategoriesTx(tx *firestore.Transaction, client *firestore.Client) ([]*categories.Category, error) {
+	docs, err := tx.Documents(client.Collection(Collection)).GetAll()
+	if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get categories: %v", err)
+	}
+
+	list := make([]*categories.Category, 0, len(docs))
+	for _, doc := range docs {
+		category, err := UnmarshallCategoryDoc(doc)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, category)
+	}
+
+	return list, nil
+}
+
+// UnmarshallCategoryDoc returns the category stored as doc.
+func UnmarshallCategoryDoc(doc *firestore.DocumentSnapshot) (*categories.Category, error) {
+	categoryModel := &models.CategoryModel{}
+	if err := doc.DataTo(categoryModel); err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall category: %v", err)
+	}
+
+	return &categories.Category{
+		Id:        doc.Ref.ID,
+		Name:      categoryModel.Name,
+		ParentId:  categoryModel.ParentId,
+		CreatedAt: categoryModel.CreatedAt,
+		UpdatedAt: categoryModel.UpdatedAt,
+		Version:   categoryModel.Version,
+	}, nil
+}
+
+func marshallCategory(category *categories.Category) *models.CategoryModel {
+	return &models.CategoryModel{
+		Name:      category.Name,
+		ParentId:  category.ParentId,
+		CreatedAt: category.CreatedAt,
+		UpdatedAt: category.UpdatedAt,
+		Version:   category.Version,
+	}
+}
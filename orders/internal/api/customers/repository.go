@@ -2,6 +2,7 @@ package customers
 
 import (
 	"github.com/leta/order-management-system/orders/internal/interfaces/api/customers"
+	"github.com/leta/order-management-system/orders/pkg/audit"
 	"github.com/leta/order-management-system/orders/pkg/models"
 	"github.com/leta/order-management-system/orders/pkg/utils"
 
@@ -14,6 +15,9 @@ import (
 	"time"
 )
 
+// Collection holds the customers, keyed by customer ID.
+const Collection = "customers"
+
 type customerRepository struct {
 	db *firebase.FirestoreService
 }
@@ -33,7 +37,7 @@ func (s *customerRepository) CheckPreconditions() {
 func (s *customerRepository) customerCollection() *firestore.CollectionRef {
 	s.CheckPreconditions()
 
-	return s.db.Client.Collection("customers")
+	return s.db.Client.Collection(Collection)
 }
 
 func (s *customerRepository) CreateCustomer(ctx context.Context, customer *customers.Customer) (*customers.Customer, error) {
@@ -42,6 +46,7 @@ func (s *customerRepository) CreateCustomer(ctx context.Context, customer *custo
 	currentTime := time.Now()
 	customer.CreatedAt = currentTime.Format(time.RFC3339)
 	customer.UpdatedAt = currentTime.Format(time.RFC3339)
+	customer.Version = 1
 
 	//err := customer.Validate()
 	//if err != nil {
@@ -49,13 +54,21 @@ func (s *customerRepository) CreateCustomer(ctx context.Context, customer *custo
 	//}
 	customerModel := s.marshallCustomer(customer)
 
-	docRef, _, err := s.customerCollection().Add(ctx, customerModel)
+	docRef := s.customerCollection().NewDoc()
+
+	err := s.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if err := tx.Create(docRef, customerModel); err != nil {
+			return err
+		}
+
+		customer.Id = docRef.ID
+
+		return s.record(ctx, tx, docRef.ID, audit.ActionCreate, nil, customer)
+	})
 	if err != nil {
 		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to create customers: %v", err)
 	}
 
-	customer.Id = docRef.ID
-
 	return customer, nil
 }
 
@@ -86,7 +99,7 @@ func (s *customerRepository) GetCustomer(ctx context.Context, id string) (*custo
 	return customer, nil
 }
 
-func (s *customerRepository) ListCustomers(ctx context.Context) ([]*customers.Customer, error) {
+func (s *customerRepository) ListCustomers(ctx context.Context, includeDeleted bool) ([]*customers.Customer, error) {
 	s.CheckPreconditions()
 
 	iter := s.customerCollection().Documents(ctx)
@@ -110,6 +123,10 @@ func (s *customerRepository) ListCustomers(ctx context.Context) ([]*customers.Cu
 		customer := s.unmarshallCustomer(customerModel)
 		customer.Id = docRef.Ref.ID
 
+		if customer.Deleted() && !includeDeleted {
+			continue
+		}
+
 		customers = append(customers, customer)
 	}
 
@@ -120,56 +137,407 @@ func (s *customerRepository) UpdateCustomer(ctx context.Context, id string, upda
 
 	s.CheckPreconditions()
 
-	customer, err := s.GetCustomer(ctx, id)
+	if id == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "id is required")
+	}
+
+	docRef := s.customerCollection().Doc(id)
+
+	var customer *customers.Customer
+
+	err := s.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return utils.Errorf(utils.NOT_FOUND_ERROR, "customers not found")
+		} else if err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to get customers: %v", err)
+		}
+
+		before, err := s.unmarshallCustomerDoc(doc)
+		if err != nil {
+			return err
+		}
+
+		if before.Deleted() {
+			return utils.Errorf(utils.NOT_FOUND_ERROR, "customer %s is deleted", id)
+		}
+
+		if err := utils.CheckETag(update.ETag, before.Version); err != nil {
+			return err
+		}
+
+		updated := *before
+		customer = &updated
+
+		if c := update.FirstName; c != nil {
+			customer.FirstName = *c
+		}
+
+		if c := update.LastName; c != nil {
+			customer.LastName = *c
+		}
+
+		if c := update.Email; c != nil {
+			customer.Email = *c
+		}
+
+		if c := update.Phone; c != nil {
+			customer.Phone = *c
+		}
+
+		if c := update.SmsOptOut; c != nil {
+			customer.SmsOptOut = *c
+		}
+
+		if c := update.EmailOptOut; c != nil {
+			customer.EmailOptOut = *c
+		}
+
+		if c := update.TaxExempt; c != nil {
+			customer.TaxExempt = *c
+		}
+
+		if c := update.Group; c != nil {
+			customer.Group = *c
+		}
+
+		if c := update.DefaultAddressId; c != nil {
+			customer.DefaultAddressId = *c
+		}
+
+		// An update mask may clear required fields.
+		if err := customer.Validate(); err != nil {
+			return err
+		}
+
+		timeNow := time.Now()
+		customer.UpdatedAt = timeNow.Format(time.RFC3339)
+		customer.Version++
+
+		if err := tx.Set(docRef, s.marshallCustomer(customer)); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to update customers: %v", err)
+		}
+
+		if err := s.record(ctx, tx, id, audit.ActionUpdate, before, customer); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "%v", err)
+		}
+
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if c := update.FirstName; c != nil {
-		customer.FirstName = *c
-	}
+	return customer, nil
 
-	if c := update.LastName; c != nil {
-		customer.LastName = *c
+}
+
+// DeleteCustomer marks a customer as deleted.
+func (s *customerRepository) DeleteCustomer(ctx context.Context, id string) error {
+	s.CheckPreconditions()
+
+	if id == "" {
+		return utils.Errorf(utils.INVALID_ERROR, "id is required")
 	}
 
-	if c := update.Email; c != nil {
-		customer.Email = *c
+	docRef := s.customerCollection().Doc(id)
+
+	return s.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		before, err := s.unmarshallCustomerDoc(doc)
+		if err != nil {
+			return err
+		}
+
+		if before.Deleted() {
+			return nil
+		}
+
+		customer := *before
+
+		timeNow := time.Now()
+		customer.UpdatedAt = timeNow.Format(time.RFC3339)
+		customer.DeletedAt = utils.DeletedAt(timeNow)
+		customer.Version++
+
+		if err := tx.Set(docRef, s.marshallCustomer(&customer)); err != nil {
+			return err
+		}
+
+		return s.record(ctx, tx, id, audit.ActionDelete, before, &customer)
+	})
+}
+
+// RestoreCustomer undoes the deletion of a customer.
+func (s *customerRepository) RestoreCustomer(ctx context.Context, id string) (*customers.Customer, error) {
+	s.CheckPreconditions()
+
+	if id == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "id is required")
 	}
 
-	if c := update.Phone; c != nil {
-		customer.Phone = *c
+	docRef := s.customerCollection().Doc(id)
+
+	var restored *customers.Customer
+
+	err := s.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return utils.Errorf(utils.NOT_FOUND_ERROR, "customers not found")
+		} else if err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to get customers: %v", err)
+		}
+
+		before, err := s.unmarshallCustomerDoc(doc)
+		if err != nil {
+			return err
+		}
+
+		restored = before
+		if !before.Deleted() {
+			return nil
+		}
+
+		customer := *before
+		customer.DeletedAt = ""
+		customer.UpdatedAt = time.Now().Format(time.RFC3339)
+		customer.Version++
+
+		if err := tx.Set(docRef, s.marshallCustomer(&customer)); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to restore customers: %v", err)
+		}
+
+		restored = &customer
+
+		if err := s.record(ctx, tx, id, audit.ActionRestore, before, &customer); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "%v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	timeNow := time.Now()
-	customer.UpdatedAt = timeNow.Format(time.RFC3339)
+	return restored, nil
+}
 
-	//err = customer.Validate()
-	//if err != nil {
-	//	return nil, utils.Errorf(utils.INVALID_ERROR, "invalid customers details provided: %v", err)
-	//}
+// PurgeCustomers removes the customers deleted before deletedBefore and
+// returns their number.
+func (s *customerRepository) PurgeCustomers(ctx context.Context, deletedBefore time.Time) (int, error) {
+	s.CheckPreconditions()
 
-	customerModel := s.marshallCustomer(customer)
+	cutoff := utils.DeletedAt(deletedBefore)
 
-	_, err = s.customerCollection().Doc(id).Set(ctx, customerModel)
+	docs, err := s.customerCollection().Where("deleted_at", "<", cutoff).Documents(ctx).GetAll()
 	if err != nil {
-		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to update customers: %v", err)
+		return 0, utils.Errorf(utils.INTERNAL_ERROR, "failed to list deleted customers: %v", err)
 	}
 
-	return customer, nil
+	purged := 0
+	for _, doc := range docs {
+		removed := false
+
+		err := s.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			removed = false
+
+			doc, err := tx.Get(doc.Ref)
+			if status.Code(err) == codes.NotFound {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			before, err := s.unmarshallCustomerDoc(doc)
+			if err != nil {
+				return err
+			}
 
+			// Restored since it was listed.
+			if !before.Deleted() || before.DeletedAt >= cutoff {
+				return nil
+			}
+
+			if err := tx.Delete(doc.Ref); err != nil {
+				return err
+			}
+
+			removed = true
+
+			return s.record(ctx, tx, doc.Ref.ID, audit.ActionPurge, before, nil)
+		})
+		if err != nil {
+			return purged, utils.Errorf(utils.INTERNAL_ERROR, "failed to purge customer %s: %v", doc.Ref.ID, err)
+		}
+
+		if removed {
+			purged++
+		}
+	}
+
+	return purged, nil
 }
 
-func (s *customerRepository) DeleteCustomer(ctx context.Context, id string) error {
+func (s *customerRepository) AddAddress(
+	ctx context.Context, customerId string, address *customers.Address, makeDefault bool, etag string) (*customers.Customer, error) {
+
+	if err := address.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.updateAddresses(ctx, customerId, etag, func(customer *customers.Customer) error {
+		added := *address
+		added.Id = s.customerCollection().NewDoc().ID
+
+		customer.Addresses = append(customer.Addresses, &added)
+		if makeDefault || customer.DefaultAddressId == "" {
+			customer.DefaultAddressId = added.Id
+		}
+
+		return nil
+	})
+}
+
+func (s *customerRepository) UpdateAddress(
+	ctx context.Context, customerId string, address *customers.Address, etag string) (*customers.Customer, error) {
+
+	if address.Id == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "address id is required")
+	}
+
+	if err := address.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.updateAddresses(ctx, customerId, etag, func(customer *customers.Customer) error {
+		for i, a := range customer.Addresses {
+			if a.Id == address.Id {
+				updated := *address
+				customer.Addresses[i] = &updated
+				return nil
+			}
+		}
+
+		return utils.Errorf(utils.NOT_FOUND_ERROR, "address %s not found", address.Id)
+	})
+}
+
+func (s *customerRepository) RemoveAddress(
+	ctx context.Context, customerId string, addressId string, etag string) (*customers.Customer, error) {
+
+	return s.updateAddresses(ctx, customerId, etag, func(customer *customers.Customer) error {
+		if customer.Address(addressId) == nil {
+			return utils.Errorf(utils.NOT_FOUND_ERROR, "address %s not found", addressId)
+		}
+
+		var addresses []*customers.Address
+		for _, a := range customer.Addresses {
+			if a.Id != addressId {
+				addresses = append(addresses, a)
+			}
+		}
+		customer.Addresses = addresses
+
+		if customer.DefaultAddressId == addressId {
+			customer.DefaultAddressId = ""
+		}
+
+		return nil
+	})
+}
+
+// updateAddresses applies change to a copy of the addresses of the customer
+// and writes it, along with its audit event.
+func (s *customerRepository) updateAddresses(
+	ctx context.Context, id string, etag string, change func(customer *customers.Customer) error) (*customers.Customer, error) {
+
 	s.CheckPreconditions()
 
 	if id == "" {
-		return utils.Errorf(utils.INVALID_ERROR, "id is required")
+		return nil, utils.Errorf(utils.INVALID_ERROR, "id is required")
 	}
 
-	_, err := s.customerCollection().Doc(id).Delete(ctx)
+	docRef := s.customerCollection().Doc(id)
+
+	var customer *customers.Customer
+
+	err := s.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return utils.Errorf(utils.NOT_FOUND_ERROR, "customers not found")
+		} else if err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to get customers: %v", err)
+		}
+
+		before, err := s.unmarshallCustomerDoc(doc)
+		if err != nil {
+			return err
+		}
+
+		if before.Deleted() {
+			return utils.Errorf(utils.NOT_FOUND_ERROR, "customer %s is deleted", id)
+		}
+
+		if err := utils.CheckETag(etag, before.Version); err != nil {
+			return err
+		}
 
-	return err
+		updated := *before
+		updated.Addresses = append([]*customers.Address(nil), before.Addresses...)
+		customer = &updated
+
+		if err := change(customer); err != nil {
+			return err
+		}
+
+		customer.UpdatedAt = time.Now().Format(time.RFC3339)
+		customer.Version++
+
+		if err := tx.Set(docRef, s.marshallCustomer(customer)); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to update customers: %v", err)
+		}
+
+		if err := s.record(ctx, tx, id, audit.ActionUpdate, before, customer); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "%v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return customer, nil
+}
+
+// record adds the audit event of a change to tx.
+func (s *customerRepository) record(
+	ctx context.Context, tx *firestore.Transaction, id string, action string, before, after *customers.Customer) error {
+
+	event, err := audit.New(ctx, audit.EntityCustomer, id, action, before, after)
+	if err != nil {
+		return err
+	}
+
+	return audit.NewFirestoreLog(s.db.Client, utils.SERVICE_NAME).Record(tx, event)
+}
+
+func (s *customerRepository) unmarshallCustomerDoc(doc *firestore.DocumentSnapshot) (*customers.Customer, error) {
+	customerModel := &models.CustomerModel{}
+	if err := doc.DataTo(customerModel); err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall customers: %v", err)
+	}
+
+	customer := s.unmarshallCustomer(customerModel)
+	customer.Id = doc.Ref.ID
+
+	return customer, nil
 }
 
 func (s *customerRepository) marshallCustomer(customer *customers.Customer) *models.CustomerModel {
@@ -181,6 +549,16 @@ func (s *customerRepository) marshallCustomer(customer *customers.Customer) *mod
 		Phone:     customer.Phone,
 		CreatedAt: customer.CreatedAt,
 		UpdatedAt: customer.UpdatedAt,
+		DeletedAt: customer.DeletedAt,
+		Version:   customer.Version,
+
+		SmsOptOut:   customer.SmsOptOut,
+		EmailOptOut: customer.EmailOptOut,
+		TaxExempt:   customer.TaxExempt,
+		Group:       customer.Group,
+
+		Addresses:        MarshallAddresses(customer.Addresses),
+		DefaultAddressId: customer.DefaultAddressId,
 	}
 }
 
@@ -193,5 +571,75 @@ func (s *customerRepository) unmarshallCustomer(customerModel *models.CustomerMo
 		Phone:     customerModel.Phone,
 		CreatedAt: customerModel.CreatedAt,
 		UpdatedAt: customerModel.UpdatedAt,
+		DeletedAt: customerModel.DeletedAt,
+		Version:   customerModel.Version,
+
+		SmsOptOut:   customerModel.SmsOptOut,
+		EmailOptOut: customerModel.EmailOptOut,
+		TaxExempt:   customerModel.TaxExempt,
+		Group:       customerModel.Group,
+
+		Addresses:        UnmarshallAddresses(customerModel.Addresses),
+		DefaultAddressId: customerModel.DefaultAddressId,
+	}
+}
+
+// UnmarshallCustomer decodes a customer document, e.g. to read the addresses
+// of a customer in the transaction of another repository.
+func UnmarshallCustomer(doc *firestore.DocumentSnapshot) (*customers.Customer, error) {
+	return (&customerRepository{}).unmarshallCustomerDoc(doc)
+}
+
+func MarshallAddresses(addresses []*customers.Address) []*models.AddressModel {
+	var addressModels []*models.AddressModel
+	for _, address := range addresses {
+		addressModels = append(addressModels, MarshallAddress(address))
+	}
+	return addressModels
+}
+
+func UnmarshallAddresses(addressModels []*models.AddressModel) []*customers.Address {
+	var addresses []*customers.Address
+	for _, addressModel := range addressModels {
+		addresses = append(addresses, UnmarshallAddress(addressModel))
+	}
+	return addresses
+}
+
+func MarshallAddress(address *customers.Address) *models.AddressModel {
+	if address == nil {
+		return nil
+	}
+
+	return &models.AddressModel{
+		Id:         address.Id,
+		Label:      address.Label,
+		Recipient:  address.Recipient,
+		Phone:      address.Phone,
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		City:       address.City,
+		Region:     address.Region,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+	}
+}
+
+func UnmarshallAddress(addressModel *models.AddressModel) *customers.Address {
+	if addressModel == nil {
+		return nil
+	}
+
+	return &customers.Address{
+		Id:         addressModel.Id,
+		Label:      addressModel.Label,
+		Recipient:  addressModel.Recipient,
+		Phone:      addressModel.Phone,
+		Line1:      addressModel.Line1,
+		Line2:      addressModel.Line2,
+		City:       addressModel.City,
+		Region:     addressModel.Region,
+		PostalCode: addressModel.PostalCode,
+		Country:    addressModel.Country,
 	}
 }
@@ -254,7 +254,7 @@ func TestCustomerService_ListCustomers(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := customerRepository.ListCustomers(tt.args.ctx)
+			got, err := customerRepository.ListCustomers(tt.args.ctx, false)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("CustomerRepository.ListCustomers() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -30,6 +30,8 @@ func (s *CustomerService) CreateCustomer(
 		LastName:  in.GetLastName(),
 		Email:     in.GetEmail(),
 		Phone:     in.GetPhone(),
+		TaxExempt: in.GetTaxExempt(),
+		Group:     in.GetGroup(),
 	})
 	if err != nil {
 		return nil, err
@@ -55,6 +57,11 @@ func (s *CustomerService) GetCustomer(
 		LastName:  p.LastName,
 		Phone:     p.Phone,
 		Email:     p.Email,
+
+		SmsOptOut:   p.SmsOptOut,
+		EmailOptOut: p.EmailOptOut,
+		TaxExempt:   p.TaxExempt,
+		Group:       p.Group,
 	}, nil
 }
 
@@ -63,7 +70,7 @@ func (s *CustomerService) ListCustomers(
 
 	log.Printf("Received: %v", in)
 
-	customers, err := s.customersRepo.ListCustomers(ctx)
+	customers, err := s.customersRepo.ListCustomers(ctx, in.GetIncludeDeleted())
 	if err != nil {
 		return nil, fmt.Errorf("failed to list customers: %w", err)
 	}
@@ -76,6 +83,11 @@ func (s *CustomerService) ListCustomers(
 			LastName:  p.LastName,
 			Phone:     p.Phone,
 			Email:     p.Email,
+
+			SmsOptOut:   p.SmsOptOut,
+			EmailOptOut: p.EmailOptOut,
+			TaxExempt:   p.TaxExempt,
+			Group:       p.Group,
 		})
 	}
 
@@ -89,21 +101,54 @@ func (s *CustomerService) UpdateCustomer(
 
 	log.Printf("Received: %v", in)
 
-	p, err := s.customersRepo.UpdateCustomer(ctx, in.GetId(), &customers.CustomerUpdate{
-		FirstName: utils.StringPtr(in.GetUpdate().GetFirstName()),
-		LastName:  utils.StringPtr(in.GetUpdate().GetLastName()),
-		Phone:     utils.StringPtr(in.GetUpdate().GetPhone()),
-		Email:     utils.StringPtr(in.GetUpdate().GetEmail()),
-	})
+	paths, err := utils.UpdatePaths(in.GetUpdateMask(), in.GetUpdate())
+	if err != nil {
+		return nil, err
+	}
+
+	update := &customers.CustomerUpdate{ETag: in.GetEtag()}
+	if paths["first_name"] {
+		update.FirstName = utils.StringPtr(in.GetUpdate().GetFirstName())
+	}
+	if paths["last_name"] {
+		update.LastName = utils.StringPtr(in.GetUpdate().GetLastName())
+	}
+	if paths["email"] {
+		update.Email = utils.StringPtr(in.GetUpdate().GetEmail())
+	}
+	if paths["phone"] {
+		update.Phone = utils.StringPtr(in.GetUpdate().GetPhone())
+	}
+	if paths["sms_opt_out"] {
+		update.SmsOptOut = utils.BoolPtr(in.GetUpdate().GetSmsOptOut())
+	}
+	if paths["email_opt_out"] {
+		update.EmailOptOut = utils.BoolPtr(in.GetUpdate().GetEmailOptOut())
+	}
+	if paths["tax_exempt"] {
+		update.TaxExempt = utils.BoolPtr(in.GetUpdate().GetTaxExempt())
+	}
+	if paths["group"] {
+		update.Group = utils.StringPtr(in.GetUpdate().GetGroup())
+	}
+
+	p, err := s.customersRepo.UpdateCustomer(ctx, in.GetId(), update)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update customers: %w", err)
 	}
 
 	return &generated.UpdateCustomerResponse{
 		Id:        p.Id,
+		Etag:      utils.ETag(p.Version),
 		FirstName: p.FirstName,
 		LastName:  p.LastName,
 		Email:     p.Email,
+		Phone:     p.Phone,
+
+		SmsOptOut:   p.SmsOptOut,
+		EmailOptOut: p.EmailOptOut,
+		TaxExempt:   p.TaxExempt,
+		Group:       p.Group,
 	}, nil
 }
 
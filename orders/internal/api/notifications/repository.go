@@ -0,0 +1,154 @@
+package notifications
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/leta/order-management-system/orders/db/firebase"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/notifications"
+	"github.com/leta/order-management-system/orders/pkg/models"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// NotificationRepository stores notifications in their own collection.
+// ListNotifications needs the composite index declared in main.tf.
+type NotificationRepository struct {
+	db *firebase.FirestoreService
+}
+
+var _ notifications.NotificationRepository = (*NotificationRepository)(nil)
+
+func NewNotificationRepository(db *firebase.FirestoreService) *NotificationRepository {
+	return &NotificationRepository{
+		db: db,
+	}
+}
+
+func (s *NotificationRepository) CheckPreconditions() {
+	if s.db == nil {
+		panic("no DB service provided")
+	}
+}
+
+func (s *NotificationRepository) notificationCollection() *firestore.CollectionRef {
+	s.CheckPreconditions()
+
+	return s.db.Client.Collection("notifications")
+}
+
+func (s *NotificationRepository) GetNotification(ctx context.Context, id string) (*notifications.Notification, error) {
+	s.CheckPreconditions()
+
+	if id == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "id is required")
+	}
+
+	docRef, err := s.notificationCollection().Doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, utils.Errorf(utils.NOT_FOUND_ERROR, "notification not found")
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get notification: %v", err)
+	}
+
+	return s.unmarshallNotification(docRef)
+}
+
+func (s *NotificationRepository) SaveNotification(ctx context.Context, notification *notifications.Notification) error {
+	s.CheckPreconditions()
+
+	if notification.Id == "" {
+		return utils.Errorf(utils.INVALID_ERROR, "id is required")
+	}
+
+	_, err := s.notificationCollection().Doc(notification.Id).Set(ctx, s.marshallNotification(notification))
+	if err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "failed to save notification: %v", err)
+	}
+
+	return nil
+}
+
+func (s *NotificationRepository) ListNotifications(
+	ctx context.Context, customerId string) ([]*notifications.Notification, error) {
+
+	s.CheckPreconditions()
+
+	if customerId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "customer_id is required")
+	}
+
+	iter := s.notificationCollection().
+		Where("customer_id", "==", customerId).
+		OrderBy("created_at", firestore.Desc).
+		Documents(ctx)
+	defer iter.Stop()
+
+	var list []*notifications.Notification
+	for {
+		docRef, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to iterate notifications: %v", err)
+		}
+
+		notification, err := s.unmarshallNotification(docRef)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, notification)
+	}
+
+	return list, nil
+}
+
+func (s *NotificationRepository) marshallNotification(notification *notifications.Notification) *models.NotificationModel {
+
+	return &models.NotificationModel{
+		CustomerId: notification.CustomerId,
+		OrderId:    notification.OrderId,
+		EventId:    notification.EventId,
+		Template:   notification.Template,
+		Channel:    notification.Channel,
+		Recipient:  notification.Recipient,
+		Subject:    notification.Subject,
+		Body:       notification.Body,
+		Status:     notification.Status,
+		Attempts:   int(notification.Attempts),
+		Error:      notification.Error,
+		CreatedAt:  notification.CreatedAt,
+		UpdatedAt:  notification.UpdatedAt,
+	}
+}
+
+func (s *NotificationRepository) unmarshallNotification(
+	docRef *firestore.DocumentSnapshot) (*notifications.Notification, error) {
+
+	notificationModel := &models.NotificationModel{}
+	if err := docRef.DataTo(notificationModel); err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall notification: %v", err)
+	}
+
+	return &notifications.Notification{
+		Id:         docRef.Ref.ID,
+		CustomerId: notificationModel.CustomerId,
+		OrderId:    notificationModel.OrderId,
+		EventId:    notificationModel.EventId,
+		Template:   notificationModel.Template,
+		Channel:    notificationModel.Channel,
+		Recipient:  notificationModel.Recipient,
+		Subject:    notificationModel.Subject,
+		Body:       notificationModel.Body,
+		Status:     notificationModel.Status,
+		Attempts:   uint(notificationModel.Attempts),
+		Error:      notificationModel.Error,
+		CreatedAt:  notificationModel.CreatedAt,
+		UpdatedAt:  notificationModel.UpdatedAt,
+	}, nil
+}
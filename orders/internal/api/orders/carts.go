@@ -0,0 +1,155 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	cartapi "github.com/leta/order-management-system/orders/internal/api/carts"
+	pricelistapi "github.com/leta/order-management-system/orders/internal/api/pricelists"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// ConvertCartToOrder writes the order as CreateOrder does, so that it is
+// audited and announced the same way and reserves the same stock, and deletes
+// the cart in the same transaction. The order is shipped to the default
+// address of the customer.
+func (r *OrderRepository) ConvertCartToOrder(
+	ctx context.Context, customerId string, etag string, expectedSubtotal *money.Money) (*orders.Order, error) {
+
+	r.CheckPreconditions()
+
+	if customerId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "customer id is required")
+	}
+
+	cartRef := r.db.Client.Collection(cartapi.Collection).Doc(customerId)
+	orderRef := r.orderCollection().NewDoc()
+
+	var order *orders.Order
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(cartRef)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to get cart: %v", err)
+		}
+
+		now := time.Now()
+
+		cart, err := cartapi.ReadCart(doc, customerId, now)
+		if err != nil {
+			return err
+		}
+
+		if err := utils.CheckETag(etag, cart.Version); err != nil {
+			return err
+		}
+
+		if len(cart.Items) == 0 {
+			return utils.Errorf(utils.INVALID_ERROR, "cart is empty")
+		}
+
+		products, err := tx.GetAll(cartapi.ProductRefs(r.db.Client, cart))
+		if err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to get products: %v", err)
+		}
+
+		pricing, err := pricelistapi.ReadPricing(ctx, r.db.Client, customerId, now)
+		if err != nil {
+			return err
+		}
+
+		if err := cartapi.PriceCart(cart, products, pricing); err != nil {
+			return err
+		}
+
+		for _, item := range cart.Items {
+			if !item.Available {
+				return utils.Errorf(utils.INVALID_ERROR, "product %s is not available", item.ProductId)
+			}
+		}
+
+		address, err := r.shippingAddressTx(tx, customerId, "")
+		if err != nil {
+			return err
+		}
+
+		subtotal, err := cart.Subtotal()
+		if err != nil {
+			return err
+		}
+
+		if expectedSubtotal != nil && *expectedSubtotal != subtotal {
+			return utils.Errorf(utils.ABORTED_ERROR,
+				"the subtotal of the cart is %s, not %s", subtotal, *expectedSubtotal)
+		}
+
+		currentTime := now.Format(time.RFC3339)
+
+		order = &orders.Order{
+			CustomerId:  customerId,
+			OrderStatus: utils.OrderStatusNew,
+			CreatedAt:   currentTime,
+			UpdatedAt:   currentTime,
+			Version:     1,
+
+			ShippingAddress:  address,
+			FulfilmentStatus: utils.FulfilmentStatusUnfulfilled,
+		}
+
+		stock := r.newStockTx(tx)
+		for _, item := range cart.Items {
+			orderItem := item.OrderItem()
+			orderItem.CreatedAt = currentTime
+			orderItem.UpdatedAt = currentTime
+			orderItem.Version = 1
+
+			p, err := stock.product(item.ProductId)
+			if err != nil {
+				return err
+			}
+
+			if err := r.expandBundle(stock, orderItem, p); err != nil {
+				return err
+			}
+
+			order.Items = append(order.Items, orderItem)
+		}
+
+		if err := order.Validate(); err != nil {
+			return err
+		}
+
+		if err := stock.reserve(order.Items); err != nil {
+			return err
+		}
+
+		if err := r.createOrderTx(ctx, tx, orderRef, order); err != nil {
+			return err
+		}
+
+		if err := stock.write(ctx); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(cartRef); err != nil {
+			return err
+		}
+
+		return r.record(ctx, tx, audit.EntityCart, customerId, audit.ActionDelete, cart, nil)
+	})
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return nil, err
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to convert cart to orders: %v", err)
+	}
+
+	return order, nil
+}
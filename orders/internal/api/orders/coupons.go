@@ -0,0 +1,296 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	promotionapi "github.com/leta/order-management-system/orders/internal/api/promotions"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/promotions"
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/models"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// couponTx holds the documents read by the transaction of a coupon change.
+type couponTx struct {
+	orderRef   *firestore.DocumentRef
+	orderModel *models.OrderModel
+	before     *orders.Order
+	items      []*orders.OrderItem
+
+	promotionRef   *firestore.DocumentRef
+	promotionModel *models.PromotionModel // nil if the promotion was deleted
+
+	redemptionRef   *firestore.DocumentRef
+	redemptionModel *models.PromotionRedemptionModel
+}
+
+// ApplyCoupon applies a coupon to an order in a transaction that counts its
+// use by the promotion, in total and for the customer of the order.
+func (r *OrderRepository) ApplyCoupon(ctx context.Context, orderId string, code string) (*orders.Order, error) {
+	r.CheckPreconditions()
+
+	code = promotions.NormalizeCode(code)
+	if orderId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "orders id is required")
+	} else if code == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "code is required")
+	}
+
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		c, err := r.getCouponTx(tx, orderId)
+		if err != nil {
+			return err
+		}
+
+		if c.orderModel.CouponCode == code {
+			return nil
+		} else if c.orderModel.CouponCode != "" {
+			return utils.Errorf(utils.INVALID_ERROR,
+				"order already has coupon %s, remove it first", c.orderModel.CouponCode)
+		}
+
+		if err := c.getPromotion(tx, r.db.Client, code, c.orderModel.CustomerId); err != nil {
+			return err
+		}
+		if c.promotionModel == nil {
+			return utils.Errorf(utils.NOT_FOUND_ERROR, "coupon %s not found", code)
+		}
+
+		if err := r.priceItems(ctx, r.newStockTx(tx), c.orderModel.CustomerId, c.items); err != nil {
+			return err
+		}
+
+		promotion := promotionapi.UnmarshallPromotion(code, c.promotionModel)
+		customerUses := int64(len(c.redemptionModel.OrderIds))
+		if err := promotion.CheckRedeemable(time.Now(), customerUses, c.items); err != nil {
+			return err
+		}
+
+		c.redemptionModel.OrderIds = append(c.redemptionModel.OrderIds, orderId)
+		c.orderModel.CouponCode = code
+
+		return r.writeCouponTx(ctx, tx, c, 1)
+	})
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return nil, err
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to apply coupon: %v", err)
+	}
+
+	return r.GetOrder(ctx, orderId)
+}
+
+// RemoveCoupon removes the coupon of an order, and its use by the promotion
+// if the promotion still exists.
+func (r *OrderRepository) RemoveCoupon(ctx context.Context, orderId string) (*orders.Order, error) {
+	r.CheckPreconditions()
+
+	if orderId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "orders id is required")
+	}
+
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		c, err := r.getCouponTx(tx, orderId)
+		if err != nil {
+			return err
+		}
+
+		code := c.orderModel.CouponCode
+		if code == "" {
+			return nil
+		}
+
+		if err := c.getPromotion(tx, r.db.Client, code, c.orderModel.CustomerId); err != nil {
+			return err
+		}
+
+		c.removeOrder(orderId)
+		c.orderModel.CouponCode = ""
+
+		return r.writeCouponTx(ctx, tx, c, -1)
+	})
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return nil, err
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to remove coupon: %v", err)
+	}
+
+	return r.GetOrder(ctx, orderId)
+}
+
+// getCouponTx reads an order whose coupon is to be changed, which must not be
+// deleted nor checked out, and its items.
+func (r *OrderRepository) getCouponTx(tx *firestore.Transaction, orderId string) (*couponTx, error) {
+	orderRef := r.orderCollection().Doc(orderId)
+
+	doc, err := tx.Get(orderRef)
+	if status.Code(err) == codes.NotFound {
+		return nil, utils.Errorf(utils.NOT_FOUND_ERROR, "orders not found")
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get orders: %v", err)
+	}
+
+	orderModel := &models.OrderModel{}
+	if err := doc.DataTo(orderModel); err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall orders: %v", err)
+	}
+
+	if orderModel.DeletedAt != "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "order is deleted")
+	}
+
+	switch utils.OrderStatus(orderModel.OrderStatus) {
+	case utils.OrderStatusNew, utils.OrderStatusPending:
+	default:
+		return nil, utils.Errorf(utils.INVALID_ERROR, "the coupon of a checked out order cannot be changed")
+	}
+
+	before := r.unmarshallOrder(orderModel)
+	before.Id = orderId
+
+	items, err := r.listOrderItemsTx(tx, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &couponTx{orderRef: orderRef, orderModel: orderModel, before: before, items: items}, nil
+}
+
+// getPromotion reads the promotion of code, if it exists, and the orders the
+// customer applied it to.
+func (c *couponTx) getPromotion(tx *firestore.Transaction, client *firestore.Client, code string, customerId string) error {
+	c.promotionRef = client.Collection(promotionapi.Collection).Doc(code)
+	c.redemptionRef = c.promotionRef.Collection(promotionapi.RedemptionCollection).Doc(customerId)
+	c.redemptionModel = &models.PromotionRedemptionModel{}
+
+	doc, err := tx.Get(c.promotionRef)
+	if status.Code(err) == codes.NotFound {
+		return nil
+	} else if err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "failed to get promotion: %v", err)
+	}
+
+	c.promotionModel = &models.PromotionModel{}
+	if err := doc.DataTo(c.promotionModel); err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall promotion: %v", err)
+	}
+
+	doc, err = tx.Get(c.redemptionRef)
+	if status.Code(err) == codes.NotFound {
+		return nil
+	} else if err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "failed to get promotion redemptions: %v", err)
+	}
+
+	if err := doc.DataTo(c.redemptionModel); err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall promotion redemptions: %v", err)
+	}
+
+	return nil
+}
+
+// removeOrder removes an order from those the customer applied the coupon
+// to.
+func (c *couponTx) removeOrder(orderId string) {
+	orderIds := c.redemptionModel.OrderIds[:0]
+	for _, id := range c.redemptionModel.OrderIds {
+		if id != orderId {
+			orderIds = append(orderIds, id)
+		}
+	}
+	c.redemptionModel.OrderIds = orderIds
+}
+
+// writeCouponTx writes the order of c and, if it exists, its promotion with
+// uses changed by delta, along with their audit events. The totals stored at
+// checkout with the order and its items are cleared, as they depend on the
+// coupon, see SetOrderTotals.
+func (r *OrderRepository) writeCouponTx(ctx context.Context, tx *firestore.Transaction, c *couponTx, delta int64) error {
+	currentTime := time.Now().Format(time.RFC3339)
+
+	if err := r.clearItemTotalsTx(ctx, tx, c.before.Id, c.items, ""); err != nil {
+		return err
+	}
+
+	c.orderModel.Tax = nil
+	c.orderModel.Discount = nil
+	c.orderModel.UpdatedAt = currentTime
+	c.orderModel.Version++
+
+	if err := tx.Set(c.orderRef, c.orderModel); err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "failed to update orders: %v", err)
+	}
+
+	after := r.unmarshallOrder(c.orderModel)
+	after.Id = c.before.Id
+
+	if err := r.record(ctx, tx, audit.EntityOrder, after.Id, audit.ActionUpdate, c.before, after); err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "%v", err)
+	}
+
+	return r.writePromotionTx(ctx, tx, c, delta, currentTime)
+}
+
+// writePromotionTx writes the promotion of c, if it exists, with uses
+// changed by delta, and the orders the customer applied it to.
+func (r *OrderRepository) writePromotionTx(
+	ctx context.Context, tx *firestore.Transaction, c *couponTx, delta int64, currentTime string) error {
+
+	if c.promotionModel == nil {
+		return nil
+	}
+
+	code := c.promotionRef.ID
+	before := promotionapi.UnmarshallPromotion(code, c.promotionModel)
+
+	c.promotionModel.Uses += delta
+	c.promotionModel.UpdatedAt = currentTime
+	c.promotionModel.Version++
+
+	if err := tx.Set(c.promotionRef, c.promotionModel); err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "failed to update promotion: %v", err)
+	}
+
+	if err := tx.Set(c.redemptionRef, c.redemptionModel); err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "failed to update promotion redemptions: %v", err)
+	}
+
+	err := r.record(ctx, tx, audit.EntityPromotion, code, audit.ActionUpdate,
+		before, promotionapi.UnmarshallPromotion(code, c.promotionModel))
+	if err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "%v", err)
+	}
+
+	return nil
+}
+
+// listOrderItemsTx reads the items of an order in tx.
+func (r *OrderRepository) listOrderItemsTx(tx *firestore.Transaction, orderId string) ([]*orders.OrderItem, error) {
+	docs, err := tx.Documents(r.orderItemCollection(orderId)).GetAll()
+	if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get orders items: %v", err)
+	}
+
+	items := make([]*orders.OrderItem, 0, len(docs))
+	for _, doc := range docs {
+		orderItemModel := &models.OrderItemModel{}
+		if err := doc.DataTo(orderItemModel); err != nil {
+			return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall orders item: %v", err)
+		}
+
+		item := r.unmarshallOrderItem(orderItemModel)
+		item.Id = doc.Ref.ID
+		items = append(items, item)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,39 @@
+package orders
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var ordersByStatus = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "orders_by_status",
+	Help: "Number of orders in each status.",
+}, []string{"status"})
+
+// RunOrderStatusMetrics refreshes the orders_by_status gauge every interval
+// until the context is cancelled.
+func RunOrderStatusMetrics(ctx context.Context, r *OrderRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		counts, err := r.CountOrdersByStatus(ctx)
+		if err != nil {
+			log.Printf("[metrics] failed to count orders by status: %v", err)
+		}
+
+		for orderStatus, count := range counts {
+			ordersByStatus.WithLabelValues(string(orderStatus)).Set(float64(count))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
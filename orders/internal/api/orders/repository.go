@@ -1,8 +1,19 @@
 package orders
 
 import (
+	"errors"
+
+	"github.com/leta/order-management-system/orders/generated"
+	customerapi "github.com/leta/order-management-system/orders/internal/api/customers"
+	pricelistapi "github.com/leta/order-management-system/orders/internal/api/pricelists"
 	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/pricelists"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/product"
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/events"
 	"github.com/leta/order-management-system/orders/pkg/models"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/outbox"
 	"github.com/leta/order-management-system/orders/pkg/utils"
 
 	"context"
@@ -10,6 +21,7 @@ import (
 	"time"
 
 	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/firestore/apiv1/firestorepb"
 	"google.golang.org/api/iterator"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -37,37 +49,131 @@ func (r *OrderRepository) orderCollection() *firestore.CollectionRef {
 	return r.db.Client.Collection("orders")
 }
 
+func (r *OrderRepository) outbox() *outbox.FirestoreStore {
+	r.CheckPreconditions()
+
+	return outbox.NewFirestoreStore(r.db.Client, utils.SERVICE_NAME)
+}
+
+// record adds the audit event of a change to tx.
+func (r *OrderRepository) record(
+	ctx context.Context, tx *firestore.Transaction, entityType string, id string, action string, before, after any) error {
+
+	event, err := audit.New(ctx, entityType, id, action, before, after)
+	if err != nil {
+		return err
+	}
+
+	return audit.NewFirestoreLog(r.db.Client, utils.SERVICE_NAME).Record(tx, event)
+}
+
+// orderItemAuditID identifies an order item in the audit log.
+func orderItemAuditID(orderId string, orderItemId string) string {
+	return orderId + "/" + orderItemId
+}
+
 func (r *OrderRepository) CreateOrder(ctx context.Context, order *orders.Order) (*orders.Order, error) {
 	r.CheckPreconditions()
 
 	// Set CreatedAt and UpdatedAt to the current time
-	currentTime := time.Now()
+	currentTime := time.Now().Format(time.RFC3339)
 
-	order.CreatedAt = currentTime.Format(time.RFC3339)
-	order.UpdatedAt = currentTime.Format(time.RFC3339)
+	order.CreatedAt = currentTime
+	order.UpdatedAt = currentTime
+	order.Version = 1
 
 	order.OrderStatus = utils.OrderStatusNew
+	order.FulfilmentStatus = utils.FulfilmentStatusUnfulfilled
+
+	for _, orderItem := range order.Items {
+		orderItem.CreatedAt = currentTime
+		orderItem.UpdatedAt = currentTime
+		orderItem.Version = 1
+	}
 
 	err := order.Validate()
 	if err != nil {
 		return nil, utils.Errorf(utils.INVALID_ERROR, "invalid orders details provided: %v", err)
 	}
 
-	orderModel := r.marshallOrder(order)
+	orderRef := r.orderCollection().NewDoc()
 
-	docRef, _, err := r.orderCollection().Add(ctx, orderModel)
-	if err != nil {
+	// The order, its items, its audit event and the OrderCreated event are
+	// written atomically, with the stock the items reserve.
+	err = r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		stock := r.newStockTx(tx)
+		if err := r.priceItems(ctx, stock, order.CustomerId, order.Items); err != nil {
+			return err
+		}
+
+		if err := checkCurrency(order.Items); err != nil {
+			return err
+		}
+
+		if err := stock.reserve(order.Items); err != nil {
+			return err
+		}
+
+		var addressId string
+		if order.ShippingAddress != nil {
+			addressId = order.ShippingAddress.Id
+		}
+
+		address, err := r.shippingAddressTx(tx, order.CustomerId, addressId)
+		if err != nil {
+			return err
+		}
+		order.ShippingAddress = address
+
+		if err := r.createOrderTx(ctx, tx, orderRef, order); err != nil {
+			return err
+		}
+
+		return stock.write(ctx)
+	})
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return nil, err
+	} else if err != nil {
 		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to create orders: %v", err)
 	}
 
-	order.Id = docRef.ID
+	order.Id = orderRef.ID
+
+	return order, nil
+}
 
-	_, err = r.CreateOrderItems(ctx, order.Id, order.Items)
+// createOrderTx writes a new order with its priced items, its audit event
+// and the OrderCreated event in tx.
+func (r *OrderRepository) createOrderTx(
+	ctx context.Context, tx *firestore.Transaction, orderRef *firestore.DocumentRef, order *orders.Order) error {
+
+	if err := tx.Create(orderRef, r.marshallOrder(order)); err != nil {
+		return err
+	}
+
+	for _, orderItem := range order.Items {
+		itemRef := r.orderItemCollection(orderRef.ID).NewDoc()
+		if err := tx.Create(itemRef, r.marshallOrderItem(orderItem)); err != nil {
+			return err
+		}
+		orderItem.Id = itemRef.ID
+	}
+
+	order.Id = orderRef.ID
+	if err := r.record(ctx, tx, audit.EntityOrder, orderRef.ID, audit.ActionCreate, nil, order); err != nil {
+		return err
+	}
+
+	event, err := events.New(ctx, orderRef.ID, &generated.OrderCreated{
+		OrderId:    orderRef.ID,
+		CustomerId: order.CustomerId,
+	})
 	if err != nil {
-		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to create orders items: %v", err)
+		return err
 	}
 
-	return order, nil
+	return r.outbox().Enqueue(tx, event)
 }
 
 func (r *OrderRepository) GetOrder(ctx context.Context, id string) (*orders.Order, error) {
@@ -103,7 +209,7 @@ func (r *OrderRepository) GetOrder(ctx context.Context, id string) (*orders.Orde
 	return order, nil
 }
 
-func (r *OrderRepository) ListOrders(ctx context.Context) ([]*orders.Order, error) {
+func (r *OrderRepository) ListOrders(ctx context.Context, includeDeleted bool) ([]*orders.Order, error) {
 	r.CheckPreconditions()
 
 	iter := r.orderCollection().Documents(ctx)
@@ -128,6 +234,10 @@ func (r *OrderRepository) ListOrders(ctx context.Context) ([]*orders.Order, erro
 
 		order.Id = doc.Ref.ID
 
+		if order.Deleted() && !includeDeleted {
+			continue
+		}
+
 		orderItems, err := r.ListOrderItems(ctx, order.Id)
 		if err != nil {
 			return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get orders items: %v", err)
@@ -141,31 +251,210 @@ func (r *OrderRepository) ListOrders(ctx context.Context) ([]*orders.Order, erro
 	return orders, nil
 }
 
+// UpdateOrderStatus sets the status of an order and, if it changed, records an
+// OrderStatusChanged event in the same transaction. Setting the current status
+// again is a no-op, so retried updates do not emit duplicate events. Other
+// changes must be allowed by utils.OrderStatus.CheckTransition, except that
+// paying an abandoned order sets its LatePaymentAt, for it to be refunded.
+// Abandoning an unpaid order puts the units of its items back in stock; those
+// of a paid order may have been shipped, and only come back through returns.
 func (r *OrderRepository) UpdateOrderStatus(
-	ctx context.Context, orderId string, status utils.OrderStatus) (*orders.Order, error) {
+	ctx context.Context, orderId string, orderStatus utils.OrderStatus, etag string) (*orders.Order, error) {
 	r.CheckPreconditions()
 
-	order, err := r.GetOrder(ctx, orderId)
-	if err != nil {
-		return nil, err
+	if orderId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "id is required")
 	}
 
-	order.OrderStatus = status
-	order.UpdatedAt = time.Now().Format(time.RFC3339)
+	orderRef := r.orderCollection().Doc(orderId)
+
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(orderRef)
+		if status.Code(err) == codes.NotFound {
+			return utils.Errorf(utils.NOT_FOUND_ERROR, "orders not found")
+		} else if err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to get orders: %v", err)
+		}
+
+		orderModel := &models.OrderModel{}
+		if err := doc.DataTo(orderModel); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall orders: %v", err)
+		}
 
-	orderModel := r.marshallOrder(order)
-	_, err = r.orderCollection().Doc(orderId).Set(ctx, orderModel)
+		if err := utils.CheckETag(etag, orderModel.Version); err != nil {
+			return err
+		}
+
+		previousStatus := orderModel.OrderStatus
+		if previousStatus == string(orderStatus) {
+			return nil
+		}
+
+		// An abandoned order released its coupon and stock, so must not be
+		// reopened nor paid. A payment settling late, e.g. an M-Pesa callback
+		// after the order was cancelled, still charged the customer: it is
+		// flagged so that it can be refunded.
+		if utils.OrderStatus(previousStatus).Abandoned() && orderStatus == utils.OrderStatusPaid {
+			return r.flagLatePaymentTx(ctx, tx, orderRef, orderModel)
+		}
+
+		if err := utils.OrderStatus(previousStatus).CheckTransition(orderStatus); err != nil {
+			return err
+		}
+
+		before := r.unmarshallOrder(orderModel)
+		before.Id = orderId
+
+		// An abandoned order no longer counts as a use of its coupon.
+		var release *couponTx
+		if orderStatus.Abandoned() && orderModel.CouponCode != "" {
+			release = &couponTx{}
+			err := release.getPromotion(tx, r.db.Client, orderModel.CouponCode, orderModel.CustomerId)
+			if err != nil {
+				return err
+			}
+
+			release.removeOrder(orderId)
+			orderModel.CouponCode = ""
+		}
+
+		stock := r.newStockTx(tx)
+		if orderStatus.Abandoned() && utils.OrderStatus(previousStatus).Unpaid() {
+			items, err := r.listOrderItemsTx(tx, orderId)
+			if err != nil {
+				return err
+			}
+
+			if err := stock.release(items); err != nil {
+				return err
+			}
+		}
+
+		currentTime := time.Now().Format(time.RFC3339)
+
+		orderModel.OrderStatus = string(orderStatus)
+		orderModel.UpdatedAt = currentTime
+		orderModel.Version++
+
+		if err := tx.Set(orderRef, orderModel); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to update orders status: %v", err)
+		}
+
+		if release != nil {
+			if err := r.writePromotionTx(ctx, tx, release, -1, currentTime); err != nil {
+				return err
+			}
+		}
+
+		if err := stock.write(ctx); err != nil {
+			return err
+		}
+
+		after := r.unmarshallOrder(orderModel)
+		after.Id = orderId
+
+		if err := r.record(ctx, tx, audit.EntityOrder, orderId, audit.ActionUpdate, before, after); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "%v", err)
+		}
+
+		event, err := events.New(ctx, orderId, &generated.OrderStatusChanged{
+			OrderId: orderId,
+			From:    getGRPCOrderStatus(utils.OrderStatus(previousStatus)),
+			To:      getGRPCOrderStatus(orderStatus),
+		})
+		if err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "%v", err)
+		}
+
+		if err := r.outbox().Enqueue(tx, event); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "%v", err)
+		}
+
+		return nil
+	})
 	if err != nil {
-		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to update orders status: %v", err)
+		return nil, err
 	}
 
 	return r.GetOrder(ctx, orderId)
 }
 
+// flagLatePaymentTx sets the LatePaymentAt of an abandoned order whose payment
+// settled, unless it is already set, so that the customer can be refunded.
+func (r *OrderRepository) flagLatePaymentTx(
+	ctx context.Context, tx *firestore.Transaction, orderRef *firestore.DocumentRef, orderModel *models.OrderModel) error {
+	if orderModel.LatePaymentAt != "" {
+		return nil
+	}
+
+	before := r.unmarshallOrder(orderModel)
+	before.Id = orderRef.ID
+
+	currentTime := time.Now().Format(time.RFC3339)
+
+	orderModel.LatePaymentAt = currentTime
+	orderModel.UpdatedAt = currentTime
+	orderModel.Version++
+
+	if err := tx.Set(orderRef, orderModel); err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "failed to update orders: %v", err)
+	}
+
+	after := r.unmarshallOrder(orderModel)
+	after.Id = orderRef.ID
+
+	if err := r.record(ctx, tx, audit.EntityOrder, orderRef.ID, audit.ActionUpdate, before, after); err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "%v", err)
+	}
+
+	return nil
+}
+
+// DeleteOrder marks an order as deleted. Its items are kept until it is
+// purged.
 func (r *OrderRepository) DeleteOrder(ctx context.Context, id string) error {
 	r.CheckPreconditions()
 
-	_, err := r.orderCollection().Doc(id).Delete(ctx)
+	if id == "" {
+		return utils.Errorf(utils.INVALID_ERROR, "id is required")
+	}
+
+	orderRef := r.orderCollection().Doc(id)
+
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(orderRef)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		orderModel := &models.OrderModel{}
+		if err := doc.DataTo(orderModel); err != nil {
+			return err
+		}
+
+		if orderModel.DeletedAt != "" {
+			return nil
+		}
+
+		before := r.unmarshallOrder(orderModel)
+		before.Id = id
+
+		timeNow := time.Now()
+		orderModel.UpdatedAt = timeNow.Format(time.RFC3339)
+		orderModel.DeletedAt = utils.DeletedAt(timeNow)
+		orderModel.Version++
+
+		if err := tx.Set(orderRef, orderModel); err != nil {
+			return err
+		}
+
+		after := r.unmarshallOrder(orderModel)
+		after.Id = id
+
+		return r.record(ctx, tx, audit.EntityOrder, id, audit.ActionDelete, before, after)
+	})
 	if err != nil {
 		return utils.Errorf(utils.INTERNAL_ERROR, "failed to delete orders: %v", err)
 	}
@@ -173,6 +462,310 @@ func (r *OrderRepository) DeleteOrder(ctx context.Context, id string) error {
 	return nil
 }
 
+// RestoreOrder undoes the deletion of an order.
+func (r *OrderRepository) RestoreOrder(ctx context.Context, id string) (*orders.Order, error) {
+	r.CheckPreconditions()
+
+	if id == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "id is required")
+	}
+
+	orderRef := r.orderCollection().Doc(id)
+
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(orderRef)
+		if status.Code(err) == codes.NotFound {
+			return utils.Errorf(utils.NOT_FOUND_ERROR, "orders not found")
+		} else if err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to get orders: %v", err)
+		}
+
+		orderModel := &models.OrderModel{}
+		if err := doc.DataTo(orderModel); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall orders: %v", err)
+		}
+
+		if orderModel.DeletedAt == "" {
+			return nil
+		}
+
+		before := r.unmarshallOrder(orderModel)
+		before.Id = id
+
+		orderModel.UpdatedAt = time.Now().Format(time.RFC3339)
+		orderModel.DeletedAt = ""
+		orderModel.Version++
+
+		if err := tx.Set(orderRef, orderModel); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to restore orders: %v", err)
+		}
+
+		after := r.unmarshallOrder(orderModel)
+		after.Id = id
+
+		if err := r.record(ctx, tx, audit.EntityOrder, id, audit.ActionRestore, before, after); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "%v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetOrder(ctx, id)
+}
+
+func (r *OrderRepository) ListUnpaidOrders(ctx context.Context, createdBefore time.Time) ([]*orders.Order, error) {
+	r.CheckPreconditions()
+
+	var statuses []string
+	for _, orderStatus := range utils.OrderStatuses {
+		// An order being checked out has a payment request pending, which may
+		// still settle.
+		if orderStatus.Unpaid() && orderStatus != utils.OrderStatusProcessing {
+			statuses = append(statuses, string(orderStatus))
+		}
+	}
+
+	docs, err := r.orderCollection().Where("order_status", "in", statuses).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to list unpaid orders: %v", err)
+	}
+
+	unpaid := make([]*orders.Order, 0)
+	for _, doc := range docs {
+		orderModel := &models.OrderModel{}
+		if err := doc.DataTo(orderModel); err != nil {
+			return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall orders: %v", err)
+		}
+
+		// Creation times are not kept in UTC, so they are compared as times.
+		// Deleted orders are included, as they hold stock too.
+		createdAt, err := time.Parse(time.RFC3339, orderModel.CreatedAt)
+		if err != nil || !createdAt.Before(createdBefore) {
+			continue
+		}
+
+		order := r.unmarshallOrder(orderModel)
+		order.Id = doc.Ref.ID
+
+		unpaid = append(unpaid, order)
+	}
+
+	return unpaid, nil
+}
+
+// PurgeOrders removes the orders deleted before deletedBefore, with their
+// items, shipments and returns, and returns their number.
+func (r *OrderRepository) PurgeOrders(ctx context.Context, deletedBefore time.Time) (int, error) {
+	r.CheckPreconditions()
+
+	cutoff := utils.DeletedAt(deletedBefore)
+
+	docs, err := r.orderCollection().Where("deleted_at", "<", cutoff).Documents(ctx).GetAll()
+	if err != nil {
+		return 0, utils.Errorf(utils.INTERNAL_ERROR, "failed to list deleted orders: %v", err)
+	}
+
+	purged := 0
+	for _, doc := range docs {
+		removed := false
+
+		err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			removed = false
+
+			doc, err := tx.Get(doc.Ref)
+			if status.Code(err) == codes.NotFound {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			orderModel := &models.OrderModel{}
+			if err := doc.DataTo(orderModel); err != nil {
+				return err
+			}
+
+			// Restored since it was listed.
+			if orderModel.DeletedAt == "" || orderModel.DeletedAt >= cutoff {
+				return nil
+			}
+
+			items, err := tx.Documents(r.orderItemCollection(doc.Ref.ID)).GetAll()
+			if err != nil {
+				return err
+			}
+
+			shipments, err := tx.Documents(r.shipmentCollection(doc.Ref.ID)).GetAll()
+			if err != nil {
+				return err
+			}
+
+			returns, err := tx.Documents(r.returnCollection(doc.Ref.ID)).GetAll()
+			if err != nil {
+				return err
+			}
+
+			before := r.unmarshallOrder(orderModel)
+			before.Id = doc.Ref.ID
+
+			children := append(append(items, shipments...), returns...)
+			for _, item := range children {
+				if err := tx.Delete(item.Ref); err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Delete(doc.Ref); err != nil {
+				return err
+			}
+
+			removed = true
+
+			return r.record(ctx, tx, audit.EntityOrder, doc.Ref.ID, audit.ActionPurge, before, nil)
+		})
+		if err != nil {
+			return purged, utils.Errorf(utils.INTERNAL_ERROR, "failed to purge order %s: %v", doc.Ref.ID, err)
+		}
+
+		if removed {
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// SetOrderTotals stores the discount and tax computed at checkout with the
+// order and its items, and the unit prices they were computed from, which
+// prices the items added before prices were recorded.
+func (r *OrderRepository) SetOrderTotals(ctx context.Context, order *orders.Order) (*orders.Order, error) {
+	r.CheckPreconditions()
+
+	if order.Id == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "id is required")
+	} else if order.Tax == nil {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "tax is required")
+	}
+
+	orderRef := r.orderCollection().Doc(order.Id)
+
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(orderRef)
+		if status.Code(err) == codes.NotFound {
+			return utils.Errorf(utils.NOT_FOUND_ERROR, "orders not found")
+		} else if err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to get orders: %v", err)
+		}
+
+		orderModel := &models.OrderModel{}
+		if err := doc.DataTo(orderModel); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall orders: %v", err)
+		}
+
+		itemDocs, err := tx.Documents(r.orderItemCollection(order.Id)).GetAll()
+		if err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to get orders items: %v", err)
+		}
+
+		stored := make(map[string]*orders.OrderItem, len(itemDocs))
+		for _, itemDoc := range itemDocs {
+			orderItemModel := &models.OrderItemModel{}
+			if err := itemDoc.DataTo(orderItemModel); err != nil {
+				return utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall orders item: %v", err)
+			}
+
+			orderItem := r.unmarshallOrderItem(orderItemModel)
+			orderItem.Id = itemDoc.Ref.ID
+			stored[orderItem.Id] = orderItem
+		}
+
+		// The totals are only right for the items they were computed for.
+		if len(stored) != len(order.Items) {
+			return utils.Errorf(utils.ABORTED_ERROR, "the items of the order changed")
+		}
+		for _, item := range order.Items {
+			if before, ok := stored[item.Id]; !ok || before.Version != item.Version {
+				return utils.Errorf(utils.ABORTED_ERROR, "the items of the order changed")
+			}
+		}
+
+		currentTime := time.Now().Format(time.RFC3339)
+
+		for _, item := range order.Items {
+			before := stored[item.Id]
+
+			orderItem := *before
+			orderItem.UnitPrice = item.UnitPrice
+			orderItem.Tax = item.Tax
+			orderItem.Discount = item.Discount
+			orderItem.UpdatedAt = currentTime
+			orderItem.Version++
+
+			if err := tx.Set(r.orderItemCollection(order.Id).Doc(item.Id), r.marshallOrderItem(&orderItem)); err != nil {
+				return utils.Errorf(utils.INTERNAL_ERROR, "failed to update orders item: %v", err)
+			}
+
+			err = r.record(ctx, tx, audit.EntityOrderItem, orderItemAuditID(order.Id, item.Id),
+				audit.ActionUpdate, before, &orderItem)
+			if err != nil {
+				return utils.Errorf(utils.INTERNAL_ERROR, "%v", err)
+			}
+		}
+
+		before := r.unmarshallOrder(orderModel)
+		before.Id = order.Id
+
+		orderModel.Tax = r.marshallOrderTax(order.Tax)
+		orderModel.Discount = r.marshallDiscount(order.Discount)
+		orderModel.UpdatedAt = currentTime
+		orderModel.Version++
+
+		if err := tx.Set(orderRef, orderModel); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to update orders: %v", err)
+		}
+
+		after := r.unmarshallOrder(orderModel)
+		after.Id = order.Id
+
+		if err := r.record(ctx, tx, audit.EntityOrder, order.Id, audit.ActionUpdate, before, after); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "%v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetOrder(ctx, order.Id)
+}
+
+func (r *OrderRepository) CountOrdersByStatus(ctx context.Context) (map[utils.OrderStatus]int64, error) {
+	r.CheckPreconditions()
+
+	counts := make(map[utils.OrderStatus]int64, len(utils.OrderStatuses))
+
+	for _, orderStatus := range utils.OrderStatuses {
+		query := r.orderCollection().Where("order_status", "==", string(orderStatus))
+
+		result, err := query.NewAggregationQuery().WithCount("count").Get(ctx)
+		if err != nil {
+			return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to count orders: %v", err)
+		}
+
+		count, ok := result["count"].(*firestorepb.Value)
+		if !ok {
+			return nil, utils.Errorf(utils.INTERNAL_ERROR, "unexpected count result for status %s", orderStatus)
+		}
+
+		counts[orderStatus] = count.GetIntegerValue()
+	}
+
+	return counts, nil
+}
+
 func (r *OrderRepository) orderItemCollection(orderId string) *firestore.CollectionRef {
 	r.CheckPreconditions()
 
@@ -189,21 +782,47 @@ func (r *OrderRepository) CreateOrderItem(
 
 	orderItem.CreatedAt = currentTime.Format(time.RFC3339)
 	orderItem.UpdatedAt = currentTime.Format(time.RFC3339)
+	orderItem.Version = 1
 
 	err := orderItem.Validate()
 	if err != nil {
 		return nil, utils.Errorf(utils.INVALID_ERROR, "invalid orders item details provided: %v", err)
 	}
 
-	orderItemModel := r.marshallOrderItem(orderItem)
+	docRef := r.orderItemCollection(orderId).NewDoc()
 
-	docRef, _, err := r.orderItemCollection(orderId).Add(ctx, orderItemModel)
-	if err != nil {
+	err = r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		stock := r.newStockTx(tx)
+		orderModel, items, err := r.addableItems(ctx, tx, stock, orderId, []*orders.OrderItem{orderItem})
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Create(docRef, r.marshallOrderItem(orderItem)); err != nil {
+			return err
+		}
+
+		orderItem.Id = docRef.ID
+
+		err = r.record(ctx, tx, audit.EntityOrderItem, orderItemAuditID(orderId, docRef.ID),
+			audit.ActionCreate, nil, orderItem)
+		if err != nil {
+			return err
+		}
+
+		if err := stock.write(ctx); err != nil {
+			return err
+		}
+
+		return r.clearTotalsTx(ctx, tx, orderId, orderModel, items, "")
+	})
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return nil, err
+	} else if err != nil {
 		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to create orders item: %v", err)
 	}
 
-	orderItem.Id = docRef.ID
-
 	return orderItem, nil
 }
 
@@ -216,36 +835,59 @@ func (r *OrderRepository) CreateOrderItems(
 		return nil, utils.Errorf(utils.INVALID_ERROR, "orders id is required")
 	}
 
-	bulkWriter := r.db.Client.BulkWriter(ctx)
-
 	currentTime := time.Now().Format(time.RFC3339)
-	var createdOrderItems []*orders.OrderItem
 
 	for _, orderItem := range orderItems {
 		// Set CreatedAt and UpdatedAt to the current time
 		orderItem.CreatedAt = currentTime
 		orderItem.UpdatedAt = currentTime
+		orderItem.Version = 1
 
 		err := orderItem.Validate()
 		if err != nil {
 			return nil, utils.Errorf(utils.INVALID_ERROR, "invalid orders item details provided: %v", err)
 		}
+	}
 
-		orderItemModel := r.marshallOrderItem(orderItem)
-		docRef := r.orderItemCollection(orderId).NewDoc() // Create a new document reference.
+	// The items and their audit events are written atomically, with the
+	// stock they reserve.
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		stock := r.newStockTx(tx)
+		orderModel, items, err := r.addableItems(ctx, tx, stock, orderId, orderItems)
+		if err != nil {
+			return err
+		}
 
-		orderItem.Id = docRef.ID
-		createdOrderItems = append(createdOrderItems, orderItem)
+		for _, orderItem := range orderItems {
+			docRef := r.orderItemCollection(orderId).NewDoc() // Create a new document reference.
 
-		_, err = bulkWriter.Create(docRef, orderItemModel)
-		if err != nil {
-			return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to create orders item: %v", err)
+			if err := tx.Create(docRef, r.marshallOrderItem(orderItem)); err != nil {
+				return err
+			}
+
+			orderItem.Id = docRef.ID
+
+			err := r.record(ctx, tx, audit.EntityOrderItem, orderItemAuditID(orderId, docRef.ID),
+				audit.ActionCreate, nil, orderItem)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := stock.write(ctx); err != nil {
+			return err
 		}
-	}
 
-	bulkWriter.Flush()
+		return r.clearTotalsTx(ctx, tx, orderId, orderModel, items, "")
+	})
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return nil, err
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to create orders item: %v", err)
+	}
 
-	return createdOrderItems, nil
+	return orderItems, nil
 }
 
 func (r *OrderRepository) GetOrderItem(
@@ -324,22 +966,93 @@ func (r *OrderRepository) UpdateOrderItem(
 		return nil, utils.Errorf(utils.INVALID_ERROR, "orders item id is required")
 	}
 
-	orderItem, err := r.GetOrderItem(ctx, orderId, orderItemId)
-	if err != nil {
-		return nil, err
-	}
+	itemRef := r.orderItemCollection(orderId).Doc(orderItemId)
 
-	if v := update.Quantity; v != nil {
-		orderItem.Quantity = *v
-	}
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		before, err := r.getOrderItemTx(tx, itemRef)
+		if err != nil {
+			return err
+		}
+
+		if err := utils.CheckETag(update.ETag, before.Version); err != nil {
+			return err
+		}
+
+		orderModel, err := r.getOrderTx(tx, r.orderCollection().Doc(orderId))
+		if err != nil {
+			return err
+		}
+
+		if err := checkItemsChangeable(orderModel); err != nil {
+			return err
+		}
+
+		items, err := r.listOrderItemsTx(tx, orderId)
+		if err != nil {
+			return err
+		}
+
+		orderItem := *before
+
+		if v := update.Quantity; v != nil {
+			orderItem.Quantity = *v
+		}
+
+		if err := orderItem.Validate(); err != nil {
+			return err
+		}
+
+		// The item is priced again as if it was added now, with the units it
+		// reserved back in stock, as prices may depend on quantities.
+		stock := r.newStockTx(tx)
+		if orderItem.Quantity != before.Quantity {
+			if err := stock.release([]*orders.OrderItem{before}); err != nil {
+				return err
+			}
+
+			orderItem.UnitPrice = money.Money{}
+			updated := []*orders.OrderItem{&orderItem}
+			if err := r.priceItems(ctx, stock, orderModel.CustomerId, updated); err != nil {
+				return err
+			}
+
+			for _, item := range items {
+				if item.Id != orderItemId {
+					updated = append(updated, item)
+				}
+			}
+			if err := checkCurrency(updated); err != nil {
+				return err
+			}
+
+			if err := stock.reserve([]*orders.OrderItem{&orderItem}); err != nil {
+				return err
+			}
+		}
+
+		// Set UpdatedAt to the current time
+		orderItem.UpdatedAt = time.Now().Format(time.RFC3339)
+		orderItem.Version++
+		clearItemTotals(&orderItem)
 
-	// Set UpdatedAt to the current time
-	orderItem.UpdatedAt = time.Now().Format(time.RFC3339)
+		if err := tx.Set(itemRef, r.marshallOrderItem(&orderItem)); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to update orders item: %v", err)
+		}
+
+		err = r.record(ctx, tx, audit.EntityOrderItem, orderItemAuditID(orderId, orderItemId),
+			audit.ActionUpdate, before, &orderItem)
+		if err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "%v", err)
+		}
 
-	orderItemModel := r.marshallOrderItem(orderItem)
-	_, err = r.orderItemCollection(orderId).Doc(orderItemId).Set(ctx, orderItemModel)
+		if err := stock.write(ctx); err != nil {
+			return err
+		}
+
+		return r.clearTotalsTx(ctx, tx, orderId, orderModel, items, orderItemId)
+	})
 	if err != nil {
-		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to update orders item: %v", err)
+		return nil, err
 	}
 
 	return r.GetOrderItem(ctx, orderId, orderItemId)
@@ -354,14 +1067,282 @@ func (r *OrderRepository) DeleteOrderItem(ctx context.Context, orderId string, o
 		return utils.Errorf(utils.INVALID_ERROR, "orders item id is required")
 	}
 
-	_, err := r.orderItemCollection(orderId).Doc(orderItemId).Delete(ctx)
+	itemRef := r.orderItemCollection(orderId).Doc(orderItemId)
+
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		before, err := r.getOrderItemTx(tx, itemRef)
+		if utils.ErrorCode(err) == utils.NOT_FOUND_ERROR {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		orderModel, err := r.getOrderTx(tx, r.orderCollection().Doc(orderId))
+		if err != nil {
+			return err
+		}
+
+		if err := checkItemsChangeable(orderModel); err != nil {
+			return err
+		}
+
+		items, err := r.listOrderItemsTx(tx, orderId)
+		if err != nil {
+			return err
+		}
+
+		// The units of the item go back in stock.
+		stock := r.newStockTx(tx)
+		if err := stock.release([]*orders.OrderItem{before}); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(itemRef); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to delete orders item: %v", err)
+		}
+
+		err = r.record(ctx, tx, audit.EntityOrderItem, orderItemAuditID(orderId, orderItemId),
+			audit.ActionDelete, before, nil)
+		if err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "%v", err)
+		}
+
+		if err := stock.write(ctx); err != nil {
+			return err
+		}
+
+		return r.clearTotalsTx(ctx, tx, orderId, orderModel, items, orderItemId)
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// priceItems sets the unit price of the unpriced items of an order of the
+// customer customerId to the price the customer pays now for their product
+// or variant, which must be orderable, see product.Product.OrderPrice and
+// pricelists.Pricing. The products are read through stock, which the caller
+// reserves the items from, and the pricing outside of the transaction, see
+// pricelistapi.ReadPricing.
+func (r *OrderRepository) priceItems(
+	ctx context.Context, stock *stockTx, customerId string, items []*orders.OrderItem) error {
+
+	var pricing *pricelists.Pricing
+	for _, item := range items {
+		if item.Priced() {
+			continue
+		}
+
+		if pricing == nil {
+			var err error
+			if pricing, err = pricelistapi.ReadPricing(ctx, r.db.Client, customerId, time.Now()); err != nil {
+				return err
+			}
+		}
+
+		product, err := stock.product(item.ProductId)
+		if err != nil {
+			return err
+		}
+
+		if err := item.Price(product, pricing); err != nil {
+			return err
+		}
+
+		if err := r.expandBundle(stock, item, product); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// expandBundle sets the components of an item of a product to those of the
+// product, if it is a bundle, and checks that the products of the components,
+// read through stock, can be ordered in the units of the item, see
+// product.Product.OrderPrice. The units of the components are taken out of
+// stock along with those of the other items, see stockTx.reserve.
+func (r *OrderRepository) expandBundle(stock *stockTx, item *orders.OrderItem, bundle *product.Product) error {
+	item.Components = nil
+	for _, component := range bundle.Components {
+		p, err := stock.get(component.ProductId)
+		if err != nil {
+			return err
+		} else if p == nil {
+			return utils.Errorf(utils.INVALID_ERROR, "product %s of bundle %s not found", component.ProductId, bundle.Id)
+		}
+
+		if _, err := p.OrderPrice(component.VariantId, item.Quantity*component.Quantity); err != nil {
+			return err
+		}
+
+		item.Components = append(item.Components, &orders.Component{
+			ProductId: component.ProductId,
+			VariantId: component.VariantId,
+			Quantity:  component.Quantity,
+		})
+	}
+
+	return nil
+}
+
+// addableItems prices items to be added to an order, checks that they are in
+// the currency of its other items and reserves their units from stock. It
+// returns the order and its other items, read in tx.
+func (r *OrderRepository) addableItems(ctx context.Context, tx *firestore.Transaction, stock *stockTx,
+	orderId string, items []*orders.OrderItem) (*models.OrderModel, []*orders.OrderItem, error) {
+
+	orderModel, err := r.getOrderTx(tx, r.orderCollection().Doc(orderId))
 	if err != nil {
-		return utils.Errorf(utils.INTERNAL_ERROR, "failed to delete orders item: %v", err)
+		return nil, nil, err
+	}
+
+	if err := checkItemsChangeable(orderModel); err != nil {
+		return nil, nil, err
+	}
+
+	existing, err := r.listOrderItemsTx(tx, orderId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := r.priceItems(ctx, stock, orderModel.CustomerId, items); err != nil {
+		return nil, nil, err
+	}
+
+	all := make([]*orders.OrderItem, 0, len(existing)+len(items))
+	if err := checkCurrency(append(append(all, existing...), items...)); err != nil {
+		return nil, nil, err
+	}
+
+	if err := stock.reserve(items); err != nil {
+		return nil, nil, err
+	}
+
+	return orderModel, existing, nil
+}
+
+// checkItemsChangeable fails with an INVALID_ERROR if the order is abandoned:
+// its items released their stock along with it.
+func checkItemsChangeable(orderModel *models.OrderModel) error {
+	if orderStatus := utils.OrderStatus(orderModel.OrderStatus); orderStatus.Abandoned() {
+		return utils.Errorf(utils.INVALID_ERROR, "the items of a %s order cannot be changed", orderStatus)
+	}
+	return nil
+}
+
+// clearTotalsTx clears the discount and tax stored at checkout with an order
+// and with its items but skip, which the caller writes itself, as they are
+// only right for the items they were computed for, see SetOrderTotals. It
+// only writes, so must be called after the reads of tx.
+func (r *OrderRepository) clearTotalsTx(
+	ctx context.Context, tx *firestore.Transaction, orderId string, orderModel *models.OrderModel,
+	items []*orders.OrderItem, skip string) error {
+
+	if orderModel.Tax != nil || orderModel.Discount != nil {
+		before := r.unmarshallOrder(orderModel)
+		before.Id = orderId
+
+		orderModel.Tax = nil
+		orderModel.Discount = nil
+		orderModel.UpdatedAt = time.Now().Format(time.RFC3339)
+		orderModel.Version++
+
+		if err := tx.Set(r.orderCollection().Doc(orderId), orderModel); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to update orders: %v", err)
+		}
+
+		after := r.unmarshallOrder(orderModel)
+		after.Id = orderId
+
+		if err := r.record(ctx, tx, audit.EntityOrder, orderId, audit.ActionUpdate, before, after); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "%v", err)
+		}
+	}
+
+	return r.clearItemTotalsTx(ctx, tx, orderId, items, skip)
+}
+
+// clearItemTotalsTx clears the discount and tax stored at checkout with the
+// items of an order but skip, see clearTotalsTx.
+func (r *OrderRepository) clearItemTotalsTx(
+	ctx context.Context, tx *firestore.Transaction, orderId string, items []*orders.OrderItem, skip string) error {
+
+	currentTime := time.Now().Format(time.RFC3339)
+
+	for _, item := range items {
+		if item.Id == skip || (item.Tax == nil && item.Discount.IsZero()) {
+			continue
+		}
+
+		orderItem := *item
+		clearItemTotals(&orderItem)
+		orderItem.UpdatedAt = currentTime
+		orderItem.Version++
+
+		itemRef := r.orderItemCollection(orderId).Doc(item.Id)
+		if err := tx.Set(itemRef, r.marshallOrderItem(&orderItem)); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to update orders item: %v", err)
+		}
+
+		err := r.record(ctx, tx, audit.EntityOrderItem, orderItemAuditID(orderId, item.Id),
+			audit.ActionUpdate, item, &orderItem)
+		if err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "%v", err)
+		}
+	}
+
+	return nil
+}
+
+func clearItemTotals(item *orders.OrderItem) {
+	item.Tax = nil
+	item.Discount = money.Zero(item.UnitPrice.Currency)
+}
+
+// checkCurrency returns an INVALID_ERROR unless the priced items are all in
+// the same currency, as an order is paid in a single one.
+func checkCurrency(items []*orders.OrderItem) error {
+	currency := ""
+	for _, item := range items {
+		if !item.Priced() {
+			continue
+		}
+
+		if currency == "" {
+			currency = item.UnitPrice.Currency
+		} else if item.UnitPrice.Currency != currency {
+			return utils.Errorf(utils.INVALID_ERROR,
+				"items of an order must be in a single currency, got %s and %s", currency, item.UnitPrice.Currency)
+		}
 	}
 
 	return nil
 }
 
+func (r *OrderRepository) getOrderItemTx(
+	tx *firestore.Transaction, itemRef *firestore.DocumentRef) (*orders.OrderItem, error) {
+
+	doc, err := tx.Get(itemRef)
+	if status.Code(err) == codes.NotFound {
+		return nil, utils.Errorf(utils.NOT_FOUND_ERROR, "orders item not found")
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get orders item: %v", err)
+	}
+
+	orderItemModel := &models.OrderItemModel{}
+	if err := doc.DataTo(orderItemModel); err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall orders item: %v", err)
+	}
+
+	orderItem := r.unmarshallOrderItem(orderItemModel)
+	orderItem.Id = doc.Ref.ID
+
+	return orderItem, nil
+}
+
 func (r *OrderRepository) marshallOrder(order *orders.Order) *models.OrderModel {
 	return &models.OrderModel{
 		CustomerId:  order.CustomerId,
@@ -369,6 +1350,15 @@ func (r *OrderRepository) marshallOrder(order *orders.Order) *models.OrderModel
 		OrderStatus: string(order.OrderStatus),
 		CreatedAt:   order.CreatedAt,
 		UpdatedAt:   order.UpdatedAt,
+		DeletedAt:   order.DeletedAt,
+		Version:     order.Version,
+		Tax:         r.marshallOrderTax(order.Tax),
+		CouponCode:  order.CouponCode,
+		Discount:    r.marshallDiscount(order.Discount),
+
+		ShippingAddress:  customerapi.MarshallAddress(order.ShippingAddress),
+		FulfilmentStatus: string(order.FulfilmentStatus),
+		LatePaymentAt:    order.LatePaymentAt,
 	}
 }
 
@@ -379,6 +1369,78 @@ func (r *OrderRepository) unmarshallOrder(order *models.OrderModel) *orders.Orde
 		OrderStatus: utils.OrderStatus(order.OrderStatus),
 		CreatedAt:   order.CreatedAt,
 		UpdatedAt:   order.UpdatedAt,
+		DeletedAt:   order.DeletedAt,
+		Version:     order.Version,
+		Tax:         r.unmarshallOrderTax(order.Tax),
+		CouponCode:  order.CouponCode,
+		Discount:    r.unmarshallDiscount(order.Discount, order.Tax),
+
+		ShippingAddress:  customerapi.UnmarshallAddress(order.ShippingAddress),
+		FulfilmentStatus: fulfilmentStatus(order.FulfilmentStatus),
+		LatePaymentAt:    order.LatePaymentAt,
+	}
+}
+
+// fulfilmentStatus returns the fulfilment status stored for an order.
+// Orders created before shipments were recorded have none and are
+// unfulfilled.
+func fulfilmentStatus(status string) utils.FulfilmentStatus {
+	if status == "" {
+		return utils.FulfilmentStatusUnfulfilled
+	}
+	return utils.FulfilmentStatus(status)
+}
+
+func (r *OrderRepository) marshallDiscount(discount *orders.Discount) *models.DiscountModel {
+	if discount == nil {
+		return nil
+	}
+
+	return &models.DiscountModel{
+		Code:        discount.Code,
+		Description: discount.Description,
+		Amount:      discount.Amount.Amount,
+	}
+}
+
+func (r *OrderRepository) unmarshallDiscount(discount *models.DiscountModel, tax *models.OrderTaxModel) *orders.Discount {
+	if discount == nil || tax == nil {
+		return nil
+	}
+
+	return &orders.Discount{
+		Code:        discount.Code,
+		Description: discount.Description,
+		Amount:      money.New(discount.Amount, tax.Currency),
+	}
+}
+
+func (r *OrderRepository) marshallOrderTax(tax *orders.OrderTax) *models.OrderTaxModel {
+	if tax == nil {
+		return nil
+	}
+
+	return &models.OrderTaxModel{
+		Inclusive: tax.Inclusive,
+		Exempt:    tax.Exempt,
+		Subtotal:  tax.Subtotal.Amount,
+		Amount:    tax.Amount.Amount,
+		Total:     tax.Total.Amount,
+		Currency:  tax.Total.Currency,
+	}
+}
+
+func (r *OrderRepository) unmarshallOrderTax(tax *models.OrderTaxModel) *orders.OrderTax {
+	if tax == nil {
+		return nil
+	}
+
+	return &orders.OrderTax{
+		Inclusive: tax.Inclusive,
+		Exempt:    tax.Exempt,
+		Subtotal:  money.New(tax.Subtotal, tax.Currency),
+		Amount:    money.New(tax.Amount, tax.Currency),
+		Total:     money.New(tax.Total, tax.Currency),
 	}
 }
 
@@ -405,17 +1467,89 @@ func (r *OrderRepository) unmarshallOrderItems(items []*models.OrderItemModel) [
 func (r *OrderRepository) marshallOrderItem(item *orders.OrderItem) *models.OrderItemModel {
 	return &models.OrderItemModel{
 		ProductId: item.ProductId,
+		VariantId: item.VariantId,
 		Quantity:  int(item.Quantity),
+		UnitPrice: item.UnitPrice.Amount,
+		Currency:  item.UnitPrice.Currency,
 		CreatedAt: item.CreatedAt,
 		UpdatedAt: item.UpdatedAt,
+		Version:   item.Version,
+		Tax:       r.marshallItemTax(item.Tax),
+		Discount:  item.Discount.Amount,
+
+		Components: marshallComponents(item.Components),
 	}
 }
 
 func (r *OrderRepository) unmarshallOrderItem(item *models.OrderItemModel) *orders.OrderItem {
+	// Items added before prices were recorded are left unpriced.
+	var unitPrice, discount money.Money
+	if item.Currency != "" {
+		unitPrice = money.New(item.UnitPrice, item.Currency)
+		discount = money.New(item.Discount, item.Currency)
+	}
+
 	return &orders.OrderItem{
 		ProductId: item.ProductId,
+		VariantId: item.VariantId,
 		Quantity:  uint(item.Quantity),
+		UnitPrice: unitPrice,
 		CreatedAt: item.CreatedAt,
 		UpdatedAt: item.UpdatedAt,
+		Version:   item.Version,
+		Tax:       r.unmarshallItemTax(item.Tax, item.Currency),
+		Discount:  discount,
+
+		Components: unmarshallComponents(item.Components),
+	}
+}
+
+func marshallComponents(components []*orders.Component) []*models.ComponentModel {
+	var componentModels []*models.ComponentModel
+	for _, component := range components {
+		componentModels = append(componentModels, &models.ComponentModel{
+			ProductId: component.ProductId,
+			VariantId: component.VariantId,
+			Quantity:  int(component.Quantity),
+		})
+	}
+	return componentModels
+}
+
+func unmarshallComponents(componentModels []*models.ComponentModel) []*orders.Component {
+	var components []*orders.Component
+	for _, component := range componentModels {
+		components = append(components, &orders.Component{
+			ProductId: component.ProductId,
+			VariantId: component.VariantId,
+			Quantity:  uint(component.Quantity),
+		})
+	}
+	return components
+}
+
+func (r *OrderRepository) marshallItemTax(tax *orders.ItemTax) *models.ItemTaxModel {
+	if tax == nil {
+		return nil
+	}
+
+	return &models.ItemTaxModel{
+		Category: tax.Category,
+		Rate:     tax.Rate,
+		Amount:   tax.Amount.Amount,
+		Total:    tax.Total.Amount,
+	}
+}
+
+func (r *OrderRepository) unmarshallItemTax(tax *models.ItemTaxModel, currency string) *orders.ItemTax {
+	if tax == nil {
+		return nil
+	}
+
+	return &orders.ItemTax{
+		Category: tax.Category,
+		Rate:     tax.Rate,
+		Amount:   money.New(tax.Amount, currency),
+		Total:    money.New(tax.Total, currency),
 	}
 }
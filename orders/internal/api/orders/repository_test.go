@@ -351,7 +351,7 @@ func TestOrderService_ListOrders(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := orderRepository.ListOrders(tt.args.ctx)
+			got, err := orderRepository.ListOrders(tt.args.ctx, false)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("OrderRepository.ListOrders() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -1107,7 +1107,7 @@ func TestOrderService_UpdateOrderStatus(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 
-			got, err := orderRepository.UpdateOrderStatus(tt.args.ctx, tt.args.orderId, tt.args.status)
+			got, err := orderRepository.UpdateOrderStatus(tt.args.ctx, tt.args.orderId, tt.args.status, "")
 			if (err != nil) != tt.wantErr {
 				t.Errorf("OrderRepository.UpdateOrderStatus() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -0,0 +1,403 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/returns"
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/models"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// returnAuditID identifies a return in the audit log.
+func returnAuditID(orderId string, returnId string) string {
+	return orderId + "/" + returnId
+}
+
+func (r *OrderRepository) returnCollection(orderId string) *firestore.CollectionRef {
+	r.CheckPreconditions()
+
+	return r.orderCollection().Doc(orderId).Collection("returns")
+}
+
+// RequestReturn checks the units of the return against the items, shipments
+// and other returns of the order in the same transaction, so that no unit is
+// returned twice.
+func (r *OrderRepository) RequestReturn(
+	ctx context.Context, ret *returns.Return, etag string) (*returns.Return, error) {
+
+	r.CheckPreconditions()
+
+	if ret.OrderId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "orders id is required")
+	}
+
+	if err := ret.Validate(); err != nil {
+		return nil, err
+	}
+
+	orderRef := r.orderCollection().Doc(ret.OrderId)
+	returnRef := r.returnCollection(ret.OrderId).NewDoc()
+
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		orderModel, err := r.getOrderTx(tx, orderRef)
+		if err != nil {
+			return err
+		}
+
+		// The order is not written: etag only makes sure the client saw
+		// the items and shipments the return is checked against.
+		if err := utils.CheckETag(etag, orderModel.Version); err != nil {
+			return err
+		}
+
+		if orderModel.OrderStatus != string(utils.OrderStatusPaid) {
+			return utils.Errorf(utils.INVALID_ERROR, "order is %s, not paid", orderModel.OrderStatus)
+		}
+
+		items, err := r.listOrderItemsTx(tx, ret.OrderId)
+		if err != nil {
+			return err
+		}
+
+		shipped, err := r.listShipmentsTx(tx, ret.OrderId)
+		if err != nil {
+			return err
+		}
+
+		requested, err := r.listReturnsTx(tx, ret.OrderId)
+		if err != nil {
+			return err
+		}
+
+		if err := returns.CheckReturnable(items, shipped, requested, ret); err != nil {
+			return err
+		}
+
+		refundAmount, err := returns.RefundAmount(items, requested, ret)
+		if err != nil {
+			return err
+		}
+
+		currentTime := time.Now().Format(time.RFC3339)
+
+		ret.Id = returnRef.ID
+		ret.Status = returns.StatusRequested
+		ret.RejectionReason = ""
+		ret.Restocked = false
+		ret.RefundAmount = refundAmount
+		ret.RefundReference = ""
+		ret.RequestedAt = currentTime
+		ret.ReceivedAt = ""
+		ret.RefundedAt = ""
+		ret.CreatedAt = currentTime
+		ret.UpdatedAt = currentTime
+		ret.Version = 1
+
+		if err := tx.Create(returnRef, marshallReturn(ret)); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to create return: %v", err)
+		}
+
+		auditID := returnAuditID(ret.OrderId, ret.Id)
+		return r.record(ctx, tx, audit.EntityReturn, auditID, audit.ActionCreate, nil, ret)
+	})
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return nil, err
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to request return: %v", err)
+	}
+
+	return ret, nil
+}
+
+func (r *OrderRepository) GetReturn(ctx context.Context, orderId string, returnId string) (*returns.Return, error) {
+	r.CheckPreconditions()
+
+	if orderId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "orders id is required")
+	} else if returnId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "return id is required")
+	}
+
+	doc, err := r.returnCollection(orderId).Doc(returnId).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, utils.Errorf(utils.NOT_FOUND_ERROR, "return not found")
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get return: %v", err)
+	}
+
+	return unmarshallReturnDoc(orderId, doc)
+}
+
+func (r *OrderRepository) ListReturns(ctx context.Context, orderId string) ([]*returns.Return, error) {
+	r.CheckPreconditions()
+
+	if orderId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "orders id is required")
+	}
+
+	iter := r.returnCollection(orderId).OrderBy("created_at", firestore.Asc).Documents(ctx)
+
+	list := make([]*returns.Return, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to iterate returns: %v", err)
+		}
+
+		ret, err := unmarshallReturnDoc(orderId, doc)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, ret)
+	}
+
+	return list, nil
+}
+
+func (r *OrderRepository) ApproveReturn(ctx context.Context, orderId string, returnId string) (*returns.Return, error) {
+	return r.updateReturn(ctx, orderId, returnId, returns.StatusApproved,
+		func(context.Context, *firestore.Transaction, *returns.Return, string) error { return nil })
+}
+
+func (r *OrderRepository) RejectReturn(
+	ctx context.Context, orderId string, returnId string, reason string) (*returns.Return, error) {
+
+	if reason == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "reason is required")
+	}
+
+	return r.updateReturn(ctx, orderId, returnId, returns.StatusRejected,
+		func(_ context.Context, _ *firestore.Transaction, ret *returns.Return, _ string) error {
+			ret.RejectionReason = reason
+			return nil
+		})
+}
+
+// ReceiveReturn puts the units of the return back in the stock of their
+// variants if they are restocked, as if they were released by their order.
+// Items removed from the order since are not restocked.
+func (r *OrderRepository) ReceiveReturn(
+	ctx context.Context, orderId string, returnId string, restock bool) (*returns.Return, error) {
+
+	return r.updateReturn(ctx, orderId, returnId, returns.StatusReceived,
+		func(ctx context.Context, tx *firestore.Transaction, ret *returns.Return, currentTime string) error {
+			ret.Restocked = restock
+			ret.ReceivedAt = currentTime
+
+			if !restock {
+				return nil
+			}
+
+			var returned []*orders.OrderItem
+			for _, item := range ret.Items {
+				orderItem, err := r.getOrderItemTx(tx, r.orderItemCollection(orderId).Doc(item.OrderItemId))
+				if utils.ErrorCode(err) == utils.NOT_FOUND_ERROR {
+					continue
+				} else if err != nil {
+					return err
+				}
+
+				orderItem.Quantity = item.Quantity
+				returned = append(returned, orderItem)
+			}
+
+			stock := r.newStockTx(tx)
+			if err := stock.release(returned); err != nil {
+				return err
+			}
+
+			return stock.write(ctx)
+		})
+}
+
+func (r *OrderRepository) MarkReturnRefunded(
+	ctx context.Context, orderId string, returnId string, reference string) (*returns.Return, error) {
+
+	if reference == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "refund reference is required")
+	}
+
+	return r.updateReturn(ctx, orderId, returnId, returns.StatusRefunded,
+		func(_ context.Context, _ *firestore.Transaction, ret *returns.Return, currentTime string) error {
+			ret.RefundReference = reference
+			ret.RefundedAt = currentTime
+			return nil
+		})
+}
+
+// returnUpdate changes a return moved to another status in tx, where it may
+// read and write before the return is written.
+type returnUpdate func(ctx context.Context, tx *firestore.Transaction, ret *returns.Return, currentTime string) error
+
+// updateReturn moves a return of an order to the status to and applies update
+// to it, along with its audit event. A return that already has that status is
+// returned as is.
+func (r *OrderRepository) updateReturn(
+	ctx context.Context, orderId string, returnId string, to string, update returnUpdate) (*returns.Return, error) {
+
+	r.CheckPreconditions()
+
+	if orderId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "orders id is required")
+	} else if returnId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "return id is required")
+	}
+
+	orderRef := r.orderCollection().Doc(orderId)
+	returnRef := r.returnCollection(orderId).Doc(returnId)
+
+	var ret *returns.Return
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if _, err := r.getOrderTx(tx, orderRef); err != nil {
+			return err
+		}
+
+		before, err := r.getReturnTx(tx, orderId, returnRef)
+		if err != nil {
+			return err
+		}
+
+		ret = before
+		if before.Status == to {
+			return nil
+		}
+
+		if err := before.CheckTransition(to); err != nil {
+			return err
+		}
+
+		currentTime := time.Now().Format(time.RFC3339)
+
+		after := *before
+		after.Status = to
+		after.UpdatedAt = currentTime
+		after.Version++
+		if err := update(ctx, tx, &after, currentTime); err != nil {
+			return err
+		}
+		ret = &after
+
+		if err := tx.Set(returnRef, marshallReturn(ret)); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to update return: %v", err)
+		}
+
+		auditID := returnAuditID(orderId, returnId)
+		return r.record(ctx, tx, audit.EntityReturn, auditID, audit.ActionUpdate, before, ret)
+	})
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return nil, err
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to update return: %v", err)
+	}
+
+	return ret, nil
+}
+
+// getReturnTx reads a return of an order in tx.
+func (r *OrderRepository) getReturnTx(
+	tx *firestore.Transaction, orderId string, returnRef *firestore.DocumentRef) (*returns.Return, error) {
+
+	doc, err := tx.Get(returnRef)
+	if status.Code(err) == codes.NotFound {
+		return nil, utils.Errorf(utils.NOT_FOUND_ERROR, "return not found")
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get return: %v", err)
+	}
+
+	return unmarshallReturnDoc(orderId, doc)
+}
+
+// listReturnsTx reads the returns of an order in tx.
+func (r *OrderRepository) listReturnsTx(tx *firestore.Transaction, orderId string) ([]*returns.Return, error) {
+	docs, err := tx.Documents(r.returnCollection(orderId)).GetAll()
+	if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get returns: %v", err)
+	}
+
+	list := make([]*returns.Return, 0, len(docs))
+	for _, doc := range docs {
+		ret, err := unmarshallReturnDoc(orderId, doc)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, ret)
+	}
+
+	return list, nil
+}
+
+func unmarshallReturnDoc(orderId string, doc *firestore.DocumentSnapshot) (*returns.Return, error) {
+	returnModel := &models.ReturnModel{}
+	if err := doc.DataTo(returnModel); err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall return: %v", err)
+	}
+
+	ret := &returns.Return{
+		Id:              doc.Ref.ID,
+		OrderId:         orderId,
+		Reason:          returnModel.Reason,
+		Status:          returnModel.Status,
+		RejectionReason: returnModel.RejectionReason,
+		Restocked:       returnModel.Restocked,
+		RefundAmount:    money.FromStored(returnModel.RefundAmount, returnModel.RefundCurrency),
+		RefundReference: returnModel.RefundReference,
+		RequestedAt:     returnModel.RequestedAt,
+		ReceivedAt:      returnModel.ReceivedAt,
+		RefundedAt:      returnModel.RefundedAt,
+		CreatedAt:       returnModel.CreatedAt,
+		UpdatedAt:       returnModel.UpdatedAt,
+		Version:         returnModel.Version,
+	}
+
+	for _, item := range returnModel.Items {
+		ret.Items = append(ret.Items, &returns.ReturnItem{
+			OrderItemId: item.OrderItemId,
+			Quantity:    uint(item.Quantity),
+		})
+	}
+
+	return ret, nil
+}
+
+func marshallReturn(ret *returns.Return) *models.ReturnModel {
+	returnModel := &models.ReturnModel{
+		Reason:          ret.Reason,
+		Status:          ret.Status,
+		RejectionReason: ret.RejectionReason,
+		Restocked:       ret.Restocked,
+		RefundAmount:    ret.RefundAmount.Amount,
+		RefundCurrency:  ret.RefundAmount.Currency,
+		RefundReference: ret.RefundReference,
+		RequestedAt:     ret.RequestedAt,
+		ReceivedAt:      ret.ReceivedAt,
+		RefundedAt:      ret.RefundedAt,
+		CreatedAt:       ret.CreatedAt,
+		UpdatedAt:       ret.UpdatedAt,
+		Version:         ret.Version,
+	}
+
+	for _, item := range ret.Items {
+		returnModel.Items = append(returnModel.Items, &models.ReturnItemModel{
+			OrderItemId: item.OrderItemId,
+			Quantity:    int(item.Quantity),
+		})
+	}
+
+	return returnModel
+}
@@ -22,6 +22,7 @@ func (s *orderService) CreateOrder(ctx context.Context, in *generated.CreateOrde
 	for _, item := range in.GetOrderItems() {
 		items = append(items, &orders.OrderItem{
 			ProductId: item.GetProductId(),
+			VariantId: item.GetVariantId(),
 			Quantity:  uint(item.GetQuantity()),
 		})
 	}
@@ -50,6 +51,7 @@ func (s *orderService) GetOrder(ctx context.Context, in *generated.GetOrderReque
 	for _, item := range order.Items {
 		orderItems = append(orderItems, &generated.OrderItem{
 			ProductId: item.ProductId,
+			VariantId: item.VariantId,
 			Quantity:  uint32(item.Quantity),
 		})
 	}
@@ -64,7 +66,7 @@ func (s *orderService) GetOrder(ctx context.Context, in *generated.GetOrderReque
 
 func (s *orderService) ListOrders(ctx context.Context, in *generated.ListOrdersRequest) (*generated.ListOrdersResponse, error) {
 
-	orders, err := s.orderRepo.ListOrders(ctx)
+	orders, err := s.orderRepo.ListOrders(ctx, in.GetIncludeDeleted())
 	if err != nil {
 		return nil, err
 	}
@@ -75,6 +77,7 @@ func (s *orderService) ListOrders(ctx context.Context, in *generated.ListOrdersR
 		for _, item := range p.Items {
 			orderItems = append(orderItems, &generated.OrderItem{
 				ProductId: item.ProductId,
+				VariantId: item.VariantId,
 				Quantity:  uint32(item.Quantity),
 			})
 		}
@@ -95,7 +98,7 @@ func (s *orderService) ListOrders(ctx context.Context, in *generated.ListOrdersR
 func (s *orderService) UpdateOrderStatus(
 	ctx context.Context, in *generated.UpdateOrderStatusRequest) (*generated.UpdateOrderStatusResponse, error) {
 
-	order, err := s.orderRepo.UpdateOrderStatus(ctx, in.GetId(), getOrderStatus(in.GetStatus()))
+	order, err := s.orderRepo.UpdateOrderStatus(ctx, in.GetId(), getOrderStatus(in.GetStatus()), in.GetEtag())
 	if err != nil {
 		return nil, err
 	}
@@ -104,6 +107,7 @@ func (s *orderService) UpdateOrderStatus(
 		Id:         order.Id,
 		CustomerId: order.CustomerId,
 		Status:     getGRPCOrderStatus(order.OrderStatus),
+		Etag:       utils.ETag(order.Version),
 	}, nil
 }
 
@@ -122,6 +126,7 @@ func (s *orderService) CreateOrderItem(
 
 	orderItem, err := s.orderRepo.CreateOrderItem(ctx, in.GetOrderId(), &orders.OrderItem{
 		ProductId: in.GetProductId(),
+		VariantId: in.GetVariantId(),
 		Quantity:  uint(in.GetQuantity()),
 	})
 	if err != nil {
@@ -143,6 +148,7 @@ func (s *orderService) GetOrderItem(ctx context.Context, in *generated.GetOrderI
 	return &generated.GetOrderItemResponse{
 		Id:        orderItem.Id,
 		ProductId: orderItem.ProductId,
+		VariantId: orderItem.VariantId,
 		Quantity:  uint32(orderItem.Quantity),
 	}, nil
 }
@@ -160,6 +166,7 @@ func (s *orderService) ListOrderItems(
 		responseOrderItems = append(responseOrderItems, &generated.OrderItem{
 			Id:        item.Id,
 			ProductId: item.ProductId,
+			VariantId: item.VariantId,
 			Quantity:  uint32(item.Quantity),
 		})
 	}
@@ -172,14 +179,17 @@ func (s *orderService) ListOrderItems(
 func (s *orderService) UpdateOrderItem(
 	ctx context.Context, in *generated.UpdateOrderItemRequest) (*generated.UpdateOrderItemResponse, error) {
 
-	var quantity *uint
-	if in.GetUpdate().GetQuantity() > 0 {
-		quantity = new(uint)
-		*quantity = uint(in.GetUpdate().GetQuantity())
+	paths, err := utils.UpdatePaths(in.GetUpdateMask(), in.GetUpdate())
+	if err != nil {
+		return nil, err
 	}
-	orderItem, err := s.orderRepo.UpdateOrderItem(ctx, in.GetOrderId(), in.GetId(), &orders.OrderItemUpdate{
-		Quantity: quantity,
-	})
+
+	update := &orders.OrderItemUpdate{ETag: in.GetEtag()}
+	if paths["quantity"] {
+		update.Quantity = utils.UintPtr(uint(in.GetUpdate().GetQuantity()))
+	}
+
+	orderItem, err := s.orderRepo.UpdateOrderItem(ctx, in.GetOrderId(), in.GetId(), update)
 	if err != nil {
 		return nil, err
 	}
@@ -187,7 +197,9 @@ func (s *orderService) UpdateOrderItem(
 	return &generated.UpdateOrderItemResponse{
 		Id:        orderItem.Id,
 		ProductId: orderItem.ProductId,
+		VariantId: orderItem.VariantId,
 		Quantity:  uint32(orderItem.Quantity),
+		Etag:      utils.ETag(orderItem.Version),
 	}, nil
 }
 
@@ -212,6 +224,12 @@ func getGRPCOrderStatus(status utils.OrderStatus) generated.OrderStatus {
 		return generated.OrderStatus_PROCESSING
 	case utils.OrderStatusPaid:
 		return generated.OrderStatus_PAID
+	case utils.OrderStatusCancelled:
+		return generated.OrderStatus_CANCELLED
+	case utils.OrderStatusFailed:
+		return generated.OrderStatus_FAILED
+	case utils.OrderStatusExpired:
+		return generated.OrderStatus_EXPIRED
 	default:
 		return generated.OrderStatus_UNKNOWN
 	}
@@ -227,6 +245,12 @@ func getOrderStatus(status generated.OrderStatus) utils.OrderStatus {
 		return utils.OrderStatusProcessing
 	case generated.OrderStatus_PAID:
 		return utils.OrderStatusPaid
+	case generated.OrderStatus_CANCELLED:
+		return utils.OrderStatusCancelled
+	case generated.OrderStatus_FAILED:
+		return utils.OrderStatusFailed
+	case generated.OrderStatus_EXPIRED:
+		return utils.OrderStatusExpired
 	default:
 		return utils.OrderStatusNew
 	}
@@ -0,0 +1,506 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	customerapi "github.com/leta/order-management-system/orders/internal/api/customers"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/customers"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/shipments"
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/models"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// shipmentAuditID identifies a shipment in the audit log.
+func shipmentAuditID(orderId string, shipmentId string) string {
+	return orderId + "/" + shipmentId
+}
+
+func (r *OrderRepository) shipmentCollection(orderId string) *firestore.CollectionRef {
+	r.CheckPreconditions()
+
+	return r.orderCollection().Doc(orderId).Collection("shipments")
+}
+
+// shippingAddressTx reads the address of a customer an order is shipped to in
+// tx: the address with addressId, or their default address if it is empty.
+// It returns nil if addressId is empty and the customer has no default
+// address.
+func (r *OrderRepository) shippingAddressTx(
+	tx *firestore.Transaction, customerId string, addressId string) (*customers.Address, error) {
+
+	doc, err := tx.Get(r.db.Client.Collection(customerapi.Collection).Doc(customerId))
+	if status.Code(err) == codes.NotFound {
+		if addressId != "" {
+			return nil, utils.Errorf(utils.INVALID_ERROR, "customer %s not found", customerId)
+		}
+		return nil, nil
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get customers: %v", err)
+	}
+
+	customer, err := customerapi.UnmarshallCustomer(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if addressId == "" {
+		return customer.DefaultAddress(), nil
+	}
+
+	address := customer.Address(addressId)
+	if address == nil {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "customer has no address %s", addressId)
+	}
+
+	return address, nil
+}
+
+func (r *OrderRepository) SetShippingAddress(
+	ctx context.Context, orderId string, addressId string, etag string) (*orders.Order, error) {
+
+	r.CheckPreconditions()
+
+	if orderId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "orders id is required")
+	} else if addressId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "address id is required")
+	}
+
+	orderRef := r.orderCollection().Doc(orderId)
+
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		orderModel, err := r.getOrderTx(tx, orderRef)
+		if err != nil {
+			return err
+		}
+
+		if err := utils.CheckETag(etag, orderModel.Version); err != nil {
+			return err
+		}
+
+		before := r.unmarshallOrder(orderModel)
+		before.Id = orderId
+
+		if before.OrderStatus.Abandoned() {
+			return utils.Errorf(utils.INVALID_ERROR, "order is %s", before.OrderStatus)
+		}
+		if before.FulfilmentStatus != utils.FulfilmentStatusUnfulfilled {
+			return utils.Errorf(utils.INVALID_ERROR, "order is %s", before.FulfilmentStatus)
+		}
+
+		address, err := r.shippingAddressTx(tx, orderModel.CustomerId, addressId)
+		if err != nil {
+			return err
+		}
+
+		orderModel.ShippingAddress = customerapi.MarshallAddress(address)
+		orderModel.UpdatedAt = time.Now().Format(time.RFC3339)
+		orderModel.Version++
+
+		if err := tx.Set(orderRef, orderModel); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to update orders: %v", err)
+		}
+
+		after := r.unmarshallOrder(orderModel)
+		after.Id = orderId
+
+		return r.record(ctx, tx, audit.EntityOrder, orderId, audit.ActionUpdate, before, after)
+	})
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return nil, err
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to set shipping address: %v", err)
+	}
+
+	return r.GetOrder(ctx, orderId)
+}
+
+// CreateShipment writes the shipment and the fulfilment status of its order
+// in the same transaction, so that no unit is shipped twice.
+func (r *OrderRepository) CreateShipment(
+	ctx context.Context, shipment *shipments.Shipment, etag string) (*shipments.Shipment, error) {
+
+	r.CheckPreconditions()
+
+	if shipment.OrderId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "orders id is required")
+	}
+
+	if err := shipment.Validate(); err != nil {
+		return nil, err
+	}
+
+	orderRef := r.orderCollection().Doc(shipment.OrderId)
+	shipmentRef := r.shipmentCollection(shipment.OrderId).NewDoc()
+
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		orderModel, err := r.getOrderTx(tx, orderRef)
+		if err != nil {
+			return err
+		}
+
+		if err := utils.CheckETag(etag, orderModel.Version); err != nil {
+			return err
+		}
+
+		if orderModel.OrderStatus != string(utils.OrderStatusPaid) {
+			return utils.Errorf(utils.INVALID_ERROR, "order is %s, not paid", orderModel.OrderStatus)
+		}
+		if orderModel.ShippingAddress == nil {
+			return utils.Errorf(utils.INVALID_ERROR, "order has no shipping address")
+		}
+
+		items, err := r.listOrderItemsTx(tx, shipment.OrderId)
+		if err != nil {
+			return err
+		}
+
+		shipped, err := r.listShipmentsTx(tx, shipment.OrderId)
+		if err != nil {
+			return err
+		}
+
+		if err := shipments.CheckShippable(items, shipped, shipment); err != nil {
+			return err
+		}
+
+		currentTime := time.Now().Format(time.RFC3339)
+
+		shipment.Id = shipmentRef.ID
+		shipment.Status = shipments.StatusShipped
+		shipment.ShippedAt = currentTime
+		shipment.DeliveredAt = ""
+		shipment.CreatedAt = currentTime
+		shipment.UpdatedAt = currentTime
+		shipment.Version = 1
+
+		if err := tx.Create(shipmentRef, marshallShipment(shipment)); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to create shipment: %v", err)
+		}
+
+		auditID := shipmentAuditID(shipment.OrderId, shipment.Id)
+		if err := r.record(ctx, tx, audit.EntityShipment, auditID, audit.ActionCreate, nil, shipment); err != nil {
+			return err
+		}
+
+		return r.writeFulfilmentTx(ctx, tx, orderRef, orderModel, items, append(shipped, shipment), currentTime)
+	})
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return nil, err
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to create shipment: %v", err)
+	}
+
+	return shipment, nil
+}
+
+func (r *OrderRepository) GetShipment(
+	ctx context.Context, orderId string, shipmentId string) (*shipments.Shipment, error) {
+
+	r.CheckPreconditions()
+
+	if orderId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "orders id is required")
+	} else if shipmentId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "shipment id is required")
+	}
+
+	doc, err := r.shipmentCollection(orderId).Doc(shipmentId).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, utils.Errorf(utils.NOT_FOUND_ERROR, "shipment not found")
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get shipment: %v", err)
+	}
+
+	return unmarshallShipmentDoc(orderId, doc)
+}
+
+func (r *OrderRepository) ListShipments(ctx context.Context, orderId string) ([]*shipments.Shipment, error) {
+	r.CheckPreconditions()
+
+	if orderId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "orders id is required")
+	}
+
+	iter := r.shipmentCollection(orderId).OrderBy("created_at", firestore.Asc).Documents(ctx)
+
+	list := make([]*shipments.Shipment, 0)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to iterate shipments: %v", err)
+		}
+
+		shipment, err := unmarshallShipmentDoc(orderId, doc)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, shipment)
+	}
+
+	return list, nil
+}
+
+// ListOrderLines reads the items and shipments of the order in the same
+// transaction, so that the units left to ship add up.
+func (r *OrderRepository) ListOrderLines(ctx context.Context, orderId string) ([]*shipments.Line, error) {
+	r.CheckPreconditions()
+
+	if orderId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "orders id is required")
+	}
+
+	orderRef := r.orderCollection().Doc(orderId)
+
+	var lines []*shipments.Line
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if _, err := r.getOrderTx(tx, orderRef); err != nil {
+			return err
+		}
+
+		items, err := r.listOrderItemsTx(tx, orderId)
+		if err != nil {
+			return err
+		}
+
+		shipped, err := r.listShipmentsTx(tx, orderId)
+		if err != nil {
+			return err
+		}
+
+		unshipped := shipments.Unshipped(items, shipped)
+
+		lines = nil
+		for _, line := range orders.Lines(items) {
+			lines = append(lines, &shipments.Line{Item: line, Unshipped: unshipped[line.Id]})
+		}
+
+		return nil
+	})
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return nil, err
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to list order lines: %v", err)
+	}
+
+	return lines, nil
+}
+
+func (r *OrderRepository) MarkShipmentDelivered(
+	ctx context.Context, orderId string, shipmentId string) (*shipments.Shipment, error) {
+
+	r.CheckPreconditions()
+
+	if orderId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "orders id is required")
+	} else if shipmentId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "shipment id is required")
+	}
+
+	orderRef := r.orderCollection().Doc(orderId)
+
+	var shipment *shipments.Shipment
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		orderModel, err := r.getOrderTx(tx, orderRef)
+		if err != nil {
+			return err
+		}
+
+		items, err := r.listOrderItemsTx(tx, orderId)
+		if err != nil {
+			return err
+		}
+
+		shipped, err := r.listShipmentsTx(tx, orderId)
+		if err != nil {
+			return err
+		}
+
+		var before *shipments.Shipment
+		for _, s := range shipped {
+			if s.Id == shipmentId {
+				before = s
+			}
+		}
+		if before == nil {
+			return utils.Errorf(utils.NOT_FOUND_ERROR, "shipment not found")
+		}
+
+		shipment = before
+		if before.Delivered() {
+			return nil
+		}
+
+		currentTime := time.Now().Format(time.RFC3339)
+
+		delivered := *before
+		delivered.Status = shipments.StatusDelivered
+		delivered.DeliveredAt = currentTime
+		delivered.UpdatedAt = currentTime
+		delivered.Version++
+		shipment = &delivered
+
+		shipmentRef := r.shipmentCollection(orderId).Doc(shipmentId)
+		if err := tx.Set(shipmentRef, marshallShipment(shipment)); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to update shipment: %v", err)
+		}
+
+		auditID := shipmentAuditID(orderId, shipmentId)
+		if err := r.record(ctx, tx, audit.EntityShipment, auditID, audit.ActionUpdate, before, shipment); err != nil {
+			return err
+		}
+
+		for i, s := range shipped {
+			if s.Id == shipmentId {
+				shipped[i] = shipment
+			}
+		}
+
+		return r.writeFulfilmentTx(ctx, tx, orderRef, orderModel, items, shipped, currentTime)
+	})
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return nil, err
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to mark shipment delivered: %v", err)
+	}
+
+	return shipment, nil
+}
+
+// getOrderTx reads an order in tx. Deleted orders are not found.
+func (r *OrderRepository) getOrderTx(
+	tx *firestore.Transaction, orderRef *firestore.DocumentRef) (*models.OrderModel, error) {
+
+	doc, err := tx.Get(orderRef)
+	if status.Code(err) == codes.NotFound {
+		return nil, utils.Errorf(utils.NOT_FOUND_ERROR, "orders not found")
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get orders: %v", err)
+	}
+
+	orderModel := &models.OrderModel{}
+	if err := doc.DataTo(orderModel); err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall orders: %v", err)
+	}
+
+	if orderModel.DeletedAt != "" {
+		return nil, utils.Errorf(utils.NOT_FOUND_ERROR, "order %s is deleted", orderRef.ID)
+	}
+
+	return orderModel, nil
+}
+
+// writeFulfilmentTx writes the fulfilment status of an order with the items,
+// shipped in shipped, if it changed, along with its audit event.
+func (r *OrderRepository) writeFulfilmentTx(
+	ctx context.Context, tx *firestore.Transaction, orderRef *firestore.DocumentRef, orderModel *models.OrderModel,
+	items []*orders.OrderItem, shipped []*shipments.Shipment, currentTime string) error {
+
+	before := r.unmarshallOrder(orderModel)
+	before.Id = orderRef.ID
+
+	fulfilment := shipments.Fulfilment(items, shipped)
+	if fulfilment == before.FulfilmentStatus {
+		return nil
+	}
+
+	orderModel.FulfilmentStatus = string(fulfilment)
+	orderModel.UpdatedAt = currentTime
+	orderModel.Version++
+
+	if err := tx.Set(orderRef, orderModel); err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "failed to update orders: %v", err)
+	}
+
+	after := r.unmarshallOrder(orderModel)
+	after.Id = orderRef.ID
+
+	return r.record(ctx, tx, audit.EntityOrder, orderRef.ID, audit.ActionUpdate, before, after)
+}
+
+// listShipmentsTx reads the shipments of an order in tx.
+func (r *OrderRepository) listShipmentsTx(tx *firestore.Transaction, orderId string) ([]*shipments.Shipment, error) {
+	docs, err := tx.Documents(r.shipmentCollection(orderId)).GetAll()
+	if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get shipments: %v", err)
+	}
+
+	list := make([]*shipments.Shipment, 0, len(docs))
+	for _, doc := range docs {
+		shipment, err := unmarshallShipmentDoc(orderId, doc)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, shipment)
+	}
+
+	return list, nil
+}
+
+func unmarshallShipmentDoc(orderId string, doc *firestore.DocumentSnapshot) (*shipments.Shipment, error) {
+	shipmentModel := &models.ShipmentModel{}
+	if err := doc.DataTo(shipmentModel); err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall shipment: %v", err)
+	}
+
+	shipment := &shipments.Shipment{
+		Id:             doc.Ref.ID,
+		OrderId:        orderId,
+		Carrier:        shipmentModel.Carrier,
+		TrackingNumber: shipmentModel.TrackingNumber,
+		Status:         shipmentModel.Status,
+		ShippedAt:      shipmentModel.ShippedAt,
+		DeliveredAt:    shipmentModel.DeliveredAt,
+		CreatedAt:      shipmentModel.CreatedAt,
+		UpdatedAt:      shipmentModel.UpdatedAt,
+		Version:        shipmentModel.Version,
+	}
+
+	for _, item := range shipmentModel.Items {
+		shipment.Items = append(shipment.Items, &shipments.ShipmentItem{
+			OrderItemId: item.OrderItemId,
+			Quantity:    uint(item.Quantity),
+		})
+	}
+
+	return shipment, nil
+}
+
+func marshallShipment(shipment *shipments.Shipment) *models.ShipmentModel {
+	shipmentModel := &models.ShipmentModel{
+		Carrier:        shipment.Carrier,
+		TrackingNumber: shipment.TrackingNumber,
+		Status:         shipment.Status,
+		ShippedAt:      shipment.ShippedAt,
+		DeliveredAt:    shipment.DeliveredAt,
+		CreatedAt:      shipment.CreatedAt,
+		UpdatedAt:      shipment.UpdatedAt,
+		Version:        shipment.Version,
+	}
+
+	for _, item := range shipment.Items {
+		shipmentModel.Items = append(shipmentModel.Items, &models.ShipmentItemModel{
+			OrderItemId: item.OrderItemId,
+			Quantity:    int(item.Quantity),
+		})
+	}
+
+	return shipmentModel
+}
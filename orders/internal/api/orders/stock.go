@@ -0,0 +1,169 @@
+package orders
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	productapi "github.com/leta/order-management-system/orders/internal/api/product"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/product"
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// stockTx reserves and releases the stock of the variants ordered in a
+// transaction. Ordering units of a variant takes them out of its stock until
+// the order is abandoned, see utils.OrderStatus.Abandoned, or the item is
+// removed.
+//
+// Each product is read once, so that the units of every line of the
+// transaction, items and bundle components alike, are checked against the
+// same stock, and written once by write, after the other reads of the
+// transaction.
+type stockTx struct {
+	r  *OrderRepository
+	tx *firestore.Transaction
+
+	// The products read, nil if they do not exist.
+	products map[string]*product.Product
+
+	// The products whose stock changed, as they were read, in the order they
+	// changed.
+	before  map[string]*product.Product
+	changed []string
+}
+
+func (r *OrderRepository) newStockTx(tx *firestore.Transaction) *stockTx {
+	return &stockTx{
+		r:        r,
+		tx:       tx,
+		products: make(map[string]*product.Product),
+		before:   make(map[string]*product.Product),
+	}
+}
+
+// get returns the product with the ID, read in the transaction the first
+// time, or nil if it does not exist.
+func (s *stockTx) get(id string) (*product.Product, error) {
+	if p, ok := s.products[id]; ok {
+		return p, nil
+	}
+
+	doc, err := s.tx.Get(s.r.db.Client.Collection(productapi.Collection).Doc(id))
+	if status.Code(err) == codes.NotFound {
+		s.products[id] = nil
+		return nil, nil
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get product: %v", err)
+	}
+
+	p, err := productapi.UnmarshallProductDoc(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	s.products[id] = p
+	return p, nil
+}
+
+// product returns the product of an order item, see get. It fails with an
+// INVALID_ERROR if the product does not exist. Its variants have the stock
+// left by the units reserved and released so far.
+func (s *stockTx) product(id string) (*product.Product, error) {
+	p, err := s.get(id)
+	if err != nil {
+		return nil, err
+	} else if p == nil {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "product %s not found", id)
+	}
+
+	return p, nil
+}
+
+// reserve takes the units of the lines of items out of the stock of their
+// variants, see product.Product.Reserve, so that each line is checked against
+// the units the other lines left. Products without variants have no stock.
+func (s *stockTx) reserve(items []*orders.OrderItem) error {
+	for _, line := range orders.Lines(items) {
+		if line.VariantId == "" {
+			continue
+		}
+
+		p, err := s.product(line.ProductId)
+		if err != nil {
+			return err
+		}
+
+		s.change(p)
+		if err := p.Reserve(line.VariantId, line.Quantity); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// release puts the units of the lines of items back into the stock of their
+// variants. Products removed since are skipped.
+func (s *stockTx) release(items []*orders.OrderItem) error {
+	for _, line := range orders.Lines(items) {
+		if line.VariantId == "" {
+			continue
+		}
+
+		p, err := s.get(line.ProductId)
+		if err != nil {
+			return err
+		} else if p == nil {
+			continue
+		}
+
+		s.change(p)
+		p.Release(line.VariantId, line.Quantity)
+	}
+
+	return nil
+}
+
+// change keeps the product as it was read before its stock first changes.
+func (s *stockTx) change(p *product.Product) {
+	if _, ok := s.before[p.Id]; ok {
+		return
+	}
+
+	before := *p
+	before.Variants = make([]*product.Variant, 0, len(p.Variants))
+	for _, variant := range p.Variants {
+		v := *variant
+		before.Variants = append(before.Variants, &v)
+	}
+
+	s.before[p.Id] = &before
+	s.changed = append(s.changed, p.Id)
+}
+
+// write writes the products whose stock changed, with their audit events.
+func (s *stockTx) write(ctx context.Context) error {
+	currentTime := time.Now().Format(time.RFC3339)
+
+	for _, id := range s.changed {
+		p := s.products[id]
+		p.UpdatedAt = currentTime
+		p.Version++
+
+		ref := s.r.db.Client.Collection(productapi.Collection).Doc(id)
+		if err := s.tx.Set(ref, productapi.MarshallProduct(p)); err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to update product: %v", err)
+		}
+
+		if err := s.r.record(ctx, s.tx, audit.EntityProduct, id, audit.ActionUpdate, s.before[id], p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,382 @@
+package pricelists
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/leta/order-management-system/orders/db/firebase"
+	customerapi "github.com/leta/order-management-system/orders/internal/api/customers"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/customers"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/pricelists"
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/models"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// Collection holds the price lists, keyed by ID.
+const Collection = "price_lists"
+
+type PriceListRepository struct {
+	db *firebase.FirestoreService
+}
+
+var _ pricelists.PriceListRepository = (*PriceListRepository)(nil)
+
+func NewPriceListRepository(db *firebase.FirestoreService) *PriceListRepository {
+	return &PriceListRepository{
+		db: db,
+	}
+}
+
+func (r *PriceListRepository) CheckPreconditions() {
+	if r.db == nil {
+		panic("no DB service provided")
+	}
+}
+
+func (r *PriceListRepository) priceListCollection() *firestore.CollectionRef {
+	r.CheckPreconditions()
+
+	return r.db.Client.Collection(Collection)
+}
+
+func (r *PriceListRepository) record(
+	ctx context.Context, tx *firestore.Transaction, id string, action string, before, after *pricelists.PriceList) error {
+
+	event, err := audit.New(ctx, audit.EntityPriceList, id, action, before, after)
+	if err != nil {
+		return err
+	}
+
+	return audit.NewFirestoreLog(r.db.Client, utils.SERVICE_NAME).Record(tx, event)
+}
+
+func (r *PriceListRepository) CreatePriceList(
+	ctx context.Context, list *pricelists.PriceList) (*pricelists.PriceList, error) {
+
+	r.CheckPreconditions()
+
+	currentTime := time.Now().Format(time.RFC3339)
+
+	list.Currency = strings.ToUpper(list.Currency)
+	list.CreatedAt = currentTime
+	list.UpdatedAt = currentTime
+	list.Version = 1
+
+	if err := list.Validate(); err != nil {
+		return nil, err
+	}
+
+	docRef := r.priceListCollection().NewDoc()
+
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		list.Id = docRef.ID
+
+		if err := tx.Create(docRef, marshallPriceList(list)); err != nil {
+			return err
+		}
+
+		return r.record(ctx, tx, list.Id, audit.ActionCreate, nil, list)
+	})
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return nil, err
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to create price list: %v", err)
+	}
+
+	return list, nil
+}
+
+func (r *PriceListRepository) GetPriceList(ctx context.Context, id string) (*pricelists.PriceList, error) {
+	r.CheckPreconditions()
+
+	if id == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "id is required")
+	}
+
+	doc, err := r.priceListCollection().Doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, utils.Errorf(utils.NOT_FOUND_ERROR, "price list not found")
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get price list: %v", err)
+	}
+
+	return UnmarshallPriceListDoc(doc)
+}
+
+func (r *PriceListRepository) ListPriceLists(ctx context.Context) ([]*pricelists.PriceList, error) {
+	r.CheckPreconditions()
+
+	iter := r.priceListCollection().OrderBy("name", firestore.Asc).Documents(ctx)
+	defer iter.Stop()
+
+	var list []*pricelists.PriceList
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to iterate price lists: %v", err)
+		}
+
+		priceList, err := UnmarshallPriceListDoc(doc)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, priceList)
+	}
+
+	return list, nil
+}
+
+func (r *PriceListRepository) UpdatePriceList(
+	ctx context.Context, id string, update *pricelists.PriceListUpdate) (*pricelists.PriceList, error) {
+
+	r.CheckPreconditions()
+
+	if id == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "id is required")
+	}
+
+	docRef := r.priceListCollection().Doc(id)
+
+	var updated *pricelists.PriceList
+
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return utils.Errorf(utils.NOT_FOUND_ERROR, "price list not found")
+		} else if err != nil {
+			return err
+		}
+
+		before, err := UnmarshallPriceListDoc(doc)
+		if err != nil {
+			return err
+		}
+
+		if err := utils.CheckETag(update.ETag, before.Version); err != nil {
+			return err
+		}
+
+		list := *before
+
+		if p := update.Name; p != nil {
+			list.Name = *p
+		}
+
+		if p := update.CustomerIds; p != nil {
+			list.CustomerIds = *p
+		}
+
+		if p := update.Groups; p != nil {
+			list.Groups = *p
+		}
+
+		if p := update.StartsAt; p != nil {
+			list.StartsAt = *p
+		}
+
+		if p := update.EndsAt; p != nil {
+			list.EndsAt = *p
+		}
+
+		if p := update.Prices; p != nil {
+			list.Prices = *p
+		}
+
+		if err := list.Validate(); err != nil {
+			return err
+		}
+
+		list.UpdatedAt = time.Now().Format(time.RFC3339)
+		list.Version++
+
+		if err := tx.Set(docRef, marshallPriceList(&list)); err != nil {
+			return err
+		}
+
+		updated = &list
+
+		return r.record(ctx, tx, id, audit.ActionUpdate, before, &list)
+	})
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return nil, err
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to update price list: %v", err)
+	}
+
+	return updated, nil
+}
+
+// DeletePriceList removes a price list. Order items keep the prices they
+// were added at.
+func (r *PriceListRepository) DeletePriceList(ctx context.Context, id string) error {
+	r.CheckPreconditions()
+
+	if id == "" {
+		return utils.Errorf(utils.INVALID_ERROR, "id is required")
+	}
+
+	docRef := r.priceListCollection().Doc(id)
+
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		before, err := UnmarshallPriceListDoc(doc)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Delete(docRef); err != nil {
+			return err
+		}
+
+		return r.record(ctx, tx, id, audit.ActionDelete, before, nil)
+	})
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return err
+	} else if err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "failed to delete price list: %v", err)
+	}
+
+	return nil
+}
+
+// ReadPricing reads the pricing of the customer customerId as of now: the
+// customer, and every price list, as stores have few enough of them to pick
+// those that apply in memory. A customer who does not exist is priced as
+// one without a group.
+//
+// Transactions read the pricing through it rather than in the transaction:
+// prices are copied onto the items they price, so reading every price list
+// in a transaction would only make edits of the lists contend with the
+// orders and carts written meanwhile.
+func ReadPricing(
+	ctx context.Context, client *firestore.Client, customerId string, now time.Time) (*pricelists.Pricing, error) {
+
+	customerDoc, err := client.Collection(customerapi.Collection).Doc(customerId).Get(ctx)
+	if err != nil && status.Code(err) != codes.NotFound {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get customer: %v", err)
+	}
+
+	docs, err := client.Collection(Collection).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get price lists: %v", err)
+	}
+
+	return newPricing(customerDoc, docs, customerId, now)
+}
+
+func newPricing(
+	customerDoc *firestore.DocumentSnapshot, docs []*firestore.DocumentSnapshot,
+	customerId string, now time.Time) (*pricelists.Pricing, error) {
+
+	pricing := &pricelists.Pricing{
+		Customer: &customers.Customer{Id: customerId},
+		Now:      now,
+	}
+
+	if customerDoc != nil && customerDoc.Exists() {
+		customer, err := customerapi.UnmarshallCustomer(customerDoc)
+		if err != nil {
+			return nil, err
+		}
+		pricing.Customer = customer
+	}
+
+	for _, doc := range docs {
+		list, err := UnmarshallPriceListDoc(doc)
+		if err != nil {
+			return nil, err
+		}
+		pricing.Lists = append(pricing.Lists, list)
+	}
+
+	return pricing, nil
+}
+
+// UnmarshallPriceListDoc returns the price list stored as doc.
+func UnmarshallPriceListDoc(doc *firestore.DocumentSnapshot) (*pricelists.PriceList, error) {
+	listModel := &models.PriceListModel{}
+	if err := doc.DataTo(listModel); err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall price list: %v", err)
+	}
+
+	list := &pricelists.PriceList{
+		Id:          doc.Ref.ID,
+		Name:        listModel.Name,
+		Currency:    listModel.Currency,
+		CustomerIds: listModel.CustomerIds,
+		Groups:      listModel.Groups,
+		StartsAt:    listModel.StartsAt,
+		EndsAt:      listModel.EndsAt,
+		CreatedAt:   listModel.CreatedAt,
+		UpdatedAt:   listModel.UpdatedAt,
+		Version:     listModel.Version,
+	}
+
+	for _, price := range listModel.Prices {
+		p := &pricelists.Price{
+			ProductId: price.ProductId,
+			VariantId: price.VariantId,
+		}
+		for _, tier := range price.Tiers {
+			p.Tiers = append(p.Tiers, &pricelists.Tier{
+				MinQuantity: uint(tier.MinQuantity),
+				UnitPrice:   money.New(tier.UnitPrice, listModel.Currency),
+			})
+		}
+		list.Prices = append(list.Prices, p)
+	}
+
+	return list, nil
+}
+
+func marshallPriceList(list *pricelists.PriceList) *models.PriceListModel {
+	listModel := &models.PriceListModel{
+		Name:        list.Name,
+		Currency:    list.Currency,
+		CustomerIds: list.CustomerIds,
+		Groups:      list.Groups,
+		StartsAt:    list.StartsAt,
+		EndsAt:      list.EndsAt,
+		CreatedAt:   list.CreatedAt,
+		UpdatedAt:   list.UpdatedAt,
+		Version:     list.Version,
+	}
+
+	for _, price := range list.Prices {
+		priceModel := &models.PriceModel{
+			ProductId: price.ProductId,
+			VariantId: price.VariantId,
+		}
+		for _, tier := range price.Tiers {
+			priceModel.Tiers = append(priceModel.Tiers, &models.TierModel{
+				MinQuantity: int(tier.MinQuantity),
+				UnitPrice:   tier.UnitPrice.Amount,
+			})
+		}
+		listModel.Prices = append(listModel.Prices, priceModel)
+	}
+
+	return listModel
+}
@@ -2,14 +2,28 @@ package product
 
 import (
 	"context"
-	"errors"
 	"github.com/leta/order-management-system/orders/pkg/models"
 	"time"
 
 	"cloud.google.com/go/firestore"
 	db "github.com/leta/order-management-system/orders/db/firebase"
+	categoryapi "github.com/leta/order-management-system/orders/internal/api/categories"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/categories"
 	"github.com/leta/order-management-system/orders/internal/interfaces/api/product"
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/utils"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Collection holds the products, keyed by ID. SkuCollection holds a
+// models.SkuModel per SKU of the products and their variants, keyed by SKU,
+// which keeps SKUs unique.
+const (
+	Collection    = "products"
+	SkuCollection = "skus"
 )
 
 type productRepository struct {
@@ -31,7 +45,13 @@ func (r *productRepository) CheckPreconditions() {
 func (r *productRepository) productCollection() *firestore.CollectionRef {
 	r.CheckPreconditions()
 
-	return r.db.Client.Collection("products")
+	return r.db.Client.Collection(Collection)
+}
+
+func (r *productRepository) skuCollection() *firestore.CollectionRef {
+	r.CheckPreconditions()
+
+	return r.db.Client.Collection(SkuCollection)
 }
 
 func (r *productRepository) CreateProduct(ctx context.Context, product *product.Product) (*product.Product, error) {
@@ -42,21 +62,49 @@ func (r *productRepository) CreateProduct(ctx context.Context, product *product.
 
 	product.CreatedAt = currentTime.Format(time.RFC3339)
 	product.UpdatedAt = currentTime.Format(time.RFC3339)
+	product.Version = 1
+
+	for _, variant := range product.Variants {
+		variant.Id = r.productCollection().NewDoc().ID
+	}
 
 	err := product.Validate()
 	if err != nil {
 		return nil, err
 	}
 
-	productModel := r.marshallProduct(product)
+	docRef := r.productCollection().NewDoc()
+
+	writeErr := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if err := r.checkCategory(tx, product.CategoryId); err != nil {
+			return err
+		}
+
+		if err := r.checkComponents(tx, product); err != nil {
+			return err
+		}
 
-	docRef, _, writeErr := r.productCollection().Add(ctx, productModel)
+		added, removed, err := r.checkSkus(tx, docRef.ID, nil, product)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Create(docRef, MarshallProduct(product)); err != nil {
+			return err
+		}
+
+		if err := r.writeSkus(tx, docRef.ID, added, removed); err != nil {
+			return err
+		}
+
+		product.Id = docRef.ID
+
+		return r.record(ctx, tx, docRef.ID, audit.ActionCreate, nil, product)
+	})
 	if writeErr != nil {
 		return nil, writeErr
 	}
 
-	product.Id = docRef.ID
-
 	return product, nil
 }
 
@@ -64,28 +112,44 @@ func (r *productRepository) GetProduct(ctx context.Context, id string) (*product
 	r.CheckPreconditions()
 
 	if id == "" {
-		return nil, errors.New("some error")
+		return nil, utils.Errorf(utils.INVALID_ERROR, "id is required")
 	}
 
 	docRef, err := r.productCollection().Doc(id).Get(ctx)
-	if err != nil {
-		return nil, err
+	if status.Code(err) == codes.NotFound {
+		return nil, utils.Errorf(utils.NOT_FOUND_ERROR, "product not found")
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get product: %v", err)
 	}
 
-	productModel := &models.ProductModel{}
-	if err := docRef.DataTo(productModel); err != nil {
-		return nil, err
-	}
+	return UnmarshallProductDoc(docRef)
+}
 
-	product := r.unmarshallProduct(productModel)
+func (r *productRepository) ListProducts(ctx context.Context, filter *product.ProductFilter) ([]*product.Product, error) {
+	r.CheckPreconditions()
 
-	product.Id = docRef.Ref.ID
+	if filter == nil {
+		filter = &product.ProductFilter{}
+	}
 
-	return product, nil
-}
+	var inCategory map[string]bool
+	if filter.CategoryId != "" {
+		docs, err := r.db.Client.Collection(categoryapi.Collection).Documents(ctx).GetAll()
+		if err != nil {
+			return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to list categories: %v", err)
+		}
 
-func (r *productRepository) ListProducts(ctx context.Context) ([]*product.Product, error) {
-	r.CheckPreconditions()
+		all := make([]*categories.Category, 0, len(docs))
+		for _, doc := range docs {
+			category, err := categoryapi.UnmarshallCategoryDoc(doc)
+			if err != nil {
+				return nil, err
+			}
+			all = append(all, category)
+		}
+
+		inCategory = categories.Descendants(all, filter.CategoryId)
+	}
 
 	iter := r.productCollection().Documents(ctx)
 
@@ -100,13 +164,22 @@ func (r *productRepository) ListProducts(ctx context.Context) ([]*product.Produc
 			return nil, err
 		}
 
-		productModel := &models.ProductModel{}
-		if err := docRef.DataTo(productModel); err != nil {
+		product, err := UnmarshallProductDoc(docRef)
+		if err != nil {
 			return nil, err
 		}
 
-		product := r.unmarshallProduct(productModel)
-		product.Id = docRef.Ref.ID
+		if product.Deleted() && !filter.IncludeDeleted {
+			continue
+		}
+
+		if !product.Active && filter.ActiveOnly {
+			continue
+		}
+
+		if inCategory != nil && !inCategory[product.CategoryId] {
+			continue
+		}
 
 		products = append(products, product)
 	}
@@ -116,70 +189,661 @@ func (r *productRepository) ListProducts(ctx context.Context) ([]*product.Produc
 
 func (r *productRepository) UpdateProduct(ctx context.Context, id string, update *product.ProductUpdate,
 ) (*product.Product, error) {
+
+	return r.update(ctx, id, update.ETag, func(_ *firestore.Transaction, updated *product.Product) error {
+		if p := update.Name; p != nil {
+			updated.Name = *p
+		}
+
+		if p := update.Description; p != nil {
+			updated.Description = *p
+		}
+
+		if p := update.Price; p != nil {
+			updated.Price = *p
+		}
+
+		if p := update.TaxCategory; p != nil {
+			updated.TaxCategory = *p
+		}
+
+		if p := update.Sku; p != nil {
+			updated.Sku = *p
+		}
+
+		if p := update.CategoryId; p != nil {
+			updated.CategoryId = *p
+		}
+
+		if p := update.Active; p != nil {
+			updated.Active = *p
+		}
+
+		if p := update.Components; p != nil {
+			updated.Components = *p
+		}
+
+		return nil
+	})
+}
+
+func (r *productRepository) AddVariant(
+	ctx context.Context, productId string, variant *product.Variant, etag string) (*product.Product, error) {
+
+	return r.update(ctx, productId, etag, func(_ *firestore.Transaction, updated *product.Product) error {
+		added := *variant
+		added.Id = r.productCollection().NewDoc().ID
+
+		updated.Variants = append(updated.Variants, &added)
+		return nil
+	})
+}
+
+func (r *productRepository) UpdateVariant(
+	ctx context.Context, productId string, variant *product.Variant, etag string) (*product.Product, error) {
+
+	if variant.Id == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "variant id is required")
+	}
+
+	return r.update(ctx, productId, etag, func(_ *firestore.Transaction, updated *product.Product) error {
+		for i, v := range updated.Variants {
+			if v.Id == variant.Id {
+				replaced := *variant
+				updated.Variants[i] = &replaced
+				return nil
+			}
+		}
+
+		return utils.Errorf(utils.NOT_FOUND_ERROR, "variant %s not found", variant.Id)
+	})
+}
+
+func (r *productRepository) RemoveVariant(
+	ctx context.Context, productId string, variantId string, etag string) (*product.Product, error) {
+
+	return r.update(ctx, productId, etag, func(tx *firestore.Transaction, updated *product.Product) error {
+		if updated.Variant(variantId) == nil {
+			return utils.Errorf(utils.NOT_FOUND_ERROR, "variant %s not found", variantId)
+		}
+
+		orderId, err := r.openOrderIncluding(tx, productId, variantId)
+		if err != nil {
+			return err
+		} else if orderId != "" {
+			return utils.Errorf(utils.INVALID_ERROR,
+				"variant %s of product %s is included in open order %s", variantId, productId, orderId)
+		}
+
+		bundleId, err := r.bundleIncluding(tx, productId, variantId)
+		if err != nil {
+			return err
+		} else if bundleId != "" {
+			return utils.Errorf(utils.INVALID_ERROR,
+				"variant %s of product %s is a component of bundle %s", variantId, productId, bundleId)
+		}
+
+		var variants []*product.Variant
+		for _, v := range updated.Variants {
+			if v.Id != variantId {
+				variants = append(variants, v)
+			}
+		}
+		updated.Variants = variants
+
+		return nil
+	})
+}
+
+// update applies change to a copy of the product, which must not be deleted,
+// and writes it along with its SKUs and audit event. change may read in tx.
+func (r *productRepository) update(
+	ctx context.Context, id string, etag string,
+	change func(tx *firestore.Transaction, updated *product.Product) error) (*product.Product, error) {
+
 	r.CheckPreconditions()
 
-	product, getErr := r.GetProduct(ctx, id)
-	if getErr != nil {
-		return nil, getErr
+	if id == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "id is required")
+	}
+
+	docRef := r.productCollection().Doc(id)
+
+	var updated *product.Product
+
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return utils.Errorf(utils.NOT_FOUND_ERROR, "product not found")
+		} else if err != nil {
+			return err
+		}
+
+		before, err := UnmarshallProductDoc(doc)
+		if err != nil {
+			return err
+		}
+
+		if before.Deleted() {
+			return utils.Errorf(utils.NOT_FOUND_ERROR, "product %s is deleted", id)
+		}
+
+		if err := utils.CheckETag(etag, before.Version); err != nil {
+			return err
+		}
+
+		after := *before
+		after.Variants = append([]*product.Variant(nil), before.Variants...)
+		after.Components = append([]*product.Component(nil), before.Components...)
+
+		if err := change(tx, &after); err != nil {
+			return err
+		}
+
+		if err := after.Validate(); err != nil {
+			return err
+		}
+
+		if after.CategoryId != before.CategoryId {
+			if err := r.checkCategory(tx, after.CategoryId); err != nil {
+				return err
+			}
+		}
+
+		if err := r.checkComponents(tx, &after); err != nil {
+			return err
+		}
+
+		// Components of bundles cannot become bundles, nor gain variants
+		// their bundles do not name.
+		if (after.Bundle() && !before.Bundle()) || (len(after.Variants) > 0 && len(before.Variants) == 0) {
+			bundleId, err := r.bundleIncluding(tx, id, "")
+			if err != nil {
+				return err
+			} else if bundleId != "" {
+				return utils.Errorf(utils.INVALID_ERROR, "product %s is a component of bundle %s", id, bundleId)
+			}
+		}
+
+		added, removed, err := r.checkSkus(tx, id, before, &after)
+		if err != nil {
+			return err
+		}
+
+		after.UpdatedAt = time.Now().Format(time.RFC3339)
+		after.Version++
+
+		if err := tx.Set(docRef, MarshallProduct(&after)); err != nil {
+			return err
+		}
+
+		if err := r.writeSkus(tx, id, added, removed); err != nil {
+			return err
+		}
+
+		updated = &after
+
+		return r.record(ctx, tx, id, audit.ActionUpdate, before, &after)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if p := update.Name; p != nil {
-		product.Name = *p
+	return updated, nil
+}
+
+// checkCategory reads the category of a product in tx, and fails with an
+// INVALID_ERROR if it does not exist. A product need not have a category.
+func (r *productRepository) checkCategory(tx *firestore.Transaction, categoryId string) error {
+	if categoryId == "" {
+		return nil
 	}
 
-	if p := update.Description; p != nil {
-		product.Description = *p
+	_, err := tx.Get(r.db.Client.Collection(categoryapi.Collection).Doc(categoryId))
+	if status.Code(err) == codes.NotFound {
+		return utils.Errorf(utils.INVALID_ERROR, "category %s not found", categoryId)
+	} else if err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "failed to get category: %v", err)
 	}
 
-	if p := update.Price; p != nil {
-		product.Price = *p
+	return nil
+}
+
+// checkComponents reads the products of the components of a bundle in tx,
+// and fails with an INVALID_ERROR unless they can be in it, see
+// product.Component.Check.
+func (r *productRepository) checkComponents(tx *firestore.Transaction, bundle *product.Product) error {
+	for _, component := range bundle.Components {
+		doc, err := tx.Get(r.productCollection().Doc(component.ProductId))
+		if status.Code(err) == codes.NotFound {
+			return utils.Errorf(utils.INVALID_ERROR, "product %s of component not found", component.ProductId)
+		} else if err != nil {
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to get product: %v", err)
+		}
+
+		p, err := UnmarshallProductDoc(doc)
+		if err != nil {
+			return err
+		}
+
+		if err := component.Check(p); err != nil {
+			return err
+		}
 	}
 
-	err := product.Validate()
+	return nil
+}
+
+// bundleIncluding returns the ID of an undeleted bundle with a component of
+// the product, or of its variant variantId if set, or the empty string if
+// there is none.
+func (r *productRepository) bundleIncluding(tx *firestore.Transaction, id string, variantId string) (string, error) {
+	docs, err := tx.Documents(r.productCollection().Where("component_ids", "array-contains", id)).GetAll()
 	if err != nil {
-		return nil, err
+		return "", utils.Errorf(utils.INTERNAL_ERROR, "failed to list bundles: %v", err)
 	}
 
-	timeNow := time.Now()
-	product.UpdatedAt = timeNow.Format(time.RFC3339)
+	for _, doc := range docs {
+		bundle, err := UnmarshallProductDoc(doc)
+		if err != nil {
+			return "", err
+		}
+
+		if bundle.Deleted() {
+			continue
+		}
+
+		for _, component := range bundle.Components {
+			if component.ProductId == id && (variantId == "" || component.VariantId == variantId) {
+				return bundle.Id, nil
+			}
+		}
+	}
+
+	return "", nil
+}
 
-	productModel := r.marshallProduct(product)
+// checkSkus reads the SKUs the product id has after a write and not before
+// it in tx, and fails with an ALREADY_EXISTS_ERROR if another product uses
+// one of them. It returns the SKUs to reserve and to release, see writeSkus.
+func (r *productRepository) checkSkus(
+	tx *firestore.Transaction, id string, before, after *product.Product) (added, removed []string, err error) {
+
+	had := make(map[string]bool)
+	if before != nil {
+		for _, sku := range before.Skus() {
+			had[sku] = true
+		}
+	}
+
+	has := make(map[string]bool)
+	for _, sku := range after.Skus() {
+		has[sku] = true
+		if !had[sku] {
+			added = append(added, sku)
+		}
+	}
+
+	for sku := range had {
+		if !has[sku] {
+			removed = append(removed, sku)
+		}
+	}
+
+	for _, sku := range added {
+		doc, err := tx.Get(r.skuCollection().Doc(sku))
+		if status.Code(err) == codes.NotFound {
+			continue
+		} else if err != nil {
+			return nil, nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get sku: %v", err)
+		}
+
+		skuModel := &models.SkuModel{}
+		if err := doc.DataTo(skuModel); err != nil {
+			return nil, nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall sku: %v", err)
+		}
+
+		if skuModel.ProductId != id {
+			return nil, nil, utils.Errorf(utils.ALREADY_EXISTS_ERROR,
+				"sku %s is used by product %s", sku, skuModel.ProductId)
+		}
+	}
+
+	return added, removed, nil
+}
+
+// writeSkus reserves the added SKUs for the product id and releases the
+// removed ones in tx.
+func (r *productRepository) writeSkus(tx *firestore.Transaction, id string, added, removed []string) error {
+	for _, sku := range added {
+		if err := tx.Set(r.skuCollection().Doc(sku), &models.SkuModel{ProductId: id}); err != nil {
+			return err
+		}
+	}
+
+	for _, sku := range removed {
+		if err := tx.Delete(r.skuCollection().Doc(sku)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
 
-	_, err = r.productCollection().Doc(id).Set(ctx, productModel)
+// DeleteProduct marks a product as deleted, unless an open order includes it.
+// Its SKUs stay reserved until it is purged.
+func (r *productRepository) DeleteProduct(ctx context.Context, id string) error {
+	r.CheckPreconditions()
+
+	if id == "" {
+		return utils.Errorf(utils.INVALID_ERROR, "id is required")
+	}
+
+	docRef := r.productCollection().Doc(id)
+
+	return r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		before, err := UnmarshallProductDoc(doc)
+		if err != nil {
+			return err
+		}
+
+		if before.Deleted() {
+			return nil
+		}
+
+		orderId, err := r.openOrderIncluding(tx, id, "")
+		if err != nil {
+			return err
+		} else if orderId != "" {
+			return utils.Errorf(utils.INVALID_ERROR, "product %s is included in open order %s", id, orderId)
+		}
+
+		bundleId, err := r.bundleIncluding(tx, id, "")
+		if err != nil {
+			return err
+		} else if bundleId != "" {
+			return utils.Errorf(utils.INVALID_ERROR, "product %s is a component of bundle %s", id, bundleId)
+		}
+
+		product := *before
+
+		timeNow := time.Now()
+		product.UpdatedAt = timeNow.Format(time.RFC3339)
+		product.DeletedAt = utils.DeletedAt(timeNow)
+		product.Version++
+
+		if err := tx.Set(docRef, MarshallProduct(&product)); err != nil {
+			return err
+		}
+
+		return r.record(ctx, tx, id, audit.ActionDelete, before, &product)
+	})
+}
+
+// openOrderIncluding returns the ID of an open, undeleted order with an item
+// of the product, or of its variant variantId if set, or the empty string if
+// there is none.
+func (r *productRepository) openOrderIncluding(tx *firestore.Transaction, id string, variantId string) (string, error) {
+	query := r.db.Client.CollectionGroup("items").Where("product_id", "==", id)
+	if variantId != "" {
+		query = query.Where("variant_id", "==", variantId)
+	}
+
+	items, err := tx.Documents(query).GetAll()
+	if err != nil {
+		return "", utils.Errorf(utils.INTERNAL_ERROR, "failed to list order items: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, item := range items {
+		orderRef := item.Ref.Parent.Parent
+		if orderRef == nil || orderRef.Parent.ID != "orders" || seen[orderRef.ID] {
+			continue
+		}
+		seen[orderRef.ID] = true
+
+		doc, err := tx.Get(orderRef)
+		if status.Code(err) == codes.NotFound {
+			continue
+		} else if err != nil {
+			return "", utils.Errorf(utils.INTERNAL_ERROR, "failed to get order: %v", err)
+		}
+
+		orderModel := &models.OrderModel{}
+		if err := doc.DataTo(orderModel); err != nil {
+			return "", utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall order: %v", err)
+		}
+
+		if orderModel.DeletedAt == "" && utils.OrderStatus(orderModel.OrderStatus).Open() {
+			return orderRef.ID, nil
+		}
+	}
+
+	return "", nil
+}
+
+// RestoreProduct undoes the deletion of a product.
+func (r *productRepository) RestoreProduct(ctx context.Context, id string) (*product.Product, error) {
+	r.CheckPreconditions()
+
+	if id == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "id is required")
+	}
+
+	docRef := r.productCollection().Doc(id)
+
+	var restored *product.Product
+
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return utils.Errorf(utils.NOT_FOUND_ERROR, "product not found")
+		} else if err != nil {
+			return err
+		}
+
+		before, err := UnmarshallProductDoc(doc)
+		if err != nil {
+			return err
+		}
+
+		restored = before
+		if !before.Deleted() {
+			return nil
+		}
+
+		// Components may have been deleted along with the bundle.
+		if err := r.checkComponents(tx, before); err != nil {
+			return err
+		}
+
+		product := *before
+		product.DeletedAt = ""
+		product.UpdatedAt = time.Now().Format(time.RFC3339)
+		product.Version++
+
+		if err := tx.Set(docRef, MarshallProduct(&product)); err != nil {
+			return err
+		}
+
+		restored = &product
+
+		return r.record(ctx, tx, id, audit.ActionRestore, before, &product)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return product, nil
+	return restored, nil
 }
 
-func (r *productRepository) DeleteProduct(ctx context.Context, id string) error {
+// PurgeProducts removes the products deleted before deletedBefore and
+// returns their number.
+func (r *productRepository) PurgeProducts(ctx context.Context, deletedBefore time.Time) (int, error) {
 	r.CheckPreconditions()
 
-	_, err := r.productCollection().Doc(id).Delete(ctx)
+	cutoff := utils.DeletedAt(deletedBefore)
+
+	docs, err := r.productCollection().Where("deleted_at", "<", cutoff).Documents(ctx).GetAll()
+	if err != nil {
+		return 0, utils.Errorf(utils.INTERNAL_ERROR, "failed to list deleted products: %v", err)
+	}
+
+	purged := 0
+	for _, doc := range docs {
+		removed := false
+
+		err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			removed = false
+
+			doc, err := tx.Get(doc.Ref)
+			if status.Code(err) == codes.NotFound {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			before, err := UnmarshallProductDoc(doc)
+			if err != nil {
+				return err
+			}
+
+			// Restored since it was listed.
+			if !before.Deleted() || before.DeletedAt >= cutoff {
+				return nil
+			}
+
+			if err := tx.Delete(doc.Ref); err != nil {
+				return err
+			}
+
+			if err := r.writeSkus(tx, doc.Ref.ID, nil, before.Skus()); err != nil {
+				return err
+			}
+
+			removed = true
+
+			return r.record(ctx, tx, doc.Ref.ID, audit.ActionPurge, before, nil)
+		})
+		if err != nil {
+			return purged, utils.Errorf(utils.INTERNAL_ERROR, "failed to purge product %s: %v", doc.Ref.ID, err)
+		}
+
+		if removed {
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// record adds the audit event of a change to tx.
+func (r *productRepository) record(
+	ctx context.Context, tx *firestore.Transaction, id string, action string, before, after *product.Product) error {
+
+	event, err := audit.New(ctx, audit.EntityProduct, id, action, before, after)
 	if err != nil {
 		return err
 	}
 
-	return err
+	return audit.NewFirestoreLog(r.db.Client, utils.SERVICE_NAME).Record(tx, event)
 }
 
-func (r *productRepository) marshallProduct(product *product.Product) *models.ProductModel {
-	return &models.ProductModel{
+// UnmarshallProductDoc returns the product stored as doc.
+func UnmarshallProductDoc(doc *firestore.DocumentSnapshot) (*product.Product, error) {
+	productModel := &models.ProductModel{}
+	if err := doc.DataTo(productModel); err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall product: %v", err)
+	}
+
+	product := UnmarshallProduct(productModel)
+	product.Id = doc.Ref.ID
+
+	return product, nil
+}
+
+// MarshallProduct returns the document of product.
+func MarshallProduct(product *product.Product) *models.ProductModel {
+	productModel := &models.ProductModel{
 		Name:        product.Name,
 		Description: product.Description,
-		Price:       int(product.Price),
+		Price:       product.Price.Amount,
+		Currency:    product.Price.Currency,
+		TaxCategory: product.TaxCategory,
 		CreatedAt:   product.CreatedAt,
 		UpdatedAt:   product.UpdatedAt,
+		DeletedAt:   product.DeletedAt,
+		Version:     product.Version,
+		Sku:         product.Sku,
+		CategoryId:  product.CategoryId,
+		Inactive:    !product.Active,
+	}
+
+	for _, variant := range product.Variants {
+		productModel.Variants = append(productModel.Variants, &models.ProductVariantModel{
+			Id:       variant.Id,
+			Sku:      variant.Sku,
+			Options:  variant.Options,
+			Price:    variant.Price.Amount,
+			Stock:    int(variant.Stock),
+			Inactive: !variant.Active,
+		})
 	}
+
+	for _, component := range product.Components {
+		productModel.Components = append(productModel.Components, &models.ComponentModel{
+			ProductId: component.ProductId,
+			VariantId: component.VariantId,
+			Quantity:  int(component.Quantity),
+		})
+		productModel.ComponentIds = append(productModel.ComponentIds, component.ProductId)
+	}
+
+	return productModel
 }
 
-func (r *productRepository) unmarshallProduct(productModel *models.ProductModel) *product.Product {
-	return &product.Product{
+// UnmarshallProduct returns the product stored as productModel, without its
+// ID.
+func UnmarshallProduct(productModel *models.ProductModel) *product.Product {
+	p := &product.Product{
 		Name:        productModel.Name,
 		Description: productModel.Description,
-		Price:       uint(productModel.Price),
+		Price:       money.FromStored(productModel.Price, productModel.Currency),
+		TaxCategory: productModel.TaxCategory,
 		CreatedAt:   productModel.CreatedAt,
 		UpdatedAt:   productModel.UpdatedAt,
+		DeletedAt:   productModel.DeletedAt,
+		Version:     productModel.Version,
+		Sku:         productModel.Sku,
+		CategoryId:  productModel.CategoryId,
+		Active:      !productModel.Inactive,
+	}
+
+	for _, variant := range productModel.Variants {
+		p.Variants = append(p.Variants, &product.Variant{
+			Id:      variant.Id,
+			Sku:     variant.Sku,
+			Options: variant.Options,
+			Price:   money.FromStored(variant.Price, productModel.Currency),
+			Stock:   uint(variant.Stock),
+			Active:  !variant.Inactive,
+		})
+	}
+
+	for _, component := range productModel.Components {
+		p.Components = append(p.Components, &product.Component{
+			ProductId: component.ProductId,
+			VariantId: component.VariantId,
+			Quantity:  uint(component.Quantity),
+		})
 	}
+
+	return p
 }
@@ -246,7 +246,7 @@ func TestProductRepository_ListProducts(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := productRepository.ListProducts(tt.args.ctx)
+			got, err := productRepository.ListProducts(tt.args.ctx, &repository.ProductFilter{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ProductRepository.ListProducts() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/leta/order-management-system/orders/generated"
 	"github.com/leta/order-management-system/orders/internal/interfaces/api/product"
+	"github.com/leta/order-management-system/orders/pkg/money"
 	"github.com/leta/order-management-system/orders/pkg/utils"
 	"log"
 )
@@ -25,7 +26,11 @@ func (s *productService) CreateProduct(ctx context.Context, in *generated.Create
 	p, err := s.productRepo.CreateProduct(ctx, &product.Product{
 		Name:        in.GetName(),
 		Description: in.GetDescription(),
-		Price:       uint(in.GetPrice()),
+		Price:       fromMoneyMessage(in.GetPrice()),
+		TaxCategory: in.GetTaxCategory(),
+		Sku:         in.GetSku(),
+		CategoryId:  in.GetCategoryId(),
+		Active:      in.Active == nil || in.GetActive(),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create product: %w", err)
@@ -49,7 +54,8 @@ func (s *productService) GetProduct(ctx context.Context, in *generated.GetProduc
 		Id:          p.Id,
 		Name:        p.Name,
 		Description: p.Description,
-		Price:       uint32(p.Price),
+		Price:       moneyMessage(p.Price),
+		TaxCategory: p.TaxCategory,
 	}, nil
 }
 
@@ -57,7 +63,11 @@ func (s *productService) ListProducts(ctx context.Context, in *generated.ListPro
 
 	log.Printf("Received: %v", in)
 
-	products, err := s.productRepo.ListProducts(ctx)
+	products, err := s.productRepo.ListProducts(ctx, &product.ProductFilter{
+		IncludeDeleted: in.GetIncludeDeleted(),
+		CategoryId:     in.GetCategoryId(),
+		ActiveOnly:     in.GetActiveOnly(),
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list products: %w", err)
 	}
@@ -68,7 +78,8 @@ func (s *productService) ListProducts(ctx context.Context, in *generated.ListPro
 			Id:          p.Id,
 			Name:        p.Name,
 			Description: p.Description,
-			Price:       uint32(p.Price),
+			Price:       moneyMessage(p.Price),
+			TaxCategory: p.TaxCategory,
 		})
 	}
 
@@ -81,20 +92,38 @@ func (s *productService) UpdateProduct(ctx context.Context, in *generated.Update
 
 	log.Printf("Received: %v", in)
 
-	p, err := s.productRepo.UpdateProduct(ctx, in.GetId(), &product.ProductUpdate{
-		Name:        utils.StringPtr(in.GetUpdate().GetName()),
-		Description: utils.StringPtr(in.GetUpdate().GetDescription()),
-		Price:       utils.UintPtr(uint(in.GetUpdate().GetPrice())),
-	})
+	paths, err := utils.UpdatePaths(in.GetUpdateMask(), in.GetUpdate())
+	if err != nil {
+		return nil, err
+	}
+
+	update := &product.ProductUpdate{ETag: in.GetEtag()}
+	if paths["name"] {
+		update.Name = utils.StringPtr(in.GetUpdate().GetName())
+	}
+	if paths["description"] {
+		update.Description = utils.StringPtr(in.GetUpdate().GetDescription())
+	}
+	if paths["price"] {
+		price := fromMoneyMessage(in.GetUpdate().GetPrice())
+		update.Price = &price
+	}
+	if paths["tax_category"] {
+		update.TaxCategory = utils.StringPtr(in.GetUpdate().GetTaxCategory())
+	}
+
+	p, err := s.productRepo.UpdateProduct(ctx, in.GetId(), update)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update product: %w", err)
 	}
 
 	return &generated.UpdateProductResponse{
 		Id:          p.Id,
+		Etag:        utils.ETag(p.Version),
 		Name:        p.Name,
 		Description: p.Description,
-		Price:       uint32(p.Price),
+		Price:       moneyMessage(p.Price),
+		TaxCategory: p.TaxCategory,
 	}, nil
 }
 
@@ -109,3 +138,11 @@ func (s *productService) DeleteProduct(ctx context.Context, in *generated.Delete
 
 	return &generated.DeleteProductResponse{}, nil
 }
+
+func moneyMessage(m money.Money) *generated.Money {
+	return &generated.Money{Amount: m.Amount, Currency: m.Currency}
+}
+
+func fromMoneyMessage(m *generated.Money) money.Money {
+	return money.New(m.GetAmount(), m.GetCurrency())
+}
@@ -0,0 +1,255 @@
+package promotions
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/leta/order-management-system/orders/db/firebase"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/promotions"
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/models"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// Collection holds the promotions, keyed by coupon code. Each has a
+// RedemptionCollection of the orders it was applied to, keyed by customer.
+const (
+	Collection           = "promotions"
+	RedemptionCollection = "redemptions"
+)
+
+type PromotionRepository struct {
+	db *firebase.FirestoreService
+}
+
+var _ promotions.PromotionRepository = (*PromotionRepository)(nil)
+
+func NewPromotionRepository(db *firebase.FirestoreService) *PromotionRepository {
+	return &PromotionRepository{
+		db: db,
+	}
+}
+
+func (r *PromotionRepository) CheckPreconditions() {
+	if r.db == nil {
+		panic("no DB service provided")
+	}
+}
+
+func (r *PromotionRepository) promotionCollection() *firestore.CollectionRef {
+	r.CheckPreconditions()
+
+	return r.db.Client.Collection(Collection)
+}
+
+func (r *PromotionRepository) record(
+	ctx context.Context, tx *firestore.Transaction, code string, action string, before, after *promotions.Promotion) error {
+
+	event, err := audit.New(ctx, audit.EntityPromotion, code, action, before, after)
+	if err != nil {
+		return err
+	}
+
+	return audit.NewFirestoreLog(r.db.Client, utils.SERVICE_NAME).Record(tx, event)
+}
+
+func (r *PromotionRepository) CreatePromotion(
+	ctx context.Context, promotion *promotions.Promotion) (*promotions.Promotion, error) {
+
+	r.CheckPreconditions()
+
+	currentTime := time.Now().Format(time.RFC3339)
+
+	promotion.Code = promotions.NormalizeCode(promotion.Code)
+	promotion.Uses = 0
+	promotion.CreatedAt = currentTime
+	promotion.UpdatedAt = currentTime
+	promotion.Version = 1
+
+	if err := promotion.Validate(); err != nil {
+		return nil, err
+	}
+
+	docRef := r.promotionCollection().Doc(promotion.Code)
+
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		err := tx.Create(docRef, MarshallPromotion(promotion))
+		if status.Code(err) == codes.AlreadyExists {
+			return utils.Errorf(utils.ALREADY_EXISTS_ERROR, "promotion %s already exists", promotion.Code)
+		} else if err != nil {
+			return err
+		}
+
+		return r.record(ctx, tx, promotion.Code, audit.ActionCreate, nil, promotion)
+	})
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return nil, err
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to create promotion: %v", err)
+	}
+
+	return promotion, nil
+}
+
+func (r *PromotionRepository) GetPromotion(ctx context.Context, code string) (*promotions.Promotion, error) {
+	r.CheckPreconditions()
+
+	code = promotions.NormalizeCode(code)
+	if code == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "code is required")
+	}
+
+	doc, err := r.promotionCollection().Doc(code).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, utils.Errorf(utils.NOT_FOUND_ERROR, "promotion not found")
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get promotion: %v", err)
+	}
+
+	promotionModel := &models.PromotionModel{}
+	if err := doc.DataTo(promotionModel); err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall promotion: %v", err)
+	}
+
+	return UnmarshallPromotion(doc.Ref.ID, promotionModel), nil
+}
+
+func (r *PromotionRepository) ListPromotions(ctx context.Context) ([]*promotions.Promotion, error) {
+	r.CheckPreconditions()
+
+	iter := r.promotionCollection().Documents(ctx)
+	defer iter.Stop()
+
+	var list []*promotions.Promotion
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to iterate promotions: %v", err)
+		}
+
+		promotionModel := &models.PromotionModel{}
+		if err := doc.DataTo(promotionModel); err != nil {
+			return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall promotion: %v", err)
+		}
+
+		list = append(list, UnmarshallPromotion(doc.Ref.ID, promotionModel))
+	}
+
+	return list, nil
+}
+
+// DeletePromotion removes a promotion and its redemptions. Orders it was
+// applied to keep its coupon code, and are checked out without discount.
+func (r *PromotionRepository) DeletePromotion(ctx context.Context, code string) error {
+	r.CheckPreconditions()
+
+	code = promotions.NormalizeCode(code)
+	if code == "" {
+		return utils.Errorf(utils.INVALID_ERROR, "code is required")
+	}
+
+	docRef := r.promotionCollection().Doc(code)
+
+	err := r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		promotionModel := &models.PromotionModel{}
+		if err := doc.DataTo(promotionModel); err != nil {
+			return err
+		}
+
+		redemptions, err := tx.Documents(docRef.Collection(RedemptionCollection)).GetAll()
+		if err != nil {
+			return err
+		}
+
+		for _, redemption := range redemptions {
+			if err := tx.Delete(redemption.Ref); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Delete(docRef); err != nil {
+			return err
+		}
+
+		return r.record(ctx, tx, code, audit.ActionDelete, UnmarshallPromotion(code, promotionModel), nil)
+	})
+	if err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "failed to delete promotion: %v", err)
+	}
+
+	return nil
+}
+
+// MarshallPromotion returns the document of promotion.
+func MarshallPromotion(promotion *promotions.Promotion) *models.PromotionModel {
+	return &models.PromotionModel{
+		Description:        promotion.Description,
+		Type:               promotion.Type,
+		PercentOff:         promotion.PercentOff,
+		AmountOff:          promotion.AmountOff.Amount,
+		AmountOffCurrency:  promotion.AmountOff.Currency,
+		BuyProductId:       promotion.BuyProductId,
+		BuyQuantity:        int(promotion.BuyQuantity),
+		GetProductId:       promotion.GetProductId,
+		GetQuantity:        int(promotion.GetQuantity),
+		MinOrderValue:      promotion.MinOrderValue.Amount,
+		MinOrderCurrency:   promotion.MinOrderValue.Currency,
+		MaxUses:            promotion.MaxUses,
+		MaxUsesPerCustomer: promotion.MaxUsesPerCustomer,
+		Uses:               promotion.Uses,
+		StartsAt:           promotion.StartsAt,
+		EndsAt:             promotion.EndsAt,
+		CreatedAt:          promotion.CreatedAt,
+		UpdatedAt:          promotion.UpdatedAt,
+		Version:            promotion.Version,
+	}
+}
+
+// UnmarshallPromotion returns the promotion of code stored as promotionModel.
+func UnmarshallPromotion(code string, promotionModel *models.PromotionModel) *promotions.Promotion {
+	promotion := &promotions.Promotion{
+		Code:               code,
+		Description:        promotionModel.Description,
+		Type:               promotionModel.Type,
+		PercentOff:         promotionModel.PercentOff,
+		BuyProductId:       promotionModel.BuyProductId,
+		BuyQuantity:        uint(promotionModel.BuyQuantity),
+		GetProductId:       promotionModel.GetProductId,
+		GetQuantity:        uint(promotionModel.GetQuantity),
+		MaxUses:            promotionModel.MaxUses,
+		MaxUsesPerCustomer: promotionModel.MaxUsesPerCustomer,
+		Uses:               promotionModel.Uses,
+		StartsAt:           promotionModel.StartsAt,
+		EndsAt:             promotionModel.EndsAt,
+		CreatedAt:          promotionModel.CreatedAt,
+		UpdatedAt:          promotionModel.UpdatedAt,
+		Version:            promotionModel.Version,
+	}
+
+	if promotionModel.AmountOffCurrency != "" {
+		promotion.AmountOff = money.New(promotionModel.AmountOff, promotionModel.AmountOffCurrency)
+	}
+	if promotionModel.MinOrderCurrency != "" {
+		promotion.MinOrderValue = money.New(promotionModel.MinOrderValue, promotionModel.MinOrderCurrency)
+	}
+
+	return promotion
+}
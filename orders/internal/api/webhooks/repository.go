@@ -0,0 +1,444 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/leta/order-management-system/orders/db/firebase"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/webhooks"
+	"github.com/leta/order-management-system/orders/pkg/models"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// WebhookRepository stores subscriptions, deliveries and attempts in their own
+// collections. Its queries need the composite indexes declared in main.tf.
+type WebhookRepository struct {
+	db *firebase.FirestoreService
+}
+
+var _ webhooks.WebhookRepository = (*WebhookRepository)(nil)
+
+func NewWebhookRepository(db *firebase.FirestoreService) *WebhookRepository {
+	return &WebhookRepository{
+		db: db,
+	}
+}
+
+func (s *WebhookRepository) CheckPreconditions() {
+	if s.db == nil {
+		panic("no DB service provided")
+	}
+}
+
+func (s *WebhookRepository) subscriptionCollection() *firestore.CollectionRef {
+	s.CheckPreconditions()
+
+	return s.db.Client.Collection("webhook_subscriptions")
+}
+
+func (s *WebhookRepository) deliveryCollection() *firestore.CollectionRef {
+	s.CheckPreconditions()
+
+	return s.db.Client.Collection("webhook_deliveries")
+}
+
+func (s *WebhookRepository) attemptCollection() *firestore.CollectionRef {
+	s.CheckPreconditions()
+
+	return s.db.Client.Collection("webhook_attempts")
+}
+
+func (s *WebhookRepository) CreateSubscription(
+	ctx context.Context, subscription *webhooks.Subscription) (*webhooks.Subscription, error) {
+
+	s.CheckPreconditions()
+
+	if err := subscription.Validate(); err != nil {
+		return nil, err
+	}
+
+	currentTime := time.Now()
+	subscription.CreatedAt = currentTime.Format(time.RFC3339)
+	subscription.UpdatedAt = currentTime.Format(time.RFC3339)
+
+	docRef, _, err := s.subscriptionCollection().Add(ctx, s.marshallSubscription(subscription))
+	if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to create webhook subscription: %v", err)
+	}
+
+	subscription.Id = docRef.ID
+
+	return subscription, nil
+}
+
+func (s *WebhookRepository) GetSubscription(ctx context.Context, id string) (*webhooks.Subscription, error) {
+	s.CheckPreconditions()
+
+	if id == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "id is required")
+	}
+
+	docRef, err := s.subscriptionCollection().Doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, utils.Errorf(utils.NOT_FOUND_ERROR, "webhook subscription not found")
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get webhook subscription: %v", err)
+	}
+
+	return s.unmarshallSubscription(docRef)
+}
+
+func (s *WebhookRepository) ListSubscriptions(ctx context.Context) ([]*webhooks.Subscription, error) {
+	s.CheckPreconditions()
+
+	return s.listSubscriptions(ctx, s.subscriptionCollection().Query)
+}
+
+func (s *WebhookRepository) ListSubscriptionsFor(ctx context.Context, eventType string) ([]*webhooks.Subscription, error) {
+	s.CheckPreconditions()
+
+	return s.listSubscriptions(ctx, s.subscriptionCollection().Where("event_types", "array-contains", eventType))
+}
+
+func (s *WebhookRepository) listSubscriptions(ctx context.Context, query firestore.Query) ([]*webhooks.Subscription, error) {
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+
+	var subscriptions []*webhooks.Subscription
+	for {
+		docRef, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to iterate webhook subscriptions: %v", err)
+		}
+
+		subscription, err := s.unmarshallSubscription(docRef)
+		if err != nil {
+			return nil, err
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, nil
+}
+
+func (s *WebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	s.CheckPreconditions()
+
+	if id == "" {
+		return utils.Errorf(utils.INVALID_ERROR, "id is required")
+	}
+
+	_, err := s.subscriptionCollection().Doc(id).Delete(ctx)
+	if err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "failed to delete webhook subscription: %v", err)
+	}
+
+	return nil
+}
+
+func (s *WebhookRepository) CreateDelivery(ctx context.Context, delivery *webhooks.Delivery) error {
+	s.CheckPreconditions()
+
+	if delivery.Id == "" {
+		return utils.Errorf(utils.INVALID_ERROR, "id is required")
+	}
+
+	_, err := s.deliveryCollection().Doc(delivery.Id).Create(ctx, s.marshallDelivery(delivery))
+	if status.Code(err) == codes.AlreadyExists {
+		return nil
+	} else if err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "failed to create webhook delivery: %v", err)
+	}
+
+	return nil
+}
+
+func (s *WebhookRepository) ClaimDueDeliveries(
+	ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*webhooks.Delivery, error) {
+
+	s.CheckPreconditions()
+
+	query := s.deliveryCollection().
+		Where("status", "==", webhooks.DeliveryPending).
+		Where("next_attempt_at", "<=", now).
+		OrderBy("next_attempt_at", firestore.Asc).
+		Limit(limit)
+
+	var claimed []*webhooks.Delivery
+
+	err := s.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		claimed = nil
+
+		docs, err := tx.Documents(query).GetAll()
+		if err != nil {
+			return err
+		}
+
+		for _, doc := range docs {
+			delivery, err := s.unmarshallDelivery(doc)
+			if err != nil {
+				return err
+			}
+
+			err = tx.Update(doc.Ref, []firestore.Update{
+				{Path: "next_attempt_at", Value: now.Add(lease)},
+			})
+			if err != nil {
+				return err
+			}
+
+			claimed = append(claimed, delivery)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to claim webhook deliveries: %v", err)
+	}
+
+	return claimed, nil
+}
+
+func (s *WebhookRepository) RecordAttempt(
+	ctx context.Context, delivery *webhooks.Delivery, attempt *webhooks.Attempt) error {
+
+	s.CheckPreconditions()
+
+	attemptRef := s.attemptCollection().NewDoc()
+
+	err := s.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if err := tx.Set(s.deliveryCollection().Doc(delivery.Id), s.marshallDelivery(delivery)); err != nil {
+			return err
+		}
+
+		return tx.Create(attemptRef, &models.WebhookAttemptModel{
+			SubscriptionId: attempt.SubscriptionId,
+			DeliveryId:     attempt.DeliveryId,
+			Attempt:        int(attempt.Attempt),
+			StatusCode:     attempt.StatusCode,
+			Error:          attempt.Error,
+			DurationMs:     attempt.Duration.Milliseconds(),
+			AttemptedAt:    attempt.AttemptedAt,
+		})
+	})
+	if err != nil {
+		return utils.Errorf(utils.INTERNAL_ERROR, "failed to record webhook delivery attempt: %v", err)
+	}
+
+	attempt.Id = attemptRef.ID
+
+	return nil
+}
+
+func (s *WebhookRepository) ListDeliveries(
+	ctx context.Context, subscriptionId string, deliveryStatus string) ([]*webhooks.Delivery, error) {
+
+	s.CheckPreconditions()
+
+	if subscriptionId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "subscription_id is required")
+	}
+
+	query := s.deliveryCollection().Where("subscription_id", "==", subscriptionId)
+	if deliveryStatus != "" {
+		query = query.Where("status", "==", deliveryStatus)
+	}
+
+	iter := query.OrderBy("created_at", firestore.Desc).Documents(ctx)
+	defer iter.Stop()
+
+	var deliveries []*webhooks.Delivery
+	for {
+		docRef, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to iterate webhook deliveries: %v", err)
+		}
+
+		delivery, err := s.unmarshallDelivery(docRef)
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+func (s *WebhookRepository) ListAttempts(
+	ctx context.Context, subscriptionId string, deliveryId string) ([]*webhooks.Attempt, error) {
+
+	s.CheckPreconditions()
+
+	if subscriptionId == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "subscription_id is required")
+	}
+
+	query := s.attemptCollection().Where("subscription_id", "==", subscriptionId)
+	if deliveryId != "" {
+		query = query.Where("delivery_id", "==", deliveryId)
+	}
+
+	iter := query.OrderBy("attempted_at", firestore.Desc).Documents(ctx)
+	defer iter.Stop()
+
+	var attempts []*webhooks.Attempt
+	for {
+		docRef, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to iterate webhook delivery attempts: %v", err)
+		}
+
+		attemptModel := &models.WebhookAttemptModel{}
+		if err := docRef.DataTo(attemptModel); err != nil {
+			return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall webhook delivery attempt: %v", err)
+		}
+
+		attempts = append(attempts, &webhooks.Attempt{
+			Id:             docRef.Ref.ID,
+			SubscriptionId: attemptModel.SubscriptionId,
+			DeliveryId:     attemptModel.DeliveryId,
+			Attempt:        uint(attemptModel.Attempt),
+			StatusCode:     attemptModel.StatusCode,
+			Error:          attemptModel.Error,
+			Duration:       time.Duration(attemptModel.DurationMs) * time.Millisecond,
+			AttemptedAt:    attemptModel.AttemptedAt,
+		})
+	}
+
+	return attempts, nil
+}
+
+func (s *WebhookRepository) RetryDelivery(
+	ctx context.Context, subscriptionId string, id string) (*webhooks.Delivery, error) {
+
+	s.CheckPreconditions()
+
+	if id == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "id is required")
+	}
+
+	var delivery *webhooks.Delivery
+	docRef := s.deliveryCollection().Doc(id)
+
+	err := s.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if status.Code(err) == codes.NotFound {
+			return utils.Errorf(utils.NOT_FOUND_ERROR, "webhook delivery not found")
+		} else if err != nil {
+			return err
+		}
+
+		delivery, err = s.unmarshallDelivery(doc)
+		if err != nil {
+			return err
+		}
+
+		if delivery.SubscriptionId != subscriptionId {
+			return utils.Errorf(utils.NOT_FOUND_ERROR, "webhook delivery not found")
+		}
+
+		if delivery.Status != webhooks.DeliveryDeadLetter {
+			return utils.Errorf(utils.INVALID_ERROR, "only dead-lettered deliveries can be retried")
+		}
+
+		// The delivery gets a fresh set of attempts.
+		now := time.Now().UTC()
+		delivery.Status = webhooks.DeliveryPending
+		delivery.Attempts = 0
+		delivery.NextAttemptAt = now
+		delivery.UpdatedAt = now
+
+		return tx.Set(docRef, s.marshallDelivery(delivery))
+	})
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return nil, err
+	} else if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to retry webhook delivery: %v", err)
+	}
+
+	return delivery, nil
+}
+
+func (s *WebhookRepository) marshallSubscription(subscription *webhooks.Subscription) *models.WebhookSubscriptionModel {
+
+	return &models.WebhookSubscriptionModel{
+		Url:        subscription.Url,
+		EventTypes: subscription.EventTypes,
+		Secret:     subscription.Secret,
+		CreatedAt:  subscription.CreatedAt,
+		UpdatedAt:  subscription.UpdatedAt,
+	}
+}
+
+func (s *WebhookRepository) unmarshallSubscription(docRef *firestore.DocumentSnapshot) (*webhooks.Subscription, error) {
+
+	subscriptionModel := &models.WebhookSubscriptionModel{}
+	if err := docRef.DataTo(subscriptionModel); err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall webhook subscription: %v", err)
+	}
+
+	return &webhooks.Subscription{
+		Id:         docRef.Ref.ID,
+		Url:        subscriptionModel.Url,
+		EventTypes: subscriptionModel.EventTypes,
+		Secret:     subscriptionModel.Secret,
+		CreatedAt:  subscriptionModel.CreatedAt,
+		UpdatedAt:  subscriptionModel.UpdatedAt,
+	}, nil
+}
+
+func (s *WebhookRepository) marshallDelivery(delivery *webhooks.Delivery) *models.WebhookDeliveryModel {
+
+	return &models.WebhookDeliveryModel{
+		SubscriptionId: delivery.SubscriptionId,
+		EventId:        delivery.EventId,
+		EventType:      delivery.EventType,
+		Body:           delivery.Body,
+		Status:         delivery.Status,
+		Attempts:       int(delivery.Attempts),
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	}
+}
+
+func (s *WebhookRepository) unmarshallDelivery(docRef *firestore.DocumentSnapshot) (*webhooks.Delivery, error) {
+
+	deliveryModel := &models.WebhookDeliveryModel{}
+	if err := docRef.DataTo(deliveryModel); err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to unmarshall webhook delivery: %v", err)
+	}
+
+	return &webhooks.Delivery{
+		Id:             docRef.Ref.ID,
+		SubscriptionId: deliveryModel.SubscriptionId,
+		EventId:        deliveryModel.EventId,
+		EventType:      deliveryModel.EventType,
+		Body:           deliveryModel.Body,
+		Status:         deliveryModel.Status,
+		Attempts:       uint(deliveryModel.Attempts),
+		NextAttemptAt:  deliveryModel.NextAttemptAt,
+		LastError:      deliveryModel.LastError,
+		CreatedAt:      deliveryModel.CreatedAt,
+		UpdatedAt:      deliveryModel.UpdatedAt,
+	}, nil
+}
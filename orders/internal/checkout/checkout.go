@@ -2,10 +2,19 @@ package checkout
 
 import (
 	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/leta/order-management-system/orders/internal/interfaces/api/customers"
 	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/pricelists"
 	"github.com/leta/order-management-system/orders/internal/interfaces/api/product"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/promotions"
+	"github.com/leta/order-management-system/orders/internal/tax"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/tracing"
 	"github.com/leta/order-management-system/orders/pkg/utils"
 
 	"github.com/leta/order-management-system/orders/internal/service"
@@ -13,10 +22,13 @@ import (
 )
 
 type CheckoutService struct {
-	productRepository  product.RepositoryInterface
-	customerRepository customers.CustomerRepositoryInterface
-	orderRepository    orders.OrderRepository
+	productRepository   product.RepositoryInterface
+	customerRepository  customers.CustomerRepositoryInterface
+	orderRepository     orders.OrderRepository
+	promotionRepository promotions.PromotionRepository
+	priceListRepository pricelists.PriceListRepository
 
+	taxCalculator  *tax.Calculator
 	paymentsClient client.PaymentsClient
 }
 
@@ -24,13 +36,19 @@ func NewCheckoutService(
 	productRepository product.RepositoryInterface,
 	customerRepository customers.CustomerRepositoryInterface,
 	orderRepository orders.OrderRepository,
+	promotionRepository promotions.PromotionRepository,
+	priceListRepository pricelists.PriceListRepository,
+	taxCalculator *tax.Calculator,
 	paymentsClient client.PaymentsClient) *CheckoutService {
 
 	return &CheckoutService{
-		orderRepository:    orderRepository,
-		productRepository:  productRepository,
-		customerRepository: customerRepository,
-		paymentsClient:     paymentsClient,
+		orderRepository:     orderRepository,
+		productRepository:   productRepository,
+		customerRepository:  customerRepository,
+		promotionRepository: promotionRepository,
+		priceListRepository: priceListRepository,
+		taxCalculator:       taxCalculator,
+		paymentsClient:      paymentsClient,
 	}
 }
 
@@ -47,37 +65,196 @@ func (s *CheckoutService) CheckPreconditions() {
 		panic("orderRepository is required")
 	}
 
+	if s.promotionRepository == nil {
+		panic("promotionRepository is required")
+	}
+
+	if s.priceListRepository == nil {
+		panic("priceListRepository is required")
+	}
+
+	if s.taxCalculator == nil {
+		panic("taxCalculator is required")
+	}
+
 	if s.paymentsClient == nil {
 		panic("paymentsClient is required")
 	}
 }
 
-func (s *CheckoutService) GetOrderCost(ctx context.Context, orderId string) (uint, error) {
+// GetOrderCost returns what the customer pays for an order, discount and tax
+// included: the total stored at checkout, or else the total computed as
+// checkout would.
+func (s *CheckoutService) GetOrderCost(ctx context.Context, orderId string) (_ money.Money, err error) {
 	s.CheckPreconditions()
 
+	ctx, span := tracing.Start(ctx, "CheckoutService.GetOrderCost")
+	defer func() { tracing.End(span, err) }()
+
 	order, err := s.orderRepository.GetOrder(ctx, orderId)
 	if err != nil {
-		return 0, err
+		return money.Money{}, err
 	}
 
-	var cost uint
-	for _, item := range order.Items {
+	if order.Tax != nil {
+		return order.Tax.Total, nil
+	}
+
+	customer, err := s.customerRepository.GetCustomer(ctx, order.CustomerId)
+	if err != nil {
+		return money.Money{}, utils.Errorf(utils.INTERNAL_ERROR, "failed to get customers: %v", err)
+	}
+
+	if err := s.applyTotals(ctx, order, customer); err != nil {
+		return money.Money{}, err
+	}
+
+	return order.Tax.Total, nil
+}
+
+// applyTotals sets the discount and tax of order and of its items. Items
+// added before prices were recorded are priced at the current price of their
+// product or variant, or that of the price lists of customer, see
+// orders.OrderItem.Price. The discount of the coupon of the order is taken
+// off the prices before tax, which depends on the tax category of the
+// products and whether customer is exempt.
+func (s *CheckoutService) applyTotals(ctx context.Context, order *orders.Order, customer *customers.Customer) error {
+	var pricing *pricelists.Pricing
+
+	categories := make([]string, len(order.Items))
+	for i, item := range order.Items {
 		product, err := s.productRepository.GetProduct(ctx, item.ProductId)
 		if err != nil {
-			return 0, utils.Errorf(utils.INTERNAL_ERROR, "failed to get product: %v", err)
+			return utils.Errorf(utils.INTERNAL_ERROR, "failed to get product: %v", err)
+		}
+
+		if !item.Priced() {
+			if pricing == nil {
+				lists, err := s.priceListRepository.ListPriceLists(ctx)
+				if err != nil {
+					return err
+				}
+				pricing = &pricelists.Pricing{Customer: customer, Lists: lists, Now: time.Now()}
+			}
+
+			if err := item.Price(product, pricing); err != nil {
+				return err
+			}
+		}
+		categories[i] = product.TaxCategory
+	}
+
+	if err := s.applyDiscount(ctx, order); err != nil {
+		return err
+	}
+
+	lines := make([]tax.Line, len(order.Items))
+	for i, item := range order.Items {
+		total, err := item.Total()
+		if err != nil {
+			return err
+		}
+
+		price, err := total.Sub(item.Discount)
+		if err != nil {
+			return utils.Errorf(utils.INVALID_ERROR, "%v", err)
+		}
+
+		lines[i] = tax.Line{Category: categories[i], Price: price}
+	}
+
+	currency := money.DefaultCurrency
+	if len(order.Items) > 0 {
+		currency = order.Items[0].UnitPrice.Currency
+	}
+
+	result, err := s.taxCalculator.Calculate(currency, lines, customer.TaxExempt)
+	if err != nil {
+		return err
+	}
+
+	for i, item := range order.Items {
+		line := result.Lines[i]
+		item.Tax = &orders.ItemTax{
+			Category: line.Category,
+			Rate:     line.Rate,
+			Amount:   line.Tax,
+			Total:    line.Gross,
 		}
+	}
 
-		cost += product.Price * item.Quantity
+	order.Tax = &orders.OrderTax{
+		Inclusive: result.Inclusive,
+		Exempt:    result.Exempt,
+		Subtotal:  result.Net,
+		Amount:    result.Tax,
+		Total:     result.Gross,
 	}
 
-	return cost, nil
+	return nil
 }
 
-func (s *CheckoutService) ProcessCheckout(ctx context.Context, orderId string) (*service.Order, error) {
-	s.CheckPreconditions()
+// applyDiscount sets the discount of the priced items of order. There is
+// none without a coupon or items, or if its promotion was deleted since it
+// was applied.
+func (s *CheckoutService) applyDiscount(ctx context.Context, order *orders.Order) error {
+	order.Discount = nil
+	for _, item := range order.Items {
+		item.Discount = money.Zero(item.UnitPrice.Currency)
+	}
+
+	if order.CouponCode == "" || len(order.Items) == 0 {
+		return nil
+	}
+
+	promotion, err := s.promotionRepository.GetPromotion(ctx, order.CouponCode)
+	if utils.ErrorCode(err) == utils.NOT_FOUND_ERROR {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	discounts, err := promotion.Discount(order.Items)
+	if err != nil {
+		return err
+	}
 
-	order, err := s.orderRepository.UpdateOrderStatus(ctx, orderId, utils.OrderStatusProcessing)
+	for i, item := range order.Items {
+		item.Discount = discounts[i]
+	}
+
+	amount, err := money.Sum(order.Items[0].UnitPrice.Currency, discounts...)
 	if err != nil {
+		return utils.Errorf(utils.INVALID_ERROR, "%v", err)
+	}
+
+	if !amount.IsZero() {
+		order.Discount = &orders.Discount{
+			Code:        promotion.Code,
+			Description: promotion.Description,
+			Amount:      amount,
+		}
+	}
+
+	return nil
+}
+
+func (s *CheckoutService) ProcessCheckout(ctx context.Context, orderId string) (_ *service.Order, err error) {
+	s.CheckPreconditions()
+
+	ctx, span := tracing.Start(ctx, "CheckoutService.ProcessCheckout")
+	span.SetAttributes(attribute.String("order.id", orderId))
+	defer func() {
+		tracing.End(span, err)
+		recordCheckout(err)
+	}()
+
+	// Fails if the order was already paid, so that it is not charged again.
+	order, err := s.orderRepository.UpdateOrderStatus(ctx, orderId, utils.OrderStatusProcessing, "")
+	var appErr *utils.Error
+	if errors.As(err, &appErr) {
+		return nil, err
+	} else if err != nil {
 		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to update orders status: %v", err)
 	}
 
@@ -91,13 +268,21 @@ func (s *CheckoutService) ProcessCheckout(ctx context.Context, orderId string) (
 		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to convert phone number to uint: %v", err)
 	}
 
-	cost, err := s.GetOrderCost(ctx, orderId)
+	if err := s.applyTotals(ctx, order, customer); err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to compute orders totals: %v", err)
+	}
+
+	// The discount and tax are stored before the payment is requested, so
+	// that the order states what the customer was asked to pay.
+	order, err = s.orderRepository.SetOrderTotals(ctx, order)
 	if err != nil {
-		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get orders cost: %v", err)
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to store orders totals: %v", err)
 	}
+
+	cost := order.Tax.Total
 	_, err = s.paymentsClient.ProcessMpesaPayment(ctx, &client.ProcessMpesaPaymentRequest{
 		OrderId:     orderId,
-		Amount:      uint32(cost),
+		Amount:      &client.Money{Amount: cost.Amount, Currency: cost.Currency},
 		CustomerId:  order.CustomerId,
 		PhoneNumber: uint64(phoneNo),
 	})
@@ -110,7 +295,12 @@ func (s *CheckoutService) ProcessCheckout(ctx context.Context, orderId string) (
 		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to get orders: %v", err)
 	}
 
-	return s.unmarshallRepositoryOrder(order), nil
+	checkedOut := s.unmarshallRepositoryOrder(order)
+	checkedOut.Total = cost
+	checkedOut.Tax = order.Tax
+	checkedOut.Discount = order.Discount
+
+	return checkedOut, nil
 }
 
 func (s *CheckoutService) unmarshallOrderItem(item *orders.OrderItem) *service.OrderItem {
@@ -118,6 +308,9 @@ func (s *CheckoutService) unmarshallOrderItem(item *orders.OrderItem) *service.O
 		Id:        item.Id,
 		ProductId: item.ProductId,
 		Quantity:  item.Quantity,
+		UnitPrice: item.UnitPrice,
+		Discount:  item.Discount,
+		Tax:       item.Tax,
 		CreatedAt: item.CreatedAt,
 		UpdatedAt: item.UpdatedAt,
 	}
@@ -0,0 +1,84 @@
+package checkout_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/leta/order-management-system/orders/internal/checkout"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/customers"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/pricelists"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/product"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/promotions"
+	"github.com/leta/order-management-system/orders/internal/tax"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+	"github.com/leta/order-management-system/payments/pkg/client"
+)
+
+// orderRepository changes the status of its orders like OrderRepository.
+// Other methods are not used.
+type orderRepository struct {
+	orders.OrderRepository
+
+	statuses map[string]utils.OrderStatus
+}
+
+func (r *orderRepository) UpdateOrderStatus(
+	ctx context.Context, orderId string, status utils.OrderStatus, etag string) (*orders.Order, error) {
+	if err := r.statuses[orderId].CheckTransition(status); err != nil {
+		return nil, err
+	}
+	r.statuses[orderId] = status
+	return &orders.Order{Id: orderId, OrderStatus: status}, nil
+}
+
+// paymentsClient records the payments requested.
+type paymentsClient struct {
+	client.PaymentsClient
+
+	payments []*client.ProcessMpesaPaymentRequest
+}
+
+func (c *paymentsClient) ProcessMpesaPayment(
+	ctx context.Context, req *client.ProcessMpesaPaymentRequest) (*client.ProcessMpesaPaymentResponse, error) {
+	c.payments = append(c.payments, req)
+	return &client.ProcessMpesaPaymentResponse{}, nil
+}
+
+// The other repositories are not used.
+type (
+	productRepository  struct{ product.RepositoryInterface }
+	customerRepository struct {
+		customers.CustomerRepositoryInterface
+	}
+	promotionRepository struct{ promotions.PromotionRepository }
+	priceListRepository struct{ pricelists.PriceListRepository }
+)
+
+func TestCheckoutService_ProcessCheckout_PaidOrder(t *testing.T) {
+	repo := &orderRepository{statuses: map[string]utils.OrderStatus{"order-1": utils.OrderStatusPaid}}
+	payments := &paymentsClient{}
+
+	s := checkout.NewCheckoutService(
+		&productRepository{},
+		&customerRepository{},
+		repo,
+		&promotionRepository{},
+		&priceListRepository{},
+		tax.NewCalculator(tax.Options{}),
+		payments,
+	)
+
+	_, err := s.ProcessCheckout(context.Background(), "order-1")
+	if code := utils.ErrorCode(err); code != utils.INVALID_ERROR {
+		t.Fatalf("ProcessCheckout() error = %v, want code %s", err, utils.INVALID_ERROR)
+	}
+
+	if got := repo.statuses["order-1"]; got != utils.OrderStatusPaid {
+		t.Errorf("order status = %q, want %q", got, utils.OrderStatusPaid)
+	}
+
+	if len(payments.payments) != 0 {
+		t.Errorf("requested %d payments, want none", len(payments.payments))
+	}
+}
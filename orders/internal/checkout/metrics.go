@@ -0,0 +1,19 @@
+package checkout
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var checkoutTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "orders_checkout_total",
+	Help: "Number of checkouts processed, by result.",
+}, []string{"result"})
+
+func recordCheckout(err error) {
+	if err != nil {
+		checkoutTotal.WithLabelValues("failure").Inc()
+		return
+	}
+	checkoutTotal.WithLabelValues("success").Inc()
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"time"
+
+	"github.com/leta/order-management-system/orders/internal/api/carts"
+	"github.com/leta/order-management-system/orders/internal/expiry"
+	"github.com/leta/order-management-system/orders/internal/notifications"
+	"github.com/leta/order-management-system/orders/internal/purge"
+	"github.com/leta/order-management-system/orders/internal/tax"
+	"github.com/leta/order-management-system/orders/internal/webhooks"
+	"github.com/leta/order-management-system/orders/pkg/config"
+	"github.com/leta/order-management-system/orders/pkg/events"
+	"github.com/leta/order-management-system/orders/pkg/grpcclient"
+	"github.com/leta/order-management-system/orders/pkg/lifecycle"
+)
+
+// Config is the configuration of the orders service.
+type Config struct {
+	// Deployment environment, e.g. "dev", "test" or "prod".
+	Environment string `yaml:"environment" env:"ENVIRONMENT"`
+
+	Server   Server        `yaml:"server"`
+	Payments Payments      `yaml:"payments"`
+	Events   config.Events `yaml:"events"`
+
+	// Delivery of partner webhooks, e.g. WEBHOOKS_MAX_ATTEMPTS.
+	Webhooks webhooks.Options `yaml:"webhooks" env:"WEBHOOKS_"`
+
+	// Customer notifications, e.g. NOTIFICATIONS_SMTP_ADDR.
+	Notifications notifications.Options `yaml:"notifications" env:"NOTIFICATIONS_"`
+
+	// Removal of deleted products, customers and orders, e.g. PURGE_RETENTION.
+	Purge purge.Options `yaml:"purge" env:"PURGE_"`
+
+	// Tax rules applied at checkout, e.g. TAX_INCLUSIVE. Rates can only be
+	// set in the config file.
+	Tax tax.Options `yaml:"tax" env:"TAX_"`
+
+	// Expiry of the carts of customers, e.g. CARTS_TTL.
+	Carts carts.Options `yaml:"carts" env:"CARTS_"`
+
+	// Expiry of unpaid orders, e.g. ORDER_EXPIRY_PAYMENT_DEADLINE.
+	OrderExpiry expiry.Options `yaml:"order_expiry" env:"ORDER_EXPIRY_"`
+
+	Firebase config.Firebase `yaml:"firebase"`
+	Tracing  config.Tracing  `yaml:"tracing"`
+}
+
+// Server configures the listeners of the orders service.
+type Server struct {
+	BindAddress string `yaml:"bind_address" env:"BIND_ADDRESS"`
+	Port        string `yaml:"port" env:"PORT"`
+	HTTPPort    string `yaml:"http_port" env:"HTTP_PORT"`
+	DebugPort   string `yaml:"debug_port" env:"DEBUG_PORT"`
+
+	// Registers the gRPC reflection service so tools like grpcurl work.
+	Reflection bool `yaml:"reflection" env:"GRPC_REFLECTION"`
+
+	// Time given to in-flight RPCs, requests and background jobs to finish
+	// after SIGTERM before they are cancelled.
+	ShutdownGracePeriod time.Duration `yaml:"shutdown_grace_period" env:"SHUTDOWN_GRACE_PERIOD"`
+}
+
+// Payments configures the connection to the payments service.
+type Payments struct {
+	Addr string `yaml:"addr" env:"PAYMENTS_ADDR"`
+
+	// Retries, timeouts and circuit breaking of the client, e.g.
+	// PAYMENTS_CLIENT_TIMEOUT.
+	Client grpcclient.Options `yaml:"client" env:"PAYMENTS_CLIENT_"`
+}
+
+// Default returns the configuration used when nothing else is specified.
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			BindAddress: "localhost",
+			Port:        "50051",
+			HTTPPort:    "8080",
+			DebugPort:   "6060",
+
+			ShutdownGracePeriod: lifecycle.DefaultGracePeriod,
+		},
+		Payments: Payments{
+			Addr:   "localhost:50052",
+			Client: grpcclient.DefaultOptions(),
+		},
+		Events: config.Events{
+			Broker:       events.BrokerInProcess,
+			Topic:        "orders-events",
+			Subscription: "orders-payments-events",
+		},
+		Webhooks:      webhooks.DefaultOptions(),
+		Notifications: notifications.DefaultOptions(),
+		Purge:         purge.DefaultOptions(),
+		Tax:           tax.DefaultOptions(),
+		Carts:         carts.DefaultOptions(),
+		OrderExpiry:   expiry.DefaultOptions(),
+	}
+}
+
+// RegisterFlags implements config.Config.
+func (c *Config) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.Environment, "environment", c.Environment, "deployment environment (dev, test, prod)")
+	fs.StringVar(&c.Server.BindAddress, "bind-address", c.Server.BindAddress, "address the servers listen on")
+	fs.StringVar(&c.Server.Port, "port", c.Server.Port, "gRPC server port")
+	fs.StringVar(&c.Server.HTTPPort, "http-port", c.Server.HTTPPort, "REST/JSON gateway port")
+	fs.StringVar(&c.Server.DebugPort, "debug-port", c.Server.DebugPort, "debug server (/metrics) port")
+	fs.BoolVar(&c.Server.Reflection, "grpc-reflection", c.Server.Reflection, "register the gRPC reflection service")
+	fs.DurationVar(&c.Server.ShutdownGracePeriod, "shutdown-grace-period", c.Server.ShutdownGracePeriod,
+		"time given to in-flight work to finish on shutdown")
+	fs.StringVar(&c.Payments.Addr, "payments-addr", c.Payments.Addr, "host:port of the payments gRPC server")
+	fs.StringVar(&c.Events.Broker, "events-broker", c.Events.Broker, "event broker (inprocess, pubsub)")
+	fs.StringVar(&c.Firebase.ProjectID, "firebase-project", c.Firebase.ProjectID, "Google Cloud project ID")
+	fs.StringVar(&c.Tracing.Exporter, "trace-exporter", c.Tracing.Exporter, "span exporter (none, stdout, otlp)")
+}
+
+// Validate implements config.Config.
+func (c *Config) Validate() error {
+	errs := []error{
+		config.ValidatePort("server.port (PORT)", c.Server.Port),
+		config.ValidatePort("server.http_port (HTTP_PORT)", c.Server.HTTPPort),
+		config.ValidatePort("server.debug_port (DEBUG_PORT)", c.Server.DebugPort),
+		config.ValidateDistinctPorts(
+			"server.port", c.Server.Port,
+			"server.http_port", c.Server.HTTPPort,
+			"server.debug_port", c.Server.DebugPort),
+		config.ValidateAddress("payments.addr (PAYMENTS_ADDR)", c.Payments.Addr),
+		c.Payments.Client.Validate("payments.client"),
+		c.Events.Validate(),
+		c.Webhooks.Validate("webhooks"),
+		c.Notifications.Validate("notifications"),
+		c.Purge.Validate("purge"),
+		c.Tax.Validate("tax"),
+		c.Carts.Validate("carts"),
+		c.OrderExpiry.Validate("order_expiry"),
+		c.Firebase.Validate(c.Environment),
+		c.Tracing.Validate(),
+	}
+
+	if c.Server.ShutdownGracePeriod <= 0 {
+		errs = append(errs, errors.New("server.shutdown_grace_period (SHUTDOWN_GRACE_PERIOD) must be positive"))
+	}
+
+	if c.Environment == "" {
+		errs = append(errs, errors.New("environment (ENVIRONMENT) is required"))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,129 @@
+// Package expiry expires the orders that were not paid before their payment
+// deadline.
+package expiry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// Actor is the audit actor of the expiries.
+const Actor = "system:order-expiry"
+
+// Options tune the expiry of unpaid orders.
+type Options struct {
+	// How long after it is created an order can be paid for.
+	PaymentDeadline time.Duration `yaml:"payment_deadline" env:"PAYMENT_DEADLINE"`
+
+	// How often unpaid orders are looked for.
+	Interval time.Duration `yaml:"interval" env:"INTERVAL"`
+}
+
+// DefaultOptions returns the options used when nothing else is specified.
+func DefaultOptions() Options {
+	return Options{
+		PaymentDeadline: 24 * time.Hour,
+		Interval:        10 * time.Minute,
+	}
+}
+
+// Validate reports every invalid option. name prefixes the error messages.
+func (o *Options) Validate(name string) error {
+	var errs []error
+
+	if o.PaymentDeadline <= 0 {
+		errs = append(errs, fmt.Errorf("%s.payment_deadline must be positive", name))
+	}
+	if o.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("%s.interval must be positive", name))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Expirer moves the orders still unpaid Options.PaymentDeadline after they
+// were created to the expired status, including those whose payment failed
+// and deleted ones. Orders being checked out are not expired, as their
+// payment request may still settle. The status change releases their coupon
+// and stock and is published like any other. Several instances may run
+// concurrently.
+type Expirer struct {
+	orderRepository orders.OrderRepository
+	options         Options
+
+	// Now returns the current time; replaced in tests.
+	Now func() time.Time
+}
+
+// NewExpirer creates a new instance of Expirer.
+func NewExpirer(orderRepository orders.OrderRepository, options Options) *Expirer {
+	return &Expirer{
+		orderRepository: orderRepository,
+		options:         options,
+		Now:             time.Now,
+	}
+}
+
+func (e *Expirer) CheckPreconditions() {
+	if e.orderRepository == nil {
+		panic("orderRepository is required")
+	}
+}
+
+// Run expires unpaid orders every Interval until ctx is cancelled.
+func (e *Expirer) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := e.Expire(ctx); err != nil {
+			log.Printf("[expiry] %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Expire expires the orders past their payment deadline and returns their
+// number. Orders changed since they were listed, e.g. paid in the meantime,
+// are left alone. It carries on with the other orders when one fails.
+func (e *Expirer) Expire(ctx context.Context) (int, error) {
+	e.CheckPreconditions()
+
+	ctx = audit.WithActor(ctx, Actor)
+	ctx = audit.WithRequestID(ctx, audit.NewRequestID())
+
+	unpaid, err := e.orderRepository.ListUnpaidOrders(ctx, e.Now().Add(-e.options.PaymentDeadline))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list unpaid orders: %w", err)
+	}
+
+	total := 0
+	var errs []error
+	for _, order := range unpaid {
+		_, err := e.orderRepository.UpdateOrderStatus(
+			ctx, order.Id, utils.OrderStatusExpired, utils.ETag(order.Version))
+		if utils.ErrorCode(err) == utils.ABORTED_ERROR {
+			continue
+		} else if err != nil {
+			errs = append(errs, fmt.Errorf("failed to expire order %s: %w", order.Id, err))
+			continue
+		}
+
+		total++
+	}
+	recordExpired(total)
+
+	return total, errors.Join(errs...)
+}
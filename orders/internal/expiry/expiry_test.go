@@ -0,0 +1,128 @@
+package expiry_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/leta/order-management-system/orders/internal/expiry"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// updateCall records the arguments of an UpdateOrderStatus call.
+type updateCall struct {
+	orderId string
+	status  utils.OrderStatus
+	etag    string
+	actor   string
+}
+
+type orderRepository struct {
+	orders.OrderRepository
+
+	createdBefore time.Time
+	unpaid        []*orders.Order
+	errs          map[string]error
+
+	calls []updateCall
+}
+
+func (r *orderRepository) ListUnpaidOrders(ctx context.Context, createdBefore time.Time) ([]*orders.Order, error) {
+	r.createdBefore = createdBefore
+	return r.unpaid, nil
+}
+
+func (r *orderRepository) UpdateOrderStatus(
+	ctx context.Context, orderId string, status utils.OrderStatus, etag string) (*orders.Order, error) {
+
+	r.calls = append(r.calls, updateCall{orderId, status, etag, audit.Actor(ctx)})
+	return &orders.Order{Id: orderId, OrderStatus: status}, r.errs[orderId]
+}
+
+func TestExpirer_Expire(t *testing.T) {
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	options := expiry.Options{PaymentDeadline: 24 * time.Hour, Interval: time.Hour}
+
+	unpaid := []*orders.Order{
+		{Id: "1", OrderStatus: utils.OrderStatusNew, Version: 1},
+		{Id: "2", OrderStatus: utils.OrderStatusPending, Version: 3},
+	}
+
+	tests := []struct {
+		name      string
+		errs      map[string]error
+		wantTotal int
+		wantErr   bool
+	}{
+		{
+			name:      "expires unpaid orders",
+			wantTotal: 2,
+		},
+		{
+			name:      "skips changed orders",
+			errs:      map[string]error{"1": utils.Errorf(utils.ABORTED_ERROR, "etag mismatch")},
+			wantTotal: 1,
+		},
+		{
+			name:      "carries on after a failure",
+			errs:      map[string]error{"1": errors.New("unavailable")},
+			wantTotal: 1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := &orderRepository{unpaid: unpaid, errs: tt.errs}
+
+			expirer := expiry.NewExpirer(repository, options)
+			expirer.Now = func() time.Time { return now }
+
+			total, err := expirer.Expire(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expire() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("Expire() = %d, want %d", total, tt.wantTotal)
+			}
+
+			if want := now.Add(-options.PaymentDeadline); !repository.createdBefore.Equal(want) {
+				t.Errorf("listed orders created before %v, want %v", repository.createdBefore, want)
+			}
+
+			wantCalls := []updateCall{
+				{"1", utils.OrderStatusExpired, utils.ETag(1), expiry.Actor},
+				{"2", utils.OrderStatusExpired, utils.ETag(3), expiry.Actor},
+			}
+			if len(repository.calls) != len(wantCalls) {
+				t.Fatalf("updated %+v, want %+v", repository.calls, wantCalls)
+			}
+			for i, call := range repository.calls {
+				if call != wantCalls[i] {
+					t.Errorf("update %d = %+v, want %+v", i, call, wantCalls[i])
+				}
+			}
+		})
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options expiry.Options
+		wantErr bool
+	}{
+		{name: "default", options: expiry.DefaultOptions()},
+		{name: "no deadline", options: expiry.Options{Interval: time.Hour}, wantErr: true},
+		{name: "no interval", options: expiry.Options{PaymentDeadline: time.Hour}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.options.Validate("order_expiry"); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package expiry
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var expired = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "expired_orders_total",
+	Help: "Number of orders expired after their payment deadline.",
+})
+
+func recordExpired(n int) {
+	expired.Add(float64(n))
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/leta/order-management-system/orders/generated"
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+func (s *GRPCServer) ListAuditEvents(
+	ctx context.Context, in *generated.ListAuditEventsRequest) (*generated.ListAuditEventsResponse, error) {
+
+	log.Printf("Received: %v", in)
+
+	if in.GetEntityId() != "" && in.GetEntityType() == "" {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "entity_type is required to filter by entity_id")
+	}
+
+	filter := audit.Filter{
+		EntityType: in.GetEntityType(),
+		EntityID:   in.GetEntityId(),
+		Limit:      int(in.GetLimit()),
+	}
+	if in.From != nil {
+		filter.From = in.GetFrom().AsTime()
+	}
+	if in.To != nil {
+		filter.To = in.GetTo().AsTime()
+	}
+
+	list, err := s.AuditLog.List(ctx, filter)
+	if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "%v", err)
+	}
+
+	var responseEvents []*generated.AuditEvent
+	for _, e := range list {
+		responseEvents = append(responseEvents, &generated.AuditEvent{
+			Id:         e.ID,
+			Service:    e.Service,
+			EntityType: e.EntityType,
+			EntityId:   e.EntityID,
+			Action:     e.Action,
+			Actor:      e.Actor,
+			RequestId:  e.RequestID,
+			Before:     e.Before,
+			After:      e.After,
+			Time:       timestamppb.New(e.Time),
+		})
+	}
+
+	return &generated.ListAuditEventsResponse{
+		Events: responseEvents,
+	}, nil
+}
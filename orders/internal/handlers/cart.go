@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/leta/order-management-system/orders/generated"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/carts"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+func (s *GRPCServer) GetCart(ctx context.Context, in *generated.GetCartRequest) (*generated.GetCartResponse, error) {
+
+	cart, err := s.CartRepository.GetCart(ctx, in.GetCustomerId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.GetCartResponse{
+		Cart: cartMessage(cart),
+	}, nil
+}
+
+func (s *GRPCServer) AddCartItem(
+	ctx context.Context, in *generated.AddCartItemRequest) (*generated.AddCartItemResponse, error) {
+
+	cart, err := s.CartRepository.AddCartItem(ctx, in.GetCustomerId(), in.GetProductId(), in.GetVariantId(),
+		uint(in.GetQuantity()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.AddCartItemResponse{
+		Cart: cartMessage(cart),
+	}, nil
+}
+
+func (s *GRPCServer) UpdateCartItem(
+	ctx context.Context, in *generated.UpdateCartItemRequest) (*generated.UpdateCartItemResponse, error) {
+
+	cart, err := s.CartRepository.UpdateCartItem(ctx, in.GetCustomerId(), in.GetProductId(), in.GetVariantId(),
+		uint(in.GetQuantity()), requestETag(ctx, in.GetEtag()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.UpdateCartItemResponse{
+		Cart: cartMessage(cart),
+	}, nil
+}
+
+func (s *GRPCServer) RemoveCartItem(
+	ctx context.Context, in *generated.RemoveCartItemRequest) (*generated.RemoveCartItemResponse, error) {
+
+	cart, err := s.CartRepository.RemoveCartItem(ctx, in.GetCustomerId(), in.GetProductId(), in.GetVariantId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.RemoveCartItemResponse{
+		Cart: cartMessage(cart),
+	}, nil
+}
+
+func (s *GRPCServer) ClearCart(ctx context.Context, in *generated.ClearCartRequest) (*generated.ClearCartResponse, error) {
+
+	if err := s.CartRepository.ClearCart(ctx, in.GetCustomerId()); err != nil {
+		return nil, err
+	}
+
+	return &generated.ClearCartResponse{}, nil
+}
+
+func (s *GRPCServer) ConvertCartToOrder(
+	ctx context.Context, in *generated.ConvertCartToOrderRequest) (*generated.ConvertCartToOrderResponse, error) {
+
+	var expectedSubtotal *money.Money
+	if in.ExpectedSubtotal != nil {
+		subtotal := fromMoneyMessage(in.GetExpectedSubtotal())
+		expectedSubtotal = &subtotal
+	}
+
+	order, err := s.OrderRepository.ConvertCartToOrder(
+		ctx, in.GetCustomerId(), requestETag(ctx, in.GetEtag()), expectedSubtotal)
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.ConvertCartToOrderResponse{
+		Order: orderMessage(order),
+	}, nil
+}
+
+func cartMessage(cart *carts.Cart) *generated.Cart {
+	var items []*generated.CartItem
+	for _, item := range cart.Items {
+		message := &generated.CartItem{
+			ProductId: item.ProductId,
+			VariantId: item.VariantId,
+			Quantity:  uint32(item.Quantity),
+			Available: item.Available,
+		}
+
+		if item.Available {
+			message.UnitPrice = moneyMessage(item.UnitPrice)
+			if total, err := item.OrderItem().Total(); err == nil {
+				message.Total = moneyMessage(total)
+			}
+		}
+
+		items = append(items, message)
+	}
+
+	message := &generated.Cart{
+		CustomerId: cart.CustomerId,
+		Items:      items,
+		Etag:       utils.ETag(cart.Version),
+		CreatedAt:  timestamp(cart.CreatedAt),
+		UpdatedAt:  timestamp(cart.UpdatedAt),
+		ExpiresAt:  timestamp(cart.ExpiresAt),
+	}
+
+	if subtotal, err := cart.Subtotal(); err == nil {
+		message.Subtotal = moneyMessage(subtotal)
+	}
+
+	return message
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"github.com/leta/order-management-system/orders/generated"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/categories"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+func (s *GRPCServer) CreateCategory(
+	ctx context.Context, in *generated.CreateCategoryRequest) (*generated.CreateCategoryResponse, error) {
+
+	log.Printf("Received: %v", in.GetName())
+
+	category, err := s.CategoryRepository.CreateCategory(ctx, &categories.Category{
+		Name:     in.GetName(),
+		ParentId: in.GetParentId(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.CreateCategoryResponse{
+		Category: categoryMessage(category),
+	}, nil
+}
+
+func (s *GRPCServer) GetCategory(
+	ctx context.Context, in *generated.GetCategoryRequest) (*generated.GetCategoryResponse, error) {
+
+	log.Printf("Received: %v", in.GetId())
+
+	category, err := s.CategoryRepository.GetCategory(ctx, in.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.GetCategoryResponse{
+		Category: categoryMessage(category),
+	}, nil
+}
+
+func (s *GRPCServer) ListCategories(
+	ctx context.Context, in *generated.ListCategoriesRequest) (*generated.ListCategoriesResponse, error) {
+
+	log.Printf("Received: %v", in)
+
+	list, err := s.CategoryRepository.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var responseCategories []*generated.Category
+	for _, category := range list {
+		responseCategories = append(responseCategories, categoryMessage(category))
+	}
+
+	return &generated.ListCategoriesResponse{
+		Categories: responseCategories,
+	}, nil
+}
+
+func (s *GRPCServer) UpdateCategory(
+	ctx context.Context, in *generated.UpdateCategoryRequest) (*generated.UpdateCategoryResponse, error) {
+
+	log.Printf("Received: %v", in)
+
+	paths, err := utils.UpdatePaths(in.GetUpdateMask(), in.GetUpdate())
+	if err != nil {
+		return nil, err
+	}
+
+	update := &categories.CategoryUpdate{ETag: requestETag(ctx, in.GetEtag())}
+	if paths["name"] {
+		update.Name = utils.StringPtr(in.GetUpdate().GetName())
+	}
+	if paths["parent_id"] {
+		update.ParentId = utils.StringPtr(in.GetUpdate().GetParentId())
+	}
+
+	category, err := s.CategoryRepository.UpdateCategory(ctx, in.GetId(), update)
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.UpdateCategoryResponse{
+		Category: categoryMessage(category),
+	}, nil
+}
+
+func (s *GRPCServer) DeleteCategory(
+	ctx context.Context, in *generated.DeleteCategoryRequest) (*generated.DeleteCategoryResponse, error) {
+
+	log.Printf("Received: %v", in.GetId())
+
+	if err := s.CategoryRepository.DeleteCategory(ctx, in.GetId()); err != nil {
+		return nil, err
+	}
+
+	return &generated.DeleteCategoryResponse{}, nil
+}
+
+func categoryMessage(category *categories.Category) *generated.Category {
+	return &generated.Category{
+		Id:        category.Id,
+		Name:      category.Name,
+		ParentId:  category.ParentId,
+		CreatedAt: timestamp(category.CreatedAt),
+		UpdatedAt: timestamp(category.UpdatedAt),
+		Etag:      utils.ETag(category.Version),
+	}
+}
@@ -17,5 +17,8 @@ func (s *GRPCServer) ProcessCheckout(
 		OrderId:    o.Id,
 		CustomerId: o.CustomerId,
 		Status:     getGRPCOrderStatus(o.OrderStatus),
+		Total:      moneyMessage(o.Total),
+		Tax:        orderTax(o.Tax),
+		Discount:   discountLine(o.Discount),
 	}, nil
 }
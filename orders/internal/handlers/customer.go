@@ -19,6 +19,8 @@ func (s *GRPCServer) CreateCustomer(
 		LastName:  in.GetLastName(),
 		Email:     in.GetEmail(),
 		Phone:     in.GetPhone(),
+		TaxExempt: in.GetTaxExempt(),
+		Group:     in.GetGroup(),
 	})
 	if err != nil {
 		return nil, err
@@ -45,6 +47,16 @@ func (s *GRPCServer) GetCustomer(
 		LastName:  p.LastName,
 		Phone:     p.Phone,
 		Email:     p.Email,
+
+		SmsOptOut:   p.SmsOptOut,
+		EmailOptOut: p.EmailOptOut,
+		TaxExempt:   p.TaxExempt,
+		Group:       p.Group,
+		Etag:        utils.ETag(p.Version),
+		DeletedAt:   timestamp(p.DeletedAt),
+
+		Addresses:        addressMessages(p.Addresses),
+		DefaultAddressId: p.DefaultAddressId,
 	}, nil
 }
 
@@ -53,20 +65,14 @@ func (s *GRPCServer) ListCustomers(
 
 	log.Printf("Received: %v", in)
 
-	customers, err := s.CustomerRepository.ListCustomers(ctx)
+	customers, err := s.CustomerRepository.ListCustomers(ctx, in.GetIncludeDeleted())
 	if err != nil {
 		return nil, fmt.Errorf("failed to list customers: %w", err)
 	}
 
 	var responseCustomers []*generated.Customer
 	for _, p := range customers {
-		responseCustomers = append(responseCustomers, &generated.Customer{
-			Id:        p.Id,
-			FirstName: p.FirstName,
-			LastName:  p.LastName,
-			Phone:     p.Phone,
-			Email:     p.Email,
-		})
+		responseCustomers = append(responseCustomers, customerMessage(p))
 	}
 
 	return &generated.ListCustomersResponse{
@@ -79,12 +85,41 @@ func (s *GRPCServer) UpdateCustomer(
 
 	log.Printf("Received: %v", in)
 
-	p, err := s.CustomerRepository.UpdateCustomer(ctx, in.GetId(), &customers.CustomerUpdate{
-		FirstName: utils.StringPtr(in.GetUpdate().GetFirstName()),
-		LastName:  utils.StringPtr(in.GetUpdate().GetLastName()),
-		Phone:     utils.StringPtr(in.GetUpdate().GetPhone()),
-		Email:     utils.StringPtr(in.GetUpdate().GetEmail()),
-	})
+	paths, err := utils.UpdatePaths(in.GetUpdateMask(), in.GetUpdate())
+	if err != nil {
+		return nil, err
+	}
+
+	update := &customers.CustomerUpdate{ETag: requestETag(ctx, in.GetEtag())}
+	if paths["first_name"] {
+		update.FirstName = utils.StringPtr(in.GetUpdate().GetFirstName())
+	}
+	if paths["last_name"] {
+		update.LastName = utils.StringPtr(in.GetUpdate().GetLastName())
+	}
+	if paths["email"] {
+		update.Email = utils.StringPtr(in.GetUpdate().GetEmail())
+	}
+	if paths["phone"] {
+		update.Phone = utils.StringPtr(in.GetUpdate().GetPhone())
+	}
+	if paths["sms_opt_out"] {
+		update.SmsOptOut = utils.BoolPtr(in.GetUpdate().GetSmsOptOut())
+	}
+	if paths["email_opt_out"] {
+		update.EmailOptOut = utils.BoolPtr(in.GetUpdate().GetEmailOptOut())
+	}
+	if paths["tax_exempt"] {
+		update.TaxExempt = utils.BoolPtr(in.GetUpdate().GetTaxExempt())
+	}
+	if paths["group"] {
+		update.Group = utils.StringPtr(in.GetUpdate().GetGroup())
+	}
+	if paths["default_address_id"] {
+		update.DefaultAddressId = utils.StringPtr(in.GetUpdate().GetDefaultAddressId())
+	}
+
+	p, err := s.CustomerRepository.UpdateCustomer(ctx, in.GetId(), update)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update customers: %w", err)
 	}
@@ -94,6 +129,16 @@ func (s *GRPCServer) UpdateCustomer(
 		FirstName: p.FirstName,
 		LastName:  p.LastName,
 		Email:     p.Email,
+		Phone:     p.Phone,
+
+		SmsOptOut:   p.SmsOptOut,
+		EmailOptOut: p.EmailOptOut,
+		TaxExempt:   p.TaxExempt,
+		Group:       p.Group,
+		Etag:        utils.ETag(p.Version),
+
+		Addresses:        addressMessages(p.Addresses),
+		DefaultAddressId: p.DefaultAddressId,
 	}, nil
 }
 
@@ -109,3 +154,129 @@ func (s *GRPCServer) DeleteCustomer(
 
 	return &generated.DeleteCustomerResponse{}, nil
 }
+
+func (s *GRPCServer) RestoreCustomer(
+	ctx context.Context, in *generated.RestoreCustomerRequest) (*generated.RestoreCustomerResponse, error) {
+
+	log.Printf("Received: %v", in)
+
+	p, err := s.CustomerRepository.RestoreCustomer(ctx, in.GetId())
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore customers: %w", err)
+	}
+
+	return &generated.RestoreCustomerResponse{
+		Customer: customerMessage(p),
+	}, nil
+}
+
+func (s *GRPCServer) AddCustomerAddress(
+	ctx context.Context, in *generated.AddCustomerAddressRequest) (*generated.AddCustomerAddressResponse, error) {
+
+	log.Printf("Received: %v", in)
+
+	p, err := s.CustomerRepository.AddAddress(ctx, in.GetCustomerId(), fromAddressMessage(in.GetAddress()),
+		in.GetMakeDefault(), requestETag(ctx, in.GetEtag()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to add customers address: %w", err)
+	}
+
+	return &generated.AddCustomerAddressResponse{
+		Customer: customerMessage(p),
+	}, nil
+}
+
+func (s *GRPCServer) UpdateCustomerAddress(
+	ctx context.Context, in *generated.UpdateCustomerAddressRequest) (*generated.UpdateCustomerAddressResponse, error) {
+
+	log.Printf("Received: %v", in)
+
+	p, err := s.CustomerRepository.UpdateAddress(ctx, in.GetCustomerId(), fromAddressMessage(in.GetAddress()),
+		requestETag(ctx, in.GetEtag()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update customers address: %w", err)
+	}
+
+	return &generated.UpdateCustomerAddressResponse{
+		Customer: customerMessage(p),
+	}, nil
+}
+
+func (s *GRPCServer) RemoveCustomerAddress(
+	ctx context.Context, in *generated.RemoveCustomerAddressRequest) (*generated.RemoveCustomerAddressResponse, error) {
+
+	log.Printf("Received: %v", in)
+
+	p, err := s.CustomerRepository.RemoveAddress(ctx, in.GetCustomerId(), in.GetAddressId(),
+		requestETag(ctx, in.GetEtag()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove customers address: %w", err)
+	}
+
+	return &generated.RemoveCustomerAddressResponse{
+		Customer: customerMessage(p),
+	}, nil
+}
+
+func customerMessage(p *customers.Customer) *generated.Customer {
+	return &generated.Customer{
+		Id:        p.Id,
+		FirstName: p.FirstName,
+		LastName:  p.LastName,
+		Phone:     p.Phone,
+		Email:     p.Email,
+
+		SmsOptOut:   p.SmsOptOut,
+		EmailOptOut: p.EmailOptOut,
+		TaxExempt:   p.TaxExempt,
+		Group:       p.Group,
+		Etag:        utils.ETag(p.Version),
+		DeletedAt:   timestamp(p.DeletedAt),
+
+		Addresses:        addressMessages(p.Addresses),
+		DefaultAddressId: p.DefaultAddressId,
+	}
+}
+
+func addressMessages(addresses []*customers.Address) []*generated.Address {
+	var messages []*generated.Address
+	for _, address := range addresses {
+		messages = append(messages, addressMessage(address))
+	}
+	return messages
+}
+
+// addressMessage returns nil if address is nil.
+func addressMessage(address *customers.Address) *generated.Address {
+	if address == nil {
+		return nil
+	}
+
+	return &generated.Address{
+		Id:         address.Id,
+		Label:      address.Label,
+		Recipient:  address.Recipient,
+		Phone:      address.Phone,
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		City:       address.City,
+		Region:     address.Region,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+	}
+}
+
+func fromAddressMessage(address *generated.Address) *customers.Address {
+	return &customers.Address{
+		Id:         address.GetId(),
+		Label:      address.GetLabel(),
+		Recipient:  address.GetRecipient(),
+		Phone:      address.GetPhone(),
+		Line1:      address.GetLine1(),
+		Line2:      address.GetLine2(),
+		City:       address.GetCity(),
+		Region:     address.GetRegion(),
+		PostalCode: address.GetPostalCode(),
+		Country:    address.GetCountry(),
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/leta/order-management-system/orders/generated"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MetadataIfMatch carries the If-Match header of REST requests, an
+// alternative to the etag field of update requests.
+const MetadataIfMatch = "if-match"
+
+func LogError(err error) {
+	log.Printf("[grpc] error: %s", err)
+}
+
+// GRPCErrorStatusCode converts an application error into a gRPC status error so
+// that clients (and the REST gateway) receive a meaningful status code.
+func GRPCErrorStatusCode(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	code := utils.ErrorCode(err)
+	switch code {
+	case utils.INVALID_ERROR:
+		return status.Error(codes.InvalidArgument, utils.ErrorMessage(err))
+	case utils.NOT_FOUND_ERROR:
+		return status.Error(codes.NotFound, utils.ErrorMessage(err))
+	case utils.ABORTED_ERROR:
+		return status.Error(codes.Aborted, utils.ErrorMessage(err))
+	case utils.ALREADY_EXISTS_ERROR:
+		return status.Error(codes.AlreadyExists, utils.ErrorMessage(err))
+	case utils.NOT_IMPLEMENTED_ERROR:
+		return status.Error(codes.Unimplemented, utils.ErrorMessage(err))
+	case utils.INTERNAL_ERROR:
+		return status.Error(codes.Internal, utils.ErrorMessage(err))
+	default:
+		return status.Error(codes.Unknown, utils.ErrorMessage(err))
+	}
+}
+
+// timestamp converts an RFC 3339 time of the repositories, returning nil if it
+// is not set.
+func timestamp(s string) *timestamppb.Timestamp {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// moneyMessage converts m to its protobuf message.
+func moneyMessage(m money.Money) *generated.Money {
+	return &generated.Money{Amount: m.Amount, Currency: m.Currency}
+}
+
+// fromMoneyMessage converts m from its protobuf message, the currency
+// defaulting to money.DefaultCurrency.
+func fromMoneyMessage(m *generated.Money) money.Money {
+	return money.New(m.GetAmount(), m.GetCurrency())
+}
+
+// requestETag returns the etag of an update request, falling back to the
+// If-Match header forwarded by the REST gateway.
+func requestETag(ctx context.Context, etag string) string {
+	if etag != "" {
+		return etag
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(MetadataIfMatch); len(values) > 0 {
+		return strings.Trim(strings.TrimPrefix(values[0], "W/"), `"`)
+	}
+
+	return ""
+}
+
+// errorInterceptor logs handler errors and maps them to gRPC status codes.
+func errorInterceptor(
+	ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+	resp, err := handler(ctx, req)
+	if err != nil {
+		LogError(err)
+		return nil, GRPCErrorStatusCode(err)
+	}
+
+	return resp, nil
+}
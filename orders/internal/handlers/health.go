@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"context"
+	"github.com/leta/order-management-system/orders/generated"
+	"log"
+)
+
+const (
+	HealthStatusOK       = "OK"
+	HealthStatusNotReady = "NOT_READY"
+)
+
+func (s *GRPCServer) HealthCheck(ctx context.Context, in *generated.HealthCheckRequest) (*generated.HealthCheckResponse, error) {
+
+	log.Printf("Received: Health check request")
+
+	if s.Health != nil && !s.Health.Ready() {
+		return &generated.HealthCheckResponse{
+			Status: HealthStatusNotReady,
+		}, nil
+	}
+
+	return &generated.HealthCheckResponse{
+		Status: HealthStatusOK,
+	}, nil
+}
@@ -0,0 +1,192 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/leta/order-management-system/orders/generated"
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/metrics"
+	"github.com/leta/order-management-system/orders/pkg/tracing"
+)
+
+const (
+	// ShutdownTimeout is the time given for outstanding requests to finish before shutdown.
+	ShutdownTimeout = 1 * time.Second
+	ReadTimeout     = 5 * time.Second
+	WriteTimeout    = 10 * time.Second
+	IdleTimeout     = 120 * time.Second
+)
+
+// HTTPServer exposes the Orders gRPC API as a REST/JSON API.
+//
+// Requests are translated by grpc-gateway and forwarded to the gRPC server at
+// GRPCAddr, so they go through the same interceptors (auth, validation, error
+// mapping) as native gRPC calls.
+type HTTPServer struct {
+	ln     net.Listener
+	server *http.Server
+	router *chi.Mux
+	conn   *grpc.ClientConn
+
+	// Bind address for the server's listener.
+	Addr string
+
+	// Address of the Orders gRPC server requests are forwarded to.
+	GRPCAddr string
+}
+
+// NewHTTPServer creates a new instance of HTTPServer.
+func NewHTTPServer() *HTTPServer {
+
+	s := &HTTPServer{
+		router: chi.NewRouter(),
+		server: &http.Server{
+			ReadTimeout:  ReadTimeout,
+			WriteTimeout: WriteTimeout,
+			IdleTimeout:  IdleTimeout,
+		},
+	}
+
+	s.router.Use(middleware.Logger)
+
+	s.server.Handler = tracing.HTTPHandler(s.router, "orders.gateway")
+
+	return s
+}
+
+// Port returns the TCP port for the running server.
+// This is useful in tests where we allocate a random port by using ":0".
+func (s *HTTPServer) Port() int {
+	if s.ln == nil {
+		return 0
+	}
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+// URL returns the local base URL of the running server.
+func (s *HTTPServer) URL() string {
+	return fmt.Sprintf("http://localhost:%d", s.Port())
+}
+
+// Open connects to the gRPC server, registers the gateway routes and begins
+// listening on the bind address.
+func (s *HTTPServer) Open(ctx context.Context) (err error) {
+	if s.GRPCAddr == "" {
+		return fmt.Errorf("grpc address is required")
+	}
+
+	opts := append(tracing.DialOptions(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	s.conn, err = grpc.DialContext(ctx, s.GRPCAddr, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to dial grpc server: %v", err)
+	}
+
+	gateway := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions: protojson.MarshalOptions{
+				UseProtoNames:   true,
+				EmitUnpopulated: true,
+			},
+		}),
+		runtime.WithIncomingHeaderMatcher(incomingHeaderMatcher),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeaderMatcher),
+	)
+
+	if err := generated.RegisterOrdersHandler(ctx, gateway, s.conn); err != nil {
+		return fmt.Errorf("failed to register gateway: %v", err)
+	}
+
+	s.registerOpenAPIRoutes(s.router)
+	s.router.Mount("/v1", gateway)
+
+	if s.ln, err = net.Listen("tcp", s.Addr); err != nil {
+		return err
+	}
+
+	// Begin serving requests on the listener. We use Serve() instead of
+	// ListenAndServe() because it allows us to check for listen errors (such
+	// as trying to use an already open port) synchronously.
+	go func() {
+		err := s.server.Serve(s.ln)
+		if err != nil && err != http.ErrServerClosed {
+			panic(err)
+		}
+	}()
+
+	return nil
+}
+
+// Close gracefully shuts down the server, giving outstanding requests
+// ShutdownTimeout to finish.
+func (s *HTTPServer) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+
+	return s.Shutdown(ctx)
+}
+
+// Shutdown stops accepting new requests, waits for outstanding requests to
+// finish or ctx to expire, and closes the connection to the gRPC server.
+func (s *HTTPServer) Shutdown(ctx context.Context) error {
+	err := s.server.Shutdown(ctx)
+
+	if s.conn != nil {
+		if cerr := s.conn.Close(); err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+// metadataIfMatch is the metadata key of the If-Match header, read by the
+// update handlers.
+const metadataIfMatch = "if-match"
+
+// incomingHeaderMatcher forwards the actor and request ID headers, and the
+// If-Match header of updates, to the gRPC server along with the headers
+// forwarded by default.
+func incomingHeaderMatcher(key string) (string, bool) {
+	switch strings.ToLower(key) {
+	case audit.MetadataActor, audit.MetadataRequestID, metadataIfMatch:
+		return strings.ToLower(key), true
+	default:
+		return runtime.DefaultHeaderMatcher(key)
+	}
+}
+
+// outgoingHeaderMatcher returns the request ID as X-Request-Id rather than
+// Grpc-Metadata-X-Request-Id.
+func outgoingHeaderMatcher(key string) (string, bool) {
+	if key == audit.MetadataRequestID {
+		return "X-Request-Id", true
+	}
+	return fmt.Sprintf("%s%s", runtime.MetadataHeaderPrefix, key), true
+}
+
+// NewDebugServer returns an HTTP server with /debug endpoints and the
+// Prometheus /metrics endpoint.
+func NewDebugServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	return &http.Server{
+		Addr:         addr,
+		Handler:      mux,
+		ReadTimeout:  ReadTimeout,
+		WriteTimeout: WriteTimeout,
+		IdleTimeout:  IdleTimeout,
+	}
+}
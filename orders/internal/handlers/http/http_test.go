@@ -0,0 +1,248 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/leta/order-management-system/orders/generated"
+	ordershttp "github.com/leta/order-management-system/orders/internal/handlers/http"
+	"github.com/leta/order-management-system/orders/pkg/audit"
+)
+
+type testOrdersServer struct {
+	generated.UnimplementedOrdersServer
+}
+
+func (s *testOrdersServer) GetProduct(
+	ctx context.Context, in *generated.GetProductRequest) (*generated.GetProductResponse, error) {
+
+	if in.GetId() == "whoami" {
+		return &generated.GetProductResponse{Id: audit.RequestID(ctx), Name: audit.Actor(ctx)}, nil
+	}
+
+	if in.GetId() != "p1" {
+		return nil, status.Error(codes.NotFound, "product not found")
+	}
+
+	return &generated.GetProductResponse{Id: "p1", Name: "Soap", Price: &generated.Money{Amount: 10000, Currency: "KES"}}, nil
+}
+
+func (s *testOrdersServer) CreateProduct(
+	ctx context.Context, in *generated.CreateProductRequest) (*generated.CreateProductResponse, error) {
+
+	if in.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+
+	return &generated.CreateProductResponse{Id: "p2"}, nil
+}
+
+func (s *testOrdersServer) UpdateProduct(
+	ctx context.Context, in *generated.UpdateProductRequest) (*generated.UpdateProductResponse, error) {
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("if-match"); len(values) == 0 || values[0] != `"3"` {
+		return nil, status.Errorf(codes.Aborted, "etag mismatch: %v", values)
+	}
+
+	// The update mask inferred by the gateway is echoed as the description.
+	return &generated.UpdateProductResponse{
+		Id:          in.GetId(),
+		Description: strings.Join(in.GetUpdateMask().GetPaths(), ","),
+		Etag:        "4",
+	}, nil
+}
+
+func newTestGateway(t *testing.T) *ordershttp.HTTPServer {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(audit.UnaryServerInterceptor()))
+	generated.RegisterOrdersServer(grpcServer, &testOrdersServer{})
+	go func() { _ = grpcServer.Serve(lis) }()
+	t.Cleanup(grpcServer.Stop)
+
+	s := ordershttp.NewHTTPServer()
+	s.Addr = "localhost:0"
+	s.GRPCAddr = lis.Addr().String()
+	if err := s.Open(context.Background()); err != nil {
+		t.Fatalf("failed to open gateway: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+
+	return s
+}
+
+func TestHTTPServer_Gateway(t *testing.T) {
+	s := newTestGateway(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		ifMatch    string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "get product",
+			method:     http.MethodGet,
+			path:       "/v1/products/p1",
+			wantStatus: http.StatusOK,
+			wantBody:   `"name":"Soap"`,
+		},
+		{
+			name:       "product not found",
+			method:     http.MethodGet,
+			path:       "/v1/products/missing",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "create product",
+			method:     http.MethodPost,
+			path:       "/v1/products",
+			body:       `{"name":"Soap","price":{"amount":"10000","currency":"KES"}}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `"id":"p2"`,
+		},
+		{
+			name:       "invalid product",
+			method:     http.MethodPost,
+			path:       "/v1/products",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "update with etag",
+			method:     http.MethodPatch,
+			path:       "/v1/products/p1",
+			body:       `{"name":"Soap"}`,
+			ifMatch:    `"3"`,
+			wantStatus: http.StatusOK,
+			wantBody:   `"etag":"4"`,
+		},
+		{
+			name:       "update infers the mask from the body",
+			method:     http.MethodPatch,
+			path:       "/v1/products/p1",
+			body:       `{"name":"Soap","price":{"amount":"0"}}`,
+			ifMatch:    `"3"`,
+			wantStatus: http.StatusOK,
+			wantBody:   `"description":"name,price.amount"`,
+		},
+		{
+			name:       "update with stale etag",
+			method:     http.MethodPatch,
+			path:       "/v1/products/p1",
+			body:       `{"name":"Soap"}`,
+			ifMatch:    `"2"`,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "unimplemented rpc",
+			method:     http.MethodGet,
+			path:       "/v1/orders",
+			wantStatus: http.StatusNotImplemented,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, s.URL()+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("failed to build request: %v", err)
+			}
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %s)", resp.StatusCode, tt.wantStatus, body)
+			}
+			if tt.wantBody != "" && !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("body = %s, want to contain %s", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHTTPServer_AuditHeaders(t *testing.T) {
+	s := newTestGateway(t)
+
+	req, err := http.NewRequest(http.MethodGet, s.URL()+"/v1/products/whoami", nil)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	req.Header.Set("X-Actor-Id", "alice")
+	req.Header.Set("X-Request-Id", "req-1")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var product struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&product); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if product.Name != "alice" {
+		t.Errorf("actor = %q, want alice", product.Name)
+	}
+	if product.Id != "req-1" {
+		t.Errorf("request id = %q, want req-1", product.Id)
+	}
+	if got := resp.Header.Get("X-Request-Id"); got != "req-1" {
+		t.Errorf("X-Request-Id = %q, want req-1", got)
+	}
+}
+
+func TestHTTPServer_OpenAPI(t *testing.T) {
+	s := newTestGateway(t)
+
+	resp, err := http.Get(s.URL() + "/openapi.json")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var doc struct {
+		Swagger string                 `json:"swagger"`
+		Paths   map[string]interface{} `json:"paths"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("failed to decode openapi document: %v", err)
+	}
+
+	if doc.Swagger != "2.0" {
+		t.Errorf("swagger = %q, want 2.0", doc.Swagger)
+	}
+	if _, ok := doc.Paths["/v1/orders/{order_id}/checkout"]; !ok {
+		t.Errorf("openapi document is missing the checkout path")
+	}
+}
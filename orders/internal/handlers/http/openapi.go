@@ -0,0 +1,19 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/leta/order-management-system/orders/generated"
+)
+
+func (s *HTTPServer) registerOpenAPIRoutes(r *chi.Mux) {
+	r.Get("/openapi.json", s.handleOpenAPI)
+}
+
+// handleOpenAPI serves the OpenAPI document generated from orders.proto.
+func (s *HTTPServer) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(generated.OrdersOpenAPI)
+}
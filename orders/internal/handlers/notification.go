@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/leta/order-management-system/orders/generated"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/notifications"
+)
+
+func (s *GRPCServer) ListNotifications(
+	ctx context.Context, in *generated.ListNotificationsRequest) (*generated.ListNotificationsResponse, error) {
+
+	log.Printf("Received: %v", in)
+
+	list, err := s.NotificationRepository.ListNotifications(ctx, in.GetCustomerId())
+	if err != nil {
+		return nil, err
+	}
+
+	var responseNotifications []*generated.Notification
+	for _, n := range list {
+		responseNotifications = append(responseNotifications, &generated.Notification{
+			Id:         n.Id,
+			CustomerId: n.CustomerId,
+			OrderId:    n.OrderId,
+			Template:   n.Template,
+			Channel:    n.Channel,
+			Recipient:  n.Recipient,
+			Subject:    n.Subject,
+			Body:       n.Body,
+			Status:     getGRPCNotificationStatus(n.Status),
+			Attempts:   uint32(n.Attempts),
+			Error:      n.Error,
+			CreatedAt:  timestamppb.New(n.CreatedAt),
+			UpdatedAt:  timestamppb.New(n.UpdatedAt),
+		})
+	}
+
+	return &generated.ListNotificationsResponse{
+		Notifications: responseNotifications,
+	}, nil
+}
+
+func getGRPCNotificationStatus(status string) generated.NotificationStatus {
+	switch status {
+	case notifications.StatusPending:
+		return generated.NotificationStatus_NOTIFICATION_PENDING
+	case notifications.StatusSent:
+		return generated.NotificationStatus_NOTIFICATION_SENT
+	case notifications.StatusFailed:
+		return generated.NotificationStatus_NOTIFICATION_FAILED
+	case notifications.StatusSkipped:
+		return generated.NotificationStatus_NOTIFICATION_SKIPPED
+	default:
+		return generated.NotificationStatus_NOTIFICATION_STATUS_UNSPECIFIED
+	}
+}
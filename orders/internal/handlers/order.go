@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"github.com/leta/order-management-system/orders/generated"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/customers"
 	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
 	"github.com/leta/order-management-system/orders/pkg/utils"
 )
@@ -13,14 +14,20 @@ func (s *GRPCServer) CreateOrder(ctx context.Context, in *generated.CreateOrderR
 	for _, item := range in.GetOrderItems() {
 		items = append(items, &orders.OrderItem{
 			ProductId: item.GetProductId(),
+			VariantId: item.GetVariantId(),
 			Quantity:  uint(item.GetQuantity()),
 		})
 	}
 
-	p, err := s.OrderRepository.CreateOrder(ctx, &orders.Order{
+	order := &orders.Order{
 		CustomerId: in.GetCustomerId(),
 		Items:      items,
-	})
+	}
+	if in.GetShippingAddressId() != "" {
+		order.ShippingAddress = &customers.Address{Id: in.GetShippingAddressId()}
+	}
+
+	p, err := s.OrderRepository.CreateOrder(ctx, order)
 	if err != nil {
 		return nil, err
 	}
@@ -37,12 +44,14 @@ func (s *GRPCServer) GetOrder(ctx context.Context, in *generated.GetOrderRequest
 		return nil, err
 	}
 
+	orderReturns, err := s.ReturnRepository.ListReturns(ctx, order.Id)
+	if err != nil {
+		return nil, err
+	}
+
 	var orderItems []*generated.OrderItem
 	for _, item := range order.Items {
-		orderItems = append(orderItems, &generated.OrderItem{
-			ProductId: item.ProductId,
-			Quantity:  uint32(item.Quantity),
-		})
+		orderItems = append(orderItems, orderItemMessage(item))
 	}
 
 	return &generated.GetOrderResponse{
@@ -50,32 +59,30 @@ func (s *GRPCServer) GetOrder(ctx context.Context, in *generated.GetOrderRequest
 		CustomerId: order.CustomerId,
 		OrderItems: orderItems,
 		Status:     getGRPCOrderStatus(order.OrderStatus),
+		Etag:       utils.ETag(order.Version),
+		DeletedAt:  timestamp(order.DeletedAt),
+		Total:      orderTotal(order),
+		Tax:        orderTax(order.Tax),
+		CouponCode: order.CouponCode,
+		Discount:   discountLine(order.Discount),
+
+		ShippingAddress:  addressMessage(order.ShippingAddress),
+		FulfilmentStatus: getGRPCFulfilmentStatus(order.FulfilmentStatus),
+		Returns:          returnMessages(orderReturns),
+		LatePaymentAt:    timestamp(order.LatePaymentAt),
 	}, nil
 }
 
 func (s *GRPCServer) ListOrders(ctx context.Context, in *generated.ListOrdersRequest) (*generated.ListOrdersResponse, error) {
 
-	orders, err := s.OrderRepository.ListOrders(ctx)
+	orders, err := s.OrderRepository.ListOrders(ctx, in.GetIncludeDeleted())
 	if err != nil {
 		return nil, err
 	}
 
 	var responseOrders []*generated.Order
 	for _, p := range orders {
-		var orderItems []*generated.OrderItem
-		for _, item := range p.Items {
-			orderItems = append(orderItems, &generated.OrderItem{
-				ProductId: item.ProductId,
-				Quantity:  uint32(item.Quantity),
-			})
-		}
-
-		responseOrders = append(responseOrders, &generated.Order{
-			Id:         p.Id,
-			CustomerId: p.CustomerId,
-			OrderItems: orderItems,
-			Status:     getGRPCOrderStatus(p.OrderStatus),
-		})
+		responseOrders = append(responseOrders, orderMessage(p))
 	}
 
 	return &generated.ListOrdersResponse{
@@ -86,7 +93,8 @@ func (s *GRPCServer) ListOrders(ctx context.Context, in *generated.ListOrdersReq
 func (s *GRPCServer) UpdateOrderStatus(
 	ctx context.Context, in *generated.UpdateOrderStatusRequest) (*generated.UpdateOrderStatusResponse, error) {
 
-	order, err := s.OrderRepository.UpdateOrderStatus(ctx, in.GetId(), getOrderStatus(in.GetStatus()))
+	order, err := s.OrderRepository.UpdateOrderStatus(
+		ctx, in.GetId(), getOrderStatus(in.GetStatus()), requestETag(ctx, in.GetEtag()))
 	if err != nil {
 		return nil, err
 	}
@@ -95,6 +103,7 @@ func (s *GRPCServer) UpdateOrderStatus(
 		Id:         order.Id,
 		CustomerId: order.CustomerId,
 		Status:     getGRPCOrderStatus(order.OrderStatus),
+		Etag:       utils.ETag(order.Version),
 	}, nil
 }
 
@@ -108,11 +117,38 @@ func (s *GRPCServer) DeleteOrder(ctx context.Context, in *generated.DeleteOrderR
 	return &generated.DeleteOrderResponse{}, nil
 }
 
+func (s *GRPCServer) RestoreOrder(ctx context.Context, in *generated.RestoreOrderRequest) (*generated.RestoreOrderResponse, error) {
+
+	order, err := s.OrderRepository.RestoreOrder(ctx, in.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.RestoreOrderResponse{
+		Order: orderMessage(order),
+	}, nil
+}
+
+func (s *GRPCServer) SetShippingAddress(
+	ctx context.Context, in *generated.SetShippingAddressRequest) (*generated.SetShippingAddressResponse, error) {
+
+	order, err := s.OrderRepository.SetShippingAddress(
+		ctx, in.GetOrderId(), in.GetAddressId(), requestETag(ctx, in.GetEtag()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.SetShippingAddressResponse{
+		Order: orderMessage(order),
+	}, nil
+}
+
 func (s *GRPCServer) CreateOrderItem(
 	ctx context.Context, in *generated.CreateOrderItemRequest) (*generated.CreateOrderItemResponse, error) {
 
 	orderItem, err := s.OrderRepository.CreateOrderItem(ctx, in.GetOrderId(), &orders.OrderItem{
 		ProductId: in.GetProductId(),
+		VariantId: in.GetVariantId(),
 		Quantity:  uint(in.GetQuantity()),
 	})
 	if err != nil {
@@ -134,7 +170,14 @@ func (s *GRPCServer) GetOrderItem(ctx context.Context, in *generated.GetOrderIte
 	return &generated.GetOrderItemResponse{
 		Id:        orderItem.Id,
 		ProductId: orderItem.ProductId,
+		VariantId: orderItem.VariantId,
 		Quantity:  uint32(orderItem.Quantity),
+		Etag:      utils.ETag(orderItem.Version),
+		UnitPrice: unitPrice(orderItem),
+		Tax:       itemTax(orderItem.Tax),
+		Discount:  itemDiscount(orderItem),
+
+		Components: itemComponentMessages(orderItem.Components),
 	}, nil
 }
 
@@ -151,7 +194,14 @@ func (s *GRPCServer) ListOrderItems(
 		responseOrderItems = append(responseOrderItems, &generated.OrderItem{
 			Id:        item.Id,
 			ProductId: item.ProductId,
+			VariantId: item.VariantId,
 			Quantity:  uint32(item.Quantity),
+			Etag:      utils.ETag(item.Version),
+			UnitPrice: unitPrice(item),
+			Tax:       itemTax(item.Tax),
+			Discount:  itemDiscount(item),
+
+			Components: itemComponentMessages(item.Components),
 		})
 	}
 
@@ -163,14 +213,17 @@ func (s *GRPCServer) ListOrderItems(
 func (s *GRPCServer) UpdateOrderItem(
 	ctx context.Context, in *generated.UpdateOrderItemRequest) (*generated.UpdateOrderItemResponse, error) {
 
-	var quantity *uint
-	if in.GetUpdate().GetQuantity() > 0 {
-		quantity = new(uint)
-		*quantity = uint(in.GetUpdate().GetQuantity())
+	paths, err := utils.UpdatePaths(in.GetUpdateMask(), in.GetUpdate())
+	if err != nil {
+		return nil, err
 	}
-	orderItem, err := s.OrderRepository.UpdateOrderItem(ctx, in.GetOrderId(), in.GetId(), &orders.OrderItemUpdate{
-		Quantity: quantity,
-	})
+
+	update := &orders.OrderItemUpdate{ETag: requestETag(ctx, in.GetEtag())}
+	if paths["quantity"] {
+		update.Quantity = utils.UintPtr(uint(in.GetUpdate().GetQuantity()))
+	}
+
+	orderItem, err := s.OrderRepository.UpdateOrderItem(ctx, in.GetOrderId(), in.GetId(), update)
 	if err != nil {
 		return nil, err
 	}
@@ -178,7 +231,10 @@ func (s *GRPCServer) UpdateOrderItem(
 	return &generated.UpdateOrderItemResponse{
 		Id:        orderItem.Id,
 		ProductId: orderItem.ProductId,
+		VariantId: orderItem.VariantId,
 		Quantity:  uint32(orderItem.Quantity),
+		Etag:      utils.ETag(orderItem.Version),
+		UnitPrice: unitPrice(orderItem),
 	}, nil
 }
 
@@ -193,6 +249,126 @@ func (s *GRPCServer) DeleteOrderItem(
 	return &generated.DeleteOrderItemResponse{}, nil
 }
 
+func orderMessage(order *orders.Order) *generated.Order {
+	var orderItems []*generated.OrderItem
+	for _, item := range order.Items {
+		orderItems = append(orderItems, orderItemMessage(item))
+	}
+
+	return &generated.Order{
+		Id:         order.Id,
+		CustomerId: order.CustomerId,
+		OrderItems: orderItems,
+		Status:     getGRPCOrderStatus(order.OrderStatus),
+		Etag:       utils.ETag(order.Version),
+		Total:      orderTotal(order),
+		Tax:        orderTax(order.Tax),
+		DeletedAt:  timestamp(order.DeletedAt),
+		CouponCode: order.CouponCode,
+		Discount:   discountLine(order.Discount),
+
+		ShippingAddress:  addressMessage(order.ShippingAddress),
+		FulfilmentStatus: getGRPCFulfilmentStatus(order.FulfilmentStatus),
+		LatePaymentAt:    timestamp(order.LatePaymentAt),
+	}
+}
+
+func orderItemMessage(item *orders.OrderItem) *generated.OrderItem {
+	return &generated.OrderItem{
+		ProductId: item.ProductId,
+		VariantId: item.VariantId,
+		Quantity:  uint32(item.Quantity),
+		Etag:      utils.ETag(item.Version),
+		UnitPrice: unitPrice(item),
+		Tax:       itemTax(item.Tax),
+		Discount:  itemDiscount(item),
+
+		Components: itemComponentMessages(item.Components),
+	}
+}
+
+func itemComponentMessages(components []*orders.Component) []*generated.BundleComponent {
+	var messages []*generated.BundleComponent
+	for _, component := range components {
+		messages = append(messages, &generated.BundleComponent{
+			ProductId: component.ProductId,
+			VariantId: component.VariantId,
+			Quantity:  uint32(component.Quantity),
+		})
+	}
+	return messages
+}
+
+// unitPrice returns the unit price of item, or nil if it has none.
+func unitPrice(item *orders.OrderItem) *generated.Money {
+	if !item.Priced() {
+		return nil
+	}
+	return moneyMessage(item.UnitPrice)
+}
+
+// orderTotal returns what the customer pays for order: the total including
+// tax once it is checked out, the price of its items before, and nil while an
+// item has no price.
+func orderTotal(order *orders.Order) *generated.Money {
+	if order.Tax != nil {
+		return moneyMessage(order.Tax.Total)
+	}
+
+	total, err := order.Total()
+	if err != nil {
+		return nil
+	}
+	return moneyMessage(total)
+}
+
+// itemDiscount returns the discount of item, or nil if it has none.
+func itemDiscount(item *orders.OrderItem) *generated.Money {
+	if item.Discount.IsZero() {
+		return nil
+	}
+	return moneyMessage(item.Discount)
+}
+
+func discountLine(discount *orders.Discount) *generated.DiscountLine {
+	if discount == nil {
+		return nil
+	}
+
+	return &generated.DiscountLine{
+		Code:        discount.Code,
+		Description: discount.Description,
+		Amount:      moneyMessage(discount.Amount),
+	}
+}
+
+func orderTax(tax *orders.OrderTax) *generated.OrderTax {
+	if tax == nil {
+		return nil
+	}
+
+	return &generated.OrderTax{
+		Inclusive: tax.Inclusive,
+		Exempt:    tax.Exempt,
+		Subtotal:  moneyMessage(tax.Subtotal),
+		Amount:    moneyMessage(tax.Amount),
+		Total:     moneyMessage(tax.Total),
+	}
+}
+
+func itemTax(tax *orders.ItemTax) *generated.ItemTax {
+	if tax == nil {
+		return nil
+	}
+
+	return &generated.ItemTax{
+		Category: tax.Category,
+		Rate:     tax.Rate,
+		Amount:   moneyMessage(tax.Amount),
+		Total:    moneyMessage(tax.Total),
+	}
+}
+
 func getGRPCOrderStatus(status utils.OrderStatus) generated.OrderStatus {
 	switch status {
 	case utils.OrderStatusNew:
@@ -203,11 +379,30 @@ func getGRPCOrderStatus(status utils.OrderStatus) generated.OrderStatus {
 		return generated.OrderStatus_PROCESSING
 	case utils.OrderStatusPaid:
 		return generated.OrderStatus_PAID
+	case utils.OrderStatusCancelled:
+		return generated.OrderStatus_CANCELLED
+	case utils.OrderStatusFailed:
+		return generated.OrderStatus_FAILED
+	case utils.OrderStatusExpired:
+		return generated.OrderStatus_EXPIRED
 	default:
 		return generated.OrderStatus_UNKNOWN
 	}
 }
 
+func getGRPCFulfilmentStatus(status utils.FulfilmentStatus) generated.FulfilmentStatus {
+	switch status {
+	case utils.FulfilmentStatusPartiallyShipped:
+		return generated.FulfilmentStatus_FULFILMENT_PARTIALLY_SHIPPED
+	case utils.FulfilmentStatusShipped:
+		return generated.FulfilmentStatus_FULFILMENT_SHIPPED
+	case utils.FulfilmentStatusDelivered:
+		return generated.FulfilmentStatus_FULFILMENT_DELIVERED
+	default:
+		return generated.FulfilmentStatus_FULFILMENT_UNFULFILLED
+	}
+}
+
 func getOrderStatus(status generated.OrderStatus) utils.OrderStatus {
 	switch status {
 	case generated.OrderStatus_NEW:
@@ -218,6 +413,12 @@ func getOrderStatus(status generated.OrderStatus) utils.OrderStatus {
 		return utils.OrderStatusProcessing
 	case generated.OrderStatus_PAID:
 		return utils.OrderStatusPaid
+	case generated.OrderStatus_CANCELLED:
+		return utils.OrderStatusCancelled
+	case generated.OrderStatus_FAILED:
+		return utils.OrderStatusFailed
+	case generated.OrderStatus_EXPIRED:
+		return utils.OrderStatusExpired
 	default:
 		return utils.OrderStatusNew
 	}
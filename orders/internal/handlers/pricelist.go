@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/leta/order-management-system/orders/generated"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/pricelists"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+func (s *GRPCServer) CreatePriceList(
+	ctx context.Context, in *generated.CreatePriceListRequest) (*generated.CreatePriceListResponse, error) {
+
+	log.Printf("Received: %v", in.GetPriceList().GetName())
+
+	message := in.GetPriceList()
+
+	list, err := s.PriceListRepository.CreatePriceList(ctx, &pricelists.PriceList{
+		Name:        message.GetName(),
+		Currency:    message.GetCurrency(),
+		CustomerIds: message.GetCustomerIds(),
+		Groups:      message.GetGroups(),
+		StartsAt:    fromTimestamp(message.GetStartsAt()),
+		EndsAt:      fromTimestamp(message.GetEndsAt()),
+		Prices:      fromListPriceMessages(message.GetPrices()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.CreatePriceListResponse{
+		PriceList: priceListMessage(list),
+	}, nil
+}
+
+func (s *GRPCServer) GetPriceList(
+	ctx context.Context, in *generated.GetPriceListRequest) (*generated.GetPriceListResponse, error) {
+
+	log.Printf("Received: %v", in.GetId())
+
+	list, err := s.PriceListRepository.GetPriceList(ctx, in.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.GetPriceListResponse{
+		PriceList: priceListMessage(list),
+	}, nil
+}
+
+func (s *GRPCServer) ListPriceLists(
+	ctx context.Context, in *generated.ListPriceListsRequest) (*generated.ListPriceListsResponse, error) {
+
+	log.Printf("Received: %v", in)
+
+	lists, err := s.PriceListRepository.ListPriceLists(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var responseLists []*generated.PriceList
+	for _, list := range lists {
+		responseLists = append(responseLists, priceListMessage(list))
+	}
+
+	return &generated.ListPriceListsResponse{
+		PriceLists: responseLists,
+	}, nil
+}
+
+func (s *GRPCServer) UpdatePriceList(
+	ctx context.Context, in *generated.UpdatePriceListRequest) (*generated.UpdatePriceListResponse, error) {
+
+	log.Printf("Received: %v", in)
+
+	paths, err := utils.UpdatePaths(in.GetUpdateMask(), in.GetUpdate())
+	if err != nil {
+		return nil, err
+	}
+
+	message := in.GetUpdate()
+
+	update := &pricelists.PriceListUpdate{ETag: requestETag(ctx, in.GetEtag())}
+	if paths["name"] {
+		update.Name = utils.StringPtr(message.GetName())
+	}
+	if paths["customer_ids"] {
+		customerIds := message.GetCustomerIds()
+		update.CustomerIds = &customerIds
+	}
+	if paths["groups"] {
+		groups := message.GetGroups()
+		update.Groups = &groups
+	}
+	if paths["starts_at"] {
+		update.StartsAt = utils.StringPtr(fromTimestamp(message.GetStartsAt()))
+	}
+	if paths["ends_at"] {
+		update.EndsAt = utils.StringPtr(fromTimestamp(message.GetEndsAt()))
+	}
+	if paths["prices"] {
+		prices := fromListPriceMessages(message.GetPrices())
+		update.Prices = &prices
+	}
+
+	list, err := s.PriceListRepository.UpdatePriceList(ctx, in.GetId(), update)
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.UpdatePriceListResponse{
+		PriceList: priceListMessage(list),
+	}, nil
+}
+
+func (s *GRPCServer) DeletePriceList(
+	ctx context.Context, in *generated.DeletePriceListRequest) (*generated.DeletePriceListResponse, error) {
+
+	log.Printf("Received: %v", in.GetId())
+
+	if err := s.PriceListRepository.DeletePriceList(ctx, in.GetId()); err != nil {
+		return nil, err
+	}
+
+	return &generated.DeletePriceListResponse{}, nil
+}
+
+// fromTimestamp converts t to an RFC 3339 time of the repositories, returning
+// "" if it is not set.
+func fromTimestamp(t *timestamppb.Timestamp) string {
+	if t == nil {
+		return ""
+	}
+	return t.AsTime().Format(time.RFC3339)
+}
+
+func fromListPriceMessages(messages []*generated.ListPrice) []*pricelists.Price {
+	var prices []*pricelists.Price
+	for _, message := range messages {
+		price := &pricelists.Price{
+			ProductId: message.GetProductId(),
+			VariantId: message.GetVariantId(),
+		}
+		for _, tier := range message.GetTiers() {
+			price.Tiers = append(price.Tiers, &pricelists.Tier{
+				MinQuantity: uint(tier.GetMinQuantity()),
+				UnitPrice:   fromMoneyMessage(tier.GetUnitPrice()),
+			})
+		}
+		prices = append(prices, price)
+	}
+	return prices
+}
+
+func priceListMessage(list *pricelists.PriceList) *generated.PriceList {
+	message := &generated.PriceList{
+		Id:          list.Id,
+		Name:        list.Name,
+		Currency:    list.Currency,
+		CustomerIds: list.CustomerIds,
+		Groups:      list.Groups,
+		StartsAt:    timestamp(list.StartsAt),
+		EndsAt:      timestamp(list.EndsAt),
+		CreatedAt:   timestamp(list.CreatedAt),
+		UpdatedAt:   timestamp(list.UpdatedAt),
+		Etag:        utils.ETag(list.Version),
+	}
+
+	for _, price := range list.Prices {
+		priceMessage := &generated.ListPrice{
+			ProductId: price.ProductId,
+			VariantId: price.VariantId,
+		}
+		for _, tier := range price.Tiers {
+			priceMessage.Tiers = append(priceMessage.Tiers, &generated.PriceTier{
+				MinQuantity: uint32(tier.MinQuantity),
+				UnitPrice:   moneyMessage(tier.UnitPrice),
+			})
+		}
+		message.Prices = append(message.Prices, priceMessage)
+	}
+
+	return message
+}
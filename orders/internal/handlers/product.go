@@ -17,7 +17,13 @@ func (s *GRPCServer) CreateProduct(ctx context.Context, in *generated.CreateProd
 	p, err := s.ProductRepository.CreateProduct(ctx, &product.Product{
 		Name:        in.GetName(),
 		Description: in.GetDescription(),
-		Price:       uint(in.GetPrice()),
+		Price:       fromMoneyMessage(in.GetPrice()),
+		TaxCategory: in.GetTaxCategory(),
+		Sku:         in.GetSku(),
+		CategoryId:  in.GetCategoryId(),
+		Active:      in.Active == nil || in.GetActive(),
+		Variants:    fromVariantMessages(in.GetVariants()),
+		Components:  fromComponentMessages(in.GetComponents()),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create product: %w", err)
@@ -41,7 +47,15 @@ func (s *GRPCServer) GetProduct(ctx context.Context, in *generated.GetProductReq
 		Id:          p.Id,
 		Name:        p.Name,
 		Description: p.Description,
-		Price:       uint32(p.Price),
+		Price:       moneyMessage(p.Price),
+		TaxCategory: p.TaxCategory,
+		Etag:        utils.ETag(p.Version),
+		DeletedAt:   timestamp(p.DeletedAt),
+		Sku:         p.Sku,
+		CategoryId:  p.CategoryId,
+		Active:      p.Active,
+		Variants:    variantMessages(p.Variants),
+		Components:  componentMessages(p.Components),
 	}, nil
 }
 
@@ -49,19 +63,18 @@ func (s *GRPCServer) ListProducts(ctx context.Context, in *generated.ListProduct
 
 	log.Printf("Received: %v", in)
 
-	products, err := s.ProductRepository.ListProducts(ctx)
+	products, err := s.ProductRepository.ListProducts(ctx, &product.ProductFilter{
+		IncludeDeleted: in.GetIncludeDeleted(),
+		CategoryId:     in.GetCategoryId(),
+		ActiveOnly:     in.GetActiveOnly(),
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list products: %w", err)
 	}
 
 	var responseProducts []*generated.Product
 	for _, p := range products {
-		responseProducts = append(responseProducts, &generated.Product{
-			Id:          p.Id,
-			Name:        p.Name,
-			Description: p.Description,
-			Price:       uint32(p.Price),
-		})
+		responseProducts = append(responseProducts, productMessage(p))
 	}
 
 	return &generated.ListProductsResponse{
@@ -73,11 +86,40 @@ func (s *GRPCServer) UpdateProduct(ctx context.Context, in *generated.UpdateProd
 
 	log.Printf("Received: %v", in)
 
-	p, err := s.ProductRepository.UpdateProduct(ctx, in.GetId(), &product.ProductUpdate{
-		Name:        utils.StringPtr(in.GetUpdate().GetName()),
-		Description: utils.StringPtr(in.GetUpdate().GetDescription()),
-		Price:       utils.UintPtr(uint(in.GetUpdate().GetPrice())),
-	})
+	paths, err := utils.UpdatePaths(in.GetUpdateMask(), in.GetUpdate())
+	if err != nil {
+		return nil, err
+	}
+
+	update := &product.ProductUpdate{ETag: requestETag(ctx, in.GetEtag())}
+	if paths["name"] {
+		update.Name = utils.StringPtr(in.GetUpdate().GetName())
+	}
+	if paths["description"] {
+		update.Description = utils.StringPtr(in.GetUpdate().GetDescription())
+	}
+	if paths["price"] {
+		price := fromMoneyMessage(in.GetUpdate().GetPrice())
+		update.Price = &price
+	}
+	if paths["tax_category"] {
+		update.TaxCategory = utils.StringPtr(in.GetUpdate().GetTaxCategory())
+	}
+	if paths["sku"] {
+		update.Sku = utils.StringPtr(in.GetUpdate().GetSku())
+	}
+	if paths["category_id"] {
+		update.CategoryId = utils.StringPtr(in.GetUpdate().GetCategoryId())
+	}
+	if paths["active"] {
+		update.Active = utils.BoolPtr(in.GetUpdate().GetActive())
+	}
+	if paths["components"] {
+		components := fromComponentMessages(in.GetUpdate().GetComponents())
+		update.Components = &components
+	}
+
+	p, err := s.ProductRepository.UpdateProduct(ctx, in.GetId(), update)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update product: %w", err)
 	}
@@ -86,7 +128,14 @@ func (s *GRPCServer) UpdateProduct(ctx context.Context, in *generated.UpdateProd
 		Id:          p.Id,
 		Name:        p.Name,
 		Description: p.Description,
-		Price:       uint32(p.Price),
+		Price:       moneyMessage(p.Price),
+		TaxCategory: p.TaxCategory,
+		Etag:        utils.ETag(p.Version),
+		Sku:         p.Sku,
+		CategoryId:  p.CategoryId,
+		Active:      p.Active,
+		Variants:    variantMessages(p.Variants),
+		Components:  componentMessages(p.Components),
 	}, nil
 }
 
@@ -101,3 +150,140 @@ func (s *GRPCServer) DeleteProduct(ctx context.Context, in *generated.DeleteProd
 
 	return &generated.DeleteProductResponse{}, nil
 }
+
+func (s *GRPCServer) RestoreProduct(ctx context.Context, in *generated.RestoreProductRequest) (*generated.RestoreProductResponse, error) {
+
+	log.Printf("Received: %v", in)
+
+	p, err := s.ProductRepository.RestoreProduct(ctx, in.GetId())
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore product: %w", err)
+	}
+
+	return &generated.RestoreProductResponse{
+		Product: productMessage(p),
+	}, nil
+}
+
+func (s *GRPCServer) AddProductVariant(
+	ctx context.Context, in *generated.AddProductVariantRequest) (*generated.AddProductVariantResponse, error) {
+
+	log.Printf("Received: %v", in)
+
+	p, err := s.ProductRepository.AddVariant(ctx, in.GetProductId(), fromVariantMessage(in.GetVariant()),
+		requestETag(ctx, in.GetEtag()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to add product variant: %w", err)
+	}
+
+	return &generated.AddProductVariantResponse{
+		Product: productMessage(p),
+	}, nil
+}
+
+func (s *GRPCServer) UpdateProductVariant(
+	ctx context.Context, in *generated.UpdateProductVariantRequest) (*generated.UpdateProductVariantResponse, error) {
+
+	log.Printf("Received: %v", in)
+
+	p, err := s.ProductRepository.UpdateVariant(ctx, in.GetProductId(), fromVariantMessage(in.GetVariant()),
+		requestETag(ctx, in.GetEtag()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update product variant: %w", err)
+	}
+
+	return &generated.UpdateProductVariantResponse{
+		Product: productMessage(p),
+	}, nil
+}
+
+func (s *GRPCServer) RemoveProductVariant(
+	ctx context.Context, in *generated.RemoveProductVariantRequest) (*generated.RemoveProductVariantResponse, error) {
+
+	log.Printf("Received: %v", in)
+
+	p, err := s.ProductRepository.RemoveVariant(ctx, in.GetProductId(), in.GetVariantId(),
+		requestETag(ctx, in.GetEtag()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove product variant: %w", err)
+	}
+
+	return &generated.RemoveProductVariantResponse{
+		Product: productMessage(p),
+	}, nil
+}
+
+func productMessage(p *product.Product) *generated.Product {
+	return &generated.Product{
+		Id:          p.Id,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       moneyMessage(p.Price),
+		TaxCategory: p.TaxCategory,
+		Etag:        utils.ETag(p.Version),
+		DeletedAt:   timestamp(p.DeletedAt),
+		Sku:         p.Sku,
+		CategoryId:  p.CategoryId,
+		Active:      p.Active,
+		Variants:    variantMessages(p.Variants),
+		Components:  componentMessages(p.Components),
+	}
+}
+
+func variantMessages(variants []*product.Variant) []*generated.ProductVariant {
+	var messages []*generated.ProductVariant
+	for _, variant := range variants {
+		messages = append(messages, &generated.ProductVariant{
+			Id:      variant.Id,
+			Sku:     variant.Sku,
+			Options: variant.Options,
+			Price:   moneyMessage(variant.Price),
+			Stock:   uint32(variant.Stock),
+			Active:  utils.BoolPtr(variant.Active),
+		})
+	}
+	return messages
+}
+
+func fromVariantMessages(messages []*generated.ProductVariant) []*product.Variant {
+	var variants []*product.Variant
+	for _, message := range messages {
+		variants = append(variants, fromVariantMessage(message))
+	}
+	return variants
+}
+
+func fromVariantMessage(message *generated.ProductVariant) *product.Variant {
+	return &product.Variant{
+		Id:      message.GetId(),
+		Sku:     message.GetSku(),
+		Options: message.GetOptions(),
+		Price:   fromMoneyMessage(message.GetPrice()),
+		Stock:   uint(message.GetStock()),
+		Active:  message.Active == nil || message.GetActive(),
+	}
+}
+
+func componentMessages(components []*product.Component) []*generated.BundleComponent {
+	var messages []*generated.BundleComponent
+	for _, component := range components {
+		messages = append(messages, &generated.BundleComponent{
+			ProductId: component.ProductId,
+			VariantId: component.VariantId,
+			Quantity:  uint32(component.Quantity),
+		})
+	}
+	return messages
+}
+
+func fromComponentMessages(messages []*generated.BundleComponent) []*product.Component {
+	var components []*product.Component
+	for _, message := range messages {
+		components = append(components, &product.Component{
+			ProductId: message.GetProductId(),
+			VariantId: message.GetVariantId(),
+			Quantity:  uint(message.GetQuantity()),
+		})
+	}
+	return components
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/leta/order-management-system/orders/generated"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/promotions"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+func (s *GRPCServer) CreatePromotion(
+	ctx context.Context, in *generated.CreatePromotionRequest) (*generated.CreatePromotionResponse, error) {
+
+	log.Printf("Received: %v", in.GetPromotion().GetCode())
+
+	promotion, err := getPromotion(in.GetPromotion())
+	if err != nil {
+		return nil, err
+	}
+
+	promotion, err = s.PromotionRepository.CreatePromotion(ctx, promotion)
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.CreatePromotionResponse{
+		Code: promotion.Code,
+	}, nil
+}
+
+func (s *GRPCServer) GetPromotion(
+	ctx context.Context, in *generated.GetPromotionRequest) (*generated.GetPromotionResponse, error) {
+
+	log.Printf("Received: %v", in.GetCode())
+
+	promotion, err := s.PromotionRepository.GetPromotion(ctx, in.GetCode())
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.GetPromotionResponse{
+		Promotion: getGRPCPromotion(promotion),
+	}, nil
+}
+
+func (s *GRPCServer) ListPromotions(
+	ctx context.Context, in *generated.ListPromotionsRequest) (*generated.ListPromotionsResponse, error) {
+
+	log.Printf("Received: %v", in)
+
+	list, err := s.PromotionRepository.ListPromotions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var responsePromotions []*generated.Promotion
+	for _, promotion := range list {
+		responsePromotions = append(responsePromotions, getGRPCPromotion(promotion))
+	}
+
+	return &generated.ListPromotionsResponse{
+		Promotions: responsePromotions,
+	}, nil
+}
+
+func (s *GRPCServer) DeletePromotion(
+	ctx context.Context, in *generated.DeletePromotionRequest) (*generated.DeletePromotionResponse, error) {
+
+	log.Printf("Received: %v", in.GetCode())
+
+	if err := s.PromotionRepository.DeletePromotion(ctx, in.GetCode()); err != nil {
+		return nil, err
+	}
+
+	return &generated.DeletePromotionResponse{
+		Code: promotions.NormalizeCode(in.GetCode()),
+	}, nil
+}
+
+func (s *GRPCServer) ApplyCoupon(
+	ctx context.Context, in *generated.ApplyCouponRequest) (*generated.ApplyCouponResponse, error) {
+
+	order, err := s.OrderRepository.ApplyCoupon(ctx, in.GetOrderId(), in.GetCode())
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.ApplyCouponResponse{
+		Order:    orderMessage(order),
+		Discount: s.previewDiscount(ctx, order),
+	}, nil
+}
+
+func (s *GRPCServer) RemoveCoupon(
+	ctx context.Context, in *generated.RemoveCouponRequest) (*generated.RemoveCouponResponse, error) {
+
+	order, err := s.OrderRepository.RemoveCoupon(ctx, in.GetOrderId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.RemoveCouponResponse{
+		Order: orderMessage(order),
+	}, nil
+}
+
+// previewDiscount returns the discount of the coupon of order at the current
+// prices of its items, which is computed again at checkout. It is nil if
+// there is none or an item has no price.
+func (s *GRPCServer) previewDiscount(ctx context.Context, order *orders.Order) *generated.DiscountLine {
+	if order.CouponCode == "" {
+		return nil
+	}
+
+	promotion, err := s.PromotionRepository.GetPromotion(ctx, order.CouponCode)
+	if err != nil {
+		return nil
+	}
+
+	discounts, err := promotion.Discount(order.Items)
+	if err != nil || len(discounts) == 0 {
+		return nil
+	}
+
+	amount, err := money.Sum(discounts[0].Currency, discounts...)
+	if err != nil || amount.IsZero() {
+		return nil
+	}
+
+	return discountLine(&orders.Discount{
+		Code:        promotion.Code,
+		Description: promotion.Description,
+		Amount:      amount,
+	})
+}
+
+func getPromotion(in *generated.Promotion) (*promotions.Promotion, error) {
+	promotion := &promotions.Promotion{
+		Code:               in.GetCode(),
+		Description:        in.GetDescription(),
+		PercentOff:         in.GetPercentOff(),
+		BuyProductId:       in.GetBuyProductId(),
+		BuyQuantity:        uint(in.GetBuyQuantity()),
+		GetProductId:       in.GetGetProductId(),
+		GetQuantity:        uint(in.GetGetQuantity()),
+		MaxUses:            int64(in.GetMaxUses()),
+		MaxUsesPerCustomer: int64(in.GetMaxUsesPerCustomer()),
+	}
+
+	switch in.GetType() {
+	case generated.DiscountType_PERCENTAGE:
+		promotion.Type = promotions.TypePercentage
+	case generated.DiscountType_FIXED:
+		promotion.Type = promotions.TypeFixed
+	case generated.DiscountType_BUY_X_GET_Y:
+		promotion.Type = promotions.TypeBuyXGetY
+	default:
+		return nil, utils.Errorf(utils.INVALID_ERROR, "unknown discount type %v", in.GetType())
+	}
+
+	if in.AmountOff != nil {
+		promotion.AmountOff = fromMoneyMessage(in.GetAmountOff())
+	}
+	if in.MinOrderValue != nil {
+		promotion.MinOrderValue = fromMoneyMessage(in.GetMinOrderValue())
+	}
+	if in.StartsAt != nil {
+		promotion.StartsAt = in.GetStartsAt().AsTime().Format(time.RFC3339)
+	}
+	if in.EndsAt != nil {
+		promotion.EndsAt = in.GetEndsAt().AsTime().Format(time.RFC3339)
+	}
+
+	return promotion, nil
+}
+
+func getGRPCPromotion(promotion *promotions.Promotion) *generated.Promotion {
+	message := &generated.Promotion{
+		Code:               promotion.Code,
+		Description:        promotion.Description,
+		PercentOff:         promotion.PercentOff,
+		BuyProductId:       promotion.BuyProductId,
+		BuyQuantity:        uint32(promotion.BuyQuantity),
+		GetProductId:       promotion.GetProductId,
+		GetQuantity:        uint32(promotion.GetQuantity),
+		MaxUses:            uint32(promotion.MaxUses),
+		MaxUsesPerCustomer: uint32(promotion.MaxUsesPerCustomer),
+		Uses:               uint32(promotion.Uses),
+		StartsAt:           timestamp(promotion.StartsAt),
+		EndsAt:             timestamp(promotion.EndsAt),
+		CreatedAt:          timestamp(promotion.CreatedAt),
+		UpdatedAt:          timestamp(promotion.UpdatedAt),
+	}
+
+	switch promotion.Type {
+	case promotions.TypePercentage:
+		message.Type = generated.DiscountType_PERCENTAGE
+	case promotions.TypeFixed:
+		message.Type = generated.DiscountType_FIXED
+	case promotions.TypeBuyXGetY:
+		message.Type = generated.DiscountType_BUY_X_GET_Y
+	}
+
+	if promotion.AmountOff.Currency != "" {
+		message.AmountOff = moneyMessage(promotion.AmountOff)
+	}
+	if promotion.MinOrderValue.Currency != "" {
+		message.MinOrderValue = moneyMessage(promotion.MinOrderValue)
+	}
+
+	return message
+}
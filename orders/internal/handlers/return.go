@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/leta/order-management-system/orders/generated"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/returns"
+)
+
+func (s *GRPCServer) RequestReturn(
+	ctx context.Context, in *generated.RequestReturnRequest) (*generated.RequestReturnResponse, error) {
+
+	ret := &returns.Return{
+		OrderId: in.GetOrderId(),
+		Reason:  in.GetReason(),
+	}
+	for _, item := range in.GetItems() {
+		ret.Items = append(ret.Items, &returns.ReturnItem{
+			OrderItemId: item.GetOrderItemId(),
+			Quantity:    uint(item.GetQuantity()),
+		})
+	}
+
+	ret, err := s.ReturnRepository.RequestReturn(ctx, ret, requestETag(ctx, in.GetEtag()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.RequestReturnResponse{
+		Return: returnMessage(ret),
+	}, nil
+}
+
+func (s *GRPCServer) GetReturn(
+	ctx context.Context, in *generated.GetReturnRequest) (*generated.GetReturnResponse, error) {
+
+	ret, err := s.ReturnRepository.GetReturn(ctx, in.GetOrderId(), in.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.GetReturnResponse{
+		Return: returnMessage(ret),
+	}, nil
+}
+
+func (s *GRPCServer) ListReturns(
+	ctx context.Context, in *generated.ListReturnsRequest) (*generated.ListReturnsResponse, error) {
+
+	list, err := s.ReturnRepository.ListReturns(ctx, in.GetOrderId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.ListReturnsResponse{
+		Returns: returnMessages(list),
+	}, nil
+}
+
+func (s *GRPCServer) ApproveReturn(
+	ctx context.Context, in *generated.ApproveReturnRequest) (*generated.ApproveReturnResponse, error) {
+
+	ret, err := s.ReturnRepository.ApproveReturn(ctx, in.GetOrderId(), in.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.ApproveReturnResponse{
+		Return: returnMessage(ret),
+	}, nil
+}
+
+func (s *GRPCServer) RejectReturn(
+	ctx context.Context, in *generated.RejectReturnRequest) (*generated.RejectReturnResponse, error) {
+
+	ret, err := s.ReturnRepository.RejectReturn(ctx, in.GetOrderId(), in.GetId(), in.GetReason())
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.RejectReturnResponse{
+		Return: returnMessage(ret),
+	}, nil
+}
+
+func (s *GRPCServer) ReceiveReturn(
+	ctx context.Context, in *generated.ReceiveReturnRequest) (*generated.ReceiveReturnResponse, error) {
+
+	ret, err := s.ReturnRepository.ReceiveReturn(ctx, in.GetOrderId(), in.GetId(), in.GetRestock())
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.ReceiveReturnResponse{
+		Return: returnMessage(ret),
+	}, nil
+}
+
+func (s *GRPCServer) RefundReturn(
+	ctx context.Context, in *generated.RefundReturnRequest) (*generated.RefundReturnResponse, error) {
+
+	ret, err := s.RefundService.RefundReturn(ctx, in.GetOrderId(), in.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.RefundReturnResponse{
+		Return: returnMessage(ret),
+	}, nil
+}
+
+func returnMessages(list []*returns.Return) []*generated.Return {
+	var messages []*generated.Return
+	for _, ret := range list {
+		messages = append(messages, returnMessage(ret))
+	}
+
+	return messages
+}
+
+func returnMessage(ret *returns.Return) *generated.Return {
+	var items []*generated.ReturnItem
+	for _, item := range ret.Items {
+		items = append(items, &generated.ReturnItem{
+			OrderItemId: item.OrderItemId,
+			Quantity:    uint32(item.Quantity),
+		})
+	}
+
+	return &generated.Return{
+		Id:              ret.Id,
+		OrderId:         ret.OrderId,
+		Items:           items,
+		Reason:          ret.Reason,
+		Status:          getGRPCReturnStatus(ret.Status),
+		RejectionReason: ret.RejectionReason,
+		Restocked:       ret.Restocked,
+		RefundAmount:    moneyMessage(ret.RefundAmount),
+		RefundReference: ret.RefundReference,
+		RequestedAt:     timestamp(ret.RequestedAt),
+		ReceivedAt:      timestamp(ret.ReceivedAt),
+		RefundedAt:      timestamp(ret.RefundedAt),
+	}
+}
+
+func getGRPCReturnStatus(status string) generated.ReturnStatus {
+	switch status {
+	case returns.StatusRequested:
+		return generated.ReturnStatus_RETURN_REQUESTED
+	case returns.StatusApproved:
+		return generated.ReturnStatus_RETURN_APPROVED
+	case returns.StatusRejected:
+		return generated.ReturnStatus_RETURN_REJECTED
+	case returns.StatusReceived:
+		return generated.ReturnStatus_RETURN_RECEIVED
+	case returns.StatusRefunded:
+		return generated.ReturnStatus_RETURN_REFUNDED
+	default:
+		return generated.ReturnStatus_RETURN_STATUS_UNSPECIFIED
+	}
+}
@@ -4,15 +4,28 @@ import (
 	"context"
 	"fmt"
 	"github.com/leta/order-management-system/orders/generated"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/carts"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/categories"
 	"github.com/leta/order-management-system/orders/internal/interfaces/api/customers"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/notifications"
 	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/pricelists"
 	"github.com/leta/order-management-system/orders/internal/interfaces/api/product"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/promotions"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/returns"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/shipments"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/webhooks"
 	"log"
 	"net"
 	"sync"
 
 	"github.com/leta/order-management-system/orders/internal/service"
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/health"
+	"github.com/leta/order-management-system/orders/pkg/metrics"
+	"github.com/leta/order-management-system/orders/pkg/tracing"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 )
 
 // GRPCServer struct represents the GRPC server for the orders management system.
@@ -21,15 +34,36 @@ type GRPCServer struct {
 
 	grpcServer *grpc.Server
 	mu         sync.Mutex // synchronizes access to the grpcServer
+	closed     bool       // set once Shutdown has been called
+
+	// Standard grpc.health.v1 service. Not registered if nil.
+	Health *health.Service
+
+	// Registers the gRPC reflection service so tools like grpcurl work.
+	EnableReflection bool
 
 	// Internal services &  repositories
 	CheckoutService service.CheckoutService
+	RefundService   service.RefundService
 
 	ProductRepository  product.RepositoryInterface
+	CategoryRepository categories.CategoryRepository
 	CustomerService    customers.CustomerServiceInterface
 	OrderService       orders.OrderServiceInterface
 	OrderRepository    orders.OrderRepository
 	CustomerRepository customers.CustomerRepositoryInterface
+	WebhookRepository  webhooks.WebhookRepository
+
+	PromotionRepository promotions.PromotionRepository
+	PriceListRepository pricelists.PriceListRepository
+	CartRepository      carts.CartRepository
+	ShipmentRepository  shipments.ShipmentRepository
+	ReturnRepository    returns.ReturnRepository
+
+	NotificationRepository notifications.NotificationRepository
+
+	// Audit log of the changes made through this server.
+	AuditLog *audit.FirestoreLog
 }
 
 // NewGRPCServer creates a new instance of GRPCServer.
@@ -45,22 +79,64 @@ func (s *GRPCServer) Run(ctx context.Context, bindAddress string, port string) e
 	}
 
 	s.mu.Lock()
-	s.grpcServer = grpc.NewServer()
+	if s.closed {
+		s.mu.Unlock()
+		return lis.Close()
+	}
+	s.grpcServer = grpc.NewServer(grpc.ChainUnaryInterceptor(
+		tracing.UnaryServerInterceptor(), metrics.UnaryServerInterceptor(), audit.UnaryServerInterceptor(),
+		errorInterceptor))
 	s.mu.Unlock()
 
 	generated.RegisterOrdersServer(s.grpcServer, s)
 
+	if s.Health != nil {
+		s.Health.Register(s.grpcServer)
+	}
+
+	if s.EnableReflection {
+		reflection.Register(s.grpcServer)
+	}
+
 	log.Printf("Starting server on port %v", lis.Addr())
 
 	return s.grpcServer.Serve(lis)
 }
 
-// Stop gracefully stops the GRPC server.
+// Stop gracefully stops the GRPC server, waiting for in-flight RPCs to finish.
 func (s *GRPCServer) Stop() {
+	_ = s.Shutdown(context.Background())
+}
+
+// Shutdown marks the server as NOT_SERVING, stops accepting new RPCs and waits
+// for in-flight RPCs to finish. If ctx expires first, the remaining RPCs are
+// cancelled and ctx's error is returned.
+func (s *GRPCServer) Shutdown(ctx context.Context) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.closed = true
+	server := s.grpcServer
+	s.mu.Unlock()
+
+	if s.Health != nil {
+		s.Health.Shutdown()
+	}
+
+	if server == nil {
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(done)
+	}()
 
-	if s.grpcServer != nil {
-		s.grpcServer.GracefulStop()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		server.Stop()
+		<-done
+		return ctx.Err()
 	}
 }
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/leta/order-management-system/orders/generated"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/shipments"
+)
+
+func (s *GRPCServer) CreateShipment(
+	ctx context.Context, in *generated.CreateShipmentRequest) (*generated.CreateShipmentResponse, error) {
+
+	shipment := &shipments.Shipment{
+		OrderId:        in.GetOrderId(),
+		Carrier:        in.GetCarrier(),
+		TrackingNumber: in.GetTrackingNumber(),
+	}
+	for _, item := range in.GetItems() {
+		shipment.Items = append(shipment.Items, &shipments.ShipmentItem{
+			OrderItemId: item.GetOrderItemId(),
+			Quantity:    uint(item.GetQuantity()),
+		})
+	}
+
+	shipment, err := s.ShipmentRepository.CreateShipment(ctx, shipment, requestETag(ctx, in.GetEtag()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.CreateShipmentResponse{
+		Shipment: shipmentMessage(shipment),
+	}, nil
+}
+
+func (s *GRPCServer) GetShipment(
+	ctx context.Context, in *generated.GetShipmentRequest) (*generated.GetShipmentResponse, error) {
+
+	shipment, err := s.ShipmentRepository.GetShipment(ctx, in.GetOrderId(), in.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.GetShipmentResponse{
+		Shipment: shipmentMessage(shipment),
+	}, nil
+}
+
+func (s *GRPCServer) ListShipments(
+	ctx context.Context, in *generated.ListShipmentsRequest) (*generated.ListShipmentsResponse, error) {
+
+	list, err := s.ShipmentRepository.ListShipments(ctx, in.GetOrderId())
+	if err != nil {
+		return nil, err
+	}
+
+	var responseShipments []*generated.Shipment
+	for _, shipment := range list {
+		responseShipments = append(responseShipments, shipmentMessage(shipment))
+	}
+
+	return &generated.ListShipmentsResponse{
+		Shipments: responseShipments,
+	}, nil
+}
+
+func (s *GRPCServer) MarkShipmentDelivered(
+	ctx context.Context, in *generated.MarkShipmentDeliveredRequest) (*generated.MarkShipmentDeliveredResponse, error) {
+
+	shipment, err := s.ShipmentRepository.MarkShipmentDelivered(ctx, in.GetOrderId(), in.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.MarkShipmentDeliveredResponse{
+		Shipment: shipmentMessage(shipment),
+	}, nil
+}
+
+func (s *GRPCServer) ListOrderLines(
+	ctx context.Context, in *generated.ListOrderLinesRequest) (*generated.ListOrderLinesResponse, error) {
+
+	lines, err := s.ShipmentRepository.ListOrderLines(ctx, in.GetOrderId())
+	if err != nil {
+		return nil, err
+	}
+
+	var responseLines []*generated.OrderLine
+	for _, line := range lines {
+		responseLines = append(responseLines, &generated.OrderLine{
+			Id:           line.Item.Id,
+			ProductId:    line.Item.ProductId,
+			VariantId:    line.Item.VariantId,
+			Quantity:     uint32(line.Item.Quantity),
+			BundleItemId: line.Item.BundleItemId,
+			Unshipped:    uint32(line.Unshipped),
+		})
+	}
+
+	return &generated.ListOrderLinesResponse{
+		Lines: responseLines,
+	}, nil
+}
+
+func shipmentMessage(shipment *shipments.Shipment) *generated.Shipment {
+	var items []*generated.ShipmentItem
+	for _, item := range shipment.Items {
+		items = append(items, &generated.ShipmentItem{
+			OrderItemId: item.OrderItemId,
+			Quantity:    uint32(item.Quantity),
+		})
+	}
+
+	return &generated.Shipment{
+		Id:             shipment.Id,
+		OrderId:        shipment.OrderId,
+		Carrier:        shipment.Carrier,
+		TrackingNumber: shipment.TrackingNumber,
+		Items:          items,
+		Status:         getGRPCShipmentStatus(shipment.Status),
+		ShippedAt:      timestamp(shipment.ShippedAt),
+		DeliveredAt:    timestamp(shipment.DeliveredAt),
+	}
+}
+
+func getGRPCShipmentStatus(status string) generated.ShipmentStatus {
+	switch status {
+	case shipments.StatusShipped:
+		return generated.ShipmentStatus_SHIPMENT_SHIPPED
+	case shipments.StatusDelivered:
+		return generated.ShipmentStatus_SHIPMENT_DELIVERED
+	default:
+		return generated.ShipmentStatus_SHIPMENT_STATUS_UNSPECIFIED
+	}
+}
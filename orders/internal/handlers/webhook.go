@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/leta/order-management-system/orders/generated"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/webhooks"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+func (s *GRPCServer) CreateWebhookSubscription(
+	ctx context.Context, in *generated.CreateWebhookSubscriptionRequest) (*generated.CreateWebhookSubscriptionResponse, error) {
+
+	log.Printf("Received: %v", in.GetUrl())
+
+	subscription, err := s.WebhookRepository.CreateSubscription(ctx, &webhooks.Subscription{
+		Url:        in.GetUrl(),
+		EventTypes: in.GetEventTypes(),
+		Secret:     in.GetSecret(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.CreateWebhookSubscriptionResponse{
+		Id: subscription.Id,
+	}, nil
+}
+
+func (s *GRPCServer) GetWebhookSubscription(
+	ctx context.Context, in *generated.GetWebhookSubscriptionRequest) (*generated.GetWebhookSubscriptionResponse, error) {
+
+	log.Printf("Received: %v", in.GetId())
+
+	subscription, err := s.WebhookRepository.GetSubscription(ctx, in.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.GetWebhookSubscriptionResponse{
+		Subscription: getGRPCWebhookSubscription(subscription),
+	}, nil
+}
+
+func (s *GRPCServer) ListWebhookSubscriptions(
+	ctx context.Context, in *generated.ListWebhookSubscriptionsRequest) (*generated.ListWebhookSubscriptionsResponse, error) {
+
+	log.Printf("Received: %v", in)
+
+	subscriptions, err := s.WebhookRepository.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var responseSubscriptions []*generated.WebhookSubscription
+	for _, subscription := range subscriptions {
+		responseSubscriptions = append(responseSubscriptions, getGRPCWebhookSubscription(subscription))
+	}
+
+	return &generated.ListWebhookSubscriptionsResponse{
+		Subscriptions: responseSubscriptions,
+	}, nil
+}
+
+func (s *GRPCServer) DeleteWebhookSubscription(
+	ctx context.Context, in *generated.DeleteWebhookSubscriptionRequest) (*generated.DeleteWebhookSubscriptionResponse, error) {
+
+	log.Printf("Received: %v", in.GetId())
+
+	if err := s.WebhookRepository.DeleteSubscription(ctx, in.GetId()); err != nil {
+		return nil, err
+	}
+
+	return &generated.DeleteWebhookSubscriptionResponse{
+		Id: in.GetId(),
+	}, nil
+}
+
+func (s *GRPCServer) ListWebhookDeliveries(
+	ctx context.Context, in *generated.ListWebhookDeliveriesRequest) (*generated.ListWebhookDeliveriesResponse, error) {
+
+	log.Printf("Received: %v", in)
+
+	var status string
+	switch in.GetStatus() {
+	case generated.WebhookDeliveryStatus_DELIVERY_STATUS_UNSPECIFIED:
+	case generated.WebhookDeliveryStatus_DELIVERY_PENDING:
+		status = webhooks.DeliveryPending
+	case generated.WebhookDeliveryStatus_DELIVERED:
+		status = webhooks.DeliveryDelivered
+	case generated.WebhookDeliveryStatus_DEAD_LETTER:
+		status = webhooks.DeliveryDeadLetter
+	default:
+		return nil, utils.Errorf(utils.INVALID_ERROR, "unknown delivery status %v", in.GetStatus())
+	}
+
+	deliveries, err := s.WebhookRepository.ListDeliveries(ctx, in.GetSubscriptionId(), status)
+	if err != nil {
+		return nil, err
+	}
+
+	var responseDeliveries []*generated.WebhookDelivery
+	for _, delivery := range deliveries {
+		responseDeliveries = append(responseDeliveries, getGRPCWebhookDelivery(delivery))
+	}
+
+	return &generated.ListWebhookDeliveriesResponse{
+		Deliveries: responseDeliveries,
+	}, nil
+}
+
+func (s *GRPCServer) ListWebhookDeliveryAttempts(
+	ctx context.Context, in *generated.ListWebhookDeliveryAttemptsRequest) (*generated.ListWebhookDeliveryAttemptsResponse, error) {
+
+	log.Printf("Received: %v", in)
+
+	attempts, err := s.WebhookRepository.ListAttempts(ctx, in.GetSubscriptionId(), in.GetDeliveryId())
+	if err != nil {
+		return nil, err
+	}
+
+	var responseAttempts []*generated.WebhookDeliveryAttempt
+	for _, attempt := range attempts {
+		responseAttempts = append(responseAttempts, &generated.WebhookDeliveryAttempt{
+			Id:             attempt.Id,
+			SubscriptionId: attempt.SubscriptionId,
+			DeliveryId:     attempt.DeliveryId,
+			Attempt:        uint32(attempt.Attempt),
+			StatusCode:     uint32(attempt.StatusCode),
+			Error:          attempt.Error,
+			DurationMs:     uint32(attempt.Duration.Milliseconds()),
+			AttemptedAt:    timestamppb.New(attempt.AttemptedAt),
+		})
+	}
+
+	return &generated.ListWebhookDeliveryAttemptsResponse{
+		Attempts: responseAttempts,
+	}, nil
+}
+
+func (s *GRPCServer) RetryWebhookDelivery(
+	ctx context.Context, in *generated.RetryWebhookDeliveryRequest) (*generated.RetryWebhookDeliveryResponse, error) {
+
+	log.Printf("Received: %v", in)
+
+	delivery, err := s.WebhookRepository.RetryDelivery(ctx, in.GetSubscriptionId(), in.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.RetryWebhookDeliveryResponse{
+		Delivery: getGRPCWebhookDelivery(delivery),
+	}, nil
+}
+
+func getGRPCWebhookSubscription(subscription *webhooks.Subscription) *generated.WebhookSubscription {
+	return &generated.WebhookSubscription{
+		Id:         subscription.Id,
+		Url:        subscription.Url,
+		EventTypes: subscription.EventTypes,
+		CreatedAt:  getGRPCTimestamp(subscription.CreatedAt),
+		UpdatedAt:  getGRPCTimestamp(subscription.UpdatedAt),
+	}
+}
+
+func getGRPCWebhookDelivery(delivery *webhooks.Delivery) *generated.WebhookDelivery {
+	status := generated.WebhookDeliveryStatus_DELIVERY_STATUS_UNSPECIFIED
+	switch delivery.Status {
+	case webhooks.DeliveryPending:
+		status = generated.WebhookDeliveryStatus_DELIVERY_PENDING
+	case webhooks.DeliveryDelivered:
+		status = generated.WebhookDeliveryStatus_DELIVERED
+	case webhooks.DeliveryDeadLetter:
+		status = generated.WebhookDeliveryStatus_DEAD_LETTER
+	}
+
+	response := &generated.WebhookDelivery{
+		Id:             delivery.Id,
+		SubscriptionId: delivery.SubscriptionId,
+		EventId:        delivery.EventId,
+		EventType:      delivery.EventType,
+		Status:         status,
+		Attempts:       uint32(delivery.Attempts),
+		LastError:      delivery.LastError,
+		CreatedAt:      timestamppb.New(delivery.CreatedAt),
+		UpdatedAt:      timestamppb.New(delivery.UpdatedAt),
+	}
+
+	if delivery.Status == webhooks.DeliveryPending {
+		response.NextAttemptAt = timestamppb.New(delivery.NextAttemptAt)
+	}
+
+	return response
+}
+
+// getGRPCTimestamp converts an RFC3339 timestamp, as stored by the
+// repositories, returning nil if it is empty or invalid.
+func getGRPCTimestamp(value string) *timestamppb.Timestamp {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return timestamppb.New(t)
+}
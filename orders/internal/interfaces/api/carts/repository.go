@@ -0,0 +1,32 @@
+package carts
+
+import (
+	"context"
+	"time"
+)
+
+// CartRepository stores the cart of each customer. Carts are priced at the
+// current prices of their products when read, and ordered by the order
+// repository, which empties them.
+type CartRepository interface {
+	// GetCart returns an empty cart if the customer has none, or it expired.
+	GetCart(ctx context.Context, customerId string) (*Cart, error)
+
+	// AddCartItem adds quantity units of a product, or of its variant
+	// variantId, to the cart, which fails with an INVALID_ERROR if it is not
+	// available or its price is in another currency than the other items.
+	AddCartItem(ctx context.Context, customerId string, productId string, variantId string, quantity uint) (*Cart, error)
+
+	// UpdateCartItem sets the quantity of a product or variant in the cart.
+	// If etag is set, it fails with an ABORTED_ERROR unless it is the current
+	// etag of the cart.
+	UpdateCartItem(ctx context.Context, customerId string, productId string, variantId string, quantity uint,
+		etag string) (*Cart, error)
+
+	RemoveCartItem(ctx context.Context, customerId string, productId string, variantId string) (*Cart, error)
+	ClearCart(ctx context.Context, customerId string) error
+
+	// PurgeExpiredCarts removes the carts that expired before now and
+	// returns their number.
+	PurgeExpiredCarts(ctx context.Context, now time.Time) (int, error)
+}
@@ -0,0 +1,98 @@
+package carts
+
+import (
+	"time"
+
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// Cart holds the products a customer intends to order, at most one item per
+// product and variant. A customer has a single cart, identified by their ID.
+type Cart struct {
+	CustomerId string      `json:"customer_id"`
+	Items      []*CartItem `json:"items"`
+	CreatedAt  string      `json:"created_at"`
+	UpdatedAt  string      `json:"updated_at"`
+
+	// The cart is emptied at this time unless it is changed before, see
+	// ExpiresAt.
+	ExpiresAt string `json:"expires_at"`
+
+	// Incremented on every write, see utils.ETag.
+	Version int64 `json:"version"`
+}
+
+type CartItem struct {
+	ProductId string `json:"product_id"`
+	Quantity  uint   `json:"quantity"`
+	AddedAt   string `json:"added_at"`
+
+	// The variant of the product; set if the product has variants.
+	VariantId string `json:"variant_id,omitempty"`
+
+	// The current price of the product or variant, set when the cart is read
+	// unless it is not available, see product.Product.OrderPrice.
+	UnitPrice money.Money `json:"unit_price"`
+	Available bool        `json:"available"`
+}
+
+// ExpiresAt formats the expiry time of a cart. Expiry times are kept in UTC,
+// so that comparing them as strings compares the times, which removing
+// expired carts relies on.
+func ExpiresAt(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Expired reports whether the cart expired at now. A cart that was never
+// written does not expire.
+func (c *Cart) Expired(now time.Time) bool {
+	return c.ExpiresAt != "" && c.ExpiresAt <= ExpiresAt(now)
+}
+
+// Item returns the item of the product and variant, or nil if it is not in
+// the cart.
+func (c *Cart) Item(productId string, variantId string) *CartItem {
+	for _, item := range c.Items {
+		if item.ProductId == productId && item.VariantId == variantId {
+			return item
+		}
+	}
+	return nil
+}
+
+// Subtotal returns the price of the available items of the cart, which must
+// all be priced in the same currency.
+func (c *Cart) Subtotal() (money.Money, error) {
+	order := &orders.Order{}
+	for _, item := range c.Items {
+		if item.Available {
+			order.Items = append(order.Items, item.OrderItem())
+		}
+	}
+
+	return order.Total()
+}
+
+// OrderItem returns the order item the cart item is ordered as.
+func (i *CartItem) OrderItem() *orders.OrderItem {
+	return &orders.OrderItem{
+		ProductId: i.ProductId,
+		VariantId: i.VariantId,
+		Quantity:  i.Quantity,
+		UnitPrice: i.UnitPrice,
+	}
+}
+
+func (i *CartItem) Validate() error {
+	if i.ProductId == "" {
+		return utils.Errorf(utils.INVALID_ERROR, "product_id is required")
+	}
+
+	if i.Quantity == 0 {
+		return utils.Errorf(utils.INVALID_ERROR, "quantity is required")
+	}
+
+	return nil
+}
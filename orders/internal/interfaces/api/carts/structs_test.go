@@ -0,0 +1,79 @@
+package carts_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/carts"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+func TestCart_Expired(t *testing.T) {
+	now := time.Date(2024, 3, 31, 15, 0, 0, 0, time.FixedZone("EAT", 3*60*60))
+
+	tests := []struct {
+		name      string
+		expiresAt string
+		want      bool
+	}{
+		{name: "never written", expiresAt: "", want: false},
+		{name: "before now", expiresAt: "2024-03-31T11:59:59Z", want: true},
+		{name: "at now", expiresAt: "2024-03-31T12:00:00Z", want: true},
+		{name: "after now", expiresAt: "2024-03-31T12:00:01Z", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cart := &carts.Cart{ExpiresAt: tt.expiresAt}
+			if got := cart.Expired(now); got != tt.want {
+				t.Errorf("Expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCart_Subtotal(t *testing.T) {
+	tests := []struct {
+		name    string
+		items   []*carts.CartItem
+		want    money.Money
+		wantErr bool
+	}{
+		{
+			name: "available items",
+			items: []*carts.CartItem{
+				{ProductId: "shirt", Quantity: 2, UnitPrice: money.New(1000, "KES"), Available: true},
+				{ProductId: "socks", Quantity: 3, UnitPrice: money.New(250, "KES"), Available: true},
+				{ProductId: "hat", Quantity: 1},
+			},
+			want: money.New(2750, "KES"),
+		},
+		{
+			name: "mixed currencies",
+			items: []*carts.CartItem{
+				{ProductId: "shirt", Quantity: 1, UnitPrice: money.New(1000, "KES"), Available: true},
+				{ProductId: "socks", Quantity: 1, UnitPrice: money.New(250, "USD"), Available: true},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cart := &carts.Cart{Items: tt.items}
+
+			got, err := cart.Subtotal()
+			if tt.wantErr {
+				if utils.ErrorCode(err) != utils.INVALID_ERROR {
+					t.Fatalf("Subtotal() error = %v, want INVALID_ERROR", err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("Subtotal() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Subtotal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
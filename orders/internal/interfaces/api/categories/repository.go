@@ -0,0 +1,23 @@
+package categories
+
+import (
+	"context"
+)
+
+// CategoryRepository stores the category tree of the catalog. Products refer
+// to a category by its ID.
+type CategoryRepository interface {
+	// CreateCategory fails with an INVALID_ERROR if the parent of the
+	// category does not exist.
+	CreateCategory(ctx context.Context, category *Category) (*Category, error)
+	GetCategory(ctx context.Context, id string) (*Category, error)
+	ListCategories(ctx context.Context) ([]*Category, error)
+
+	// UpdateCategory fails with an INVALID_ERROR if the category would be
+	// moved under itself or one of its subcategories.
+	UpdateCategory(ctx context.Context, id string, update *CategoryUpdate) (*Category, error)
+
+	// DeleteCategory fails with an INVALID_ERROR if the category has
+	// subcategories or products, deleted or not.
+	DeleteCategory(ctx context.Context, id string) error
+}
@@ -0,0 +1,92 @@
+package categories
+
+import (
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// Category groups products. Categories form a tree: a category without a
+// parent is at the top of the catalog.
+type Category struct {
+	Id       string `json:"id"`
+	Name     string `json:"name"`
+	ParentId string `json:"parent_id,omitempty"`
+
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+
+	// Incremented on every write, see utils.ETag.
+	Version int64 `json:"version"`
+}
+
+type CategoryUpdate struct {
+	Name *string
+
+	// Must be the ID of another category, which is not a subcategory of the
+	// category, or empty to move it to the top of the catalog.
+	ParentId *string
+
+	// If set, the update fails unless it is the category's current etag.
+	ETag string
+}
+
+func (c *Category) Validate() error {
+	if c.Name == "" {
+		return utils.Errorf(utils.INVALID_ERROR, "name is required")
+	}
+
+	return nil
+}
+
+// Descendants returns the IDs of the category id and of all its
+// subcategories among all.
+func Descendants(all []*Category, id string) map[string]bool {
+	children := make(map[string][]string)
+	for _, category := range all {
+		if category.ParentId != "" {
+			children[category.ParentId] = append(children[category.ParentId], category.Id)
+		}
+	}
+
+	descendants := map[string]bool{id: true}
+	next := []string{id}
+	for len(next) > 0 {
+		parent := next[0]
+		next = next[1:]
+
+		for _, child := range children[parent] {
+			if !descendants[child] {
+				descendants[child] = true
+				next = append(next, child)
+			}
+		}
+	}
+
+	return descendants
+}
+
+// CheckParent fails with an INVALID_ERROR unless the category id can be moved
+// under parentId among all: the parent must exist, and must be neither the
+// category nor one of its subcategories, which would make a cycle.
+func CheckParent(all []*Category, id string, parentId string) error {
+	if parentId == "" {
+		return nil
+	}
+
+	found := false
+	for _, category := range all {
+		if category.Id == parentId {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return utils.Errorf(utils.INVALID_ERROR, "parent category %s not found", parentId)
+	}
+
+	if Descendants(all, id)[parentId] {
+		return utils.Errorf(utils.INVALID_ERROR, "category %s cannot be moved under itself or its subcategory %s",
+			id, parentId)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package categories_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/categories"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// clothing
+// ├── shirts
+// │   └── t-shirts
+// └── shoes
+// toys
+var catalog = []*categories.Category{
+	{Id: "clothing", Name: "Clothing"},
+	{Id: "shirts", Name: "Shirts", ParentId: "clothing"},
+	{Id: "t-shirts", Name: "T-shirts", ParentId: "shirts"},
+	{Id: "shoes", Name: "Shoes", ParentId: "clothing"},
+	{Id: "toys", Name: "Toys"},
+}
+
+func TestDescendants(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want map[string]bool
+	}{
+		{
+			name: "subtree",
+			id:   "clothing",
+			want: map[string]bool{"clothing": true, "shirts": true, "t-shirts": true, "shoes": true},
+		},
+		{
+			name: "leaf",
+			id:   "t-shirts",
+			want: map[string]bool{"t-shirts": true},
+		},
+		{
+			name: "unknown category",
+			id:   "books",
+			want: map[string]bool{"books": true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := categories.Descendants(catalog, tt.id); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Descendants() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckParent(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		parentId string
+		wantErr  bool
+	}{
+		{name: "top of the catalog", id: "shirts", parentId: ""},
+		{name: "other subtree", id: "shirts", parentId: "toys"},
+		{name: "new category", id: "", parentId: "shoes"},
+		{name: "parent not found", id: "shirts", parentId: "books", wantErr: true},
+		{name: "itself", id: "shirts", parentId: "shirts", wantErr: true},
+		{name: "own subcategory", id: "clothing", parentId: "t-shirts", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := categories.CheckParent(catalog, tt.id, tt.parentId)
+			if tt.wantErr {
+				if utils.ErrorCode(err) != utils.INVALID_ERROR {
+					t.Errorf("CheckParent() error = %v, want INVALID_ERROR", err)
+				}
+			} else if err != nil {
+				t.Errorf("CheckParent() error = %v", err)
+			}
+		})
+	}
+}
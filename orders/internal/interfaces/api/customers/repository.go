@@ -1,11 +1,31 @@
 package customers
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
+// CustomerRepositoryInterface soft-deletes customers: a deleted customer is
+// still returned by GetCustomer but left out of ListCustomers unless
+// includeDeleted is set, and cannot be updated until it is restored.
 type CustomerRepositoryInterface interface {
 	CreateCustomer(ctx context.Context, customer *Customer) (*Customer, error)
 	GetCustomer(ctx context.Context, id string) (*Customer, error)
-	ListCustomers(ctx context.Context) ([]*Customer, error)
+	ListCustomers(ctx context.Context, includeDeleted bool) ([]*Customer, error)
 	UpdateCustomer(ctx context.Context, id string, update *CustomerUpdate) (*Customer, error)
 	DeleteCustomer(ctx context.Context, id string) error
+	RestoreCustomer(ctx context.Context, id string) (*Customer, error)
+	PurgeCustomers(ctx context.Context, deletedBefore time.Time) (int, error)
+
+	// AddAddress adds an address to the customer, which becomes their default
+	// address if makeDefault is set or they had none. The address IDs are set
+	// by the repository. If etag is set, the address methods fail with an
+	// ABORTED_ERROR unless it is the current etag of the customer.
+	AddAddress(ctx context.Context, customerId string, address *Address, makeDefault bool, etag string) (*Customer, error)
+	// UpdateAddress replaces the address of the customer with the ID of
+	// address.
+	UpdateAddress(ctx context.Context, customerId string, address *Address, etag string) (*Customer, error)
+	// RemoveAddress removes an address of the customer, who is left without
+	// a default address if it was their default.
+	RemoveAddress(ctx context.Context, customerId string, addressId string, etag string) (*Customer, error)
 }
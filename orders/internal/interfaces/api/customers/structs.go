@@ -1,6 +1,8 @@
 package customers
 
 import (
+	"strings"
+
 	"github.com/leta/order-management-system/orders/pkg/utils"
 )
 
@@ -12,6 +14,75 @@ type Customer struct {
 	Phone     string
 	CreatedAt string
 	UpdatedAt string
+
+	// Set when the customer was deleted, see utils.DeletedAt.
+	DeletedAt string
+
+	// Incremented on every write, see utils.ETag.
+	Version int64
+
+	// Opt-outs of order notifications, per channel.
+	SmsOptOut   bool
+	EmailOptOut bool
+
+	// Exempts the customer's orders from tax, e.g. for diplomatic missions.
+	TaxExempt bool
+
+	// The group of the customer, if any, e.g. "wholesale". Price lists can
+	// be assigned to groups, see pricelists.PriceList.
+	Group string
+
+	// Where the customer has their orders shipped. Orders are shipped to the
+	// default address unless another one is chosen.
+	Addresses        []*Address
+	DefaultAddressId string
+}
+
+// Address is a postal address of a customer. Orders keep a copy of the
+// address they are shipped to, which later changes to the customer's
+// addresses leave alone.
+type Address struct {
+	Id string
+
+	// Tells the customer's addresses apart, e.g. "home" or "office".
+	Label string
+
+	// Who receives the shipments, if not the customer.
+	Recipient string
+	Phone     string
+
+	Line1      string
+	Line2      string
+	City       string
+	Region     string
+	PostalCode string
+
+	// ISO 3166-1 alpha-2 country code, e.g. KE.
+	Country string
+}
+
+// Address returns the address with the ID, or nil if the customer has none.
+func (c *Customer) Address(id string) *Address {
+	for _, address := range c.Addresses {
+		if address.Id == id {
+			return address
+		}
+	}
+	return nil
+}
+
+// DefaultAddress returns the default address of the customer, or nil if
+// they have none.
+func (c *Customer) DefaultAddress() *Address {
+	if c.DefaultAddressId == "" {
+		return nil
+	}
+	return c.Address(c.DefaultAddressId)
+}
+
+// Deleted reports whether the customer was soft-deleted.
+func (c *Customer) Deleted() bool {
+	return c.DeletedAt != ""
 }
 
 func (c *Customer) Validate() error {
@@ -31,6 +102,26 @@ func (c *Customer) Validate() error {
 		return utils.Errorf(utils.INVALID_ERROR, "phone is required")
 	}
 
+	if c.DefaultAddressId != "" && c.DefaultAddress() == nil {
+		return utils.Errorf(utils.INVALID_ERROR, "address %s not found", c.DefaultAddressId)
+	}
+
+	return nil
+}
+
+func (a *Address) Validate() error {
+	if a.Line1 == "" {
+		return utils.Errorf(utils.INVALID_ERROR, "line1 is required")
+	}
+
+	if a.City == "" {
+		return utils.Errorf(utils.INVALID_ERROR, "city is required")
+	}
+
+	if len(a.Country) != 2 || strings.ToUpper(a.Country) != a.Country {
+		return utils.Errorf(utils.INVALID_ERROR, "country must be an ISO 3166-1 alpha-2 code, e.g. KE")
+	}
+
 	return nil
 }
 
@@ -39,4 +130,16 @@ type CustomerUpdate struct {
 	LastName  *string
 	Phone     *string
 	Email     *string
+
+	SmsOptOut   *bool
+	EmailOptOut *bool
+	TaxExempt   *bool
+	Group       *string
+
+	// Must be the ID of an address of the customer, or empty to have no
+	// default address.
+	DefaultAddressId *string
+
+	// If set, the update fails unless it is the customer's current etag.
+	ETag string
 }
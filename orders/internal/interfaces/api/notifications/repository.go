@@ -0,0 +1,14 @@
+package notifications
+
+import "context"
+
+type NotificationRepository interface {
+	// GetNotification returns a NOT_FOUND error if no notification has the id.
+	GetNotification(ctx context.Context, id string) (*Notification, error)
+
+	// SaveNotification creates or replaces the notification with its Id.
+	SaveNotification(ctx context.Context, notification *Notification) error
+
+	// ListNotifications returns the notifications of a customer, newest first.
+	ListNotifications(ctx context.Context, customerId string) ([]*Notification, error)
+}
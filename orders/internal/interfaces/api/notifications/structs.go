@@ -0,0 +1,52 @@
+package notifications
+
+import "time"
+
+// Channels notifications are sent over.
+const (
+	ChannelSMS   = "sms"
+	ChannelEmail = "email"
+)
+
+var Channels = []string{ChannelSMS, ChannelEmail}
+
+// Templates of the notifications, one per order event customers are told of.
+const (
+	TemplateOrderPlaced     = "order_placed"
+	TemplatePaymentReceived = "payment_received"
+	TemplatePaymentFailed   = "payment_failed"
+	TemplateOrderCancelled  = "order_cancelled"
+	TemplateOrderExpired    = "order_expired"
+)
+
+// Statuses of a notification.
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// Notification records a message sent, or to be sent, to a customer over one
+// channel because of one event.
+type Notification struct {
+	Id         string    `json:"id"`
+	CustomerId string    `json:"customer_id"`
+	OrderId    string    `json:"order_id"`
+	EventId    string    `json:"event_id"`
+	Template   string    `json:"template"`
+	Channel    string    `json:"channel"`
+	Recipient  string    `json:"recipient"`
+	Subject    string    `json:"subject"`
+	Body       string    `json:"body"`
+	Status     string    `json:"status"`
+	Attempts   uint      `json:"attempts"`
+	Error      string    `json:"error"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Done reports whether nothing is left to do for the notification.
+func (n *Notification) Done() bool {
+	return n.Status == StatusSent || n.Status == StatusFailed || n.Status == StatusSkipped
+}
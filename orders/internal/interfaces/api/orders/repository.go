@@ -1,22 +1,83 @@
 package orders
 
 import (
+	"github.com/leta/order-management-system/orders/pkg/money"
 	"github.com/leta/order-management-system/orders/pkg/utils"
 
 	"context"
+	"time"
 )
 
 // OrderRepositoryInterface is an interface for interacting with order data.
+//
+// Orders are soft-deleted: a deleted order keeps its items, is still returned
+// by GetOrder and is left out of ListOrders unless includeDeleted is set.
+// PurgeOrders removes orders deleted before a given time along with their
+// items.
 type OrderRepository interface {
 
 	// Order CRUD
+	// CreateOrder ships the order to a copy of the default address of its
+	// customer, if they have one. If order.ShippingAddress is set, only its
+	// ID is used, to choose another address of the customer.
 	CreateOrder(ctx context.Context, order *Order) (*Order, error)
 	GetOrder(ctx context.Context, id string) (*Order, error)
-	ListOrders(ctx context.Context) ([]*Order, error)
-	UpdateOrderStatus(ctx context.Context, orderId string, status utils.OrderStatus) (*Order, error)
+	ListOrders(ctx context.Context, includeDeleted bool) ([]*Order, error)
+	// UpdateOrderStatus fails with an ABORTED_ERROR if etag is set and is not
+	// the order's current etag. Cancelling or expiring an order removes its
+	// coupon, which no longer counts as used, and puts the stock of its items
+	// back if it was unpaid. Changes utils.OrderStatus.CheckTransition
+	// refuses fail with an INVALID_ERROR, e.g. checking out a paid order,
+	// except that paying a cancelled or expired order sets its LatePaymentAt,
+	// as the customer is owed a refund.
+	UpdateOrderStatus(ctx context.Context, orderId string, status utils.OrderStatus, etag string) (*Order, error)
 	DeleteOrder(ctx context.Context, id string) error
+	RestoreOrder(ctx context.Context, id string) (*Order, error)
+	PurgeOrders(ctx context.Context, deletedBefore time.Time) (int, error)
+	// ListUnpaidOrders returns the orders created before createdBefore that
+	// are still unpaid, i.e. new, pending or whose payment failed, without
+	// their items. Orders being checked out are not, as their payment may
+	// still settle. Deleted orders are included, as they still hold stock.
+	ListUnpaidOrders(ctx context.Context, createdBefore time.Time) ([]*Order, error)
+	// SetOrderTotals stores the discount and tax of order and of its items,
+	// along with their unit prices. It fails with an ABORTED_ERROR if the
+	// items of the order changed since order was read. The totals are
+	// cleared when the items or the coupon of the order change.
+	SetOrderTotals(ctx context.Context, order *Order) (*Order, error)
+
+	// ApplyCoupon applies the coupon of a promotion to an order not checked
+	// out yet, and counts it as used. It fails with an INVALID_ERROR if the
+	// coupon cannot be applied to the order, including when the order has
+	// another coupon. Applying the coupon of the order again is a no-op.
+	ApplyCoupon(ctx context.Context, orderId string, code string) (*Order, error)
+	// RemoveCoupon removes the coupon of an order not checked out yet, and
+	// counts it as unused.
+	RemoveCoupon(ctx context.Context, orderId string) (*Order, error)
+
+	// SetShippingAddress ships an order to a copy of an address of its
+	// customer. It fails with an INVALID_ERROR once the order was cancelled,
+	// expired or shipped, and with an ABORTED_ERROR if etag is set and is not
+	// the current etag of the order.
+	SetShippingAddress(ctx context.Context, orderId string, addressId string, etag string) (*Order, error)
+
+	// ConvertCartToOrder creates an order of the items of the cart of a
+	// customer at the current prices of their products, and empties the cart,
+	// atomically. It fails with an INVALID_ERROR if the cart is empty or a
+	// product is not available, and with an ABORTED_ERROR if etag is set and
+	// is not the current etag of the cart, or expectedSubtotal is set and is
+	// not its subtotal.
+	ConvertCartToOrder(
+		ctx context.Context, customerId string, etag string, expectedSubtotal *money.Money) (*Order, error)
 
 	// OrderItem CRUD
+	//
+	// Orders reserve the units of their items, bundle components included,
+	// from the stock of their variants: creating an order or item, or raising
+	// the quantity of an item, fails with an INVALID_ERROR if fewer units are
+	// left in stock, and deleting an item or lowering its quantity puts them
+	// back. Changing the quantity of an item also prices it again at the
+	// current prices, which may depend on quantities. The items of a cancelled
+	// or expired order cannot be changed.
 	CreateOrderItem(ctx context.Context, orderId string, orderItem *OrderItem) (*OrderItem, error)
 	GetOrderItem(ctx context.Context, orderId string, orderItemId string) (*OrderItem, error)
 	ListOrderItems(ctx context.Context, orderId string) ([]*OrderItem, error)
@@ -1,6 +1,12 @@
 package orders
 
 import (
+	"fmt"
+
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/customers"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/pricelists"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/product"
+	"github.com/leta/order-management-system/orders/pkg/money"
 	"github.com/leta/order-management-system/orders/pkg/utils"
 )
 
@@ -9,7 +15,124 @@ type OrderItem struct {
 	ProductId string `json:"product_id"`
 	Quantity  uint   `json:"quantity"`
 	CreatedAt string `json:"created_at"`
+
+	// The variant of the product ordered; set if the product has variants.
+	VariantId string `json:"variant_id,omitempty"`
 	UpdatedAt string `json:"updated_at"`
+
+	// The price of the product when the item was added to the order. Zero
+	// for items added before prices were recorded, see Priced.
+	UnitPrice money.Money `json:"unit_price"`
+
+	// Incremented on every write, see utils.ETag.
+	Version int64 `json:"version"`
+
+	// Set when the order is checked out.
+	Tax *ItemTax `json:"tax,omitempty"`
+
+	// The share of the discount of the order on the item, before tax. Set
+	// when the order is checked out.
+	Discount money.Money `json:"discount"`
+
+	// Set on items of bundles: the components of a unit of the bundle when
+	// it was added to the order. The item is priced as a unit, and shipped
+	// as a line per component, see Lines.
+	Components []*Component `json:"components,omitempty"`
+
+	// Set on the lines of the components of an item of a bundle: the ID of
+	// the item, see Lines.
+	BundleItemId string `json:"bundle_item_id,omitempty"`
+}
+
+// Component is a product, or one of its variants, in a unit of the bundle of
+// an order item.
+type Component struct {
+	ProductId string `json:"product_id"`
+	VariantId string `json:"variant_id,omitempty"`
+	Quantity  uint   `json:"quantity"`
+}
+
+// ItemTax is the tax on an order item.
+type ItemTax struct {
+	// Tax category of the product and its rate in basis points.
+	Category string `json:"category"`
+	Rate     int64  `json:"rate"`
+
+	Amount money.Money `json:"amount"`
+	// The price of the item including tax.
+	Total money.Money `json:"total"`
+}
+
+// Priced reports whether the unit price of the item is known.
+func (o *OrderItem) Priced() bool {
+	return o.UnitPrice.Currency != ""
+}
+
+// Price sets the unit price of the item to what the customer of pricing pays
+// now for its quantity of the product p, or of its variant, which must be
+// orderable, see product.Product.OrderPrice and pricelists.Pricing.UnitPrice.
+func (o *OrderItem) Price(p *product.Product, pricing *pricelists.Pricing) error {
+	base, err := p.OrderPrice(o.VariantId, o.Quantity)
+	if err != nil {
+		return err
+	}
+
+	o.UnitPrice = pricing.UnitPrice(o.ProductId, o.VariantId, o.Quantity, base)
+	return nil
+}
+
+// Lines returns the lines the item is shipped in: the item itself, or a line
+// per component if it is an item of a bundle, with the units of the
+// component in the units of the item, see LineId.
+func (o *OrderItem) Lines() []*OrderItem {
+	if len(o.Components) == 0 {
+		return []*OrderItem{o}
+	}
+
+	lines := make([]*OrderItem, 0, len(o.Components))
+	for i, component := range o.Components {
+		lines = append(lines, &OrderItem{
+			Id:           LineId(o.Id, i),
+			ProductId:    component.ProductId,
+			VariantId:    component.VariantId,
+			Quantity:     o.Quantity * component.Quantity,
+			CreatedAt:    o.CreatedAt,
+			UpdatedAt:    o.UpdatedAt,
+			Version:      o.Version,
+			BundleItemId: o.Id,
+		})
+	}
+	return lines
+}
+
+// LineId returns the ID of the line of the component at index i of the
+// order item itemId. The IDs of items have no dashes, so that lines and
+// items can be shipped alike.
+func LineId(itemId string, i int) string {
+	return fmt.Sprintf("%s-%d", itemId, i+1)
+}
+
+// Lines returns the lines the items are shipped in, see OrderItem.Lines.
+func Lines(items []*OrderItem) []*OrderItem {
+	var lines []*OrderItem
+	for _, item := range items {
+		lines = append(lines, item.Lines()...)
+	}
+	return lines
+}
+
+// Total returns the price of the item.
+func (o *OrderItem) Total() (money.Money, error) {
+	if !o.Priced() {
+		return money.Money{}, utils.Errorf(utils.INVALID_ERROR, "item %s has no price", o.Id)
+	}
+
+	total, err := o.UnitPrice.Mul(int64(o.Quantity))
+	if err != nil {
+		return money.Money{}, utils.Errorf(utils.INVALID_ERROR, "%v", err)
+	}
+
+	return total, nil
 }
 
 func (o *OrderItem) Validate() error {
@@ -26,6 +149,9 @@ func (o *OrderItem) Validate() error {
 
 type OrderItemUpdate struct {
 	Quantity *uint `json:"quantity"`
+
+	// If set, the update fails unless it is the item's current etag.
+	ETag string `json:"-"`
 }
 
 type Order struct {
@@ -35,6 +161,83 @@ type Order struct {
 	OrderStatus utils.OrderStatus `json:"order_status"`
 	CreatedAt   string            `json:"created_at"`
 	UpdatedAt   string            `json:"updated_at"`
+
+	// Set when the order was deleted, see utils.DeletedAt.
+	DeletedAt string `json:"deleted_at,omitempty"`
+
+	// Incremented on every write, see utils.ETag.
+	Version int64 `json:"version"`
+
+	// Set when the order is checked out.
+	Tax *OrderTax `json:"tax,omitempty"`
+
+	// Coupon code of the promotion applied to the order, if any.
+	CouponCode string `json:"coupon_code,omitempty"`
+
+	// The discount of the coupon. Set when the order is checked out, if the
+	// coupon gave a discount.
+	Discount *Discount `json:"discount,omitempty"`
+
+	// A copy of the customer's address the order is shipped to. Required to
+	// ship the order.
+	ShippingAddress *customers.Address `json:"shipping_address,omitempty"`
+
+	// Updated as the order is shipped and delivered.
+	FulfilmentStatus utils.FulfilmentStatus `json:"fulfilment_status"`
+
+	// Set when a payment settled after the order was cancelled or expired,
+	// e.g. cancelled while its payment request was pending: the customer was
+	// charged for an order that is not fulfilled, and is owed a refund.
+	LatePaymentAt string `json:"late_payment_at,omitempty"`
+}
+
+// Discount is a discount included in the total of an order.
+type Discount struct {
+	Code        string      `json:"code"`
+	Description string      `json:"description"`
+	Amount      money.Money `json:"amount"`
+}
+
+// OrderTax is the tax on an order, the sum of the tax on its items.
+type OrderTax struct {
+	// Whether the prices of the items included tax.
+	Inclusive bool `json:"inclusive"`
+	// Whether the customer was exempt from tax.
+	Exempt bool `json:"exempt"`
+
+	// The price of the items less discount excluding tax, the tax, and their
+	// sum, which the customer pays.
+	Subtotal money.Money `json:"subtotal"`
+	Amount   money.Money `json:"amount"`
+	Total    money.Money `json:"total"`
+}
+
+// Deleted reports whether the order was soft-deleted.
+func (o *Order) Deleted() bool {
+	return o.DeletedAt != ""
+}
+
+// Total returns the price of the items of the order, which must all be
+// priced in the same currency. It excludes the tax not included in prices;
+// see Tax for what the customer pays once the order is checked out.
+func (o *Order) Total() (money.Money, error) {
+	if len(o.Items) == 0 {
+		return money.Zero(money.DefaultCurrency), nil
+	}
+
+	total := money.Zero(o.Items[0].UnitPrice.Currency)
+	for _, item := range o.Items {
+		line, err := item.Total()
+		if err != nil {
+			return money.Money{}, err
+		}
+
+		if total, err = total.Add(line); err != nil {
+			return money.Money{}, utils.Errorf(utils.INVALID_ERROR, "%v", err)
+		}
+	}
+
+	return total, nil
 }
 
 func (o *Order) Validate() error {
@@ -0,0 +1,110 @@
+package orders_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/customers"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/pricelists"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/product"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+func TestOrderItem_Price(t *testing.T) {
+	soap := &product.Product{
+		Id:     "soap",
+		Price:  money.New(100, "KES"),
+		Active: true,
+	}
+	shirt := &product.Product{
+		Id:     "shirt",
+		Price:  money.New(1000, "KES"),
+		Active: true,
+		Variants: []*product.Variant{
+			{Id: "m", Price: money.New(1200, "KES"), Stock: 20, Active: true},
+		},
+	}
+
+	pricing := &pricelists.Pricing{
+		Customer: &customers.Customer{Id: "alice"},
+		Now:      time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+		Lists: []*pricelists.PriceList{{
+			Currency: "KES",
+			Prices: []*pricelists.Price{
+				{ProductId: "soap", Tiers: []*pricelists.Tier{
+					{MinQuantity: 1, UnitPrice: money.New(95, "KES")},
+					{MinQuantity: 10, UnitPrice: money.New(80, "KES")},
+				}},
+				{ProductId: "shirt", VariantId: "m", Tiers: []*pricelists.Tier{
+					{MinQuantity: 12, UnitPrice: money.New(1100, "KES")},
+				}},
+			},
+		}},
+	}
+
+	tests := []struct {
+		name    string
+		product *product.Product
+		item    orders.OrderItem
+		want    money.Money
+		wantErr bool
+	}{
+		{
+			name:    "below the quantity break",
+			product: soap,
+			item:    orders.OrderItem{ProductId: "soap", Quantity: 9},
+			want:    money.New(95, "KES"),
+		},
+		{
+			name:    "priced again across the quantity break",
+			product: soap,
+			item:    orders.OrderItem{ProductId: "soap", Quantity: 10, UnitPrice: money.New(95, "KES")},
+			want:    money.New(80, "KES"),
+		},
+		{
+			name:    "priced again back below the quantity break",
+			product: soap,
+			item:    orders.OrderItem{ProductId: "soap", Quantity: 2, UnitPrice: money.New(80, "KES")},
+			want:    money.New(95, "KES"),
+		},
+		{
+			name:    "variant below its quantity break",
+			product: shirt,
+			item:    orders.OrderItem{ProductId: "shirt", VariantId: "m", Quantity: 11},
+			want:    money.New(1200, "KES"),
+		},
+		{
+			name:    "variant across its quantity break",
+			product: shirt,
+			item:    orders.OrderItem{ProductId: "shirt", VariantId: "m", Quantity: 12},
+			want:    money.New(1100, "KES"),
+		},
+		{
+			name:    "variant out of stock",
+			product: shirt,
+			item:    orders.OrderItem{ProductId: "shirt", VariantId: "m", Quantity: 21},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := tt.item
+
+			err := item.Price(tt.product, pricing)
+			if tt.wantErr {
+				if utils.ErrorCode(err) != utils.INVALID_ERROR {
+					t.Fatalf("Price() error = %v, want INVALID_ERROR", err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("Price() error = %v", err)
+			}
+
+			if item.UnitPrice != tt.want {
+				t.Errorf("UnitPrice = %v, want %v", item.UnitPrice, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package pricelists
+
+import (
+	"context"
+)
+
+// PriceListRepository stores price lists. Order items and carts are priced
+// with the lists in effect when their items are added, see Pricing; later
+// changes to the lists leave the prices of order items alone.
+type PriceListRepository interface {
+	CreatePriceList(ctx context.Context, list *PriceList) (*PriceList, error)
+	GetPriceList(ctx context.Context, id string) (*PriceList, error)
+	ListPriceLists(ctx context.Context) ([]*PriceList, error)
+	UpdatePriceList(ctx context.Context, id string, update *PriceListUpdate) (*PriceList, error)
+	DeletePriceList(ctx context.Context, id string) error
+}
@@ -0,0 +1,243 @@
+package pricelists
+
+import (
+	"time"
+
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/customers"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// PriceList sets the unit prices some customers pay for products during a
+// validity window, e.g. for wholesale customers. Customers pay the lowest of
+// the catalogue price and the prices of the lists that apply to them, see
+// Pricing.
+type PriceList struct {
+	Id   string
+	Name string
+
+	// The currency of the prices. The list only prices products in it.
+	Currency string
+
+	// Who the list applies to: the customers with the IDs and those of the
+	// groups, see customers.Customer.Group. A list with neither applies to
+	// every customer.
+	CustomerIds []string
+	Groups      []string
+
+	// Validity window, RFC3339; open-ended if empty.
+	StartsAt string
+	EndsAt   string
+
+	Prices []*Price
+
+	CreatedAt string
+	UpdatedAt string
+
+	// Incremented on every write, see utils.ETag.
+	Version int64
+}
+
+// Price is the unit price of a product, or of one of its variants, by
+// quantity.
+type Price struct {
+	ProductId string
+
+	// If set, the price is for the variant only; otherwise it is for the
+	// product and those of its variants the list has no price for.
+	VariantId string
+
+	// Quantity breaks: an item is priced at the tier with the highest
+	// MinQuantity not above its quantity. Items of fewer units than every
+	// tier are not priced by the list.
+	Tiers []*Tier
+}
+
+// Tier is the unit price of items of at least MinQuantity units.
+type Tier struct {
+	MinQuantity uint
+	UnitPrice   money.Money
+}
+
+type PriceListUpdate struct {
+	Name        *string
+	CustomerIds *[]string
+	Groups      *[]string
+	StartsAt    *string
+	EndsAt      *string
+	Prices      *[]*Price
+
+	// If set, the update fails unless it is the price list's current etag.
+	ETag string
+}
+
+func (l *PriceList) Validate() error {
+	if l.Name == "" {
+		return utils.Errorf(utils.INVALID_ERROR, "name is required")
+	}
+
+	if money.Exponent(l.Currency) < 0 {
+		return utils.Errorf(utils.INVALID_ERROR, "unsupported currency %q", l.Currency)
+	}
+
+	startsAt, err := parseTime(l.StartsAt)
+	if err != nil {
+		return utils.Errorf(utils.INVALID_ERROR, "invalid starts_at: %v", err)
+	}
+	endsAt, err := parseTime(l.EndsAt)
+	if err != nil {
+		return utils.Errorf(utils.INVALID_ERROR, "invalid ends_at: %v", err)
+	}
+	if !startsAt.IsZero() && !endsAt.IsZero() && !endsAt.After(startsAt) {
+		return utils.Errorf(utils.INVALID_ERROR, "ends_at must be after starts_at")
+	}
+
+	type key struct{ productId, variantId string }
+	seen := make(map[key]bool)
+	for _, price := range l.Prices {
+		if price.ProductId == "" {
+			return utils.Errorf(utils.INVALID_ERROR, "product_id of price is required")
+		}
+
+		k := key{price.ProductId, price.VariantId}
+		if seen[k] {
+			return utils.Errorf(utils.INVALID_ERROR, "product %s is priced twice", price.ProductId)
+		}
+		seen[k] = true
+
+		if err := price.validate(l.Currency); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Price) validate(currency string) error {
+	if len(p.Tiers) == 0 {
+		return utils.Errorf(utils.INVALID_ERROR, "tiers of the price of product %s are required", p.ProductId)
+	}
+
+	quantities := make(map[uint]bool)
+	for _, tier := range p.Tiers {
+		if tier.MinQuantity == 0 {
+			return utils.Errorf(utils.INVALID_ERROR, "min_quantity of the tiers of product %s is required", p.ProductId)
+		} else if quantities[tier.MinQuantity] {
+			return utils.Errorf(utils.INVALID_ERROR,
+				"product %s has two tiers from %d units", p.ProductId, tier.MinQuantity)
+		}
+		quantities[tier.MinQuantity] = true
+
+		if err := tier.UnitPrice.Validate(); err != nil {
+			return utils.Errorf(utils.INVALID_ERROR, "invalid price of product %s: %v", p.ProductId, err)
+		} else if tier.UnitPrice.Currency != currency {
+			return utils.Errorf(utils.INVALID_ERROR, "price of product %s must be in %s, not %s",
+				p.ProductId, currency, tier.UnitPrice.Currency)
+		}
+	}
+
+	return nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// Active reports whether now is in the validity window of the list.
+func (l *PriceList) Active(now time.Time) bool {
+	if startsAt, err := parseTime(l.StartsAt); err != nil || (!startsAt.IsZero() && now.Before(startsAt)) {
+		return false
+	}
+	if endsAt, err := parseTime(l.EndsAt); err != nil || (!endsAt.IsZero() && !now.Before(endsAt)) {
+		return false
+	}
+	return true
+}
+
+// AppliesTo reports whether the list applies to the customer, whatever the
+// time.
+func (l *PriceList) AppliesTo(customer *customers.Customer) bool {
+	if len(l.CustomerIds) == 0 && len(l.Groups) == 0 {
+		return true
+	}
+
+	for _, id := range l.CustomerIds {
+		if id == customer.Id {
+			return true
+		}
+	}
+
+	for _, group := range l.Groups {
+		if customer.Group != "" && group == customer.Group {
+			return true
+		}
+	}
+
+	return false
+}
+
+// UnitPrice returns the unit price the list sets for quantity units of the
+// product, or of its variant variantId, and false if it sets none. The price
+// of the variant takes precedence over that of the product.
+func (l *PriceList) UnitPrice(productId string, variantId string, quantity uint) (money.Money, bool) {
+	var productPrice, variantPrice *Price
+	for _, price := range l.Prices {
+		if price.ProductId != productId {
+			continue
+		}
+
+		if price.VariantId == "" {
+			productPrice = price
+		} else if variantId != "" && price.VariantId == variantId {
+			variantPrice = price
+		}
+	}
+
+	price := variantPrice
+	if price == nil {
+		price = productPrice
+	}
+	if price == nil {
+		return money.Money{}, false
+	}
+
+	var best *Tier
+	for _, tier := range price.Tiers {
+		if tier.MinQuantity <= quantity && (best == nil || tier.MinQuantity > best.MinQuantity) {
+			best = tier
+		}
+	}
+	if best == nil {
+		return money.Money{}, false
+	}
+
+	return best.UnitPrice, true
+}
+
+// Pricing resolves the unit prices a customer pays at a time.
+type Pricing struct {
+	Customer *customers.Customer
+	Lists    []*PriceList
+	Now      time.Time
+}
+
+// UnitPrice returns the unit price of quantity units of the product, or of
+// its variant variantId, whose catalogue price is base: the lowest of base
+// and the prices of the lists active at p.Now that apply to p.Customer and
+// are in the currency of base.
+func (p *Pricing) UnitPrice(productId string, variantId string, quantity uint, base money.Money) money.Money {
+	unitPrice := base
+	for _, list := range p.Lists {
+		if list.Currency != base.Currency || !list.Active(p.Now) || !list.AppliesTo(p.Customer) {
+			continue
+		}
+
+		if price, ok := list.UnitPrice(productId, variantId, quantity); ok && price.Amount < unitPrice.Amount {
+			unitPrice = price
+		}
+	}
+	return unitPrice
+}
@@ -0,0 +1,261 @@
+package pricelists_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/customers"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/pricelists"
+	"github.com/leta/order-management-system/orders/pkg/money"
+)
+
+func tiers(prices ...int64) []*pricelists.Tier {
+	var list []*pricelists.Tier
+	for i := 0; i+1 < len(prices); i += 2 {
+		list = append(list, &pricelists.Tier{
+			MinQuantity: uint(prices[i]),
+			UnitPrice:   money.New(prices[i+1], "KES"),
+		})
+	}
+	return list
+}
+
+func TestPriceList_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    pricelists.PriceList
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			list: pricelists.PriceList{
+				Name:     "Wholesale",
+				Currency: "KES",
+				StartsAt: "2026-01-01T00:00:00Z",
+				EndsAt:   "2027-01-01T00:00:00Z",
+				Prices: []*pricelists.Price{
+					{ProductId: "soap", Tiers: tiers(1, 90, 10, 80)},
+					{ProductId: "soap", VariantId: "large", Tiers: tiers(1, 150)},
+				},
+			},
+		},
+		{
+			name:    "missing name",
+			list:    pricelists.PriceList{Currency: "KES"},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported currency",
+			list:    pricelists.PriceList{Name: "Wholesale", Currency: "XXX"},
+			wantErr: true,
+		},
+		{
+			name: "ends before it starts",
+			list: pricelists.PriceList{
+				Name:     "Wholesale",
+				Currency: "KES",
+				StartsAt: "2027-01-01T00:00:00Z",
+				EndsAt:   "2026-01-01T00:00:00Z",
+			},
+			wantErr: true,
+		},
+		{
+			name: "product priced twice",
+			list: pricelists.PriceList{
+				Name:     "Wholesale",
+				Currency: "KES",
+				Prices: []*pricelists.Price{
+					{ProductId: "soap", Tiers: tiers(1, 90)},
+					{ProductId: "soap", Tiers: tiers(10, 80)},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "no tiers",
+			list: pricelists.PriceList{
+				Name:     "Wholesale",
+				Currency: "KES",
+				Prices:   []*pricelists.Price{{ProductId: "soap"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "tier from zero units",
+			list: pricelists.PriceList{
+				Name:     "Wholesale",
+				Currency: "KES",
+				Prices:   []*pricelists.Price{{ProductId: "soap", Tiers: tiers(0, 90)}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "two tiers from the same quantity",
+			list: pricelists.PriceList{
+				Name:     "Wholesale",
+				Currency: "KES",
+				Prices:   []*pricelists.Price{{ProductId: "soap", Tiers: tiers(10, 90, 10, 80)}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "price in another currency",
+			list: pricelists.PriceList{
+				Name:     "Wholesale",
+				Currency: "USD",
+				Prices:   []*pricelists.Price{{ProductId: "soap", Tiers: tiers(1, 90)}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.list.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPriceList_UnitPrice(t *testing.T) {
+	list := &pricelists.PriceList{
+		Currency: "KES",
+		Prices: []*pricelists.Price{
+			{ProductId: "soap", Tiers: tiers(1, 90, 10, 80, 100, 70)},
+			{ProductId: "soap", VariantId: "large", Tiers: tiers(1, 150)},
+			{ProductId: "oil", Tiers: tiers(12, 200)},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		productId string
+		variantId string
+		quantity  uint
+		want      int64
+		wantOk    bool
+	}{
+		{name: "first tier", productId: "soap", quantity: 9, want: 90, wantOk: true},
+		{name: "tier boundary", productId: "soap", quantity: 10, want: 80, wantOk: true},
+		{name: "highest tier", productId: "soap", quantity: 500, want: 70, wantOk: true},
+		{name: "variant price", productId: "soap", variantId: "large", quantity: 50, want: 150, wantOk: true},
+		{name: "variant without price", productId: "soap", variantId: "small", quantity: 50, want: 80, wantOk: true},
+		{name: "below every tier", productId: "oil", quantity: 6},
+		{name: "product without price", productId: "salt", quantity: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := list.UnitPrice(tt.productId, tt.variantId, tt.quantity)
+			if ok != tt.wantOk || (ok && got != money.New(tt.want, "KES")) {
+				t.Errorf("UnitPrice() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestPricing_UnitPrice(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	base := money.New(100, "KES")
+
+	everyone := &pricelists.PriceList{
+		Currency: "KES",
+		Prices:   []*pricelists.Price{{ProductId: "soap", Tiers: tiers(1, 95)}},
+	}
+	wholesale := &pricelists.PriceList{
+		Currency: "KES",
+		Groups:   []string{"wholesale"},
+		Prices:   []*pricelists.Price{{ProductId: "soap", Tiers: tiers(1, 80)}},
+	}
+	vip := &pricelists.PriceList{
+		Currency:    "KES",
+		CustomerIds: []string{"alice"},
+		Prices:      []*pricelists.Price{{ProductId: "soap", Tiers: tiers(1, 85)}},
+	}
+	expired := &pricelists.PriceList{
+		Currency: "KES",
+		EndsAt:   "2026-01-01T00:00:00Z",
+		Prices:   []*pricelists.Price{{ProductId: "soap", Tiers: tiers(1, 50)}},
+	}
+	upcoming := &pricelists.PriceList{
+		Currency: "KES",
+		StartsAt: "2026-07-01T00:00:00Z",
+		Prices:   []*pricelists.Price{{ProductId: "soap", Tiers: tiers(1, 50)}},
+	}
+	dollars := &pricelists.PriceList{
+		Currency: "USD",
+		Prices:   []*pricelists.Price{{ProductId: "soap", Tiers: []*pricelists.Tier{{MinQuantity: 1, UnitPrice: money.New(1, "USD")}}}},
+	}
+	higher := &pricelists.PriceList{
+		Currency: "KES",
+		Prices:   []*pricelists.Price{{ProductId: "soap", Tiers: tiers(1, 120)}},
+	}
+
+	tests := []struct {
+		name     string
+		customer *customers.Customer
+		lists    []*pricelists.PriceList
+		want     int64
+	}{
+		{
+			name:     "no lists",
+			customer: &customers.Customer{Id: "bob"},
+			want:     100,
+		},
+		{
+			name:     "list for everyone",
+			customer: &customers.Customer{Id: "bob"},
+			lists:    []*pricelists.PriceList{everyone},
+			want:     95,
+		},
+		{
+			name:     "group of the customer",
+			customer: &customers.Customer{Id: "bob", Group: "wholesale"},
+			lists:    []*pricelists.PriceList{everyone, wholesale},
+			want:     80,
+		},
+		{
+			name:     "other group",
+			customer: &customers.Customer{Id: "bob", Group: "retail"},
+			lists:    []*pricelists.PriceList{wholesale},
+			want:     100,
+		},
+		{
+			name:     "customer id",
+			customer: &customers.Customer{Id: "alice"},
+			lists:    []*pricelists.PriceList{everyone, vip},
+			want:     85,
+		},
+		{
+			name:     "lowest wins",
+			customer: &customers.Customer{Id: "alice", Group: "wholesale"},
+			lists:    []*pricelists.PriceList{vip, wholesale},
+			want:     80,
+		},
+		{
+			name:     "outside the validity window",
+			customer: &customers.Customer{Id: "bob"},
+			lists:    []*pricelists.PriceList{expired, upcoming},
+			want:     100,
+		},
+		{
+			name:     "other currency",
+			customer: &customers.Customer{Id: "bob"},
+			lists:    []*pricelists.PriceList{dollars},
+			want:     100,
+		},
+		{
+			name:     "never above the catalogue price",
+			customer: &customers.Customer{Id: "bob"},
+			lists:    []*pricelists.PriceList{higher},
+			want:     100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pricing := &pricelists.Pricing{Customer: tt.customer, Lists: tt.lists, Now: now}
+			if got := pricing.UnitPrice("soap", "", 1, base); got != money.New(tt.want, "KES") {
+				t.Errorf("UnitPrice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
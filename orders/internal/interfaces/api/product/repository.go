@@ -1,12 +1,37 @@
 package product
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // RepositoryInterface is an interface for product repo
+//
+// Deleting a product only marks it as deleted: it is still returned by
+// GetProduct, so that existing orders can be priced, but is left out of
+// ListProducts unless the filter includes deleted products, and cannot be
+// updated until it is restored. PurgeProducts removes products deleted
+// before a given time.
+//
+// Writes fail with an ALREADY_EXISTS_ERROR if a SKU of the product is used
+// by another product, and with an INVALID_ERROR if its category does not
+// exist. They fail with an INVALID_ERROR if a component of a bundle cannot
+// be in it, see Component.Check, and products and variants that are
+// components of undeleted bundles cannot be deleted or removed.
 type RepositoryInterface interface {
 	CreateProduct(ctx context.Context, product *Product) (*Product, error)
 	GetProduct(ctx context.Context, id string) (*Product, error)
-	ListProducts(ctx context.Context) ([]*Product, error)
+	ListProducts(ctx context.Context, filter *ProductFilter) ([]*Product, error)
 	UpdateProduct(ctx context.Context, id string, update *ProductUpdate) (*Product, error)
 	DeleteProduct(ctx context.Context, id string) error
+	RestoreProduct(ctx context.Context, id string) (*Product, error)
+	PurgeProducts(ctx context.Context, deletedBefore time.Time) (int, error)
+
+	AddVariant(ctx context.Context, productId string, variant *Variant, etag string) (*Product, error)
+	// UpdateVariant replaces the variant of the product with the ID of
+	// variant. Order items of the variant keep the price they were added at.
+	UpdateVariant(ctx context.Context, productId string, variant *Variant, etag string) (*Product, error)
+	// RemoveVariant fails with an INVALID_ERROR if an open order includes
+	// the variant.
+	RemoveVariant(ctx context.Context, productId string, variantId string, etag string) (*Product, error)
 }
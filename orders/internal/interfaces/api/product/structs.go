@@ -1,6 +1,10 @@
 package product
 
 import (
+	"sort"
+	"strings"
+
+	"github.com/leta/order-management-system/orders/pkg/money"
 	"github.com/leta/order-management-system/orders/pkg/utils"
 )
 
@@ -8,15 +12,198 @@ type Product struct {
 	Id          string
 	Name        string
 	Description string
-	Price       uint
+	Price       money.Money
 	CreatedAt   string
 	UpdatedAt   string
+
+	// Set when the product was deleted, see utils.DeletedAt.
+	DeletedAt string
+
+	// Incremented on every write, see utils.ETag.
+	Version int64
+
+	// Tax category of the product, see tax.Options; the default category if
+	// empty.
+	TaxCategory string
+
+	// Stock keeping unit of the product, if any. SKUs are unique across
+	// products and their variants.
+	Sku string
+
+	// The ID of the category of the product, if any, see categories.Category.
+	CategoryId string
+
+	// Inactive products are listed but cannot be ordered.
+	Active bool
+
+	// A product with variants is ordered as one of them, see OrderPrice.
+	Variants []*Variant
+
+	// A product with components is a bundle: it is sold as a unit at its own
+	// price, and its components are shipped as separate lines, see
+	// orders.Lines. Bundles have no variants, and their components are not
+	// bundles.
+	Components []*Component
+}
+
+// Component is a product, or one of its variants, in a unit of a bundle.
+type Component struct {
+	ProductId string
+
+	// Required if the product has variants.
+	VariantId string
+
+	// The number of units of the component in a unit of the bundle.
+	Quantity uint
+}
+
+// Variant is a version of a product, e.g. a size or colour, with its own
+// price and stock.
+type Variant struct {
+	Id string
+
+	// Required, see Product.Sku.
+	Sku string
+
+	// What sets the variant apart from the others of its product, e.g.
+	// {"size": "M", "colour": "red"}.
+	Options map[string]string
+
+	// In the currency of the product.
+	Price money.Money
+
+	// The number of units that can be ordered. Orders take the units of their
+	// items out of it until they are abandoned unpaid or the items removed;
+	// carts only check it. Set through the variant.
+	Stock uint
+
+	// Inactive variants cannot be ordered.
+	Active bool
+}
+
+// Deleted reports whether the product was soft-deleted.
+func (p *Product) Deleted() bool {
+	return p.DeletedAt != ""
+}
+
+// Bundle reports whether the product is made of other products.
+func (p *Product) Bundle() bool {
+	return len(p.Components) > 0
+}
+
+// Variant returns the variant with the ID, or nil if the product has none.
+func (p *Product) Variant(id string) *Variant {
+	for _, variant := range p.Variants {
+		if variant.Id == id {
+			return variant
+		}
+	}
+	return nil
+}
+
+// Skus returns the SKUs of the product and its variants.
+func (p *Product) Skus() []string {
+	var skus []string
+	if p.Sku != "" {
+		skus = append(skus, p.Sku)
+	}
+	for _, variant := range p.Variants {
+		skus = append(skus, variant.Sku)
+	}
+	return skus
+}
+
+// OrderPrice returns the unit price of quantity units of the product, or of
+// its variant variantId, ordered now. A product with variants can only be
+// ordered as one of them. It fails with an INVALID_ERROR if the product or
+// variant is deleted, inactive or out of stock.
+func (p *Product) OrderPrice(variantId string, quantity uint) (money.Money, error) {
+	if p.Deleted() {
+		return money.Money{}, utils.Errorf(utils.INVALID_ERROR, "product %s is deleted", p.Id)
+	}
+
+	if !p.Active {
+		return money.Money{}, utils.Errorf(utils.INVALID_ERROR, "product %s is not active", p.Id)
+	}
+
+	if variantId == "" {
+		if len(p.Variants) > 0 {
+			return money.Money{}, utils.Errorf(utils.INVALID_ERROR,
+				"product %s has variants, variant_id is required", p.Id)
+		}
+		return p.Price, nil
+	}
+
+	variant := p.Variant(variantId)
+	if variant == nil {
+		return money.Money{}, utils.Errorf(utils.INVALID_ERROR,
+			"variant %s of product %s not found", variantId, p.Id)
+	}
+
+	if !variant.Active {
+		return money.Money{}, utils.Errorf(utils.INVALID_ERROR,
+			"variant %s of product %s is not active", variantId, p.Id)
+	}
+
+	if variant.Stock < quantity {
+		return money.Money{}, utils.Errorf(utils.INVALID_ERROR,
+			"%d units of variant %s of product %s are in stock, not %d", variant.Stock, variantId, p.Id, quantity)
+	}
+
+	return variant.Price, nil
+}
+
+// Reserve takes quantity units of the variant variantId out of its stock. It
+// fails with an INVALID_ERROR if the variant does not exist or fewer units
+// are in stock.
+func (p *Product) Reserve(variantId string, quantity uint) error {
+	variant := p.Variant(variantId)
+	if variant == nil {
+		return utils.Errorf(utils.INVALID_ERROR, "variant %s of product %s not found", variantId, p.Id)
+	}
+
+	if variant.Stock < quantity {
+		return utils.Errorf(utils.INVALID_ERROR,
+			"%d units of variant %s of product %s are in stock, not %d", variant.Stock, variantId, p.Id, quantity)
+	}
+
+	variant.Stock -= quantity
+	return nil
+}
+
+// Release puts quantity units of the variant variantId back in its stock, if
+// it still exists.
+func (p *Product) Release(variantId string, quantity uint) {
+	if variant := p.Variant(variantId); variant != nil {
+		variant.Stock += quantity
+	}
 }
 
 type ProductUpdate struct {
 	Name        *string
 	Description *string
-	Price       *uint
+	Price       *money.Money
+	TaxCategory *string
+	Sku         *string
+	CategoryId  *string
+	Active      *bool
+	Components  *[]*Component
+
+	// If set, the update fails unless it is the product's current etag.
+	ETag string
+}
+
+// ProductFilter selects the products ListProducts returns.
+type ProductFilter struct {
+	// Lists deleted products too.
+	IncludeDeleted bool
+
+	// If set, lists only the products of the category and its
+	// subcategories.
+	CategoryId string
+
+	// Leaves inactive products out.
+	ActiveOnly bool
 }
 
 func (p *Product) Validate() error {
@@ -24,5 +211,148 @@ func (p *Product) Validate() error {
 		return utils.Errorf(utils.INVALID_ERROR, "name is required")
 	}
 
+	if err := p.Price.Validate(); err != nil {
+		return utils.Errorf(utils.INVALID_ERROR, "invalid price: %v", err)
+	}
+
+	if err := ValidateSku(p.Sku); err != nil {
+		return err
+	}
+
+	skus := make(map[string]bool)
+	if p.Sku != "" {
+		skus[p.Sku] = true
+	}
+
+	ids := make(map[string]bool)
+	options := make(map[string]bool)
+	for _, variant := range p.Variants {
+		if variant.Id == "" {
+			return utils.Errorf(utils.INVALID_ERROR, "variant id is required")
+		} else if ids[variant.Id] {
+			return utils.Errorf(utils.INVALID_ERROR, "variant %s is listed twice", variant.Id)
+		}
+		ids[variant.Id] = true
+
+		if err := variant.Validate(); err != nil {
+			return err
+		}
+
+		if variant.Price.Currency != p.Price.Currency {
+			return utils.Errorf(utils.INVALID_ERROR, "price of variant %s must be in %s, not %s",
+				variant.Id, p.Price.Currency, variant.Price.Currency)
+		}
+
+		if skus[variant.Sku] {
+			return utils.Errorf(utils.INVALID_ERROR, "sku %s is used twice", variant.Sku)
+		}
+		skus[variant.Sku] = true
+
+		key := variant.optionsKey()
+		if options[key] {
+			return utils.Errorf(utils.INVALID_ERROR, "variant %s has the options of another variant", variant.Id)
+		}
+		options[key] = true
+	}
+
+	if p.Bundle() && len(p.Variants) > 0 {
+		return utils.Errorf(utils.INVALID_ERROR, "bundles cannot have variants")
+	}
+
+	components := make(map[Component]bool)
+	for _, component := range p.Components {
+		if component.ProductId == "" {
+			return utils.Errorf(utils.INVALID_ERROR, "product id of component is required")
+		} else if component.ProductId == p.Id {
+			return utils.Errorf(utils.INVALID_ERROR, "product %s cannot be a component of itself", p.Id)
+		}
+
+		if component.Quantity == 0 {
+			return utils.Errorf(utils.INVALID_ERROR, "quantity of component %s is required", component.ProductId)
+		}
+
+		key := Component{ProductId: component.ProductId, VariantId: component.VariantId}
+		if components[key] {
+			return utils.Errorf(utils.INVALID_ERROR, "component %s is listed twice", component.ProductId)
+		}
+		components[key] = true
+	}
+
+	return nil
+}
+
+// Check fails with an INVALID_ERROR unless p, the product of the component,
+// can be a component of a bundle: it must not be deleted or a bundle itself,
+// and the variant of the component must be one of its variants, if it has
+// any.
+func (c *Component) Check(p *Product) error {
+	if p.Deleted() {
+		return utils.Errorf(utils.INVALID_ERROR, "product %s is deleted", p.Id)
+	}
+
+	if p.Bundle() {
+		return utils.Errorf(utils.INVALID_ERROR, "product %s is a bundle, bundles cannot contain bundles", p.Id)
+	}
+
+	if c.VariantId == "" {
+		if len(p.Variants) > 0 {
+			return utils.Errorf(utils.INVALID_ERROR,
+				"product %s has variants, variant_id of component is required", p.Id)
+		}
+		return nil
+	}
+
+	if p.Variant(c.VariantId) == nil {
+		return utils.Errorf(utils.INVALID_ERROR, "variant %s of product %s not found", c.VariantId, p.Id)
+	}
+
+	return nil
+}
+
+func (v *Variant) Validate() error {
+	if v.Sku == "" {
+		return utils.Errorf(utils.INVALID_ERROR, "sku of variant is required")
+	}
+
+	if err := ValidateSku(v.Sku); err != nil {
+		return err
+	}
+
+	if len(v.Options) == 0 {
+		return utils.Errorf(utils.INVALID_ERROR, "options of variant %s are required", v.Sku)
+	}
+
+	if err := v.Price.Validate(); err != nil {
+		return utils.Errorf(utils.INVALID_ERROR, "invalid price of variant %s: %v", v.Sku, err)
+	}
+
+	return nil
+}
+
+// optionsKey returns the options of the variant in a canonical form.
+func (v *Variant) optionsKey() string {
+	names := make([]string, 0, len(v.Options))
+	for name := range v.Options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte(0)
+		b.WriteString(v.Options[name])
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+// ValidateSku fails with an INVALID_ERROR unless sku is empty or can be used
+// as the ID of the document that reserves it.
+func ValidateSku(sku string) error {
+	if strings.Contains(sku, "/") || sku == "." || sku == ".." || len(sku) > 100 {
+		return utils.Errorf(utils.INVALID_ERROR, "invalid sku %q", sku)
+	}
+
 	return nil
 }
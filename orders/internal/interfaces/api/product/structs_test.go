@@ -0,0 +1,365 @@
+package product_test
+
+import (
+	"testing"
+
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/product"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+func shirt() *product.Product {
+	return &product.Product{
+		Id:     "shirt",
+		Name:   "Shirt",
+		Price:  money.New(100000, "KES"),
+		Sku:    "SHIRT",
+		Active: true,
+		Variants: []*product.Variant{
+			{
+				Id:      "m",
+				Sku:     "SHIRT-M",
+				Options: map[string]string{"size": "M"},
+				Price:   money.New(120000, "KES"),
+				Stock:   3,
+				Active:  true,
+			},
+			{
+				Id:      "xl",
+				Sku:     "SHIRT-XL",
+				Options: map[string]string{"size": "XL"},
+				Price:   money.New(150000, "KES"),
+				Stock:   0,
+				Active:  true,
+			},
+		},
+	}
+}
+
+func TestProduct_OrderPrice(t *testing.T) {
+	tests := []struct {
+		name      string
+		change    func(p *product.Product)
+		variantId string
+		quantity  uint
+		want      money.Money
+		wantErr   bool
+	}{
+		{
+			name:      "variant in stock",
+			variantId: "m",
+			quantity:  3,
+			want:      money.New(120000, "KES"),
+		},
+		{
+			name:     "product without variants",
+			change:   func(p *product.Product) { p.Variants = nil },
+			quantity: 10,
+			want:     money.New(100000, "KES"),
+		},
+		{
+			name:     "variant required",
+			quantity: 1,
+			wantErr:  true,
+		},
+		{
+			name:      "variant not found",
+			variantId: "s",
+			quantity:  1,
+			wantErr:   true,
+		},
+		{
+			name:      "not enough stock",
+			variantId: "m",
+			quantity:  4,
+			wantErr:   true,
+		},
+		{
+			name:      "out of stock",
+			variantId: "xl",
+			quantity:  1,
+			wantErr:   true,
+		},
+		{
+			name:      "inactive variant",
+			change:    func(p *product.Product) { p.Variants[0].Active = false },
+			variantId: "m",
+			quantity:  1,
+			wantErr:   true,
+		},
+		{
+			name:      "inactive product",
+			change:    func(p *product.Product) { p.Active = false },
+			variantId: "m",
+			quantity:  1,
+			wantErr:   true,
+		},
+		{
+			name:      "deleted product",
+			change:    func(p *product.Product) { p.DeletedAt = "2024-03-31T12:00:00.000000000Z" },
+			variantId: "m",
+			quantity:  1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := shirt()
+			if tt.change != nil {
+				tt.change(p)
+			}
+
+			got, err := p.OrderPrice(tt.variantId, tt.quantity)
+			if tt.wantErr {
+				if utils.ErrorCode(err) != utils.INVALID_ERROR {
+					t.Fatalf("OrderPrice() error = %v, want INVALID_ERROR", err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("OrderPrice() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("OrderPrice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProduct_Reserve(t *testing.T) {
+	tests := []struct {
+		name      string
+		variantId string
+		reserved  []uint
+		wantStock uint
+		wantErr   bool
+	}{
+		{
+			name:      "within stock",
+			variantId: "m",
+			reserved:  []uint{1, 2},
+			wantStock: 0,
+		},
+		{
+			name:      "beyond the units left",
+			variantId: "m",
+			reserved:  []uint{2, 2},
+			wantStock: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "out of stock",
+			variantId: "xl",
+			reserved:  []uint{1},
+			wantErr:   true,
+		},
+		{
+			name:      "variant not found",
+			variantId: "s",
+			reserved:  []uint{1},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := shirt()
+
+			var err error
+			for _, quantity := range tt.reserved {
+				if err = p.Reserve(tt.variantId, quantity); err != nil {
+					break
+				}
+			}
+
+			if tt.wantErr {
+				if utils.ErrorCode(err) != utils.INVALID_ERROR {
+					t.Fatalf("Reserve() error = %v, want INVALID_ERROR", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Reserve() error = %v", err)
+			}
+
+			if variant := p.Variant(tt.variantId); variant != nil && variant.Stock != tt.wantStock {
+				t.Errorf("Stock = %d, want %d", variant.Stock, tt.wantStock)
+			}
+		})
+	}
+}
+
+func TestProduct_Release(t *testing.T) {
+	p := shirt()
+
+	if err := p.Reserve("m", 3); err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	p.Release("m", 2)
+	p.Release("s", 1)
+
+	if got := p.Variant("m").Stock; got != 2 {
+		t.Errorf("Stock = %d, want 2", got)
+	}
+}
+
+func TestProduct_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(p *product.Product)
+		wantErr bool
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name:    "sku of product used by variant",
+			change:  func(p *product.Product) { p.Variants[1].Sku = "SHIRT" },
+			wantErr: true,
+		},
+		{
+			name:    "sku of variant used twice",
+			change:  func(p *product.Product) { p.Variants[1].Sku = "SHIRT-M" },
+			wantErr: true,
+		},
+		{
+			name:    "variant without sku",
+			change:  func(p *product.Product) { p.Variants[0].Sku = "" },
+			wantErr: true,
+		},
+		{
+			name:    "invalid sku",
+			change:  func(p *product.Product) { p.Sku = "SHIRT/M" },
+			wantErr: true,
+		},
+		{
+			name:    "variant without options",
+			change:  func(p *product.Product) { p.Variants[0].Options = nil },
+			wantErr: true,
+		},
+		{
+			name:    "variants with the same options",
+			change:  func(p *product.Product) { p.Variants[1].Options = map[string]string{"size": "M"} },
+			wantErr: true,
+		},
+		{
+			name:    "variant in another currency",
+			change:  func(p *product.Product) { p.Variants[0].Price = money.New(1000, "USD") },
+			wantErr: true,
+		},
+		{
+			name: "bundle",
+			change: func(p *product.Product) {
+				p.Variants = nil
+				p.Components = []*product.Component{{ProductId: "socks", Quantity: 3}}
+			},
+		},
+		{
+			name:    "bundle with variants",
+			change:  func(p *product.Product) { p.Components = []*product.Component{{ProductId: "socks", Quantity: 3}} },
+			wantErr: true,
+		},
+		{
+			name: "component without quantity",
+			change: func(p *product.Product) {
+				p.Variants = nil
+				p.Components = []*product.Component{{ProductId: "socks"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "component of itself",
+			change: func(p *product.Product) {
+				p.Variants = nil
+				p.Components = []*product.Component{{ProductId: "shirt", Quantity: 1}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "component listed twice",
+			change: func(p *product.Product) {
+				p.Variants = nil
+				p.Components = []*product.Component{
+					{ProductId: "socks", Quantity: 1},
+					{ProductId: "socks", Quantity: 2},
+				}
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := shirt()
+			if tt.change != nil {
+				tt.change(p)
+			}
+
+			err := p.Validate()
+			if tt.wantErr {
+				if utils.ErrorCode(err) != utils.INVALID_ERROR {
+					t.Errorf("Validate() error = %v, want INVALID_ERROR", err)
+				}
+			} else if err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestComponent_Check(t *testing.T) {
+	tests := []struct {
+		name      string
+		change    func(p *product.Product)
+		component *product.Component
+		wantErr   bool
+	}{
+		{
+			name:      "variant",
+			component: &product.Component{ProductId: "shirt", VariantId: "m", Quantity: 1},
+		},
+		{
+			name:      "product without variants",
+			change:    func(p *product.Product) { p.Variants = nil },
+			component: &product.Component{ProductId: "shirt", Quantity: 1},
+		},
+		{
+			name:      "variant required",
+			component: &product.Component{ProductId: "shirt", Quantity: 1},
+			wantErr:   true,
+		},
+		{
+			name:      "variant not found",
+			component: &product.Component{ProductId: "shirt", VariantId: "s", Quantity: 1},
+			wantErr:   true,
+		},
+		{
+			name: "bundle",
+			change: func(p *product.Product) {
+				p.Variants = nil
+				p.Components = []*product.Component{{ProductId: "socks", Quantity: 3}}
+			},
+			component: &product.Component{ProductId: "shirt", Quantity: 1},
+			wantErr:   true,
+		},
+		{
+			name:      "deleted product",
+			change:    func(p *product.Product) { p.DeletedAt = "2024-03-31T12:00:00.000000000Z" },
+			component: &product.Component{ProductId: "shirt", VariantId: "m", Quantity: 1},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := shirt()
+			if tt.change != nil {
+				tt.change(p)
+			}
+
+			err := tt.component.Check(p)
+			if tt.wantErr {
+				if utils.ErrorCode(err) != utils.INVALID_ERROR {
+					t.Errorf("Check() error = %v, want INVALID_ERROR", err)
+				}
+			} else if err != nil {
+				t.Errorf("Check() error = %v", err)
+			}
+		})
+	}
+}
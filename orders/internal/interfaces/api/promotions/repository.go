@@ -0,0 +1,17 @@
+package promotions
+
+import (
+	"context"
+)
+
+// PromotionRepository stores promotions by coupon code. Coupons are applied
+// to and removed from orders by the order repository, which counts their
+// uses.
+type PromotionRepository interface {
+	// CreatePromotion fails with an ALREADY_EXISTS_ERROR if a promotion has
+	// the same coupon code.
+	CreatePromotion(ctx context.Context, promotion *Promotion) (*Promotion, error)
+	GetPromotion(ctx context.Context, code string) (*Promotion, error)
+	ListPromotions(ctx context.Context) ([]*Promotion, error)
+	DeletePromotion(ctx context.Context, code string) error
+}
@@ -0,0 +1,319 @@
+package promotions
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// Types of discount.
+const (
+	TypePercentage = "percentage"
+	TypeFixed      = "fixed"
+	TypeBuyXGetY   = "buy_x_get_y"
+)
+
+// basisPoints is the number of basis points in a whole, the unit of
+// PercentOff.
+const basisPoints = 10000
+
+var codePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,32}$`)
+
+// NormalizeCode returns code as stored: coupon codes are case-insensitive.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+type Promotion struct {
+	// The coupon code, which identifies the promotion; see NormalizeCode.
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	Type        string `json:"type"`
+
+	// For TypePercentage, in basis points.
+	PercentOff int64 `json:"percent_off,omitempty"`
+
+	// For TypeFixed.
+	AmountOff money.Money `json:"amount_off"`
+
+	// For TypeBuyXGetY: GetQuantity units of GetProductId are free for every
+	// BuyQuantity units of BuyProductId.
+	BuyProductId string `json:"buy_product_id,omitempty"`
+	BuyQuantity  uint   `json:"buy_quantity,omitempty"`
+	GetProductId string `json:"get_product_id,omitempty"`
+	GetQuantity  uint   `json:"get_quantity,omitempty"`
+
+	// Price the items must reach for a discount. None if it has no currency.
+	MinOrderValue money.Money `json:"min_order_value"`
+
+	// Number of orders the coupon can be applied to, in total and per
+	// customer; unlimited if 0.
+	MaxUses            int64 `json:"max_uses"`
+	MaxUsesPerCustomer int64 `json:"max_uses_per_customer"`
+
+	// Number of orders the coupon is applied to.
+	Uses int64 `json:"uses"`
+
+	// Validity window, RFC3339; open-ended if empty.
+	StartsAt string `json:"starts_at,omitempty"`
+	EndsAt   string `json:"ends_at,omitempty"`
+
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+
+	// Incremented on every write, see utils.ETag.
+	Version int64 `json:"version"`
+}
+
+func (p *Promotion) Validate() error {
+	if !codePattern.MatchString(p.Code) {
+		return utils.Errorf(utils.INVALID_ERROR,
+			"code must be 3 to 32 letters, digits, '-' or '_', got %q", p.Code)
+	}
+
+	switch p.Type {
+	case TypePercentage:
+		if p.PercentOff <= 0 || p.PercentOff > basisPoints {
+			return utils.Errorf(utils.INVALID_ERROR, "percent_off must be between 1 and %d", basisPoints)
+		}
+	case TypeFixed:
+		if err := p.AmountOff.Validate(); err != nil {
+			return utils.Errorf(utils.INVALID_ERROR, "invalid amount_off: %v", err)
+		} else if p.AmountOff.IsZero() {
+			return utils.Errorf(utils.INVALID_ERROR, "amount_off is required")
+		}
+	case TypeBuyXGetY:
+		if p.BuyProductId == "" || p.GetProductId == "" {
+			return utils.Errorf(utils.INVALID_ERROR, "buy_product_id and get_product_id are required")
+		}
+		if p.BuyQuantity == 0 || p.GetQuantity == 0 {
+			return utils.Errorf(utils.INVALID_ERROR, "buy_quantity and get_quantity are required")
+		}
+	default:
+		return utils.Errorf(utils.INVALID_ERROR, "unknown discount type %q", p.Type)
+	}
+
+	if p.MinOrderValue.Currency != "" {
+		if err := p.MinOrderValue.Validate(); err != nil {
+			return utils.Errorf(utils.INVALID_ERROR, "invalid min_order_value: %v", err)
+		}
+	}
+
+	if p.MaxUses < 0 || p.MaxUsesPerCustomer < 0 {
+		return utils.Errorf(utils.INVALID_ERROR, "max_uses and max_uses_per_customer must not be negative")
+	}
+
+	startsAt, err := parseTime(p.StartsAt)
+	if err != nil {
+		return utils.Errorf(utils.INVALID_ERROR, "invalid starts_at: %v", err)
+	}
+	endsAt, err := parseTime(p.EndsAt)
+	if err != nil {
+		return utils.Errorf(utils.INVALID_ERROR, "invalid ends_at: %v", err)
+	}
+	if !startsAt.IsZero() && !endsAt.IsZero() && !endsAt.After(startsAt) {
+		return utils.Errorf(utils.INVALID_ERROR, "ends_at must be after starts_at")
+	}
+
+	return nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// Active reports whether now is in the validity window of the promotion.
+func (p *Promotion) Active(now time.Time) bool {
+	if startsAt, err := parseTime(p.StartsAt); err != nil || (!startsAt.IsZero() && now.Before(startsAt)) {
+		return false
+	}
+	if endsAt, err := parseTime(p.EndsAt); err != nil || (!endsAt.IsZero() && !now.Before(endsAt)) {
+		return false
+	}
+	return true
+}
+
+// CheckRedeemable returns an INVALID_ERROR unless the coupon can be applied
+// at now to an order of items, by a customer who applied it to customerUses
+// other orders.
+func (p *Promotion) CheckRedeemable(now time.Time, customerUses int64, items []*orders.OrderItem) error {
+	if !p.Active(now) {
+		return utils.Errorf(utils.INVALID_ERROR, "coupon %s is not valid at this time", p.Code)
+	}
+
+	if p.MaxUses > 0 && p.Uses >= p.MaxUses {
+		return utils.Errorf(utils.INVALID_ERROR, "coupon %s has been used up", p.Code)
+	}
+
+	if p.MaxUsesPerCustomer > 0 && customerUses >= p.MaxUsesPerCustomer {
+		return utils.Errorf(utils.INVALID_ERROR, "coupon %s was already used by the customer", p.Code)
+	}
+
+	if len(items) == 0 {
+		return utils.Errorf(utils.INVALID_ERROR, "coupon %s cannot be applied to an order without items", p.Code)
+	}
+
+	order := &orders.Order{Items: items}
+	subtotal, err := order.Total()
+	if err != nil {
+		return err
+	}
+
+	return p.checkEligible(subtotal)
+}
+
+// checkEligible returns an INVALID_ERROR unless an order whose items cost
+// subtotal is given a discount.
+func (p *Promotion) checkEligible(subtotal money.Money) error {
+	if p.MinOrderValue.Currency != "" {
+		if p.MinOrderValue.Currency != subtotal.Currency {
+			return utils.Errorf(utils.INVALID_ERROR, "coupon %s is for orders in %s", p.Code, p.MinOrderValue.Currency)
+		} else if subtotal.Amount < p.MinOrderValue.Amount {
+			return utils.Errorf(utils.INVALID_ERROR, "coupon %s requires orders of at least %s", p.Code, p.MinOrderValue)
+		}
+	}
+
+	if p.Type == TypeFixed && p.AmountOff.Currency != subtotal.Currency {
+		return utils.Errorf(utils.INVALID_ERROR, "coupon %s is for orders in %s", p.Code, p.AmountOff.Currency)
+	}
+
+	return nil
+}
+
+// Discount returns the discount of the promotion on each of items, which must
+// all be priced in the same currency. The discounts are zero if the items do
+// not reach the minimum order value, or are in another currency.
+func (p *Promotion) Discount(items []*orders.OrderItem) ([]money.Money, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	prices := make([]money.Money, len(items))
+	for i, item := range items {
+		price, err := item.Total()
+		if err != nil {
+			return nil, err
+		}
+		prices[i] = price
+	}
+
+	subtotal, err := money.Sum(prices[0].Currency, prices...)
+	if err != nil {
+		return nil, utils.Errorf(utils.INVALID_ERROR, "%v", err)
+	}
+
+	discounts := make([]money.Money, len(items))
+	for i := range discounts {
+		discounts[i] = money.Zero(subtotal.Currency)
+	}
+
+	if p.checkEligible(subtotal) != nil {
+		return discounts, nil
+	}
+
+	switch p.Type {
+	case TypePercentage:
+		for i, price := range prices {
+			if discounts[i], err = price.MulRatio(p.PercentOff, basisPoints, money.HalfUp); err != nil {
+				return nil, utils.Errorf(utils.INVALID_ERROR, "%v", err)
+			}
+		}
+	case TypeFixed:
+		if err := p.spread(prices, subtotal, discounts); err != nil {
+			return nil, err
+		}
+	case TypeBuyXGetY:
+		if err := p.freeUnits(items, discounts); err != nil {
+			return nil, err
+		}
+	}
+
+	return discounts, nil
+}
+
+// spread divides AmountOff, or subtotal if lower, between the items in
+// proportion to their prices. The minor units left by rounding down go to
+// the first items.
+func (p *Promotion) spread(prices []money.Money, subtotal money.Money, discounts []money.Money) error {
+	amount := p.AmountOff.Amount
+	if amount > subtotal.Amount {
+		amount = subtotal.Amount
+	}
+	if subtotal.Amount == 0 {
+		return nil
+	}
+
+	left := amount
+	for i, price := range prices {
+		share, err := money.New(amount, price.Currency).MulRatio(price.Amount, subtotal.Amount, money.Down)
+		if err != nil {
+			return utils.Errorf(utils.INVALID_ERROR, "%v", err)
+		}
+		discounts[i] = share
+		left -= share.Amount
+	}
+
+	for i := 0; left > 0 && i < len(discounts); i++ {
+		if discounts[i].Amount < prices[i].Amount {
+			discounts[i].Amount++
+			left--
+		}
+	}
+
+	return nil
+}
+
+// freeUnits sets the discounts of the items of GetProductId to the price of
+// their free units.
+func (p *Promotion) freeUnits(items []*orders.OrderItem, discounts []money.Money) error {
+	var bought, wanted uint
+	for _, item := range items {
+		if item.ProductId == p.BuyProductId {
+			bought += item.Quantity
+		}
+		if item.ProductId == p.GetProductId {
+			wanted += item.Quantity
+		}
+	}
+
+	var free uint
+	if p.BuyProductId == p.GetProductId {
+		// The free units are among those bought.
+		free = bought / (p.BuyQuantity + p.GetQuantity) * p.GetQuantity
+	} else {
+		free = bought / p.BuyQuantity * p.GetQuantity
+		if free > wanted {
+			free = wanted
+		}
+	}
+
+	for i, item := range items {
+		if free == 0 {
+			return nil
+		}
+		if item.ProductId != p.GetProductId {
+			continue
+		}
+
+		units := item.Quantity
+		if units > free {
+			units = free
+		}
+		free -= units
+
+		discount, err := item.UnitPrice.Mul(int64(units))
+		if err != nil {
+			return utils.Errorf(utils.INVALID_ERROR, "%v", err)
+		}
+		discounts[i] = discount
+	}
+
+	return nil
+}
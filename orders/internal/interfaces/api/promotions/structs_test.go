@@ -0,0 +1,174 @@
+package promotions_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/promotions"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+func kes(amount int64) money.Money {
+	return money.New(amount, "KES")
+}
+
+func item(productId string, quantity uint, unitPrice int64) *orders.OrderItem {
+	return &orders.OrderItem{ProductId: productId, Quantity: quantity, UnitPrice: kes(unitPrice)}
+}
+
+func TestPromotion_Discount(t *testing.T) {
+	items := []*orders.OrderItem{
+		item("shirt", 2, 1000),
+		item("socks", 3, 333),
+	}
+
+	tests := []struct {
+		name      string
+		promotion promotions.Promotion
+		items     []*orders.OrderItem
+		want      []int64
+	}{
+		{
+			name:      "percentage",
+			promotion: promotions.Promotion{Type: promotions.TypePercentage, PercentOff: 1500},
+			items:     items,
+			// 999 * 15% = 149.85
+			want: []int64{300, 150},
+		},
+		{
+			name:      "fixed spread by price",
+			promotion: promotions.Promotion{Type: promotions.TypeFixed, AmountOff: kes(500)},
+			items:     items,
+			// 500 * 2000/2999 = 333.44 and 500 * 999/2999 = 166.56, the
+			// unit left by rounding down going to the first item.
+			want: []int64{334, 166},
+		},
+		{
+			name:      "fixed above subtotal",
+			promotion: promotions.Promotion{Type: promotions.TypeFixed, AmountOff: kes(5000)},
+			items:     items,
+			want:      []int64{2000, 999},
+		},
+		{
+			name: "fixed in another currency",
+			promotion: promotions.Promotion{
+				Type: promotions.TypeFixed, AmountOff: money.New(500, "USD")},
+			items: items,
+			want:  []int64{0, 0},
+		},
+		{
+			name: "buy shirts get socks",
+			promotion: promotions.Promotion{
+				Type:         promotions.TypeBuyXGetY,
+				BuyProductId: "shirt", BuyQuantity: 1,
+				GetProductId: "socks", GetQuantity: 1,
+			},
+			items: items,
+			want:  []int64{0, 666},
+		},
+		{
+			name: "buy 2 get 1 of the same",
+			promotion: promotions.Promotion{
+				Type:         promotions.TypeBuyXGetY,
+				BuyProductId: "socks", BuyQuantity: 2,
+				GetProductId: "socks", GetQuantity: 1,
+			},
+			items: items,
+			want:  []int64{0, 333},
+		},
+		{
+			name: "below minimum",
+			promotion: promotions.Promotion{
+				Type: promotions.TypePercentage, PercentOff: 1500, MinOrderValue: kes(3000)},
+			items: items,
+			want:  []int64{0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.promotion.Discount(tt.items)
+			if err != nil {
+				t.Fatalf("Discount() error = %v", err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("Discount() = %v, want %v", got, tt.want)
+			}
+			for i, discount := range got {
+				if discount.Amount != tt.want[i] || discount.Currency != "KES" {
+					t.Errorf("Discount()[%d] = %v, want %d", i, discount, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPromotion_CheckRedeemable(t *testing.T) {
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	items := []*orders.OrderItem{item("shirt", 2, 1000)}
+
+	tests := []struct {
+		name         string
+		promotion    promotions.Promotion
+		customerUses int64
+		wantErr      bool
+	}{
+		{
+			name: "redeemable",
+			promotion: promotions.Promotion{
+				StartsAt: "2024-03-29T00:00:00Z", EndsAt: "2024-04-02T00:00:00Z",
+				MaxUses: 10, Uses: 9, MaxUsesPerCustomer: 2, MinOrderValue: kes(2000)},
+			customerUses: 1,
+		},
+		{
+			name:      "not started",
+			promotion: promotions.Promotion{StartsAt: "2024-04-01T00:00:00Z"},
+			wantErr:   true,
+		},
+		{
+			name:      "ended",
+			promotion: promotions.Promotion{EndsAt: "2024-03-31T12:00:00Z"},
+			wantErr:   true,
+		},
+		{
+			name:      "used up",
+			promotion: promotions.Promotion{MaxUses: 10, Uses: 10},
+			wantErr:   true,
+		},
+		{
+			name:         "used by customer",
+			promotion:    promotions.Promotion{MaxUsesPerCustomer: 1},
+			customerUses: 1,
+			wantErr:      true,
+		},
+		{
+			name:      "below minimum",
+			promotion: promotions.Promotion{MinOrderValue: kes(2001)},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.promotion.Type = promotions.TypePercentage
+			tt.promotion.PercentOff = 1000
+
+			err := tt.promotion.CheckRedeemable(now, tt.customerUses, items)
+			if tt.wantErr {
+				if utils.ErrorCode(err) != utils.INVALID_ERROR {
+					t.Errorf("CheckRedeemable() error = %v, want INVALID_ERROR", err)
+				}
+			} else if err != nil {
+				t.Errorf("CheckRedeemable() error = %v", err)
+			}
+		})
+	}
+
+	t.Run("no items", func(t *testing.T) {
+		promotion := promotions.Promotion{Type: promotions.TypePercentage, PercentOff: 1000}
+		if err := promotion.CheckRedeemable(now, 0, nil); utils.ErrorCode(err) != utils.INVALID_ERROR {
+			t.Errorf("CheckRedeemable() error = %v, want INVALID_ERROR", err)
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package returns
+
+import (
+	"context"
+)
+
+// ReturnRepository stores the returns of each order. Returns are checked
+// against the items and shipments of their order, so they are implemented by
+// the order repository.
+type ReturnRepository interface {
+	// RequestReturn asks to send back shipped units of the items of a paid
+	// order. It fails with an INVALID_ERROR if the units were not shipped or
+	// are already in a return that was not rejected, and with an
+	// ABORTED_ERROR if etag is set and is not the current etag of the order,
+	// which lets the client request a return only for the order it saw. The
+	// order itself is left unchanged.
+	RequestReturn(ctx context.Context, ret *Return, etag string) (*Return, error)
+	GetReturn(ctx context.Context, orderId string, returnId string) (*Return, error)
+	ListReturns(ctx context.Context, orderId string) ([]*Return, error)
+
+	// ApproveReturn and RejectReturn decide on a requested return. Rejected
+	// units can be requested again.
+	ApproveReturn(ctx context.Context, orderId string, returnId string) (*Return, error)
+	RejectReturn(ctx context.Context, orderId string, returnId string, reason string) (*Return, error)
+
+	// ReceiveReturn records that the units of an approved return came back,
+	// and whether they were put back in the stock of their variants, which
+	// restock adds them to.
+	ReceiveReturn(ctx context.Context, orderId string, returnId string, restock bool) (*Return, error)
+
+	// MarkReturnRefunded records the refund of a received return with the
+	// reference of the payments service. Marking a refunded return again is
+	// a no-op.
+	MarkReturnRefunded(ctx context.Context, orderId string, returnId string, reference string) (*Return, error)
+}
@@ -0,0 +1,252 @@
+package returns
+
+import (
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/shipments"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// Statuses of a return. A requested return is approved or rejected; the units
+// of an approved return are received and then refunded.
+const (
+	StatusRequested = "requested"
+	StatusApproved  = "approved"
+	StatusRejected  = "rejected"
+	StatusReceived  = "received"
+	StatusRefunded  = "refunded"
+)
+
+// previous is the status a return must have to move to each status.
+var previous = map[string]string{
+	StatusApproved: StatusRequested,
+	StatusRejected: StatusRequested,
+	StatusReceived: StatusApproved,
+	StatusRefunded: StatusReceived,
+}
+
+// Return is a request by the customer to send back some or all of the
+// shipped units of the items of a paid order, and be refunded for them.
+type Return struct {
+	Id      string        `json:"id"`
+	OrderId string        `json:"order_id"`
+	Items   []*ReturnItem `json:"items"`
+	Reason  string        `json:"reason"`
+	Status  string        `json:"status"`
+
+	// Set when the return is rejected.
+	RejectionReason string `json:"rejection_reason,omitempty"`
+
+	// Set when the return is received: whether its units were put back in
+	// stock.
+	Restocked bool `json:"restocked"`
+
+	// What the customer paid for the units, set when the return is requested.
+	RefundAmount money.Money `json:"refund_amount"`
+
+	// Reference of the refund in the payments service, set once refunded.
+	RefundReference string `json:"refund_reference,omitempty"`
+
+	RequestedAt string `json:"requested_at"`
+	ReceivedAt  string `json:"received_at,omitempty"`
+	RefundedAt  string `json:"refunded_at,omitempty"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+
+	// Incremented on every write, see utils.ETag.
+	Version int64 `json:"version"`
+}
+
+// ReturnItem is a number of units of an order item in a return.
+type ReturnItem struct {
+	OrderItemId string `json:"order_item_id"`
+	Quantity    uint   `json:"quantity"`
+}
+
+// Open reports whether the units of the return are, or may be, sent back.
+func (r *Return) Open() bool {
+	return r.Status != StatusRejected
+}
+
+// CheckTransition fails with an INVALID_ERROR unless the return can move to
+// status.
+func (r *Return) CheckTransition(status string) error {
+	from, ok := previous[status]
+	if !ok {
+		return utils.Errorf(utils.INVALID_ERROR, "invalid return status %q", status)
+	}
+
+	if r.Status != from {
+		return utils.Errorf(utils.INVALID_ERROR, "return is %s, not %s", r.Status, from)
+	}
+
+	return nil
+}
+
+func (r *Return) Validate() error {
+	if r.Reason == "" {
+		return utils.Errorf(utils.INVALID_ERROR, "reason is required")
+	}
+
+	if len(r.Items) == 0 {
+		return utils.Errorf(utils.INVALID_ERROR, "items are required")
+	}
+
+	seen := make(map[string]bool)
+	for _, item := range r.Items {
+		if item.OrderItemId == "" {
+			return utils.Errorf(utils.INVALID_ERROR, "order_item_id is required")
+		}
+
+		if item.Quantity == 0 {
+			return utils.Errorf(utils.INVALID_ERROR, "quantity is required")
+		}
+
+		if seen[item.OrderItemId] {
+			return utils.Errorf(utils.INVALID_ERROR, "order item %s is listed twice", item.OrderItemId)
+		}
+		seen[item.OrderItemId] = true
+	}
+
+	return nil
+}
+
+// Returnable returns the number of units of each order item that are in the
+// shipments shipped, see shipments.Shipped, and not in any open return of
+// returns. Bundles are returned whole.
+func Returnable(items []*orders.OrderItem, shipped []*shipments.Shipment, returns []*Return) map[string]uint {
+	returnable := shipments.Shipped(items, shipped)
+
+	for _, ret := range returns {
+		if !ret.Open() {
+			continue
+		}
+
+		for _, item := range ret.Items {
+			if returnable[item.OrderItemId] < item.Quantity {
+				returnable[item.OrderItemId] = 0
+			} else {
+				returnable[item.OrderItemId] -= item.Quantity
+			}
+		}
+	}
+
+	return returnable
+}
+
+// CheckReturnable fails with an INVALID_ERROR unless the units of ret are
+// among those Returnable.
+func CheckReturnable(
+	items []*orders.OrderItem, shipped []*shipments.Shipment, returns []*Return, ret *Return) error {
+
+	returnable := Returnable(items, shipped, returns)
+
+	for _, item := range ret.Items {
+		left, ok := returnable[item.OrderItemId]
+		if !ok {
+			return utils.Errorf(utils.INVALID_ERROR, "order item %s not found", item.OrderItemId)
+		}
+
+		if item.Quantity > left {
+			return utils.Errorf(utils.INVALID_ERROR,
+				"%d units of order item %s can be returned, not %d", left, item.OrderItemId, item.Quantity)
+		}
+	}
+
+	return nil
+}
+
+// RefundAmount returns what the customer paid for the units of ret: their
+// share of the price of each item including tax and less discount. The share
+// of the units of an item in ret and in the other open returns of returns is
+// rounded down, and the units of ret are refunded that share less what the
+// units of the other returns are, so that returning every unit of an item,
+// in as many returns as needed, refunds all that was paid for it.
+func RefundAmount(items []*orders.OrderItem, returns []*Return, ret *Return) (money.Money, error) {
+	byId := make(map[string]*orders.OrderItem, len(items))
+	for _, item := range items {
+		byId[item.Id] = item
+	}
+
+	// The units of each item in the other open returns.
+	earlier := make(map[string]int64)
+	for _, other := range returns {
+		if other == ret || (ret.Id != "" && other.Id == ret.Id) || !other.Open() {
+			continue
+		}
+
+		for _, item := range other.Items {
+			earlier[item.OrderItemId] += int64(item.Quantity)
+		}
+	}
+
+	var total money.Money
+	for i, returned := range ret.Items {
+		item, ok := byId[returned.OrderItemId]
+		if !ok {
+			return money.Money{}, utils.Errorf(utils.INVALID_ERROR, "order item %s not found", returned.OrderItemId)
+		}
+
+		paid, err := itemPaid(item)
+		if err != nil {
+			return money.Money{}, err
+		}
+
+		refund, err := refundShare(paid, item.Quantity, earlier[returned.OrderItemId], returned.Quantity)
+		if err != nil {
+			return money.Money{}, err
+		}
+
+		if i == 0 {
+			total = refund
+		} else if total, err = total.Add(refund); err != nil {
+			return money.Money{}, utils.Errorf(utils.INVALID_ERROR, "%v", err)
+		}
+	}
+
+	return total, nil
+}
+
+// refundShare returns the share of paid, what all the quantity units of an
+// item cost, of returned units returned after earlier ones, see RefundAmount.
+func refundShare(paid money.Money, quantity uint, earlier int64, returned uint) (money.Money, error) {
+	before, err := paid.MulRatio(earlier, int64(quantity), money.Down)
+	if err != nil {
+		return money.Money{}, utils.Errorf(utils.INVALID_ERROR, "%v", err)
+	}
+
+	after, err := paid.MulRatio(earlier+int64(returned), int64(quantity), money.Down)
+	if err != nil {
+		return money.Money{}, utils.Errorf(utils.INVALID_ERROR, "%v", err)
+	}
+
+	refund, err := after.Sub(before)
+	if err != nil {
+		return money.Money{}, utils.Errorf(utils.INVALID_ERROR, "%v", err)
+	}
+
+	return refund, nil
+}
+
+// itemPaid returns what the customer paid for all the units of item.
+func itemPaid(item *orders.OrderItem) (money.Money, error) {
+	if item.Tax != nil {
+		return item.Tax.Total, nil
+	}
+
+	total, err := item.Total()
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	if item.Discount.IsZero() {
+		return total, nil
+	}
+
+	paid, err := total.Sub(item.Discount)
+	if err != nil {
+		return money.Money{}, utils.Errorf(utils.INVALID_ERROR, "%v", err)
+	}
+
+	return paid, nil
+}
@@ -0,0 +1,158 @@
+package returns_test
+
+import (
+	"testing"
+
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/returns"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/shipments"
+	"github.com/leta/order-management-system/orders/pkg/money"
+)
+
+func ret(status string, items ...*returns.ReturnItem) *returns.Return {
+	return &returns.Return{Reason: "damaged", Status: status, Items: items}
+}
+
+func item(orderItemId string, quantity uint) *returns.ReturnItem {
+	return &returns.ReturnItem{OrderItemId: orderItemId, Quantity: quantity}
+}
+
+var orderItems = []*orders.OrderItem{
+	{
+		Id: "shirt", Quantity: 2, UnitPrice: money.New(150000, "KES"),
+		Tax: &orders.ItemTax{Total: money.New(348000, "KES")},
+	},
+	{
+		Id: "socks", Quantity: 3, UnitPrice: money.New(10000, "KES"),
+		Discount: money.New(1000, "KES"),
+	},
+}
+
+var shipped = []*shipments.Shipment{
+	{Items: []*shipments.ShipmentItem{{OrderItemId: "shirt", Quantity: 2}, {OrderItemId: "socks", Quantity: 1}}},
+}
+
+func TestCheckReturnable(t *testing.T) {
+	tests := []struct {
+		name    string
+		returns []*returns.Return
+		ret     *returns.Return
+		wantErr bool
+	}{
+		{
+			name: "shipped units",
+			ret:  ret("", item("shirt", 2), item("socks", 1)),
+		},
+		{
+			name:    "unshipped units",
+			ret:     ret("", item("socks", 2)),
+			wantErr: true,
+		},
+		{
+			name:    "unknown item",
+			ret:     ret("", item("hat", 1)),
+			wantErr: true,
+		},
+		{
+			name:    "units in an open return",
+			returns: []*returns.Return{ret(returns.StatusApproved, item("shirt", 1))},
+			ret:     ret("", item("shirt", 2)),
+			wantErr: true,
+		},
+		{
+			name:    "units in a rejected return",
+			returns: []*returns.Return{ret(returns.StatusRejected, item("shirt", 2))},
+			ret:     ret("", item("shirt", 2)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := returns.CheckReturnable(orderItems, shipped, tt.returns, tt.ret)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckReturnable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRefundAmount(t *testing.T) {
+	tests := []struct {
+		name    string
+		returns []*returns.Return
+		ret     *returns.Return
+		want    money.Money
+	}{
+		{
+			name: "price including tax",
+			ret:  ret("", item("shirt", 1)),
+			want: money.New(174000, "KES"),
+		},
+		{
+			name: "price less discount, rounded down",
+			ret:  ret("", item("socks", 1)),
+			want: money.New(9666, "KES"),
+		},
+		{
+			name: "several items",
+			ret:  ret("", item("shirt", 2), item("socks", 3)),
+			want: money.New(377000, "KES"),
+		},
+		{
+			name:    "after an open return",
+			returns: []*returns.Return{ret(returns.StatusApproved, item("socks", 1))},
+			ret:     ret("", item("socks", 1)),
+			want:    money.New(9667, "KES"),
+		},
+		{
+			name: "last units get the remainder",
+			returns: []*returns.Return{
+				ret(returns.StatusRefunded, item("socks", 1)),
+				ret(returns.StatusRequested, item("socks", 1)),
+			},
+			ret:  ret("", item("socks", 1)),
+			want: money.New(9667, "KES"),
+		},
+		{
+			name:    "after a rejected return",
+			returns: []*returns.Return{ret(returns.StatusRejected, item("socks", 2))},
+			ret:     ret("", item("socks", 1)),
+			want:    money.New(9666, "KES"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := returns.RefundAmount(orderItems, tt.returns, tt.ret)
+			if err != nil {
+				t.Fatalf("RefundAmount() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RefundAmount() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckTransition(t *testing.T) {
+	tests := []struct {
+		from    string
+		to      string
+		wantErr bool
+	}{
+		{from: returns.StatusRequested, to: returns.StatusApproved},
+		{from: returns.StatusRequested, to: returns.StatusRejected},
+		{from: returns.StatusApproved, to: returns.StatusReceived},
+		{from: returns.StatusReceived, to: returns.StatusRefunded},
+		{from: returns.StatusRequested, to: returns.StatusReceived, wantErr: true},
+		{from: returns.StatusRejected, to: returns.StatusApproved, wantErr: true},
+		{from: returns.StatusApproved, to: returns.StatusRefunded, wantErr: true},
+		{from: returns.StatusReceived, to: returns.StatusRequested, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			err := ret(tt.from).CheckTransition(tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckTransition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package shipments
+
+import (
+	"context"
+)
+
+// ShipmentRepository stores the shipments of each order. Shipments are
+// written along with the fulfilment status of their order, so they are
+// implemented by the order repository.
+type ShipmentRepository interface {
+	// CreateShipment ships units of the items of a paid order. It fails with
+	// an INVALID_ERROR if the order has no shipping address or the units were
+	// already shipped, and with an ABORTED_ERROR if etag is set and is not
+	// the current etag of the order.
+	CreateShipment(ctx context.Context, shipment *Shipment, etag string) (*Shipment, error)
+	GetShipment(ctx context.Context, orderId string, shipmentId string) (*Shipment, error)
+	ListShipments(ctx context.Context, orderId string) ([]*Shipment, error)
+
+	// MarkShipmentDelivered records that a shipment reached the customer.
+	// Marking a delivered shipment again is a no-op.
+	MarkShipmentDelivered(ctx context.Context, orderId string, shipmentId string) (*Shipment, error)
+
+	// ListOrderLines returns the lines an order is shipped in, see
+	// orders.Lines, with the units of each left to ship.
+	ListOrderLines(ctx context.Context, orderId string) ([]*Line, error)
+}
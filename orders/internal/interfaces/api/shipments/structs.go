@@ -0,0 +1,184 @@
+package shipments
+
+import (
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// Statuses of a shipment.
+const (
+	StatusShipped   = "shipped"
+	StatusDelivered = "delivered"
+)
+
+// Shipment is a parcel sent to the customer with some or all of the units of
+// the lines of a paid order, see orders.Lines. An order is shipped in as many shipments as
+// needed, each to the shipping address of the order.
+type Shipment struct {
+	Id             string          `json:"id"`
+	OrderId        string          `json:"order_id"`
+	Carrier        string          `json:"carrier"`
+	TrackingNumber string          `json:"tracking_number"`
+	Items          []*ShipmentItem `json:"items"`
+	Status         string          `json:"status"`
+	ShippedAt      string          `json:"shipped_at"`
+	DeliveredAt    string          `json:"delivered_at,omitempty"`
+	CreatedAt      string          `json:"created_at"`
+	UpdatedAt      string          `json:"updated_at"`
+
+	// Incremented on every write, see utils.ETag.
+	Version int64 `json:"version"`
+}
+
+// ShipmentItem is a number of units of a line of an order in a shipment: an
+// order item, or a component of an item of a bundle.
+type ShipmentItem struct {
+	OrderItemId string `json:"order_item_id"`
+	Quantity    uint   `json:"quantity"`
+}
+
+// Line is a line of an order, see orders.Lines, with the number of its units
+// not in any shipment.
+type Line struct {
+	Item      *orders.OrderItem
+	Unshipped uint
+}
+
+// Delivered reports whether the shipment reached the customer.
+func (s *Shipment) Delivered() bool {
+	return s.Status == StatusDelivered
+}
+
+func (s *Shipment) Validate() error {
+	if s.Carrier == "" {
+		return utils.Errorf(utils.INVALID_ERROR, "carrier is required")
+	}
+
+	if s.TrackingNumber == "" {
+		return utils.Errorf(utils.INVALID_ERROR, "tracking_number is required")
+	}
+
+	if len(s.Items) == 0 {
+		return utils.Errorf(utils.INVALID_ERROR, "items are required")
+	}
+
+	seen := make(map[string]bool)
+	for _, item := range s.Items {
+		if item.OrderItemId == "" {
+			return utils.Errorf(utils.INVALID_ERROR, "order_item_id is required")
+		}
+
+		if item.Quantity == 0 {
+			return utils.Errorf(utils.INVALID_ERROR, "quantity is required")
+		}
+
+		if seen[item.OrderItemId] {
+			return utils.Errorf(utils.INVALID_ERROR, "order item %s is listed twice", item.OrderItemId)
+		}
+		seen[item.OrderItemId] = true
+	}
+
+	return nil
+}
+
+// Unshipped returns the number of units of each line of the order items not
+// in any of shipments, by line ID.
+func Unshipped(items []*orders.OrderItem, shipments []*Shipment) map[string]uint {
+	unshipped := make(map[string]uint)
+	for _, line := range orders.Lines(items) {
+		unshipped[line.Id] += line.Quantity
+	}
+
+	for _, shipment := range shipments {
+		for _, item := range shipment.Items {
+			if unshipped[item.OrderItemId] < item.Quantity {
+				unshipped[item.OrderItemId] = 0
+			} else {
+				unshipped[item.OrderItemId] -= item.Quantity
+			}
+		}
+	}
+
+	return unshipped
+}
+
+// Shipped returns the number of units of each order item in shipments. Only
+// the units of an item of a bundle of which every component was shipped
+// count.
+func Shipped(items []*orders.OrderItem, shipments []*Shipment) map[string]uint {
+	lines := make(map[string]uint)
+	for _, shipment := range shipments {
+		for _, item := range shipment.Items {
+			lines[item.OrderItemId] += item.Quantity
+		}
+	}
+
+	shipped := make(map[string]uint)
+	for _, item := range items {
+		if len(item.Components) == 0 {
+			shipped[item.Id] = lines[item.Id]
+			continue
+		}
+
+		units := item.Quantity
+		for i, component := range item.Components {
+			if n := lines[orders.LineId(item.Id, i)] / component.Quantity; n < units {
+				units = n
+			}
+		}
+		shipped[item.Id] = units
+	}
+
+	return shipped
+}
+
+// CheckShippable fails with an INVALID_ERROR unless the units of shipment
+// are among those of the lines of the order items not in any of shipped.
+func CheckShippable(items []*orders.OrderItem, shipped []*Shipment, shipment *Shipment) error {
+	unshipped := Unshipped(items, shipped)
+
+	bundles := make(map[string]bool)
+	for _, item := range items {
+		bundles[item.Id] = len(item.Components) > 0
+	}
+
+	for _, item := range shipment.Items {
+		left, ok := unshipped[item.OrderItemId]
+		if !ok && bundles[item.OrderItemId] {
+			return utils.Errorf(utils.INVALID_ERROR,
+				"order item %s is a bundle, its components are shipped instead", item.OrderItemId)
+		} else if !ok {
+			return utils.Errorf(utils.INVALID_ERROR, "order item %s not found", item.OrderItemId)
+		}
+
+		if item.Quantity > left {
+			return utils.Errorf(utils.INVALID_ERROR,
+				"%d units of order item %s are left to ship, not %d", left, item.OrderItemId, item.Quantity)
+		}
+	}
+
+	return nil
+}
+
+// Fulfilment returns the fulfilment status of an order with the items, shipped
+// in shipments. An order is delivered once every unit of its lines was
+// shipped and every shipment delivered.
+func Fulfilment(items []*orders.OrderItem, shipments []*Shipment) utils.FulfilmentStatus {
+	if len(shipments) == 0 {
+		return utils.FulfilmentStatusUnfulfilled
+	}
+
+	for _, left := range Unshipped(items, shipments) {
+		if left > 0 {
+			return utils.FulfilmentStatusPartiallyShipped
+		}
+	}
+
+	for _, shipment := range shipments {
+		if !shipment.Delivered() {
+			return utils.FulfilmentStatusShipped
+		}
+	}
+
+	return utils.FulfilmentStatusDelivered
+}
@@ -0,0 +1,219 @@
+package shipments_test
+
+import (
+	"testing"
+
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/shipments"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+func shipment(status string, items ...*shipments.ShipmentItem) *shipments.Shipment {
+	return &shipments.Shipment{Carrier: "G4S", TrackingNumber: "KE123", Status: status, Items: items}
+}
+
+func item(orderItemId string, quantity uint) *shipments.ShipmentItem {
+	return &shipments.ShipmentItem{OrderItemId: orderItemId, Quantity: quantity}
+}
+
+var orderItems = []*orders.OrderItem{
+	{Id: "shirt", Quantity: 2},
+	{Id: "socks", Quantity: 3},
+}
+
+func TestFulfilment(t *testing.T) {
+	tests := []struct {
+		name      string
+		shipments []*shipments.Shipment
+		want      utils.FulfilmentStatus
+	}{
+		{
+			name: "not shipped",
+			want: utils.FulfilmentStatusUnfulfilled,
+		},
+		{
+			name: "some units shipped",
+			shipments: []*shipments.Shipment{
+				shipment(shipments.StatusDelivered, item("shirt", 2), item("socks", 1)),
+			},
+			want: utils.FulfilmentStatusPartiallyShipped,
+		},
+		{
+			name: "every unit shipped",
+			shipments: []*shipments.Shipment{
+				shipment(shipments.StatusDelivered, item("shirt", 2)),
+				shipment(shipments.StatusShipped, item("socks", 3)),
+			},
+			want: utils.FulfilmentStatusShipped,
+		},
+		{
+			name: "every shipment delivered",
+			shipments: []*shipments.Shipment{
+				shipment(shipments.StatusDelivered, item("shirt", 2)),
+				shipment(shipments.StatusDelivered, item("socks", 3)),
+			},
+			want: utils.FulfilmentStatusDelivered,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shipments.Fulfilment(orderItems, tt.shipments); got != tt.want {
+				t.Errorf("Fulfilment() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckShippable(t *testing.T) {
+	shipped := []*shipments.Shipment{
+		shipment(shipments.StatusShipped, item("shirt", 1)),
+	}
+
+	tests := []struct {
+		name     string
+		shipment *shipments.Shipment
+		wantErr  bool
+	}{
+		{
+			name:     "units left",
+			shipment: shipment("", item("shirt", 1), item("socks", 3)),
+		},
+		{
+			name:     "units already shipped",
+			shipment: shipment("", item("shirt", 2)),
+			wantErr:  true,
+		},
+		{
+			name:     "unknown order item",
+			shipment: shipment("", item("hat", 1)),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := shipments.CheckShippable(orderItems, shipped, tt.shipment)
+			if tt.wantErr {
+				if utils.ErrorCode(err) != utils.INVALID_ERROR {
+					t.Errorf("CheckShippable() error = %v, want INVALID_ERROR", err)
+				}
+			} else if err != nil {
+				t.Errorf("CheckShippable() error = %v", err)
+			}
+		})
+	}
+}
+
+// kit is an item of a bundle of two pens and a pad, shipped as lines kit-1
+// and kit-2.
+var kit = &orders.OrderItem{
+	Id:       "kit",
+	Quantity: 2,
+	Components: []*orders.Component{
+		{ProductId: "pen", Quantity: 2},
+		{ProductId: "pad", Quantity: 1},
+	},
+}
+
+func TestCheckShippable_bundle(t *testing.T) {
+	tests := []struct {
+		name     string
+		shipment *shipments.Shipment
+		wantErr  bool
+	}{
+		{
+			name:     "components",
+			shipment: shipment("", item("kit-1", 4), item("kit-2", 1)),
+		},
+		{
+			name:     "more units than in the bundles",
+			shipment: shipment("", item("kit-2", 3)),
+			wantErr:  true,
+		},
+		{
+			name:     "bundle",
+			shipment: shipment("", item("kit", 1)),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := shipments.CheckShippable([]*orders.OrderItem{kit}, nil, tt.shipment)
+			if tt.wantErr {
+				if utils.ErrorCode(err) != utils.INVALID_ERROR {
+					t.Errorf("CheckShippable() error = %v, want INVALID_ERROR", err)
+				}
+			} else if err != nil {
+				t.Errorf("CheckShippable() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestShipped(t *testing.T) {
+	tests := []struct {
+		name      string
+		shipments []*shipments.Shipment
+		want      uint
+	}{
+		{
+			name: "not shipped",
+			want: 0,
+		},
+		{
+			name: "some components shipped",
+			shipments: []*shipments.Shipment{
+				shipment(shipments.StatusShipped, item("kit-1", 4)),
+			},
+			want: 0,
+		},
+		{
+			name: "components of one bundle shipped",
+			shipments: []*shipments.Shipment{
+				shipment(shipments.StatusShipped, item("kit-1", 3)),
+				shipment(shipments.StatusShipped, item("kit-2", 2)),
+			},
+			want: 1,
+		},
+		{
+			name: "every component shipped",
+			shipments: []*shipments.Shipment{
+				shipment(shipments.StatusShipped, item("kit-1", 4), item("kit-2", 2)),
+			},
+			want: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := []*orders.OrderItem{kit, orderItems[0]}
+			shipped := shipments.Shipped(items, tt.shipments)
+			if got := shipped["kit"]; got != tt.want {
+				t.Errorf("Shipped()[kit] = %v, want %v", got, tt.want)
+			}
+			if got := shipped["shirt"]; got != 0 {
+				t.Errorf("Shipped()[shirt] = %v, want 0", got)
+			}
+		})
+	}
+}
+
+func TestShipment_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		shipment *shipments.Shipment
+		wantErr  bool
+	}{
+		{name: "valid", shipment: shipment("", item("shirt", 1))},
+		{name: "no items", shipment: shipment(""), wantErr: true},
+		{name: "no quantity", shipment: shipment("", item("shirt", 0)), wantErr: true},
+		{name: "item listed twice", shipment: shipment("", item("shirt", 1), item("shirt", 1)), wantErr: true},
+		{name: "no carrier", shipment: &shipments.Shipment{TrackingNumber: "KE123",
+			Items: []*shipments.ShipmentItem{item("shirt", 1)}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.shipment.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+)
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *Subscription) (*Subscription, error)
+	GetSubscription(ctx context.Context, id string) (*Subscription, error)
+	ListSubscriptions(ctx context.Context) ([]*Subscription, error)
+	DeleteSubscription(ctx context.Context, id string) error
+
+	// ListSubscriptionsFor returns the subscriptions receiving eventType.
+	ListSubscriptionsFor(ctx context.Context, eventType string) ([]*Subscription, error)
+
+	// CreateDelivery stores a pending delivery. It does nothing if a delivery
+	// with the same Id exists, so an event seen twice is delivered once.
+	CreateDelivery(ctx context.Context, delivery *Delivery) error
+
+	// ClaimDueDeliveries returns up to limit pending deliveries whose next
+	// attempt is due at now, and postpones them by lease so that no other
+	// worker attempts them meanwhile.
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*Delivery, error)
+
+	// RecordAttempt stores the outcome of an attempt along with the updated
+	// delivery.
+	RecordAttempt(ctx context.Context, delivery *Delivery, attempt *Attempt) error
+
+	// ListDeliveries returns the deliveries of a subscription, newest first,
+	// only those with the given status if it is not empty.
+	ListDeliveries(ctx context.Context, subscriptionId string, status string) ([]*Delivery, error)
+
+	// ListAttempts returns the attempts of a subscription's deliveries, newest
+	// first, only those of deliveryId if it is not empty.
+	ListAttempts(ctx context.Context, subscriptionId string, deliveryId string) ([]*Attempt, error)
+
+	// RetryDelivery moves a dead-lettered delivery back to pending, due now.
+	RetryDelivery(ctx context.Context, subscriptionId string, id string) (*Delivery, error)
+}
@@ -0,0 +1,115 @@
+package webhooks
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// Events partners can subscribe to.
+const (
+	EventOrderCreated   = "order.created"
+	EventOrderPaid      = "order.paid"
+	EventOrderFailed    = "order.failed"
+	EventOrderCancelled = "order.cancelled"
+	EventOrderExpired   = "order.expired"
+)
+
+var EventTypes = []string{
+	EventOrderCreated,
+	EventOrderPaid,
+	EventOrderFailed,
+	EventOrderCancelled,
+	EventOrderExpired,
+}
+
+// Statuses of a delivery.
+const (
+	DeliveryPending    = "pending"
+	DeliveryDelivered  = "delivered"
+	DeliveryDeadLetter = "dead_letter"
+)
+
+type Subscription struct {
+	Id         string   `json:"id"`
+	Url        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"-"`
+	CreatedAt  string   `json:"created_at"`
+	UpdatedAt  string   `json:"updated_at"`
+}
+
+func (s *Subscription) Validate() error {
+	if s.Url == "" {
+		return utils.Errorf(utils.INVALID_ERROR, "url is required")
+	}
+
+	u, err := url.Parse(s.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return utils.Errorf(utils.INVALID_ERROR, "url must be an absolute http or https URL")
+	}
+
+	if len(s.EventTypes) == 0 {
+		return utils.Errorf(utils.INVALID_ERROR, "event_types are required")
+	}
+
+	for _, eventType := range s.EventTypes {
+		if !IsEventType(eventType) {
+			return utils.Errorf(utils.INVALID_ERROR, "unknown event type %q", eventType)
+		}
+	}
+
+	if len(s.Secret) < 16 {
+		return utils.Errorf(utils.INVALID_ERROR, "secret must be at least 16 characters")
+	}
+
+	return nil
+}
+
+// Subscribes reports whether the subscription receives events of eventType.
+func (s *Subscription) Subscribes(eventType string) bool {
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func IsEventType(eventType string) bool {
+	for _, t := range EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery is an event to be sent, or sent, to one subscription. Its body is
+// fixed when it is created so that every attempt sends the same payload.
+type Delivery struct {
+	Id             string    `json:"id"`
+	SubscriptionId string    `json:"subscription_id"`
+	EventId        string    `json:"event_id"`
+	EventType      string    `json:"event_type"`
+	Body           []byte    `json:"-"`
+	Status         string    `json:"status"`
+	Attempts       uint      `json:"attempts"`
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	LastError      string    `json:"last_error"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Attempt records the outcome of one HTTP request of a delivery.
+type Attempt struct {
+	Id             string        `json:"id"`
+	SubscriptionId string        `json:"subscription_id"`
+	DeliveryId     string        `json:"delivery_id"`
+	Attempt        uint          `json:"attempt"`
+	StatusCode     int           `json:"status_code"`
+	Error          string        `json:"error"`
+	Duration       time.Duration `json:"duration"`
+	AttemptedAt    time.Time     `json:"attempted_at"`
+}
@@ -0,0 +1,176 @@
+// Package migrate rewrites documents stored in earlier formats.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/models"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// Actor is recorded in the audit log as the author of migrations.
+const Actor = "system:migrate"
+
+// Money rewrites the documents stored before amounts carried a currency, and
+// returns the number rewritten: product prices, in major units of
+// money.DefaultCurrency, are converted to minor units, and order items are
+// given the price of their product as unit price.
+//
+// Documents are read the same way before and after, so the service can run
+// during the migration. Each document is rewritten in its own transaction
+// that checks it still needs it, which makes the migration safe to rerun.
+func Money(ctx context.Context, client *firestore.Client) (int, error) {
+	ctx = audit.WithActor(ctx, Actor)
+	ctx = audit.WithRequestID(ctx, audit.NewRequestID())
+
+	products, err := migrateDocuments(ctx, client, client.Collection("products").Documents(ctx), migrateProduct)
+	if err != nil {
+		return products, fmt.Errorf("failed to migrate products: %w", err)
+	}
+	log.Printf("[migrate] converted the prices of %d products", products)
+
+	items, err := migrateDocuments(ctx, client, client.CollectionGroup("items").Documents(ctx), migrateOrderItem)
+	if err != nil {
+		return products + items, fmt.Errorf("failed to migrate order items: %w", err)
+	}
+	log.Printf("[migrate] priced %d order items", items)
+
+	return products + items, nil
+}
+
+// migrateFunc rewrites the document ref in tx if needed, and reports whether
+// it did.
+type migrateFunc func(ctx context.Context, client *firestore.Client, tx *firestore.Transaction,
+	ref *firestore.DocumentRef) (bool, error)
+
+func migrateDocuments(
+	ctx context.Context, client *firestore.Client, iter *firestore.DocumentIterator, migrate migrateFunc) (int, error) {
+
+	defer iter.Stop()
+
+	migrated := 0
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return migrated, nil
+		} else if err != nil {
+			return migrated, err
+		}
+
+		var rewritten bool
+		err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) (err error) {
+			rewritten, err = migrate(ctx, client, tx, doc.Ref)
+			return err
+		})
+		if err != nil {
+			return migrated, fmt.Errorf("%s: %w", doc.Ref.Path, err)
+		}
+
+		if rewritten {
+			migrated++
+		}
+	}
+}
+
+func migrateProduct(
+	ctx context.Context, client *firestore.Client, tx *firestore.Transaction, ref *firestore.DocumentRef) (bool, error) {
+
+	doc, err := tx.Get(ref)
+	if err != nil {
+		return false, err
+	}
+
+	var product models.ProductModel
+	if err := doc.DataTo(&product); err != nil {
+		return false, err
+	}
+
+	if product.Currency != "" {
+		return false, nil
+	}
+
+	price := money.FromStored(product.Price, product.Currency)
+
+	migrated := product
+	migrated.Price = price.Amount
+	migrated.Currency = price.Currency
+
+	err = tx.Update(ref, []firestore.Update{
+		{Path: "price", Value: migrated.Price},
+		{Path: "currency", Value: migrated.Currency},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, record(ctx, client, tx, audit.EntityProduct, ref.ID, &product, &migrated)
+}
+
+func migrateOrderItem(
+	ctx context.Context, client *firestore.Client, tx *firestore.Transaction, ref *firestore.DocumentRef) (bool, error) {
+
+	// Only the items of orders are priced.
+	order := ref.Parent.Parent
+	if order == nil || order.Parent.ID != "orders" || order.Parent.Parent != nil {
+		return false, nil
+	}
+
+	doc, err := tx.Get(ref)
+	if err != nil {
+		return false, err
+	}
+
+	var item models.OrderItemModel
+	if err := doc.DataTo(&item); err != nil {
+		return false, err
+	}
+
+	if item.Currency != "" {
+		return false, nil
+	}
+
+	productDoc, err := tx.Get(client.Collection("products").Doc(item.ProductId))
+	if err != nil {
+		return false, fmt.Errorf("failed to get product %s: %w", item.ProductId, err)
+	}
+
+	var product models.ProductModel
+	if err := productDoc.DataTo(&product); err != nil {
+		return false, err
+	}
+
+	price := money.FromStored(product.Price, product.Currency)
+
+	migrated := item
+	migrated.UnitPrice = price.Amount
+	migrated.Currency = price.Currency
+
+	err = tx.Update(ref, []firestore.Update{
+		{Path: "unit_price", Value: migrated.UnitPrice},
+		{Path: "currency", Value: migrated.Currency},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, record(ctx, client, tx, audit.EntityOrderItem, order.ID+"/"+ref.ID, &item, &migrated)
+}
+
+// record adds the audit event of the migration of a document to tx.
+func record(ctx context.Context, client *firestore.Client, tx *firestore.Transaction,
+	entityType string, id string, before, after any) error {
+
+	event, err := audit.New(ctx, entityType, id, audit.ActionUpdate, before, after)
+	if err != nil {
+		return err
+	}
+
+	return audit.NewFirestoreLog(client, utils.SERVICE_NAME).Record(tx, event)
+}
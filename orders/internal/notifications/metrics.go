@@ -0,0 +1,11 @@
+package notifications
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var notificationsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "notifications_total",
+	Help: "Number of customer notifications processed, by channel, template and status.",
+}, []string{"channel", "template", "status"})
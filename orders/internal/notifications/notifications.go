@@ -0,0 +1,113 @@
+// Package notifications tells customers about their orders by SMS and email.
+//
+// A Notifier renders a template for every order event customers care about
+// and sends it over each configured channel, unless the customer opted out.
+// Every message is recorded, along with whether it was sent.
+package notifications
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Senders that can be configured for a channel.
+const (
+	SenderNone       = "none"
+	SenderLog        = "log"
+	SenderSMTP       = "smtp"
+	SenderSMSGateway = "gateway"
+)
+
+// Options configure the senders of each channel.
+type Options struct {
+	// Sender of SMS: "gateway", "log" or "none".
+	SMS string `yaml:"sms" env:"SMS_SENDER"`
+
+	// Sender of emails: "smtp", "log" or "none".
+	Email string `yaml:"email" env:"EMAIL_SENDER"`
+
+	// File the log sender appends messages to, as JSON lines. Messages are
+	// written to the service log if it is empty.
+	LogFile string `yaml:"log_file" env:"LOG_FILE"`
+
+	// Directory overriding the built-in templates, see Templates.
+	TemplatesDir string `yaml:"templates_dir" env:"TEMPLATES_DIR"`
+
+	// Number of attempts to send a message before giving up.
+	MaxAttempts int `yaml:"max_attempts" env:"MAX_ATTEMPTS"`
+
+	SMTP       SMTPOptions       `yaml:"smtp" env:"SMTP_"`
+	SMSGateway SMSGatewayOptions `yaml:"sms_gateway" env:"SMS_GATEWAY_"`
+}
+
+// SMTPOptions configure the SMTP sender.
+type SMTPOptions struct {
+	// host:port of the SMTP server. STARTTLS is used when offered.
+	Addr     string `yaml:"addr" env:"ADDR"`
+	Username string `yaml:"username" env:"USERNAME"`
+	Password string `yaml:"password" env:"PASSWORD"`
+	From     string `yaml:"from" env:"FROM"`
+}
+
+// SMSGatewayOptions configure the SMS gateway sender.
+type SMSGatewayOptions struct {
+	// Endpoint messages are POSTed to, authenticated with the API key as a
+	// bearer token.
+	URL    string `yaml:"url" env:"URL"`
+	APIKey string `yaml:"api_key" env:"API_KEY"`
+
+	// Sender ID or short code the messages appear to come from.
+	SenderID string        `yaml:"sender_id" env:"SENDER_ID"`
+	Timeout  time.Duration `yaml:"timeout" env:"TIMEOUT"`
+}
+
+// DefaultOptions returns the options used when nothing else is specified.
+// Messages are only logged until real senders are configured.
+func DefaultOptions() Options {
+	return Options{
+		SMS:         SenderLog,
+		Email:       SenderLog,
+		MaxAttempts: 5,
+		SMSGateway: SMSGatewayOptions{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Validate reports every invalid option. name prefixes the error messages.
+func (o *Options) Validate(name string) error {
+	var errs []error
+
+	switch o.SMS {
+	case SenderNone, SenderLog:
+	case SenderSMSGateway:
+		if o.SMSGateway.URL == "" {
+			errs = append(errs, fmt.Errorf("%s.sms_gateway.url is required with the gateway sender", name))
+		}
+		if o.SMSGateway.Timeout <= 0 {
+			errs = append(errs, fmt.Errorf("%s.sms_gateway.timeout must be positive", name))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("%s.sms %q is not one of gateway, log or none", name, o.SMS))
+	}
+
+	switch o.Email {
+	case SenderNone, SenderLog:
+	case SenderSMTP:
+		if o.SMTP.Addr == "" {
+			errs = append(errs, fmt.Errorf("%s.smtp.addr is required with the smtp sender", name))
+		}
+		if o.SMTP.From == "" {
+			errs = append(errs, fmt.Errorf("%s.smtp.from is required with the smtp sender", name))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("%s.email %q is not one of smtp, log or none", name, o.Email))
+	}
+
+	if o.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("%s.max_attempts must be at least 1", name))
+	}
+
+	return errors.Join(errs...)
+}
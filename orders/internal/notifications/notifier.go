@@ -0,0 +1,225 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/leta/order-management-system/orders/generated"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/customers"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/notifications"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/pkg/events"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// Notifier notifies customers of the events of their orders. Each event
+// yields one notification per channel, keyed by event and channel, so events
+// seen twice are only notified once.
+//
+// A message that cannot be sent fails the event, so that it is delivered
+// again, until MaxAttempts attempts were made.
+type Notifier struct {
+	orderRepository    orders.OrderRepository
+	customerRepository customers.CustomerRepositoryInterface
+	repository         notifications.NotificationRepository
+
+	templates *Templates
+	senders   map[string]Sender
+
+	MaxAttempts int
+}
+
+// NewNotifier creates a new instance of Notifier sending over the channels of
+// senders, see NewSenders.
+func NewNotifier(
+	orderRepository orders.OrderRepository,
+	customerRepository customers.CustomerRepositoryInterface,
+	repository notifications.NotificationRepository,
+	templates *Templates,
+	senders map[string]Sender) *Notifier {
+
+	return &Notifier{
+		orderRepository:    orderRepository,
+		customerRepository: customerRepository,
+		repository:         repository,
+		templates:          templates,
+		senders:            senders,
+		MaxAttempts:        DefaultOptions().MaxAttempts,
+	}
+}
+
+func (n *Notifier) CheckPreconditions() {
+	if n.orderRepository == nil {
+		panic("orderRepository is required")
+	}
+
+	if n.customerRepository == nil {
+		panic("customerRepository is required")
+	}
+
+	if n.repository == nil {
+		panic("repository is required")
+	}
+
+	if n.templates == nil {
+		panic("templates are required")
+	}
+}
+
+// Register subscribes the notifier to the order events routed by r.
+func (n *Notifier) Register(r *events.Router) {
+	r.Handle(events.TypeOf(&generated.OrderCreated{}), n.handleOrderCreated)
+	r.Handle(events.TypeOf(&generated.OrderStatusChanged{}), n.handleOrderStatusChanged)
+}
+
+func (n *Notifier) handleOrderCreated(ctx context.Context, event *events.Event) error {
+	var order generated.OrderCreated
+	if err := event.Decode(&order); err != nil {
+		return err
+	}
+
+	return n.notify(ctx, event, notifications.TemplateOrderPlaced, order.GetOrderId())
+}
+
+func (n *Notifier) handleOrderStatusChanged(ctx context.Context, event *events.Event) error {
+	var change generated.OrderStatusChanged
+	if err := event.Decode(&change); err != nil {
+		return err
+	}
+
+	switch change.GetTo() {
+	case generated.OrderStatus_PAID:
+		return n.notify(ctx, event, notifications.TemplatePaymentReceived, change.GetOrderId())
+	case generated.OrderStatus_FAILED:
+		return n.notify(ctx, event, notifications.TemplatePaymentFailed, change.GetOrderId())
+	case generated.OrderStatus_CANCELLED:
+		return n.notify(ctx, event, notifications.TemplateOrderCancelled, change.GetOrderId())
+	case generated.OrderStatus_EXPIRED:
+		return n.notify(ctx, event, notifications.TemplateOrderExpired, change.GetOrderId())
+	default:
+		return nil
+	}
+}
+
+func (n *Notifier) notify(ctx context.Context, event *events.Event, template string, orderId string) error {
+	n.CheckPreconditions()
+
+	if len(n.senders) == 0 {
+		return nil
+	}
+
+	order, err := n.orderRepository.GetOrder(ctx, orderId)
+	if err != nil {
+		return err
+	}
+
+	customer, err := n.customerRepository.GetCustomer(ctx, order.CustomerId)
+	if utils.ErrorCode(err) == utils.NOT_FOUND_ERROR {
+		log.Printf("[notifications] not sending %s of order %s: customer %s not found", template, order.Id, order.CustomerId)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	data := &TemplateData{Customer: customer, Order: order}
+
+	var errs []error
+	for _, channel := range notifications.Channels {
+		sender, ok := n.senders[channel]
+		if !ok {
+			continue
+		}
+
+		if err := n.send(ctx, event, template, channel, sender, data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (n *Notifier) send(
+	ctx context.Context, event *events.Event, template string, channel string, sender Sender, data *TemplateData) error {
+
+	id := event.ID + "-" + channel
+
+	notification, err := n.repository.GetNotification(ctx, id)
+	if utils.ErrorCode(err) == utils.NOT_FOUND_ERROR {
+		notification = &notifications.Notification{
+			Id:         id,
+			CustomerId: data.Customer.Id,
+			OrderId:    data.Order.Id,
+			EventId:    event.ID,
+			Template:   template,
+			Channel:    channel,
+			Status:     notifications.StatusPending,
+			CreatedAt:  time.Now().UTC(),
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if notification.Done() {
+		return nil
+	}
+
+	recipient, optedOut := data.Customer.Phone, data.Customer.SmsOptOut
+	if channel == notifications.ChannelEmail {
+		recipient, optedOut = data.Customer.Email, data.Customer.EmailOptOut
+	}
+
+	notification.Recipient = recipient
+	notification.UpdatedAt = time.Now().UTC()
+
+	var sendErr error
+
+	switch {
+	case optedOut:
+		notification.Status = notifications.StatusSkipped
+		notification.Error = "customer opted out of " + channel
+	case recipient == "":
+		notification.Status = notifications.StatusSkipped
+		notification.Error = "customer has no " + channel + " address"
+	default:
+		subject, body, err := n.templates.Render(template, channel, data)
+		if err != nil {
+			notification.Status = notifications.StatusFailed
+			notification.Error = err.Error()
+			break
+		}
+
+		notification.Subject = subject
+		notification.Body = body
+		notification.Attempts++
+
+		sendErr = sender.Send(ctx, &Message{
+			Channel: channel,
+			To:      recipient,
+			Subject: subject,
+			Body:    body,
+		})
+
+		switch {
+		case sendErr == nil:
+			notification.Status = notifications.StatusSent
+			notification.Error = ""
+		case int(notification.Attempts) >= n.MaxAttempts:
+			notification.Status = notifications.StatusFailed
+			notification.Error = sendErr.Error()
+			log.Printf("[notifications] gave up on %s after %d attempts: %v", id, notification.Attempts, sendErr)
+			sendErr = nil
+		default:
+			notification.Error = sendErr.Error()
+		}
+	}
+
+	notificationsProcessed.WithLabelValues(channel, template, notification.Status).Inc()
+
+	if err := n.repository.SaveNotification(ctx, notification); err != nil {
+		return err
+	}
+
+	return sendErr
+}
@@ -0,0 +1,246 @@
+package notifications_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/leta/order-management-system/orders/generated"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/customers"
+	api "github.com/leta/order-management-system/orders/internal/interfaces/api/notifications"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/internal/notifications"
+	"github.com/leta/order-management-system/orders/pkg/events"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// orderRepository returns a fixed order. Other methods are not used.
+type orderRepository struct {
+	orders.OrderRepository
+}
+
+func (r *orderRepository) GetOrder(ctx context.Context, id string) (*orders.Order, error) {
+	return &orders.Order{Id: id, CustomerId: "customer-1", Items: []*orders.OrderItem{{ProductId: "p", Quantity: 1}}}, nil
+}
+
+// customerRepository returns a fixed customer. Other methods are not used.
+type customerRepository struct {
+	customers.CustomerRepositoryInterface
+
+	customer *customers.Customer
+}
+
+func (r *customerRepository) GetCustomer(ctx context.Context, id string) (*customers.Customer, error) {
+	return r.customer, nil
+}
+
+type notificationRepository struct {
+	notifications map[string]*api.Notification
+}
+
+func (r *notificationRepository) GetNotification(ctx context.Context, id string) (*api.Notification, error) {
+	n, ok := r.notifications[id]
+	if !ok {
+		return nil, utils.Errorf(utils.NOT_FOUND_ERROR, "notification not found")
+	}
+	copied := *n
+	return &copied, nil
+}
+
+func (r *notificationRepository) SaveNotification(ctx context.Context, n *api.Notification) error {
+	copied := *n
+	r.notifications[n.Id] = &copied
+	return nil
+}
+
+func (r *notificationRepository) ListNotifications(ctx context.Context, customerId string) ([]*api.Notification, error) {
+	return nil, errors.New("not implemented")
+}
+
+// recorder records sent messages, failing the first failures sends.
+type recorder struct {
+	sent     []*notifications.Message
+	failures int
+}
+
+func (r *recorder) Send(ctx context.Context, msg *notifications.Message) error {
+	if r.failures > 0 {
+		r.failures--
+		return errors.New("gateway unavailable")
+	}
+	r.sent = append(r.sent, msg)
+	return nil
+}
+
+type fixture struct {
+	repo  *notificationRepository
+	sms   *recorder
+	email *recorder
+	bus   *events.InProcessBus
+}
+
+func newFixture(t *testing.T, customer *customers.Customer) *fixture {
+	t.Helper()
+
+	templates, err := notifications.LoadTemplates("")
+	if err != nil {
+		t.Fatalf("LoadTemplates() error = %v", err)
+	}
+
+	f := &fixture{
+		repo:  &notificationRepository{notifications: make(map[string]*api.Notification)},
+		sms:   &recorder{},
+		email: &recorder{},
+		bus:   events.NewInProcessBus(),
+	}
+
+	notifier := notifications.NewNotifier(
+		&orderRepository{}, &customerRepository{customer: customer}, f.repo, templates,
+		map[string]notifications.Sender{api.ChannelSMS: f.sms, api.ChannelEmail: f.email})
+	notifier.MaxAttempts = 2
+	notifier.Register(f.bus.Router)
+
+	return f
+}
+
+func (f *fixture) publish(t *testing.T, id string, msg *generated.OrderStatusChanged) error {
+	t.Helper()
+
+	event, err := events.New(context.Background(), msg.GetOrderId(), msg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	event.ID = id
+
+	return f.bus.Publish(context.Background(), event)
+}
+
+func newCustomer() *customers.Customer {
+	return &customers.Customer{
+		Id:        "customer-1",
+		FirstName: "Wanjiru",
+		Email:     "wanjiru@example.com",
+		Phone:     "+254700000001",
+	}
+}
+
+func TestNotifier(t *testing.T) {
+	f := newFixture(t, newCustomer())
+
+	paid := &generated.OrderStatusChanged{OrderId: "order-1", To: generated.OrderStatus_PAID}
+	for i := 0; i < 2; i++ {
+		if err := f.publish(t, "event-1", paid); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	// Not notified.
+	if err := f.publish(t, "event-2", &generated.OrderStatusChanged{OrderId: "order-1", To: generated.OrderStatus_PENDING}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	if len(f.sms.sent) != 1 || len(f.email.sent) != 1 {
+		t.Fatalf("sent %d SMS and %d emails, want 1 each", len(f.sms.sent), len(f.email.sent))
+	}
+
+	if sms := f.sms.sent[0]; sms.To != "+254700000001" || !strings.Contains(sms.Body, "payment for order order-1") {
+		t.Errorf("SMS = %+v", sms)
+	}
+
+	if email := f.email.sent[0]; email.To != "wanjiru@example.com" || email.Subject != "Payment received for order order-1" ||
+		!strings.HasPrefix(email.Body, "Hi Wanjiru,") {
+		t.Errorf("email = %+v", email)
+	}
+
+	for _, id := range []string{"event-1-sms", "event-1-email"} {
+		n := f.repo.notifications[id]
+		if n == nil || n.Status != api.StatusSent || n.Template != api.TemplatePaymentReceived || n.Attempts != 1 {
+			t.Errorf("notification %s = %+v", id, n)
+		}
+	}
+
+	if len(f.repo.notifications) != 2 {
+		t.Errorf("recorded %d notifications, want 2", len(f.repo.notifications))
+	}
+}
+
+func TestNotifier_OptOut(t *testing.T) {
+	customer := newCustomer()
+	customer.SmsOptOut = true
+	customer.Email = ""
+
+	f := newFixture(t, customer)
+
+	if err := f.publish(t, "event-1", &generated.OrderStatusChanged{OrderId: "order-1", To: generated.OrderStatus_CANCELLED}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	if len(f.sms.sent) != 0 || len(f.email.sent) != 0 {
+		t.Fatalf("sent %d SMS and %d emails, want none", len(f.sms.sent), len(f.email.sent))
+	}
+
+	for _, id := range []string{"event-1-sms", "event-1-email"} {
+		if n := f.repo.notifications[id]; n == nil || n.Status != api.StatusSkipped {
+			t.Errorf("notification %s = %+v, want skipped", id, n)
+		}
+	}
+}
+
+func TestNotifier_Retries(t *testing.T) {
+	f := newFixture(t, newCustomer())
+	f.sms.failures = 5
+
+	failed := &generated.OrderStatusChanged{OrderId: "order-1", To: generated.OrderStatus_FAILED}
+
+	// The first failure fails the event so that it is delivered again.
+	if err := f.publish(t, "event-1", failed); err == nil {
+		t.Fatal("Publish() succeeded, want the SMS failure")
+	}
+	if n := f.repo.notifications["event-1-sms"]; n.Status != api.StatusPending || n.Error == "" {
+		t.Errorf("after first attempt: %+v", n)
+	}
+
+	// The last attempt gives up, and the email is not sent twice.
+	if err := f.publish(t, "event-1", failed); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if n := f.repo.notifications["event-1-sms"]; n.Status != api.StatusFailed || n.Attempts != 2 {
+		t.Errorf("after last attempt: %+v", n)
+	}
+	if len(f.email.sent) != 1 {
+		t.Errorf("sent %d emails, want 1", len(f.email.sent))
+	}
+}
+
+func TestTemplates_Render(t *testing.T) {
+	templates, err := notifications.LoadTemplates("")
+	if err != nil {
+		t.Fatalf("LoadTemplates() error = %v", err)
+	}
+
+	data := &notifications.TemplateData{
+		Customer: newCustomer(),
+		Order:    &orders.Order{Id: "order-1"},
+	}
+
+	for _, name := range []string{
+		api.TemplateOrderPlaced, api.TemplatePaymentReceived, api.TemplatePaymentFailed, api.TemplateOrderCancelled,
+	} {
+		for _, channel := range api.Channels {
+			subject, body, err := templates.Render(name, channel, data)
+			if err != nil {
+				t.Errorf("Render(%s, %s) error = %v", name, channel, err)
+				continue
+			}
+
+			if !strings.Contains(body, "order-1") || (channel == api.ChannelEmail) != (subject != "") {
+				t.Errorf("Render(%s, %s) = %q, %q", name, channel, subject, body)
+			}
+		}
+	}
+
+	if _, err := notifications.LoadTemplates(t.TempDir()); err == nil {
+		t.Error("LoadTemplates() of an empty directory succeeded")
+	}
+}
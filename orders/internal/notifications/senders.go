@@ -0,0 +1,213 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/notifications"
+	"github.com/leta/order-management-system/orders/pkg/tracing"
+)
+
+// Message is a rendered notification. Subject is empty for SMS.
+type Message struct {
+	Channel string `json:"channel"`
+	To      string `json:"to"`
+	Subject string `json:"subject,omitempty"`
+	Body    string `json:"body"`
+}
+
+// Sender delivers messages over one channel.
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// SenderFunc adapts a function to a Sender.
+type SenderFunc func(ctx context.Context, msg *Message) error
+
+// Send implements Sender.
+func (f SenderFunc) Send(ctx context.Context, msg *Message) error {
+	return f(ctx, msg)
+}
+
+// NewSenders creates the sender of each channel from options. Channels whose
+// sender is "none" are left out.
+func NewSenders(options Options) map[string]Sender {
+	senders := make(map[string]Sender)
+
+	switch options.SMS {
+	case SenderLog:
+		senders[notifications.ChannelSMS] = NewLogSender(options.LogFile)
+	case SenderSMSGateway:
+		senders[notifications.ChannelSMS] = NewSMSGatewaySender(options.SMSGateway)
+	}
+
+	switch options.Email {
+	case SenderLog:
+		senders[notifications.ChannelEmail] = NewLogSender(options.LogFile)
+	case SenderSMTP:
+		senders[notifications.ChannelEmail] = NewSMTPSender(options.SMTP)
+	}
+
+	return senders
+}
+
+// LogSender writes messages instead of sending them, for development.
+type LogSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewLogSender creates a LogSender appending to the file at path, or writing
+// to the service log if path is empty.
+func NewLogSender(path string) *LogSender {
+	return &LogSender{
+		path: path,
+	}
+}
+
+// Send implements Sender.
+func (s *LogSender) Send(ctx context.Context, msg *Message) error {
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	if s.path == "" {
+		log.Printf("[notifications] %s", line)
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", s.path, err)
+	}
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write to %s: %v", s.path, err)
+	}
+
+	return f.Close()
+}
+
+// SMTPSender sends plain text emails through an SMTP server.
+type SMTPSender struct {
+	options SMTPOptions
+}
+
+// NewSMTPSender creates a new instance of SMTPSender.
+func NewSMTPSender(options SMTPOptions) *SMTPSender {
+	return &SMTPSender{
+		options: options,
+	}
+}
+
+// Send implements Sender. The context is not honoured by net/smtp; the
+// server's own timeouts apply.
+func (s *SMTPSender) Send(ctx context.Context, msg *Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid email address %q: %v", msg.To, err)
+	}
+
+	from, err := mail.ParseAddress(s.options.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address %q: %v", s.options.From, err)
+	}
+
+	var auth smtp.Auth
+	if s.options.Username != "" {
+		host, _, err := net.SplitHostPort(s.options.Addr)
+		if err != nil {
+			return fmt.Errorf("invalid SMTP address %q: %v", s.options.Addr, err)
+		}
+		auth = smtp.PlainAuth("", s.options.Username, s.options.Password, host)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	err = smtp.SendMail(s.options.Addr, auth, from.Address, []string{to.Address}, []byte(b.String()))
+	if err != nil {
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+
+	return nil
+}
+
+// SMSGatewaySender sends SMS through an HTTP API. Each message is POSTed as
+// {"from": ..., "to": ..., "message": ...}; any 2xx response means it was
+// accepted.
+type SMSGatewaySender struct {
+	options SMSGatewayOptions
+	client  *http.Client
+}
+
+// NewSMSGatewaySender creates a new instance of SMSGatewaySender.
+func NewSMSGatewaySender(options SMSGatewayOptions) *SMSGatewaySender {
+	client := tracing.HTTPClient()
+	client.Timeout = options.Timeout
+
+	return &SMSGatewaySender{
+		options: options,
+		client:  client,
+	}
+}
+
+// Send implements Sender.
+func (s *SMSGatewaySender) Send(ctx context.Context, msg *Message) error {
+	body, err := json.Marshal(map[string]string{
+		"from":    s.options.SenderID,
+		"to":      msg.To,
+		"message": msg.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.options.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.options.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.options.APIKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send SMS: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("SMS gateway responded %s: %s", resp.Status, bytes.TrimSpace(respBody))
+	}
+
+	return nil
+}
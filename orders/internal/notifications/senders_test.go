@@ -0,0 +1,103 @@
+package notifications_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leta/order-management-system/orders/internal/notifications"
+)
+
+func TestSMSGatewaySender(t *testing.T) {
+	var got map[string]string
+	var auth string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if got["to"] == "+254700000002" {
+			http.Error(w, "invalid number", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	sender := notifications.NewSMSGatewaySender(notifications.SMSGatewayOptions{
+		URL:      server.URL,
+		APIKey:   "key",
+		SenderID: "SHOP",
+		Timeout:  time.Second,
+	})
+
+	err := sender.Send(context.Background(), &notifications.Message{To: "+254700000001", Body: "hello"})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if auth != "Bearer key" || got["from"] != "SHOP" || got["to"] != "+254700000001" || got["message"] != "hello" {
+		t.Errorf("request = %v, Authorization %q", got, auth)
+	}
+
+	err = sender.Send(context.Background(), &notifications.Message{To: "+254700000002", Body: "hello"})
+	if err == nil || !strings.Contains(err.Error(), "invalid number") {
+		t.Errorf("Send() error = %v, want the gateway's error", err)
+	}
+}
+
+func TestLogSender(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.log")
+	sender := notifications.NewLogSender(path)
+
+	for _, to := range []string{"a@example.com", "b@example.com"} {
+		err := sender.Send(context.Background(), &notifications.Message{Channel: "email", To: to, Subject: "s", Body: "b"})
+		if err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `"to":"b@example.com"`) {
+		t.Errorf("log = %q", data)
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(o *notifications.Options)
+		wantErr bool
+	}{
+		{name: "default", mutate: func(o *notifications.Options) {}},
+		{name: "smtp without addr", mutate: func(o *notifications.Options) { o.Email = notifications.SenderSMTP }, wantErr: true},
+		{name: "smtp", mutate: func(o *notifications.Options) {
+			o.Email = notifications.SenderSMTP
+			o.SMTP = notifications.SMTPOptions{Addr: "smtp.example.com:587", From: "shop@example.com"}
+		}},
+		{name: "gateway without url", mutate: func(o *notifications.Options) { o.SMS = notifications.SenderSMSGateway }, wantErr: true},
+		{name: "unknown sender", mutate: func(o *notifications.Options) { o.SMS = "carrier-pigeon" }, wantErr: true},
+		{name: "no attempts", mutate: func(o *notifications.Options) { o.MaxAttempts = 0 }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := notifications.DefaultOptions()
+			tt.mutate(&o)
+
+			if err := o.Validate("notifications"); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package notifications
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/customers"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/notifications"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+)
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// Names of the templates each file must define.
+const (
+	templateSMS          = "sms"
+	templateEmailSubject = "email_subject"
+	templateEmailBody    = "email_body"
+)
+
+var templateNames = []string{
+	notifications.TemplateOrderPlaced,
+	notifications.TemplatePaymentReceived,
+	notifications.TemplatePaymentFailed,
+	notifications.TemplateOrderCancelled,
+	notifications.TemplateOrderExpired,
+}
+
+// TemplateData is what templates are executed with.
+type TemplateData struct {
+	Customer *customers.Customer
+	Order    *orders.Order
+}
+
+// Templates render notifications. There is one text/template file per
+// notification, e.g. order_placed.tmpl, defining the "sms", "email_subject"
+// and "email_body" templates.
+type Templates struct {
+	templates map[string]*template.Template
+}
+
+// LoadTemplates parses the templates in dir, or the built-in ones if dir is
+// empty. Every notification must have a template file.
+func LoadTemplates(dir string) (*Templates, error) {
+	var fsys fs.FS = os.DirFS(dir)
+	if dir == "" {
+		fsys, _ = fs.Sub(builtinTemplates, "templates")
+	}
+
+	t := &Templates{
+		templates: make(map[string]*template.Template),
+	}
+
+	for _, name := range templateNames {
+		tmpl, err := template.New(name).Option("missingkey=error").ParseFS(fsys, name+".tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s template: %v", name, err)
+		}
+
+		for _, define := range []string{templateSMS, templateEmailSubject, templateEmailBody} {
+			if tmpl.Lookup(define) == nil {
+				return nil, fmt.Errorf("%s template does not define %q", name, define)
+			}
+		}
+
+		t.templates[name] = tmpl
+	}
+
+	return t, nil
+}
+
+// Render returns the subject and body of the named notification for a
+// channel. The subject of SMS is empty.
+func (t *Templates) Render(name string, channel string, data *TemplateData) (subject string, body string, err error) {
+	tmpl, ok := t.templates[name]
+	if !ok {
+		return "", "", fmt.Errorf("unknown template %q", name)
+	}
+
+	execute := func(define string) (string, error) {
+		var b strings.Builder
+		if err := tmpl.ExecuteTemplate(&b, define, data); err != nil {
+			return "", fmt.Errorf("failed to render %s %s: %v", name, define, err)
+		}
+		return strings.TrimSpace(b.String()), nil
+	}
+
+	switch channel {
+	case notifications.ChannelSMS:
+		body, err = execute(templateSMS)
+		return "", body, err
+	case notifications.ChannelEmail:
+		if subject, err = execute(templateEmailSubject); err != nil {
+			return "", "", err
+		}
+		body, err = execute(templateEmailBody)
+		return subject, body + "\n", err
+	default:
+		return "", "", fmt.Errorf("unknown channel %q", channel)
+	}
+}
@@ -0,0 +1,15 @@
+package purge
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var purged = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "purged_entities_total",
+	Help: "Number of deleted entities removed after their retention period, by entity type.",
+}, []string{"type"})
+
+func recordPurged(entityType string, n int) {
+	purged.WithLabelValues(entityType).Add(float64(n))
+}
@@ -0,0 +1,145 @@
+// Package purge removes soft-deleted products, customers and orders for good
+// once their retention period is over.
+package purge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/customers"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/product"
+	"github.com/leta/order-management-system/orders/pkg/audit"
+)
+
+// Actor is the audit actor of the purges.
+const Actor = "system:purge"
+
+// Options tune the purge of deleted entities.
+type Options struct {
+	// How long deleted entities can be restored before they are purged.
+	Retention time.Duration `yaml:"retention" env:"RETENTION"`
+
+	// How often deleted entities are looked for.
+	Interval time.Duration `yaml:"interval" env:"INTERVAL"`
+}
+
+// DefaultOptions returns the options used when nothing else is specified.
+func DefaultOptions() Options {
+	return Options{
+		Retention: 30 * 24 * time.Hour,
+		Interval:  time.Hour,
+	}
+}
+
+// Validate reports every invalid option. name prefixes the error messages.
+func (o *Options) Validate(name string) error {
+	var errs []error
+
+	if o.Retention <= 0 {
+		errs = append(errs, fmt.Errorf("%s.retention must be positive", name))
+	}
+	if o.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("%s.interval must be positive", name))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Purger removes the entities deleted more than Options.Retention ago.
+// Orders are purged first, so that they do not outlive their products and
+// customers. Several instances may run concurrently.
+type Purger struct {
+	productRepository  product.RepositoryInterface
+	customerRepository customers.CustomerRepositoryInterface
+	orderRepository    orders.OrderRepository
+	options            Options
+
+	// Now returns the current time; replaced in tests.
+	Now func() time.Time
+}
+
+// NewPurger creates a new instance of Purger.
+func NewPurger(
+	productRepository product.RepositoryInterface,
+	customerRepository customers.CustomerRepositoryInterface,
+	orderRepository orders.OrderRepository,
+	options Options) *Purger {
+
+	return &Purger{
+		productRepository:  productRepository,
+		customerRepository: customerRepository,
+		orderRepository:    orderRepository,
+		options:            options,
+		Now:                time.Now,
+	}
+}
+
+func (p *Purger) CheckPreconditions() {
+	if p.productRepository == nil {
+		panic("productRepository is required")
+	}
+
+	if p.customerRepository == nil {
+		panic("customerRepository is required")
+	}
+
+	if p.orderRepository == nil {
+		panic("orderRepository is required")
+	}
+}
+
+// Run purges deleted entities every Interval until ctx is cancelled.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.Purge(ctx); err != nil {
+			log.Printf("[purge] %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge removes the entities whose retention period is over and returns
+// their number. It carries on with the other entity types when one fails.
+func (p *Purger) Purge(ctx context.Context) (int, error) {
+	p.CheckPreconditions()
+
+	ctx = audit.WithActor(ctx, Actor)
+	ctx = audit.WithRequestID(ctx, audit.NewRequestID())
+
+	deletedBefore := p.Now().Add(-p.options.Retention)
+
+	purges := []struct {
+		entityType string
+		purge      func(ctx context.Context, deletedBefore time.Time) (int, error)
+	}{
+		{audit.EntityOrder, p.orderRepository.PurgeOrders},
+		{audit.EntityProduct, p.productRepository.PurgeProducts},
+		{audit.EntityCustomer, p.customerRepository.PurgeCustomers},
+	}
+
+	total := 0
+	var errs []error
+	for _, purge := range purges {
+		n, err := purge.purge(ctx, deletedBefore)
+		recordPurged(purge.entityType, n)
+		total += n
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to purge %s entities: %w", purge.entityType, err))
+		}
+	}
+
+	return total, errors.Join(errs...)
+}
@@ -0,0 +1,136 @@
+package purge_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/customers"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/product"
+	"github.com/leta/order-management-system/orders/internal/purge"
+	"github.com/leta/order-management-system/orders/pkg/audit"
+)
+
+// purgeCall records the arguments of a Purge* call.
+type purgeCall struct {
+	deletedBefore time.Time
+	actor         string
+}
+
+type productRepository struct {
+	product.RepositoryInterface
+
+	calls []purgeCall
+	n     int
+	err   error
+}
+
+func (r *productRepository) PurgeProducts(ctx context.Context, deletedBefore time.Time) (int, error) {
+	r.calls = append(r.calls, purgeCall{deletedBefore, audit.Actor(ctx)})
+	return r.n, r.err
+}
+
+type customerRepository struct {
+	customers.CustomerRepositoryInterface
+
+	calls []purgeCall
+	n     int
+	err   error
+}
+
+func (r *customerRepository) PurgeCustomers(ctx context.Context, deletedBefore time.Time) (int, error) {
+	r.calls = append(r.calls, purgeCall{deletedBefore, audit.Actor(ctx)})
+	return r.n, r.err
+}
+
+type orderRepository struct {
+	orders.OrderRepository
+
+	calls []purgeCall
+	n     int
+	err   error
+}
+
+func (r *orderRepository) PurgeOrders(ctx context.Context, deletedBefore time.Time) (int, error) {
+	r.calls = append(r.calls, purgeCall{deletedBefore, audit.Actor(ctx)})
+	return r.n, r.err
+}
+
+func TestPurger_Purge(t *testing.T) {
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	options := purge.Options{Retention: 30 * 24 * time.Hour, Interval: time.Hour}
+
+	tests := []struct {
+		name      string
+		productsN int
+		ordersN   int
+		ordersErr error
+		wantTotal int
+		wantErr   bool
+	}{
+		{
+			name:      "purges every entity type",
+			productsN: 2,
+			ordersN:   3,
+			wantTotal: 5,
+		},
+		{
+			name:      "carries on after a failure",
+			productsN: 2,
+			ordersN:   1,
+			ordersErr: errors.New("unavailable"),
+			wantTotal: 3,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products := &productRepository{n: tt.productsN}
+			customers := &customerRepository{}
+			orders := &orderRepository{n: tt.ordersN, err: tt.ordersErr}
+
+			purger := purge.NewPurger(products, customers, orders, options)
+			purger.Now = func() time.Time { return now }
+
+			total, err := purger.Purge(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Purge() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("Purge() = %d, want %d", total, tt.wantTotal)
+			}
+
+			wantCall := purgeCall{deletedBefore: now.Add(-options.Retention), actor: purge.Actor}
+			for name, calls := range map[string][]purgeCall{
+				"products":  products.calls,
+				"customers": customers.calls,
+				"orders":    orders.calls,
+			} {
+				if len(calls) != 1 || calls[0] != wantCall {
+					t.Errorf("%s purged with %+v, want [%+v]", name, calls, wantCall)
+				}
+			}
+		})
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options purge.Options
+		wantErr bool
+	}{
+		{name: "default", options: purge.DefaultOptions()},
+		{name: "no retention", options: purge.Options{Interval: time.Hour}, wantErr: true},
+		{name: "no interval", options: purge.Options{Retention: time.Hour}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.options.Validate("purge"); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package refunds
+
+import (
+	"context"
+
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/returns"
+	"github.com/leta/order-management-system/orders/pkg/tracing"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+	"github.com/leta/order-management-system/payments/pkg/client"
+)
+
+// RefundService refunds the customer for received returns through the
+// payments service.
+type RefundService struct {
+	returnRepository returns.ReturnRepository
+	paymentsClient   client.PaymentsClient
+}
+
+func NewRefundService(returnRepository returns.ReturnRepository, paymentsClient client.PaymentsClient) *RefundService {
+	return &RefundService{
+		returnRepository: returnRepository,
+		paymentsClient:   paymentsClient,
+	}
+}
+
+func (s *RefundService) CheckPreconditions() {
+	if s.returnRepository == nil {
+		panic("returnRepository is required")
+	}
+
+	if s.paymentsClient == nil {
+		panic("paymentsClient is required")
+	}
+}
+
+// RefundReturn sends the refund amount of a received return back to the
+// phone that paid for the order, then marks the return refunded. The return
+// id identifies the refund in the payments service, so a refund that was sent
+// but not marked is not paid again when retried.
+func (s *RefundService) RefundReturn(
+	ctx context.Context, orderId string, returnId string) (_ *returns.Return, err error) {
+
+	s.CheckPreconditions()
+
+	ctx, span := tracing.Start(ctx, "RefundService.RefundReturn")
+	defer func() { tracing.End(span, err) }()
+
+	ret, err := s.returnRepository.GetReturn(ctx, orderId, returnId)
+	if err != nil {
+		return nil, err
+	}
+
+	if ret.Status == returns.StatusRefunded {
+		return ret, nil
+	}
+
+	if err := ret.CheckTransition(returns.StatusRefunded); err != nil {
+		return nil, err
+	}
+
+	res, err := s.paymentsClient.RefundMpesaPayment(ctx, &client.RefundMpesaPaymentRequest{
+		OrderId:  orderId,
+		RefundId: returnId,
+		Amount:   &client.Money{Amount: ret.RefundAmount.Amount, Currency: ret.RefundAmount.Currency},
+		Reason:   ret.Reason,
+	})
+	if err != nil {
+		return nil, utils.Errorf(utils.INTERNAL_ERROR, "failed to refund return: %v", err)
+	}
+
+	// Set once M-Pesa accepted the refund, which a concurrent retry may still
+	// be waiting for.
+	if res.GetConversationId() == "" {
+		return nil, utils.Errorf(utils.ABORTED_ERROR, "refund of return %s is being sent", returnId)
+	}
+
+	return s.returnRepository.MarkReturnRefunded(ctx, orderId, returnId, res.GetConversationId())
+}
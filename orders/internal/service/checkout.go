@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/pkg/money"
 	"github.com/leta/order-management-system/orders/pkg/utils"
 )
 
@@ -11,6 +13,10 @@ type OrderItem struct {
 	Quantity  uint   `json:"quantity"`
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
+
+	UnitPrice money.Money     `json:"unit_price"`
+	Discount  money.Money     `json:"discount"`
+	Tax       *orders.ItemTax `json:"tax"`
 }
 
 type Order struct {
@@ -20,6 +26,12 @@ type Order struct {
 	OrderStatus utils.OrderStatus `json:"order_status"`
 	CreatedAt   string            `json:"created_at"`
 	UpdatedAt   string            `json:"updated_at"`
+
+	// The amount the customer was asked to pay, and the discount and tax it
+	// includes.
+	Total    money.Money      `json:"total"`
+	Discount *orders.Discount `json:"discount"`
+	Tax      *orders.OrderTax `json:"tax"`
 }
 
 type CheckoutService interface {
@@ -0,0 +1,11 @@
+package service
+
+import (
+	"context"
+
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/returns"
+)
+
+type RefundService interface {
+	RefundReturn(ctx context.Context, orderId string, returnId string) (*returns.Return, error)
+}
@@ -0,0 +1,80 @@
+package subscribers
+
+import (
+	"context"
+	"log"
+
+	"github.com/leta/order-management-system/orders/generated"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/events"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// actor is the audit actor of the changes made by the subscribers.
+const actor = "system:payment-events"
+
+// PaymentEvents updates orders from the events of the payments service.
+// Setting an order's status is idempotent, so redelivered events are harmless.
+// Payments settled once their order was cancelled or expired flag the order's
+// LatePaymentAt, so that the customer is refunded.
+type PaymentEvents struct {
+	orderRepository orders.OrderRepository
+}
+
+// NewPaymentEvents creates a new instance of PaymentEvents.
+func NewPaymentEvents(orderRepository orders.OrderRepository) *PaymentEvents {
+	return &PaymentEvents{
+		orderRepository: orderRepository,
+	}
+}
+
+func (s *PaymentEvents) CheckPreconditions() {
+	if s.orderRepository == nil {
+		panic("orderRepository is required")
+	}
+}
+
+// Register subscribes the handlers to the payment events routed by r.
+func (s *PaymentEvents) Register(r *events.Router) {
+	r.Handle(events.TypeOf(&generated.PaymentSucceeded{}), s.handlePaymentSucceeded)
+	r.Handle(events.TypeOf(&generated.PaymentFailed{}), s.handlePaymentFailed)
+}
+
+func (s *PaymentEvents) handlePaymentSucceeded(ctx context.Context, event *events.Event) error {
+	s.CheckPreconditions()
+
+	ctx = audit.WithActor(ctx, actor)
+
+	var payment generated.PaymentSucceeded
+	if err := event.Decode(&payment); err != nil {
+		return err
+	}
+
+	return s.updateOrderStatus(ctx, payment.GetOrderId(), utils.OrderStatusPaid)
+}
+
+func (s *PaymentEvents) handlePaymentFailed(ctx context.Context, event *events.Event) error {
+	s.CheckPreconditions()
+
+	ctx = audit.WithActor(ctx, actor)
+
+	var payment generated.PaymentFailed
+	if err := event.Decode(&payment); err != nil {
+		return err
+	}
+
+	return s.updateOrderStatus(ctx, payment.GetOrderId(), utils.OrderStatusFailed)
+}
+
+// updateOrderStatus sets the status of an order, dropping the update if the
+// order refuses it, which retrying would not change.
+func (s *PaymentEvents) updateOrderStatus(ctx context.Context, orderId string, orderStatus utils.OrderStatus) error {
+	_, err := s.orderRepository.UpdateOrderStatus(ctx, orderId, orderStatus, "")
+	if utils.ErrorCode(err) == utils.INVALID_ERROR {
+		log.Printf("[payment events] dropped %s status of order %s: %v", orderStatus, orderId, err)
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,106 @@
+package subscribers_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/leta/order-management-system/orders/generated"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/orders"
+	"github.com/leta/order-management-system/orders/internal/subscribers"
+	"github.com/leta/order-management-system/orders/pkg/events"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// orderRepository records status updates, and payments of abandoned orders
+// like OrderRepository.UpdateOrderStatus. Other methods are not used.
+type orderRepository struct {
+	orders.OrderRepository
+
+	statuses     map[string]utils.OrderStatus
+	latePayments map[string]bool
+}
+
+func (r *orderRepository) UpdateOrderStatus(
+	ctx context.Context, orderId string, status utils.OrderStatus, etag string) (*orders.Order, error) {
+	if current := r.statuses[orderId]; current.Abandoned() {
+		if status == utils.OrderStatusPaid {
+			r.latePayments[orderId] = true
+			return &orders.Order{Id: orderId, OrderStatus: current}, nil
+		}
+		return nil, utils.Errorf(utils.INVALID_ERROR, "the status of a %s order cannot be changed", current)
+	}
+	r.statuses[orderId] = status
+	return &orders.Order{Id: orderId, OrderStatus: status}, nil
+}
+
+func TestPaymentEvents(t *testing.T) {
+	tests := []struct {
+		name    string
+		current utils.OrderStatus
+		event   func(orderID string) (*events.Event, error)
+		want    utils.OrderStatus
+		// Whether the order is flagged as paid late.
+		wantLate bool
+	}{
+		{
+			name: "payment succeeded",
+			event: func(orderID string) (*events.Event, error) {
+				return events.New(context.Background(), "payment-1", &generated.PaymentSucceeded{OrderId: orderID})
+			},
+			want: utils.OrderStatusPaid,
+		},
+		{
+			name: "payment failed",
+			event: func(orderID string) (*events.Event, error) {
+				return events.New(context.Background(), "payment-1", &generated.PaymentFailed{OrderId: orderID})
+			},
+			want: utils.OrderStatusFailed,
+		},
+		{
+			name:    "payment of an expired order",
+			current: utils.OrderStatusExpired,
+			event: func(orderID string) (*events.Event, error) {
+				return events.New(context.Background(), "payment-1", &generated.PaymentSucceeded{OrderId: orderID})
+			},
+			want:     utils.OrderStatusExpired,
+			wantLate: true,
+		},
+		{
+			name:    "failed payment of a cancelled order",
+			current: utils.OrderStatusCancelled,
+			event: func(orderID string) (*events.Event, error) {
+				return events.New(context.Background(), "payment-1", &generated.PaymentFailed{OrderId: orderID})
+			},
+			want: utils.OrderStatusCancelled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &orderRepository{
+				statuses:     map[string]utils.OrderStatus{"order-1": tt.current},
+				latePayments: make(map[string]bool),
+			}
+
+			bus := events.NewInProcessBus()
+			subscribers.NewPaymentEvents(repo).Register(bus.Router)
+
+			event, err := tt.event("order-1")
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			if err := bus.Publish(context.Background(), event); err != nil {
+				t.Fatalf("Publish() error = %v", err)
+			}
+
+			if got := repo.statuses["order-1"]; got != tt.want {
+				t.Errorf("order status = %q, want %q", got, tt.want)
+			}
+
+			if got := repo.latePayments["order-1"]; got != tt.wantLate {
+				t.Errorf("late payment = %v, want %v", got, tt.wantLate)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+// Package tax computes the taxes on orders, such as Kenyan VAT, from rates
+// configured per product tax category.
+package tax
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// Tax categories of the default options.
+const (
+	CategoryStandard  = "standard"
+	CategoryZeroRated = "zero_rated"
+	CategoryExempt    = "exempt"
+)
+
+// BasisPoints is the number of basis points in a whole, the unit of rates.
+const BasisPoints = 10000
+
+// Options configure the tax rules.
+type Options struct {
+	// Whether product prices include tax, as Kenyan consumer prices must.
+	Inclusive bool `yaml:"inclusive" env:"INCLUSIVE"`
+
+	// Category of products that do not specify one.
+	DefaultCategory string `yaml:"default_category" env:"DEFAULT_CATEGORY"`
+
+	// Rates of the tax categories in basis points, e.g. 1600 for 16%.
+	Rates map[string]int64 `yaml:"rates"`
+}
+
+// DefaultOptions returns the options used when nothing else is specified:
+// Kenyan VAT, with prices including it.
+func DefaultOptions() Options {
+	return Options{
+		Inclusive:       true,
+		DefaultCategory: CategoryStandard,
+		Rates: map[string]int64{
+			CategoryStandard:  1600,
+			CategoryZeroRated: 0,
+			CategoryExempt:    0,
+		},
+	}
+}
+
+// Validate reports every invalid option. name prefixes the error messages.
+func (o *Options) Validate(name string) error {
+	var errs []error
+
+	if _, ok := o.Rates[o.DefaultCategory]; !ok {
+		errs = append(errs, fmt.Errorf("%s.default_category %q has no rate", name, o.DefaultCategory))
+	}
+
+	categories := make([]string, 0, len(o.Rates))
+	for category := range o.Rates {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	for _, category := range categories {
+		if rate := o.Rates[category]; rate < 0 || rate > BasisPoints {
+			errs = append(errs, fmt.Errorf("%s.rates.%s must be between 0 and %d", name, category, BasisPoints))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Line is a line of an order to be taxed.
+type Line struct {
+	// Tax category of the product; the default category if empty.
+	Category string
+	// The price of the line, i.e. unit price times quantity.
+	Price money.Money
+}
+
+// LineTax is the tax on a line.
+type LineTax struct {
+	Category string
+	// Rate in basis points.
+	Rate int64
+
+	// The price of the line excluding tax, the tax, and their sum.
+	Net   money.Money
+	Tax   money.Money
+	Gross money.Money
+}
+
+// Result is the tax on an order.
+type Result struct {
+	// Whether prices included tax.
+	Inclusive bool
+	// Whether the customer was exempt from tax.
+	Exempt bool
+
+	// The tax on each line, in order.
+	Lines []LineTax
+
+	// The sums of the lines.
+	Net   money.Money
+	Tax   money.Money
+	Gross money.Money
+}
+
+// Calculator applies the tax rules of its options.
+type Calculator struct {
+	options Options
+}
+
+func NewCalculator(options Options) *Calculator {
+	return &Calculator{
+		options: options,
+	}
+}
+
+// rate returns category, or the default category if empty, and its rate.
+// Unknown categories are an INVALID_ERROR.
+func (c *Calculator) rate(category string) (string, int64, error) {
+	if category == "" {
+		category = c.options.DefaultCategory
+	}
+
+	rate, ok := c.options.Rates[category]
+	if !ok {
+		return "", 0, utils.Errorf(utils.INVALID_ERROR, "unknown tax category %q", category)
+	}
+
+	return category, rate, nil
+}
+
+// Calculate returns the tax on lines, all priced in currency.
+//
+// Tax is rounded half up on each line, as it appears on invoices, and the
+// order amounts are the sums of the lines. With inclusive prices, the tax is
+// the part of the price it accounts for, and exempt customers pay the price
+// excluding it; otherwise it is added to the price, and exempt customers pay
+// the price.
+func (c *Calculator) Calculate(currency string, lines []Line, exempt bool) (*Result, error) {
+	result := &Result{
+		Inclusive: c.options.Inclusive,
+		Exempt:    exempt,
+		Lines:     make([]LineTax, len(lines)),
+		Net:       money.Zero(currency),
+		Tax:       money.Zero(currency),
+		Gross:     money.Zero(currency),
+	}
+
+	for i, line := range lines {
+		lineTax, err := c.line(line, exempt)
+		if err != nil {
+			return nil, err
+		}
+		result.Lines[i] = lineTax
+
+		if result.Net, err = result.Net.Add(lineTax.Net); err != nil {
+			return nil, utils.Errorf(utils.INVALID_ERROR, "%v", err)
+		}
+		if result.Tax, err = result.Tax.Add(lineTax.Tax); err != nil {
+			return nil, utils.Errorf(utils.INVALID_ERROR, "%v", err)
+		}
+		if result.Gross, err = result.Gross.Add(lineTax.Gross); err != nil {
+			return nil, utils.Errorf(utils.INVALID_ERROR, "%v", err)
+		}
+	}
+
+	return result, nil
+}
+
+func (c *Calculator) line(line Line, exempt bool) (LineTax, error) {
+	category, rate, err := c.rate(line.Category)
+	if err != nil {
+		return LineTax{}, err
+	}
+
+	lineTax := LineTax{Category: category, Rate: rate}
+
+	if c.options.Inclusive {
+		// The tax included in the price is price * rate / (1 + rate).
+		included, err := line.Price.MulRatio(rate, BasisPoints+rate, money.HalfUp)
+		if err != nil {
+			return LineTax{}, utils.Errorf(utils.INVALID_ERROR, "%v", err)
+		}
+
+		lineTax.Net, err = line.Price.Sub(included)
+		if err != nil {
+			return LineTax{}, utils.Errorf(utils.INVALID_ERROR, "%v", err)
+		}
+		lineTax.Tax = included
+	} else {
+		lineTax.Net = line.Price
+		lineTax.Tax, err = line.Price.MulRatio(rate, BasisPoints, money.HalfUp)
+		if err != nil {
+			return LineTax{}, utils.Errorf(utils.INVALID_ERROR, "%v", err)
+		}
+	}
+
+	if exempt {
+		lineTax.Tax = money.Zero(line.Price.Currency)
+	}
+
+	lineTax.Gross, err = lineTax.Net.Add(lineTax.Tax)
+	if err != nil {
+		return LineTax{}, utils.Errorf(utils.INVALID_ERROR, "%v", err)
+	}
+
+	return lineTax, nil
+}
@@ -0,0 +1,133 @@
+package tax_test
+
+import (
+	"testing"
+
+	"github.com/leta/order-management-system/orders/internal/tax"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+func kes(amount int64) money.Money {
+	return money.New(amount, "KES")
+}
+
+func TestCalculator_Calculate(t *testing.T) {
+	lines := []tax.Line{
+		{Price: kes(11600)},
+		{Category: tax.CategoryZeroRated, Price: kes(5000)},
+		{Category: tax.CategoryStandard, Price: kes(999)},
+	}
+
+	tests := []struct {
+		name      string
+		inclusive bool
+		exempt    bool
+		lines     []tax.Line
+		wantLines []int64
+		wantNet   money.Money
+		wantTax   money.Money
+		wantGross money.Money
+		wantErr   bool
+	}{
+		{
+			name:      "inclusive",
+			inclusive: true,
+			lines:     lines,
+			// 999 * 16/116 = 137.79
+			wantLines: []int64{1600, 0, 138},
+			wantNet:   kes(15861),
+			wantTax:   kes(1738),
+			wantGross: kes(17599),
+		},
+		{
+			name:  "exclusive",
+			lines: lines,
+			// 999 * 16% = 159.84
+			wantLines: []int64{1856, 0, 160},
+			wantNet:   kes(17599),
+			wantTax:   kes(2016),
+			wantGross: kes(19615),
+		},
+		{
+			name:      "inclusive exempt",
+			inclusive: true,
+			exempt:    true,
+			lines:     lines,
+			wantLines: []int64{0, 0, 0},
+			wantNet:   kes(15861),
+			wantTax:   kes(0),
+			wantGross: kes(15861),
+		},
+		{
+			name:      "exclusive exempt",
+			exempt:    true,
+			lines:     lines,
+			wantLines: []int64{0, 0, 0},
+			wantNet:   kes(17599),
+			wantTax:   kes(0),
+			wantGross: kes(17599),
+		},
+		{
+			name:    "unknown category",
+			lines:   []tax.Line{{Category: "luxury", Price: kes(100)}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := tax.DefaultOptions()
+			options.Inclusive = tt.inclusive
+
+			got, err := tax.NewCalculator(options).Calculate("KES", tt.lines, tt.exempt)
+			if tt.wantErr {
+				if utils.ErrorCode(err) != utils.INVALID_ERROR {
+					t.Fatalf("Calculate() error = %v, want INVALID_ERROR", err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("Calculate() error = %v", err)
+			}
+
+			for i, line := range got.Lines {
+				if line.Tax.Amount != tt.wantLines[i] {
+					t.Errorf("line %d tax = %v, want %d", i, line.Tax, tt.wantLines[i])
+				}
+			}
+			if got.Net != tt.wantNet || got.Tax != tt.wantTax || got.Gross != tt.wantGross {
+				t.Errorf("Calculate() = %v + %v = %v, want %v + %v = %v",
+					got.Net, got.Tax, got.Gross, tt.wantNet, tt.wantTax, tt.wantGross)
+			}
+			if got.Lines[0].Category != tax.CategoryStandard || got.Lines[0].Rate != 1600 {
+				t.Errorf("line 0 taxed as %s at %d, want the default category", got.Lines[0].Category, got.Lines[0].Rate)
+			}
+		})
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options tax.Options
+		wantErr bool
+	}{
+		{name: "default", options: tax.DefaultOptions()},
+		{
+			name:    "default category without rate",
+			options: tax.Options{DefaultCategory: "standard", Rates: map[string]int64{"reduced": 800}},
+			wantErr: true,
+		},
+		{
+			name:    "rate above 100%",
+			options: tax.Options{DefaultCategory: "standard", Rates: map[string]int64{"standard": 10001}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.options.Validate("tax"); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/leta/order-management-system/orders/generated"
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/webhooks"
+	"github.com/leta/order-management-system/orders/pkg/events"
+)
+
+// Payload is the JSON body of a delivery.
+type Payload struct {
+	// ID of the event. An event may be delivered more than once; receivers
+	// should discard IDs they have already processed.
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      OrderData `json:"data"`
+}
+
+// OrderData describes the order an event is about.
+type OrderData struct {
+	OrderID    string `json:"order_id"`
+	CustomerID string `json:"customer_id,omitempty"`
+	Status     string `json:"status"`
+}
+
+// Dispatcher creates the deliveries of order events to the subscriptions that
+// receive them. Deliveries are keyed by event and subscription, so events
+// seen twice are only delivered once.
+type Dispatcher struct {
+	repository webhooks.WebhookRepository
+}
+
+// NewDispatcher creates a new instance of Dispatcher.
+func NewDispatcher(repository webhooks.WebhookRepository) *Dispatcher {
+	return &Dispatcher{
+		repository: repository,
+	}
+}
+
+func (d *Dispatcher) CheckPreconditions() {
+	if d.repository == nil {
+		panic("repository is required")
+	}
+}
+
+// Register subscribes the dispatcher to the order events routed by r.
+func (d *Dispatcher) Register(r *events.Router) {
+	r.Handle(events.TypeOf(&generated.OrderCreated{}), d.handleOrderCreated)
+	r.Handle(events.TypeOf(&generated.OrderStatusChanged{}), d.handleOrderStatusChanged)
+}
+
+func (d *Dispatcher) handleOrderCreated(ctx context.Context, event *events.Event) error {
+	var order generated.OrderCreated
+	if err := event.Decode(&order); err != nil {
+		return err
+	}
+
+	return d.dispatch(ctx, event, webhooks.EventOrderCreated, OrderData{
+		OrderID:    order.GetOrderId(),
+		CustomerID: order.GetCustomerId(),
+		Status:     strings.ToLower(generated.OrderStatus_NEW.String()),
+	})
+}
+
+func (d *Dispatcher) handleOrderStatusChanged(ctx context.Context, event *events.Event) error {
+	var change generated.OrderStatusChanged
+	if err := event.Decode(&change); err != nil {
+		return err
+	}
+
+	var eventType string
+	switch change.GetTo() {
+	case generated.OrderStatus_PAID:
+		eventType = webhooks.EventOrderPaid
+	case generated.OrderStatus_FAILED:
+		eventType = webhooks.EventOrderFailed
+	case generated.OrderStatus_CANCELLED:
+		eventType = webhooks.EventOrderCancelled
+	case generated.OrderStatus_EXPIRED:
+		eventType = webhooks.EventOrderExpired
+	default:
+		return nil
+	}
+
+	return d.dispatch(ctx, event, eventType, OrderData{
+		OrderID: change.GetOrderId(),
+		Status:  strings.ToLower(change.GetTo().String()),
+	})
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, event *events.Event, eventType string, data OrderData) error {
+	d.CheckPreconditions()
+
+	subscriptions, err := d.repository.ListSubscriptionsFor(ctx, eventType)
+	if err != nil {
+		return err
+	}
+
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(&Payload{
+		ID:        event.ID,
+		Type:      eventType,
+		CreatedAt: event.CreatedAt,
+		Data:      data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s webhook: %v", eventType, err)
+	}
+
+	now := time.Now().UTC()
+
+	var errs []error
+	for _, subscription := range subscriptions {
+		err := d.repository.CreateDelivery(ctx, &webhooks.Delivery{
+			Id:             event.ID + "-" + subscription.Id,
+			SubscriptionId: subscription.Id,
+			EventId:        event.ID,
+			EventType:      eventType,
+			Body:           body,
+			Status:         webhooks.DeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,26 @@
+package webhooks
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	deliveryAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "webhook_delivery_attempts_total",
+		Help: "Number of webhook delivery attempts, by event type and result.",
+	}, []string{"type", "result"})
+
+	deadLetters = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "webhook_dead_letters_total",
+		Help: "Number of webhook deliveries dead-lettered after their last failed attempt, by event type.",
+	}, []string{"type"})
+)
+
+func recordAttempt(eventType string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	deliveryAttempts.WithLabelValues(eventType, result).Inc()
+}
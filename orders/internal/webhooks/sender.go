@@ -0,0 +1,204 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/leta/order-management-system/orders/internal/interfaces/api/webhooks"
+	"github.com/leta/order-management-system/orders/pkg/tracing"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+const DefaultBatchSize = 20
+
+// Sender POSTs due deliveries to their subscriptions. A delivery succeeds
+// when the receiver answers with a 2xx status; otherwise it is retried after
+// a backoff, and dead-lettered once Options.MaxAttempts attempts failed.
+// Every attempt is recorded.
+//
+// Several instances may run concurrently: deliveries are claimed for twice
+// the request timeout before being attempted.
+type Sender struct {
+	repository webhooks.WebhookRepository
+	client     *http.Client
+	options    Options
+
+	BatchSize int
+}
+
+// NewSender creates a new instance of Sender.
+func NewSender(repository webhooks.WebhookRepository, options Options) *Sender {
+	client := tracing.HTTPClient()
+	client.Timeout = options.Timeout
+
+	return &Sender{
+		repository: repository,
+		client:     client,
+		options:    options,
+		BatchSize:  DefaultBatchSize,
+	}
+}
+
+func (s *Sender) CheckPreconditions() {
+	if s.repository == nil {
+		panic("repository is required")
+	}
+}
+
+// Run sends due deliveries every PollInterval until ctx is cancelled.
+func (s *Sender) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.SendDue(ctx); err != nil {
+			log.Printf("[webhooks] %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDue makes one attempt of up to BatchSize due deliveries, concurrently,
+// and returns the number that succeeded.
+func (s *Sender) SendDue(ctx context.Context) (int, error) {
+	s.CheckPreconditions()
+
+	deliveries, err := s.repository.ClaimDueDeliveries(ctx, time.Now().UTC(), 2*s.options.Timeout, s.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	subscriptions := make(map[string]*webhooks.Subscription)
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		delivered int
+	)
+
+	for _, delivery := range deliveries {
+		subscription, ok := subscriptions[delivery.SubscriptionId]
+		if !ok {
+			subscription, err = s.repository.GetSubscription(ctx, delivery.SubscriptionId)
+			if err != nil && utils.ErrorCode(err) != utils.NOT_FOUND_ERROR {
+				// The claim expires and the delivery is attempted again later.
+				log.Printf("[webhooks] %v", err)
+				continue
+			}
+			subscriptions[delivery.SubscriptionId] = subscription
+		}
+
+		wg.Add(1)
+		go func(delivery *webhooks.Delivery, subscription *webhooks.Subscription) {
+			defer wg.Done()
+
+			if s.attempt(ctx, delivery, subscription) {
+				mu.Lock()
+				delivered++
+				mu.Unlock()
+			}
+		}(delivery, subscription)
+	}
+
+	wg.Wait()
+
+	return delivered, nil
+}
+
+// attempt sends the delivery to subscription, which is nil if it was deleted,
+// records the outcome and reports whether it succeeded.
+func (s *Sender) attempt(ctx context.Context, delivery *webhooks.Delivery, subscription *webhooks.Subscription) bool {
+	start := time.Now().UTC()
+
+	var (
+		statusCode int
+		err        error
+	)
+	if subscription == nil {
+		err = fmt.Errorf("subscription %s was deleted", delivery.SubscriptionId)
+	} else {
+		statusCode, err = s.post(ctx, delivery, subscription, start)
+	}
+
+	now := time.Now().UTC()
+	delivery.Attempts++
+	delivery.UpdatedAt = now
+
+	attempt := &webhooks.Attempt{
+		SubscriptionId: delivery.SubscriptionId,
+		DeliveryId:     delivery.Id,
+		Attempt:        delivery.Attempts,
+		StatusCode:     statusCode,
+		Duration:       now.Sub(start),
+		AttemptedAt:    start,
+	}
+
+	switch {
+	case err == nil:
+		delivery.Status = webhooks.DeliveryDelivered
+		delivery.LastError = ""
+	case subscription == nil || int(delivery.Attempts) >= s.options.MaxAttempts:
+		delivery.Status = webhooks.DeliveryDeadLetter
+		delivery.LastError = err.Error()
+		attempt.Error = err.Error()
+		deadLetters.WithLabelValues(delivery.EventType).Inc()
+		log.Printf("[webhooks] dead-lettered delivery %s after %d attempts: %v", delivery.Id, delivery.Attempts, err)
+	default:
+		delivery.NextAttemptAt = now.Add(s.options.Backoff(int(delivery.Attempts)))
+		delivery.LastError = err.Error()
+		attempt.Error = err.Error()
+	}
+
+	recordAttempt(delivery.EventType, err)
+
+	// If this fails the claim expires and the delivery is attempted again.
+	if err := s.repository.RecordAttempt(ctx, delivery, attempt); err != nil {
+		log.Printf("[webhooks] %v", err)
+	}
+
+	return err == nil
+}
+
+func (s *Sender) post(
+	ctx context.Context, delivery *webhooks.Delivery, subscription *webhooks.Subscription, now time.Time) (int, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Url, bytes.NewReader(delivery.Body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, delivery.Id)
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(subscription.Secret, timestamp, delivery.Body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Drain some of the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,96 @@
+// Package webhooks notifies partners of order events over HTTP.
+//
+// A Dispatcher turns domain events into one delivery per matching
+// subscription, and a Sender POSTs due deliveries to the subscriptions' URLs,
+// retrying failures with exponential backoff until they are dead-lettered.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Headers of every delivery request.
+const (
+	HeaderID        = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Options tune the delivery of webhooks.
+type Options struct {
+	// Timeout of each delivery request.
+	Timeout time.Duration `yaml:"timeout" env:"TIMEOUT"`
+
+	// Number of failed attempts after which a delivery is dead-lettered.
+	MaxAttempts int `yaml:"max_attempts" env:"MAX_ATTEMPTS"`
+
+	// Bounds of the exponential backoff between attempts.
+	InitialBackoff time.Duration `yaml:"initial_backoff" env:"INITIAL_BACKOFF"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" env:"MAX_BACKOFF"`
+
+	// How often due deliveries are looked for.
+	PollInterval time.Duration `yaml:"poll_interval" env:"POLL_INTERVAL"`
+}
+
+// DefaultOptions returns the options used when nothing else is specified.
+// A delivery is retried for about a day before being dead-lettered.
+func DefaultOptions() Options {
+	return Options{
+		Timeout:        10 * time.Second,
+		MaxAttempts:    10,
+		InitialBackoff: 30 * time.Second,
+		MaxBackoff:     6 * time.Hour,
+		PollInterval:   5 * time.Second,
+	}
+}
+
+// Validate reports every invalid option. name prefixes the error messages.
+func (o *Options) Validate(name string) error {
+	var errs []error
+
+	if o.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("%s.timeout must be positive", name))
+	}
+	if o.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("%s.max_attempts must be at least 1", name))
+	}
+	if o.InitialBackoff <= 0 || o.MaxBackoff < o.InitialBackoff {
+		errs = append(errs, fmt.Errorf("%s.initial_backoff must be positive and not above max_backoff", name))
+	}
+	if o.PollInterval <= 0 {
+		errs = append(errs, fmt.Errorf("%s.poll_interval must be positive", name))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Backoff returns the wait before the attempt following the given number of
+// failed attempts: InitialBackoff doubled after each failure, up to MaxBackoff.
+func (o *Options) Backoff(failures int) time.Duration {
+	backoff := o.InitialBackoff
+	for i := 1; i < failures && backoff < o.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > o.MaxBackoff {
+		backoff = o.MaxBackoff
+	}
+	return backoff
+}
+
+// Sign returns the hex-encoded HMAC-SHA256 of "<timestamp>.<body>" keyed with
+// the subscription's secret. Deliveries carry it as "sha256=<signature>" in
+// the X-Webhook-Signature header; receivers should recompute it, compare in
+// constant time and reject stale timestamps to prevent replays.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,357 @@
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leta/order-management-system/orders/generated"
+	api "github.com/leta/order-management-system/orders/internal/interfaces/api/webhooks"
+	"github.com/leta/order-management-system/orders/internal/webhooks"
+	"github.com/leta/order-management-system/orders/pkg/events"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+// memoryRepository is an in-memory webhook repository. Listing deliveries
+// and retrying them is not used.
+type memoryRepository struct {
+	api.WebhookRepository
+
+	mu            sync.Mutex
+	subscriptions map[string]*api.Subscription
+	deliveries    map[string]*api.Delivery
+	attempts      []*api.Attempt
+}
+
+func newMemoryRepository(subscriptions ...*api.Subscription) *memoryRepository {
+	r := &memoryRepository{
+		subscriptions: make(map[string]*api.Subscription),
+		deliveries:    make(map[string]*api.Delivery),
+	}
+	for _, subscription := range subscriptions {
+		r.subscriptions[subscription.Id] = subscription
+	}
+	return r
+}
+
+func (r *memoryRepository) GetSubscription(ctx context.Context, id string) (*api.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	subscription, ok := r.subscriptions[id]
+	if !ok {
+		return nil, utils.Errorf(utils.NOT_FOUND_ERROR, "webhook subscription not found")
+	}
+	return subscription, nil
+}
+
+func (r *memoryRepository) ListSubscriptionsFor(ctx context.Context, eventType string) ([]*api.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var subscriptions []*api.Subscription
+	for _, subscription := range r.subscriptions {
+		if subscription.Subscribes(eventType) {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (r *memoryRepository) CreateDelivery(ctx context.Context, delivery *api.Delivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.deliveries[delivery.Id]; !ok {
+		r.deliveries[delivery.Id] = delivery
+	}
+	return nil
+}
+
+func (r *memoryRepository) ClaimDueDeliveries(
+	ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*api.Delivery, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var claimed []*api.Delivery
+	for _, delivery := range r.deliveries {
+		if delivery.Status == api.DeliveryPending && !delivery.NextAttemptAt.After(now) && len(claimed) < limit {
+			copied := *delivery
+			delivery.NextAttemptAt = now.Add(lease)
+			claimed = append(claimed, &copied)
+		}
+	}
+	return claimed, nil
+}
+
+func (r *memoryRepository) RecordAttempt(ctx context.Context, delivery *api.Delivery, attempt *api.Attempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := *delivery
+	r.deliveries[delivery.Id] = &copied
+	r.attempts = append(r.attempts, attempt)
+	return nil
+}
+
+// makeDue lets every pending delivery be attempted again.
+func (r *memoryRepository) makeDue() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, delivery := range r.deliveries {
+		delivery.NextAttemptAt = time.Time{}
+	}
+}
+
+func (r *memoryRepository) delivery(id string) api.Delivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return *r.deliveries[id]
+}
+
+func newEvent(t *testing.T, id string, msg *generated.OrderStatusChanged) *events.Event {
+	t.Helper()
+
+	event, err := events.New(context.Background(), msg.GetOrderId(), msg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	event.ID = id
+
+	return event
+}
+
+func testOptions() webhooks.Options {
+	options := webhooks.DefaultOptions()
+	options.MaxAttempts = 3
+	return options
+}
+
+func TestDispatcher(t *testing.T) {
+	repo := newMemoryRepository(
+		&api.Subscription{Id: "paid", EventTypes: []string{api.EventOrderPaid}},
+		&api.Subscription{Id: "all", EventTypes: api.EventTypes},
+	)
+
+	bus := events.NewInProcessBus()
+	webhooks.NewDispatcher(repo).Register(bus.Router)
+
+	publish := func(event *events.Event) {
+		t.Helper()
+		if err := bus.Publish(context.Background(), event); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	paid := newEvent(t, "event-1", &generated.OrderStatusChanged{OrderId: "order-1", To: generated.OrderStatus_PAID})
+	publish(paid)
+	publish(paid)
+	publish(newEvent(t, "event-2", &generated.OrderStatusChanged{OrderId: "order-1", To: generated.OrderStatus_PENDING}))
+	publish(newEvent(t, "event-3", &generated.OrderStatusChanged{OrderId: "order-2", To: generated.OrderStatus_CANCELLED}))
+
+	want := map[string]string{
+		"event-1-paid": api.EventOrderPaid,
+		"event-1-all":  api.EventOrderPaid,
+		"event-3-all":  api.EventOrderCancelled,
+	}
+
+	if len(repo.deliveries) != len(want) {
+		t.Fatalf("got %d deliveries, want %d", len(repo.deliveries), len(want))
+	}
+
+	for id, eventType := range want {
+		delivery, ok := repo.deliveries[id]
+		if !ok {
+			t.Fatalf("delivery %s was not created", id)
+		}
+		if delivery.EventType != eventType || delivery.Status != api.DeliveryPending {
+			t.Errorf("delivery %s = %s %s, want %s pending", id, delivery.EventType, delivery.Status, eventType)
+		}
+	}
+
+	var payload webhooks.Payload
+	if err := json.Unmarshal(repo.deliveries["event-1-all"].Body, &payload); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if payload.ID != "event-1" || payload.Type != api.EventOrderPaid ||
+		payload.Data.OrderID != "order-1" || payload.Data.Status != "paid" {
+		t.Errorf("payload = %+v", payload)
+	}
+}
+
+func TestSender(t *testing.T) {
+	const secret = "0123456789abcdef"
+
+	// The receiver fails the first request, then verifies the signature.
+	var (
+		mu       sync.Mutex
+		requests int
+		badSig   bool
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		want := "sha256=" + webhooks.Sign(secret, r.Header.Get(webhooks.HeaderTimestamp), body)
+		if r.Header.Get(webhooks.HeaderSignature) != want || r.Header.Get(webhooks.HeaderEvent) != api.EventOrderPaid {
+			badSig = true
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}))
+	defer server.Close()
+
+	repo := newMemoryRepository(&api.Subscription{Id: "sub", Url: server.URL, Secret: secret})
+	repo.deliveries["d"] = &api.Delivery{
+		Id:             "d",
+		SubscriptionId: "sub",
+		EventType:      api.EventOrderPaid,
+		Body:           []byte(`{"id":"event-1"}`),
+		Status:         api.DeliveryPending,
+	}
+
+	options := testOptions()
+	sender := webhooks.NewSender(repo, options)
+
+	before := time.Now()
+	if n, err := sender.SendDue(context.Background()); err != nil || n != 0 {
+		t.Fatalf("SendDue() = %d, %v, want 0, nil", n, err)
+	}
+
+	delivery := repo.delivery("d")
+	if delivery.Status != api.DeliveryPending || delivery.Attempts != 1 || delivery.LastError == "" {
+		t.Fatalf("after failure: %+v", delivery)
+	}
+	if delivery.NextAttemptAt.Before(before.Add(options.InitialBackoff)) {
+		t.Errorf("next attempt at %v, want after backoff of %v", delivery.NextAttemptAt, options.InitialBackoff)
+	}
+
+	// Not due yet.
+	if n, _ := sender.SendDue(context.Background()); n != 0 {
+		t.Fatalf("SendDue() = %d before the backoff elapsed", n)
+	}
+
+	repo.makeDue()
+	if n, err := sender.SendDue(context.Background()); err != nil || n != 1 {
+		t.Fatalf("SendDue() = %d, %v, want 1, nil", n, err)
+	}
+
+	if badSig {
+		t.Fatal("receiver rejected the signature")
+	}
+
+	delivery = repo.delivery("d")
+	if delivery.Status != api.DeliveryDelivered || delivery.Attempts != 2 {
+		t.Errorf("after success: %+v", delivery)
+	}
+
+	if len(repo.attempts) != 2 ||
+		repo.attempts[0].StatusCode != http.StatusServiceUnavailable || repo.attempts[0].Error == "" ||
+		repo.attempts[1].StatusCode != http.StatusOK || repo.attempts[1].Attempt != 2 {
+		t.Errorf("attempts = %+v %+v", repo.attempts[0], repo.attempts[1])
+	}
+}
+
+func TestSender_DeadLetter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	repo := newMemoryRepository(&api.Subscription{Id: "sub", Url: server.URL, Secret: "0123456789abcdef"})
+	repo.deliveries["d"] = &api.Delivery{Id: "d", SubscriptionId: "sub", Status: api.DeliveryPending}
+	repo.deliveries["deleted"] = &api.Delivery{Id: "deleted", SubscriptionId: "gone", Status: api.DeliveryPending}
+
+	options := testOptions()
+	sender := webhooks.NewSender(repo, options)
+
+	for i := 0; i < options.MaxAttempts; i++ {
+		repo.makeDue()
+		if _, err := sender.SendDue(context.Background()); err != nil {
+			t.Fatalf("SendDue() error = %v", err)
+		}
+	}
+
+	if d := repo.delivery("d"); d.Status != api.DeliveryDeadLetter || int(d.Attempts) != options.MaxAttempts {
+		t.Errorf("delivery d = %s after %d attempts, want dead_letter after %d", d.Status, d.Attempts, options.MaxAttempts)
+	}
+
+	// Deliveries of deleted subscriptions are dead-lettered at once.
+	if d := repo.delivery("deleted"); d.Status != api.DeliveryDeadLetter || d.Attempts != 1 {
+		t.Errorf("delivery deleted = %s after %d attempts, want dead_letter after 1", d.Status, d.Attempts)
+	}
+}
+
+func TestOptions_Backoff(t *testing.T) {
+	options := webhooks.Options{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 1, want: time.Second},
+		{failures: 2, want: 2 * time.Second},
+		{failures: 4, want: 8 * time.Second},
+		{failures: 5, want: 10 * time.Second},
+		{failures: 50, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := options.Backoff(tt.failures); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestSubscription_Validate(t *testing.T) {
+	valid := func() *api.Subscription {
+		return &api.Subscription{
+			Url:        "https://partner.example.com/hooks",
+			EventTypes: []string{api.EventOrderCreated},
+			Secret:     "0123456789abcdef",
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(s *api.Subscription)
+		wantErr bool
+	}{
+		{name: "valid", mutate: func(s *api.Subscription) {}},
+		{name: "relative url", mutate: func(s *api.Subscription) { s.Url = "/hooks" }, wantErr: true},
+		{name: "ftp url", mutate: func(s *api.Subscription) { s.Url = "ftp://example.com" }, wantErr: true},
+		{name: "no event types", mutate: func(s *api.Subscription) { s.EventTypes = nil }, wantErr: true},
+		{name: "unknown event type", mutate: func(s *api.Subscription) { s.EventTypes = []string{"order.shipped"} }, wantErr: true},
+		{name: "short secret", mutate: func(s *api.Subscription) { s.Secret = "secret" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid()
+			tt.mutate(s)
+
+			err := s.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && utils.ErrorCode(err) != utils.INVALID_ERROR {
+				t.Errorf("ErrorCode() = %s, want %s", utils.ErrorCode(err), utils.INVALID_ERROR)
+			}
+		})
+	}
+}
@@ -24,6 +24,209 @@ resource "google_cloud_run_service" "orders_service" {
 
 resource "google_project" "project" {}
 
+# Lets the outbox relay of each service list its undelivered events in order.
+resource "google_firestore_index" "outbox_pending" {
+  project    = google_project.project.project_id
+  collection = "outbox"
+
+  fields {
+    field_path = "service"
+    order      = "ASCENDING"
+  }
+
+  fields {
+    field_path = "published"
+    order      = "ASCENDING"
+  }
+
+  fields {
+    field_path = "created_at"
+    order      = "ASCENDING"
+  }
+}
+
+# Lets the webhook senders find due deliveries.
+resource "google_firestore_index" "webhook_deliveries_due" {
+  project    = google_project.project.project_id
+  collection = "webhook_deliveries"
+
+  fields {
+    field_path = "status"
+    order      = "ASCENDING"
+  }
+
+  fields {
+    field_path = "next_attempt_at"
+    order      = "ASCENDING"
+  }
+}
+
+# ListWebhookDeliveries, with and without a status filter.
+resource "google_firestore_index" "webhook_deliveries_by_subscription" {
+  project    = google_project.project.project_id
+  collection = "webhook_deliveries"
+
+  fields {
+    field_path = "subscription_id"
+    order      = "ASCENDING"
+  }
+
+  fields {
+    field_path = "created_at"
+    order      = "DESCENDING"
+  }
+}
+
+resource "google_firestore_index" "webhook_deliveries_by_subscription_status" {
+  project    = google_project.project.project_id
+  collection = "webhook_deliveries"
+
+  fields {
+    field_path = "subscription_id"
+    order      = "ASCENDING"
+  }
+
+  fields {
+    field_path = "status"
+    order      = "ASCENDING"
+  }
+
+  fields {
+    field_path = "created_at"
+    order      = "DESCENDING"
+  }
+}
+
+# ListWebhookDeliveryAttempts, with and without a delivery filter.
+resource "google_firestore_index" "webhook_attempts_by_subscription" {
+  project    = google_project.project.project_id
+  collection = "webhook_attempts"
+
+  fields {
+    field_path = "subscription_id"
+    order      = "ASCENDING"
+  }
+
+  fields {
+    field_path = "attempted_at"
+    order      = "DESCENDING"
+  }
+}
+
+resource "google_firestore_index" "webhook_attempts_by_delivery" {
+  project    = google_project.project.project_id
+  collection = "webhook_attempts"
+
+  fields {
+    field_path = "subscription_id"
+    order      = "ASCENDING"
+  }
+
+  fields {
+    field_path = "delivery_id"
+    order      = "ASCENDING"
+  }
+
+  fields {
+    field_path = "attempted_at"
+    order      = "DESCENDING"
+  }
+}
+
+# ListNotifications.
+resource "google_firestore_index" "notifications_by_customer" {
+  project    = google_project.project.project_id
+  collection = "notifications"
+
+  fields {
+    field_path = "customer_id"
+    order      = "ASCENDING"
+  }
+
+  fields {
+    field_path = "created_at"
+    order      = "DESCENDING"
+  }
+}
+
+# ListAuditEvents filtered by entity. The payments service writes to its own
+# audit_events collection, covered by the same indexes when the services
+# share a project.
+resource "google_firestore_index" "audit_events_by_entity" {
+  project    = google_project.project.project_id
+  collection = "audit_events"
+
+  fields {
+    field_path = "entity_type"
+    order      = "ASCENDING"
+  }
+
+  fields {
+    field_path = "entity_id"
+    order      = "ASCENDING"
+  }
+
+  fields {
+    field_path = "time"
+    order      = "DESCENDING"
+  }
+}
+
+resource "google_firestore_index" "audit_events_by_entity_type" {
+  project    = google_project.project.project_id
+  collection = "audit_events"
+
+  fields {
+    field_path = "entity_type"
+    order      = "ASCENDING"
+  }
+
+  fields {
+    field_path = "time"
+    order      = "DESCENDING"
+  }
+}
+
+# DeleteProduct looks for open orders including the product across the items
+# of every order.
+resource "google_firestore_field" "order_items_product_id" {
+  project    = google_project.project.project_id
+  collection = "items"
+  field      = "product_id"
+
+  index_config {
+    indexes {
+      order       = "ASCENDING"
+      query_scope = "COLLECTION_GROUP"
+    }
+  }
+}
+
 output "service_url" {
   value = google_cloud_run_service.orders_service.status[0].url
 }
+
+# Domain events, see orders/proto/events.proto. Set EVENTS_BROKER=pubsub on
+# both services to use them.
+resource "google_pubsub_topic" "orders_events" {
+  project = google_project.project.project_id
+  name    = "orders-events"
+}
+
+resource "google_pubsub_topic" "payments_events" {
+  project = google_project.project.project_id
+  name    = "payments-events"
+}
+
+resource "google_pubsub_subscription" "orders_payments_events" {
+  project = google_project.project.project_id
+  name    = "orders-payments-events"
+  topic   = google_pubsub_topic.payments_events.id
+
+  enable_message_ordering = true
+
+  retry_policy {
+    minimum_backoff = "1s"
+    maximum_backoff = "60s"
+  }
+}
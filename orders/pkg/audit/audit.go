@@ -0,0 +1,118 @@
+// Package audit records who changed which entity, when, and how.
+//
+// Repositories write an Event in the transaction of every create, update and
+// delete, so the log cannot miss a committed change. Events are never updated
+// or deleted.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Actions recorded in the log. Deletions are soft and can be restored;
+// purging removes a deleted entity for good.
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionPurge   = "purge"
+)
+
+// Types of the audited entities.
+const (
+	EntityProduct   = "product"
+	EntityCategory  = "category"
+	EntityCustomer  = "customer"
+	EntityOrder     = "order"
+	EntityOrderItem = "order_item"
+	EntityPromotion = "promotion"
+	EntityPriceList = "price_list"
+	EntityCart      = "cart"
+	EntityShipment  = "shipment"
+	EntityReturn    = "return"
+	EntityPayment   = "payment"
+	EntityRefund    = "refund"
+)
+
+// Event is one change of an entity.
+type Event struct {
+	ID string
+
+	// Service whose database holds the entity, e.g. "orders".
+	Service string
+
+	EntityType string
+	EntityID   string
+	Action     string
+
+	// Who made the change and the request it was made in, see WithActor and
+	// UnaryServerInterceptor.
+	Actor     string
+	RequestID string
+
+	// JSON encoding of the entity before and after the change. Before is
+	// empty for creations and After for deletions.
+	Before string
+	After  string
+
+	Time time.Time
+}
+
+// New describes a change of an entity made in the request of ctx. before or
+// after is nil when the entity did not exist.
+func New(ctx context.Context, entityType string, entityID string, action string, before, after any) (*Event, error) {
+	event := &Event{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Actor:      Actor(ctx),
+		RequestID:  RequestID(ctx),
+		Time:       time.Now().UTC(),
+	}
+
+	var err error
+	if event.Before, err = encode(before); err != nil {
+		return nil, fmt.Errorf("failed to encode %s %s: %v", entityType, entityID, err)
+	}
+	if event.After, err = encode(after); err != nil {
+		return nil, fmt.Errorf("failed to encode %s %s: %v", entityType, entityID, err)
+	}
+
+	return event, nil
+}
+
+func encode(v any) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	if string(b) == "null" {
+		return "", nil
+	}
+
+	return string(b), nil
+}
+
+// Filter selects events. Zero fields match every event.
+type Filter struct {
+	EntityType string
+
+	// Requires EntityType.
+	EntityID string
+
+	// Bounds of the event time, inclusive.
+	From time.Time
+	To   time.Time
+
+	// Maximum number of events returned, newest first.
+	Limit int
+}
@@ -0,0 +1,147 @@
+package audit_test
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/leta/order-management-system/orders/pkg/audit"
+)
+
+type product struct {
+	Name  string `json:"name"`
+	Price uint   `json:"price"`
+}
+
+func TestNew(t *testing.T) {
+	var missing *product
+
+	tests := []struct {
+		name       string
+		before     any
+		after      any
+		wantBefore string
+		wantAfter  string
+	}{
+		{
+			name:      "create",
+			after:     &product{Name: "Soap", Price: 100},
+			wantAfter: `{"name":"Soap","price":100}`,
+		},
+		{
+			name:       "update",
+			before:     &product{Name: "Soap", Price: 100},
+			after:      &product{Name: "Soap", Price: 120},
+			wantBefore: `{"name":"Soap","price":100}`,
+			wantAfter:  `{"name":"Soap","price":120}`,
+		},
+		{
+			name:       "delete with typed nil",
+			before:     &product{Name: "Soap", Price: 100},
+			after:      missing,
+			wantBefore: `{"name":"Soap","price":100}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := audit.WithRequestID(audit.WithActor(context.Background(), "alice"), "req-1")
+
+			event, err := audit.New(ctx, audit.EntityProduct, "p1", audit.ActionUpdate, tt.before, tt.after)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			if event.Before != tt.wantBefore {
+				t.Errorf("Before = %s, want %s", event.Before, tt.wantBefore)
+			}
+			if event.After != tt.wantAfter {
+				t.Errorf("After = %s, want %s", event.After, tt.wantAfter)
+			}
+			if event.Actor != "alice" || event.RequestID != "req-1" {
+				t.Errorf("actor, request id = %s, %s, want alice, req-1", event.Actor, event.RequestID)
+			}
+			if event.Time.IsZero() {
+				t.Errorf("Time is not set")
+			}
+		})
+	}
+}
+
+func TestActor(t *testing.T) {
+	if got := audit.Actor(context.Background()); got != audit.Anonymous {
+		t.Errorf("Actor() = %s, want %s", got, audit.Anonymous)
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	tests := []struct {
+		name          string
+		md            metadata.MD
+		wantActor     string
+		wantRequestID string
+	}{
+		{
+			name:          "identified request",
+			md:            metadata.Pairs(audit.MetadataActor, "alice", audit.MetadataRequestID, "req-1"),
+			wantActor:     "alice",
+			wantRequestID: "req-1",
+		},
+		{
+			name:      "anonymous request",
+			md:        metadata.MD{},
+			wantActor: audit.Anonymous,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+
+			var actor, requestID string
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				actor, requestID = audit.Actor(ctx), audit.RequestID(ctx)
+				return nil, nil
+			}
+
+			_, err := audit.UnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+			if err != nil {
+				t.Fatalf("interceptor error = %v", err)
+			}
+
+			if actor != tt.wantActor {
+				t.Errorf("actor = %s, want %s", actor, tt.wantActor)
+			}
+			if tt.wantRequestID != "" && requestID != tt.wantRequestID {
+				t.Errorf("request id = %s, want %s", requestID, tt.wantRequestID)
+			}
+			if requestID == "" {
+				t.Errorf("request id is not set")
+			}
+		})
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	ctx := audit.WithRequestID(audit.WithActor(context.Background(), "system:test"), "req-1")
+
+	var md metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply interface{},
+		cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+
+		md, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+
+	err := audit.UnaryClientInterceptor()(ctx, "/test.Service/Update", nil, nil, nil, invoker)
+	if err != nil {
+		t.Fatalf("interceptor error = %v", err)
+	}
+
+	if got := md.Get(audit.MetadataActor); len(got) != 1 || got[0] != "system:test" {
+		t.Errorf("%s = %v, want [system:test]", audit.MetadataActor, got)
+	}
+	if got := md.Get(audit.MetadataRequestID); len(got) != 1 || got[0] != "req-1" {
+		t.Errorf("%s = %v, want [req-1]", audit.MetadataRequestID, got)
+	}
+}
@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Metadata keys carrying the actor and request ID of an RPC. The REST
+// gateway forwards them from the X-Actor-Id and X-Request-Id headers.
+const (
+	MetadataActor     = "x-actor-id"
+	MetadataRequestID = "x-request-id"
+)
+
+// Anonymous is the actor of requests that do not identify one.
+const Anonymous = "anonymous"
+
+type contextKey int
+
+const (
+	actorKey contextKey = iota
+	requestIDKey
+)
+
+// WithActor returns a copy of ctx attributing changes to actor. Background
+// work uses "system:<name>", e.g. "system:payment-events".
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// Actor returns the actor of ctx, or Anonymous.
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey).(string); ok && actor != "" {
+		return actor
+	}
+	return Anonymous
+}
+
+// WithRequestID returns a copy of ctx carrying the ID of the request being
+// served.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the request ID of ctx, or the empty string.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// NewRequestID returns a random request ID.
+func NewRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// UnaryServerInterceptor reads the actor and request ID of incoming RPCs into
+// their context, assigning a request ID if the caller did not, and returns
+// the request ID in the response header.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+
+		if actor := first(md.Get(MetadataActor)); actor != "" {
+			ctx = WithActor(ctx, actor)
+		}
+
+		requestID := first(md.Get(MetadataRequestID))
+		if requestID == "" {
+			requestID = NewRequestID()
+		}
+		ctx = WithRequestID(ctx, requestID)
+
+		_ = grpc.SetHeader(ctx, metadata.Pairs(MetadataRequestID, requestID))
+
+		return handler(ctx, req)
+	}
+}
+
+// UnaryClientInterceptor forwards the actor and request ID of the context to
+// the peer, so that changes it makes on behalf of a request are attributed
+// to the same actor and request.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{},
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+
+		if actor, ok := ctx.Value(actorKey).(string); ok && actor != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, MetadataActor, actor)
+		}
+		if requestID := RequestID(ctx); requestID != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, MetadataRequestID, requestID)
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
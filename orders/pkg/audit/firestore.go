@@ -0,0 +1,142 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+// Collection is the Firestore collection holding the audit log of a service.
+const Collection = "audit_events"
+
+// DefaultLimit is the number of events listed when the filter sets none.
+const DefaultLimit = 100
+
+// FirestoreLog keeps the audit log in the service's own Firestore database,
+// so that events are committed atomically with the changes they describe.
+//
+// List needs composite indexes on (entity_type ASC, entity_id ASC, time DESC)
+// and (entity_type ASC, time DESC).
+type FirestoreLog struct {
+	client  *firestore.Client
+	service string
+}
+
+// NewFirestoreLog creates a new instance of FirestoreLog recording the events
+// of service.
+func NewFirestoreLog(client *firestore.Client, service string) *FirestoreLog {
+	return &FirestoreLog{
+		client:  client,
+		service: service,
+	}
+}
+
+func (l *FirestoreLog) CheckPreconditions() {
+	if l.client == nil {
+		panic("no Firestore client provided")
+	}
+}
+
+type eventModel struct {
+	Service    string    `firestore:"service"`
+	EntityType string    `firestore:"entity_type"`
+	EntityID   string    `firestore:"entity_id"`
+	Action     string    `firestore:"action"`
+	Actor      string    `firestore:"actor"`
+	RequestID  string    `firestore:"request_id"`
+	Before     string    `firestore:"before"`
+	After      string    `firestore:"after"`
+	Time       time.Time `firestore:"time"`
+}
+
+func (l *FirestoreLog) collection() *firestore.CollectionRef {
+	l.CheckPreconditions()
+
+	return l.client.Collection(Collection)
+}
+
+// Record writes event to the log as part of tx and sets its ID and service.
+func (l *FirestoreLog) Record(tx *firestore.Transaction, event *Event) error {
+	l.CheckPreconditions()
+
+	docRef := l.collection().NewDoc()
+	event.Service = l.service
+
+	err := tx.Create(docRef, &eventModel{
+		Service:    event.Service,
+		EntityType: event.EntityType,
+		EntityID:   event.EntityID,
+		Action:     event.Action,
+		Actor:      event.Actor,
+		RequestID:  event.RequestID,
+		Before:     event.Before,
+		After:      event.After,
+		Time:       event.Time,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record audit event of %s %s: %v", event.EntityType, event.EntityID, err)
+	}
+
+	event.ID = docRef.ID
+
+	return nil
+}
+
+// List returns the events matching filter, newest first.
+func (l *FirestoreLog) List(ctx context.Context, filter Filter) ([]*Event, error) {
+	l.CheckPreconditions()
+
+	if filter.EntityID != "" && filter.EntityType == "" {
+		return nil, errors.New("entity_type is required to filter by entity_id")
+	}
+
+	query := l.collection().Query
+	if filter.EntityType != "" {
+		query = query.Where("entity_type", "==", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		query = query.Where("entity_id", "==", filter.EntityID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("time", ">=", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("time", "<=", filter.To)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	docs, err := query.OrderBy("time", firestore.Desc).Limit(limit).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %v", err)
+	}
+
+	list := make([]*Event, 0, len(docs))
+	for _, doc := range docs {
+		var model eventModel
+		if err := doc.DataTo(&model); err != nil {
+			return nil, fmt.Errorf("failed to decode audit event %s: %v", doc.Ref.ID, err)
+		}
+
+		list = append(list, &Event{
+			ID:         doc.Ref.ID,
+			Service:    model.Service,
+			EntityType: model.EntityType,
+			EntityID:   model.EntityID,
+			Action:     model.Action,
+			Actor:      model.Actor,
+			RequestID:  model.RequestID,
+			Before:     model.Before,
+			After:      model.After,
+			Time:       model.Time,
+		})
+	}
+
+	return list, nil
+}
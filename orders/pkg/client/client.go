@@ -3,10 +3,10 @@ package client
 import (
 	"context"
 	"github.com/leta/order-management-system/orders/generated"
-	"log"
+
+	"github.com/leta/order-management-system/orders/pkg/grpcclient"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 type OrdersClient interface {
@@ -27,12 +27,8 @@ func NewGrpcOrderClient(conn *grpc.ClientConn) *GrpcOrderClient {
 	}
 }
 
-func ConnectToOrderService(address string) (*grpc.ClientConn, error) {
-
-	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
-	if err != nil {
-		log.Fatalf("Failed to connect: %v", err)
-	}
-
-	return conn, nil
+// ConnectToOrderService dials the orders service without waiting for it to be
+// up. Setting an order's status is idempotent, so it is retried.
+func ConnectToOrderService(address string, opts grpcclient.Options) (*grpc.ClientConn, error) {
+	return grpcclient.Dial(address, opts, "/orders.Orders/UpdateOrderStatus")
 }
@@ -10,7 +10,17 @@ import (
 type UpdateOrderStatusRequest = generated.UpdateOrderStatusRequest
 type UpdateOrderStatusResponse = generated.UpdateOrderStatusResponse
 
+type OrderStatus = generated.OrderStatus
+
 var OrderStatusPaid = generated.OrderStatus_PAID
 var OrderStatusCancelled = generated.OrderStatus_CANCELLED
 var OrderStatusFailed = generated.OrderStatus_FAILED
+var OrderStatusExpired = generated.OrderStatus_EXPIRED
 var OrderStatusPending = generated.OrderStatus_PENDING
+
+// Events published by the payments service, see proto/events.proto.
+type PaymentSucceeded = generated.PaymentSucceeded
+type PaymentFailed = generated.PaymentFailed
+
+// Money is an amount in the minor units of its currency, see pkg/money.
+type Money = generated.Money
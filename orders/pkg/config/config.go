@@ -0,0 +1,250 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is implemented by the typed configuration of each service.
+type Config interface {
+	// RegisterFlags binds command line flags to the configuration fields.
+	RegisterFlags(fs *flag.FlagSet)
+
+	// Validate reports every problem with the configuration at once.
+	Validate() error
+}
+
+// Options are the command line flags understood by every service, in addition
+// to the ones registered by its Config.
+type Options struct {
+	// Path to a YAML configuration file.
+	File string
+
+	// Print the effective configuration, with secrets redacted, and exit.
+	PrintConfig bool
+}
+
+// Load populates cfg from, in increasing order of precedence, its current
+// (default) values, the YAML file given by --config, the environment variables
+// named by the `env` struct tags and the command line flags. The result is
+// validated before it is returned.
+//
+// The returned Options are non-nil as long as the command line could be parsed,
+// so --print-config can still show an invalid configuration.
+func Load(name string, cfg Config, args []string) (*Options, error) {
+	opts := &Options{}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&opts.File, "config", "", "path to a YAML configuration file")
+	fs.BoolVar(&opts.PrintConfig, "print-config", false, "print the configuration with secrets redacted and exit")
+	cfg.RegisterFlags(fs)
+
+	// Parse once to find the configuration file, and again after the file and
+	// environment are applied so that flags take precedence over both.
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if opts.File != "" {
+		if err := LoadFile(opts.File, cfg); err != nil {
+			return opts, err
+		}
+	}
+
+	if err := LoadEnv(cfg, os.LookupEnv); err != nil {
+		return opts, err
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return opts, fmt.Errorf("invalid configuration:\n%v", err)
+	}
+
+	return opts, nil
+}
+
+// MustLoad loads cfg like Load and logs it with secrets redacted. With
+// --print-config it prints the configuration and exits. On error it logs every
+// problem found and exits with a non-zero status.
+func MustLoad(name string, cfg Config, args []string) {
+	opts, err := Load(name, cfg, args)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+
+	if opts != nil && opts.PrintConfig {
+		if perr := Print(os.Stdout, cfg); perr != nil {
+			log.Fatalf("failed to print config: %v", perr)
+		}
+	}
+
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+
+	if opts.PrintConfig {
+		os.Exit(0)
+	}
+
+	log.Printf("Loaded %s configuration: %+v", name, cfg)
+}
+
+// LoadFile decodes the YAML file at path into cfg. Keys that do not map to a
+// configuration field are rejected so typos do not go unnoticed.
+func LoadFile(path string, cfg interface{}) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %v", err)
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(cfg); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to decode config file %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// LoadEnv sets every field of cfg tagged with `env:"NAME"` for which lookup
+// finds a value. Nested structs are walked recursively; the env tag of a
+// struct field, e.g. `env:"PAYMENTS_CLIENT_"`, prefixes the names of its
+// fields. All malformed values are reported together.
+func LoadEnv(cfg interface{}, lookup func(string) (string, bool)) error {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config must be a pointer to a struct, got %T", cfg)
+	}
+
+	var errs []error
+	loadEnv(v.Elem(), "", lookup, &errs)
+
+	return errors.Join(errs...)
+}
+
+func loadEnv(v reflect.Value, prefix string, lookup func(string) (string, bool), errs *[]error) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, ok := field.Tag.Lookup("env")
+		if value.Kind() == reflect.Struct {
+			loadEnv(value, prefix+name, lookup, errs)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		name = prefix + name
+		raw, ok := lookup(name)
+		if !ok {
+			continue
+		}
+
+		if err := setValue(value, raw); err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %v", name, err))
+		}
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func setValue(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+
+	return nil
+}
+
+// Print writes cfg to w as YAML. Secret fields are redacted.
+func Print(w io.Writer, cfg interface{}) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(cfg); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// Redacted is what Secret values are replaced with when printed.
+const Redacted = "[REDACTED]"
+
+// Secret is a string that is never printed, logged or marshalled in clear.
+// Use Value to get the actual secret.
+type Secret string
+
+// Value returns the secret in clear.
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String implements fmt.Stringer, so secrets are redacted by the log and fmt
+// packages, including when they are nested in a struct printed with %v.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return Redacted
+}
+
+// GoString implements fmt.GoStringer for the %#v verb.
+func (s Secret) GoString() string {
+	return strconv.Quote(s.String())
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
@@ -0,0 +1,192 @@
+package config_test
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leta/order-management-system/orders/pkg/config"
+)
+
+type testConfig struct {
+	Name    string        `yaml:"name" env:"TEST_NAME"`
+	Port    string        `yaml:"port" env:"TEST_PORT"`
+	Timeout time.Duration `yaml:"timeout" env:"TEST_TIMEOUT"`
+	Nested  struct {
+		Enabled bool          `yaml:"enabled" env:"TEST_ENABLED"`
+		Token   config.Secret `yaml:"token" env:"TEST_TOKEN"`
+	} `yaml:"nested"`
+}
+
+func (c *testConfig) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.Name, "name", c.Name, "")
+	fs.StringVar(&c.Port, "port", c.Port, "")
+}
+
+func (c *testConfig) Validate() error {
+	var errs []error
+	if c.Name == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+	errs = append(errs, config.ValidatePort("port", c.Port))
+	return errors.Join(errs...)
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeFile(t, "name: file\nport: \"1000\"\ntimeout: 5s\nnested:\n  enabled: true\n")
+
+	t.Setenv("TEST_PORT", "2000")
+	t.Setenv("TEST_TOKEN", "s3cr3t")
+
+	cfg := &testConfig{Name: "default", Port: "1", Timeout: time.Second}
+	opts, err := config.Load("test", cfg, []string{"--config", path, "--name", "flag"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if opts.File != path {
+		t.Errorf("Options.File = %q, want %q", opts.File, path)
+	}
+
+	tests := []struct {
+		field string
+		got   interface{}
+		want  interface{}
+	}{
+		{field: "Name (flag over file)", got: cfg.Name, want: "flag"},
+		{field: "Port (env over file)", got: cfg.Port, want: "2000"},
+		{field: "Timeout (file over default)", got: cfg.Timeout, want: 5 * time.Second},
+		{field: "Nested.Enabled (file)", got: cfg.Nested.Enabled, want: true},
+		{field: "Nested.Token (env)", got: cfg.Nested.Token.Value(), want: "s3cr3t"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.field, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoad_ReportsAllErrors(t *testing.T) {
+	cfg := &testConfig{Port: "not-a-port"}
+
+	opts, err := config.Load("test", cfg, []string{"--print-config"})
+	if err == nil {
+		t.Fatalf("Load() expected an error for an invalid configuration")
+	}
+
+	if opts == nil || !opts.PrintConfig {
+		t.Errorf("Load() Options = %+v, want PrintConfig", opts)
+	}
+
+	for _, want := range []string{"name is required", `port "not-a-port" is not a valid port`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error = %q, want it to contain %q", err, want)
+		}
+	}
+}
+
+func TestLoadFile_UnknownField(t *testing.T) {
+	path := writeFile(t, "name: file\nprot: \"1000\"\n")
+
+	if err := config.LoadFile(path, &testConfig{}); err == nil {
+		t.Errorf("LoadFile() expected an error for an unknown field")
+	}
+}
+
+func TestLoadEnv_InvalidValues(t *testing.T) {
+	env := map[string]string{"TEST_TIMEOUT": "soon", "TEST_ENABLED": "maybe"}
+	lookup := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+
+	err := config.LoadEnv(&testConfig{}, lookup)
+	if err == nil {
+		t.Fatalf("LoadEnv() expected an error")
+	}
+
+	for key := range env {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("LoadEnv() error = %q, want it to mention %s", err, key)
+		}
+	}
+}
+
+func TestLoadEnv_Prefix(t *testing.T) {
+	type client struct {
+		Timeout time.Duration `env:"TIMEOUT"`
+	}
+	cfg := struct {
+		Payments client `env:"PAYMENTS_"`
+		Orders   client `env:"ORDERS_"`
+	}{}
+
+	env := map[string]string{"PAYMENTS_TIMEOUT": "2s", "TIMEOUT": "3s"}
+	lookup := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+
+	if err := config.LoadEnv(&cfg, lookup); err != nil {
+		t.Fatalf("LoadEnv() error = %v", err)
+	}
+
+	if cfg.Payments.Timeout != 2*time.Second {
+		t.Errorf("Payments.Timeout = %v, want %v", cfg.Payments.Timeout, 2*time.Second)
+	}
+	if cfg.Orders.Timeout != 0 {
+		t.Errorf("Orders.Timeout = %v, want it unset", cfg.Orders.Timeout)
+	}
+}
+
+func TestSecret_Redacted(t *testing.T) {
+	cfg := &testConfig{Name: "test"}
+	cfg.Nested.Token = "s3cr3t"
+
+	var buf bytes.Buffer
+	if err := config.Print(&buf, cfg); err != nil {
+		t.Fatalf("Print() error = %v", err)
+	}
+
+	outputs := map[string]string{
+		"Print": buf.String(),
+		"%v":    fmt.Sprintf("%v", cfg),
+		"%+v":   fmt.Sprintf("%+v", cfg),
+		"%#v":   fmt.Sprintf("%#v", cfg),
+	}
+	for name, out := range outputs {
+		if strings.Contains(out, "s3cr3t") {
+			t.Errorf("%s output leaks the secret: %s", name, out)
+		}
+		if !strings.Contains(out, config.Redacted) {
+			t.Errorf("%s output = %s, want it to contain %s", name, out, config.Redacted)
+		}
+	}
+}
+
+func TestValidateDistinctPorts(t *testing.T) {
+	if err := config.ValidateDistinctPorts("grpc", "50051", "http", "8080"); err != nil {
+		t.Errorf("ValidateDistinctPorts() error = %v", err)
+	}
+
+	err := config.ValidateDistinctPorts("grpc", "50051", "http", "8080", "debug", "50051")
+	if err == nil || !strings.Contains(err.Error(), "grpc and debug both use port 50051") {
+		t.Errorf("ValidateDistinctPorts() error = %v, want a conflict between grpc and debug", err)
+	}
+}
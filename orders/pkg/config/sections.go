@@ -0,0 +1,130 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/leta/order-management-system/orders/pkg/events"
+	"github.com/leta/order-management-system/orders/pkg/tracing"
+)
+
+// Firebase configures the Firebase app and its Firestore client.
+type Firebase struct {
+	ProjectID       string `yaml:"project_id" env:"GOOGLE_CLOUD_PROJECT"`
+	CredentialsFile string `yaml:"credentials_file" env:"GOOGLE_APPLICATION_CREDENTIALS"`
+
+	// Connects Firestore to a local emulator instead of Google Cloud.
+	EmulatorHost string `yaml:"emulator_host" env:"FIRESTORE_EMULATOR_HOST"`
+}
+
+// Validate checks the Firebase configuration for the given environment. The
+// emulator is mandatory in the dev and test environments.
+func (c *Firebase) Validate(environment string) error {
+	var errs []error
+
+	if c.ProjectID == "" {
+		errs = append(errs, errors.New("firebase.project_id (GOOGLE_CLOUD_PROJECT) is required"))
+	}
+
+	if c.EmulatorHost == "" {
+		if environment == "dev" || environment == "test" {
+			errs = append(errs, fmt.Errorf(
+				"firebase.emulator_host (FIRESTORE_EMULATOR_HOST) is required in the %s environment", environment))
+		}
+
+		if c.CredentialsFile == "" {
+			errs = append(errs, errors.New(
+				"firebase.credentials_file (GOOGLE_APPLICATION_CREDENTIALS) is required when not using the emulator"))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Events configures the broker domain events are published to. Pub/Sub uses
+// the Firebase project and credentials.
+type Events struct {
+	// "inprocess" only delivers events to handlers of the same process,
+	// "pubsub" also publishes them to Google Cloud Pub/Sub.
+	Broker string `yaml:"broker" env:"EVENTS_BROKER"`
+
+	// Pub/Sub topic the service publishes its events to.
+	Topic string `yaml:"topic" env:"EVENTS_TOPIC"`
+
+	// Pub/Sub subscription the service receives its peer's events from.
+	Subscription string `yaml:"subscription" env:"EVENTS_SUBSCRIPTION"`
+
+	// Connects Pub/Sub to a local emulator instead of Google Cloud.
+	EmulatorHost string `yaml:"emulator_host" env:"PUBSUB_EMULATOR_HOST"`
+}
+
+// Validate checks that the broker is supported and, for Pub/Sub, that a topic
+// is set.
+func (c *Events) Validate() error {
+	switch c.Broker {
+	case events.BrokerInProcess:
+		return nil
+	case events.BrokerPubSub:
+		if c.Topic == "" {
+			return errors.New("events.topic (EVENTS_TOPIC) is required with the pubsub broker")
+		}
+		return nil
+	default:
+		return fmt.Errorf("events.broker (EVENTS_BROKER) %q is not one of inprocess or pubsub", c.Broker)
+	}
+}
+
+// Tracing configures the OpenTelemetry span exporter.
+type Tracing struct {
+	// One of "none", "stdout" or "otlp". The OTLP exporter reads its endpoint
+	// from the standard OTEL_EXPORTER_OTLP_ENDPOINT variable.
+	Exporter string `yaml:"exporter" env:"OTEL_TRACES_EXPORTER"`
+}
+
+// Validate checks that the exporter is supported.
+func (c *Tracing) Validate() error {
+	switch c.Exporter {
+	case "", tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+		return nil
+	default:
+		return fmt.Errorf("tracing.exporter (OTEL_TRACES_EXPORTER) %q is not one of none, stdout or otlp", c.Exporter)
+	}
+}
+
+// ValidatePort returns an error if port is not a valid TCP port number.
+func ValidatePort(name string, port string) error {
+	n, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || n == 0 {
+		return fmt.Errorf("%s %q is not a valid port", name, port)
+	}
+	return nil
+}
+
+// ValidateAddress returns an error if addr is not a host:port pair.
+func ValidateAddress(name string, addr string) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return fmt.Errorf("%s %q is not a valid host:port address", name, addr)
+	}
+	return ValidatePort(name, port)
+}
+
+// ValidateDistinctPorts returns an error for every port used more than once.
+// Ports are given as name/value pairs.
+func ValidateDistinctPorts(ports ...string) error {
+	var errs []error
+
+	seen := make(map[string]string)
+	for i := 0; i+1 < len(ports); i += 2 {
+		name, port := ports[i], ports[i+1]
+		if other, ok := seen[port]; ok {
+			errs = append(errs, fmt.Errorf("%s and %s both use port %s", other, name, port))
+			continue
+		}
+		seen[port] = name
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,178 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/leta/order-management-system/orders/pkg/tracing"
+)
+
+// Brokers events can be published to.
+const (
+	BrokerInProcess = "inprocess"
+	BrokerPubSub    = "pubsub"
+)
+
+// Event is a domain event. Its payload is one of the messages of
+// proto/events.proto.
+type Event struct {
+	// ID is assigned when the event is written to the outbox. Delivery is
+	// at-least-once, so handlers must tolerate seeing the same ID twice.
+	ID string
+
+	// Type is the full name of the payload message, e.g. "events.PaymentSucceeded".
+	Type string
+
+	// AggregateID identifies the entity the event is about, e.g. the order
+	// ID. Events of the same aggregate are delivered in the order written.
+	AggregateID string
+
+	Payload []byte
+
+	// W3C traceparent of the request that produced the event, so that
+	// handling it continues its trace.
+	TraceParent string
+
+	CreatedAt time.Time
+}
+
+// New creates an event about aggregateID carrying msg.
+func New(ctx context.Context, aggregateID string, msg proto.Message) (*Event, error) {
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %v", TypeOf(msg), err)
+	}
+
+	return &Event{
+		Type:        TypeOf(msg),
+		AggregateID: aggregateID,
+		Payload:     payload,
+		TraceParent: tracing.TraceParent(ctx),
+		CreatedAt:   time.Now().UTC(),
+	}, nil
+}
+
+// TypeOf returns the event type of msg.
+func TypeOf(msg proto.Message) string {
+	return string(msg.ProtoReflect().Descriptor().FullName())
+}
+
+// Decode decodes the payload of the event into msg, which must be of the
+// event's type.
+func (e *Event) Decode(msg proto.Message) error {
+	if t := TypeOf(msg); t != e.Type {
+		return fmt.Errorf("cannot decode %s event %s into %s", e.Type, e.ID, t)
+	}
+
+	if err := proto.Unmarshal(e.Payload, msg); err != nil {
+		return fmt.Errorf("failed to decode %s event %s: %v", e.Type, e.ID, err)
+	}
+
+	return nil
+}
+
+// Publisher delivers events to a broker or a peer service.
+type Publisher interface {
+	Publish(ctx context.Context, event *Event) error
+}
+
+// PublisherFunc adapts a function to a Publisher.
+type PublisherFunc func(ctx context.Context, event *Event) error
+
+// Publish implements Publisher.
+func (f PublisherFunc) Publish(ctx context.Context, event *Event) error {
+	return f(ctx, event)
+}
+
+// Handler reacts to an event. An error causes the event to be delivered again.
+type Handler func(ctx context.Context, event *Event) error
+
+// Router dispatches events to the handlers registered for their type.
+type Router struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+// NewRouter creates a new instance of Router.
+func NewRouter() *Router {
+	return &Router{
+		handlers: make(map[string][]Handler),
+	}
+}
+
+// Handle registers h for events of the given type, see TypeOf.
+func (r *Router) Handle(eventType string, h Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.handlers[eventType] = append(r.handlers[eventType], h)
+}
+
+// Dispatch runs every handler of the event's type and returns their errors.
+// Events without handlers are ignored.
+func (r *Router) Dispatch(ctx context.Context, event *Event) (err error) {
+	r.mu.RLock()
+	handlers := r.handlers[event.Type]
+	r.mu.RUnlock()
+
+	if len(handlers) == 0 {
+		return nil
+	}
+
+	ctx, span := tracing.Resume(ctx, event.TraceParent, "events.Handle "+event.Type)
+	defer func() {
+		tracing.End(span, err)
+		recordHandled(event.Type, err)
+	}()
+
+	var errs []error
+	for _, h := range handlers {
+		if err := h(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to handle %s event %s: %w", event.Type, event.ID, err)
+	}
+
+	return nil
+}
+
+// InProcessBus delivers events synchronously to the handlers of the same
+// process. Publish returns the handlers' errors, so an outbox relay retries
+// the event until every handler succeeds.
+type InProcessBus struct {
+	*Router
+}
+
+// NewInProcessBus creates a new instance of InProcessBus.
+func NewInProcessBus() *InProcessBus {
+	return &InProcessBus{
+		Router: NewRouter(),
+	}
+}
+
+// Publish implements Publisher.
+func (b *InProcessBus) Publish(ctx context.Context, event *Event) error {
+	return b.Dispatch(ctx, event)
+}
+
+// Publishers publishes every event to each of its publishers in turn,
+// stopping at the first error.
+type Publishers []Publisher
+
+// Publish implements Publisher.
+func (p Publishers) Publish(ctx context.Context, event *Event) error {
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/leta/order-management-system/orders/generated"
+	"github.com/leta/order-management-system/orders/pkg/events"
+)
+
+func newEvent(t *testing.T, msg *generated.PaymentSucceeded) *events.Event {
+	t.Helper()
+
+	event, err := events.New(context.Background(), msg.GetPaymentId(), msg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	event.ID = "event-1"
+
+	return event
+}
+
+func TestEvent_Decode(t *testing.T) {
+	event := newEvent(t, &generated.PaymentSucceeded{PaymentId: "payment-1", OrderId: "order-1"})
+
+	if event.Type != "events.PaymentSucceeded" {
+		t.Errorf("Type = %q, want %q", event.Type, "events.PaymentSucceeded")
+	}
+
+	var payment generated.PaymentSucceeded
+	if err := event.Decode(&payment); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if payment.GetOrderId() != "order-1" {
+		t.Errorf("Decode() OrderId = %q, want %q", payment.GetOrderId(), "order-1")
+	}
+
+	if err := event.Decode(&generated.PaymentFailed{}); err == nil {
+		t.Errorf("Decode() into another type expected an error")
+	}
+}
+
+func TestInProcessBus_Publish(t *testing.T) {
+	bus := events.NewInProcessBus()
+
+	var handled []string
+	bus.Handle(events.TypeOf(&generated.PaymentSucceeded{}), func(ctx context.Context, event *events.Event) error {
+		var payment generated.PaymentSucceeded
+		if err := event.Decode(&payment); err != nil {
+			return err
+		}
+		handled = append(handled, payment.GetOrderId())
+		return nil
+	})
+	bus.Handle(events.TypeOf(&generated.PaymentFailed{}), func(ctx context.Context, event *events.Event) error {
+		return errors.New("order service unavailable")
+	})
+
+	ctx := context.Background()
+
+	succeeded := newEvent(t, &generated.PaymentSucceeded{PaymentId: "payment-1", OrderId: "order-1"})
+	if err := bus.Publish(ctx, succeeded); err != nil {
+		t.Errorf("Publish() error = %v", err)
+	}
+	if len(handled) != 1 || handled[0] != "order-1" {
+		t.Errorf("handled %v, want [order-1]", handled)
+	}
+
+	failed, err := events.New(ctx, "payment-2", &generated.PaymentFailed{PaymentId: "payment-2"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := bus.Publish(ctx, failed); err == nil || !strings.Contains(err.Error(), "order service unavailable") {
+		t.Errorf("Publish() error = %v, want the handler's error", err)
+	}
+
+	created, err := events.New(ctx, "order-1", &generated.OrderCreated{OrderId: "order-1"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := bus.Publish(ctx, created); err != nil {
+		t.Errorf("Publish() of an event without handlers error = %v", err)
+	}
+}
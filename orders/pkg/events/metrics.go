@@ -0,0 +1,19 @@
+package events
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var handledEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "events_handled_total",
+	Help: "Number of events dispatched to handlers, by event type and result.",
+}, []string{"type", "result"})
+
+func recordHandled(eventType string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	handledEvents.WithLabelValues(eventType, result).Inc()
+}
@@ -0,0 +1,149 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Attributes of the Pub/Sub messages carrying events.
+const (
+	AttributeID          = "id"
+	AttributeType        = "type"
+	AttributeAggregateID = "aggregate_id"
+	AttributeTraceParent = "traceparent"
+	AttributeCreatedAt   = "created_at"
+)
+
+var _ Publisher = (*PubSubPublisher)(nil)
+
+// NewPubSubClient creates a Pub/Sub client for projectID. It connects to the
+// emulator at emulatorHost if it is not empty, and authenticates with the
+// service account key in credentialsFile otherwise, if set.
+func NewPubSubClient(
+	ctx context.Context, projectID string, credentialsFile string, emulatorHost string,
+	opts ...option.ClientOption) (*pubsub.Client, error) {
+
+	if emulatorHost == "" && credentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(credentialsFile))
+	}
+
+	if emulatorHost != "" {
+		opts = append(opts,
+			option.WithEndpoint(emulatorHost),
+			option.WithoutAuthentication(),
+			option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())))
+	}
+
+	client, err := pubsub.NewClient(ctx, projectID, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Pub/Sub client: %v", err)
+	}
+
+	return client, nil
+}
+
+// PubSubPublisher publishes events to a Google Cloud Pub/Sub topic. Messages
+// are ordered by aggregate, so subscriptions should enable message ordering.
+type PubSubPublisher struct {
+	topic *pubsub.Topic
+}
+
+// NewPubSubPublisher creates a new instance of PubSubPublisher.
+func NewPubSubPublisher(client *pubsub.Client, topicID string) *PubSubPublisher {
+	topic := client.Topic(topicID)
+	topic.EnableMessageOrdering = true
+
+	return &PubSubPublisher{
+		topic: topic,
+	}
+}
+
+// Publish implements Publisher. It returns once Pub/Sub has stored the event.
+func (p *PubSubPublisher) Publish(ctx context.Context, event *Event) error {
+	result := p.topic.Publish(ctx, &pubsub.Message{
+		Data: event.Payload,
+		Attributes: map[string]string{
+			AttributeID:          event.ID,
+			AttributeType:        event.Type,
+			AttributeAggregateID: event.AggregateID,
+			AttributeTraceParent: event.TraceParent,
+			AttributeCreatedAt:   event.CreatedAt.Format(time.RFC3339Nano),
+		},
+		OrderingKey: event.AggregateID,
+	})
+
+	if _, err := result.Get(ctx); err != nil {
+		// Publishing is paused for the key after a failure, so that later
+		// events do not overtake this one. The outbox retries it in order.
+		p.topic.ResumePublish(event.AggregateID)
+		return fmt.Errorf("failed to publish %s event %s to %s: %v", event.Type, event.ID, p.topic.ID(), err)
+	}
+
+	return nil
+}
+
+// Stop flushes pending messages and stops the publisher's goroutines.
+func (p *PubSubPublisher) Stop(context.Context) error {
+	p.topic.Stop()
+	return nil
+}
+
+// PubSubSubscriber receives events from a Google Cloud Pub/Sub subscription
+// and dispatches them to the handlers of its router. An event is acknowledged
+// once every handler succeeded, and delivered again otherwise.
+type PubSubSubscriber struct {
+	*Router
+
+	subscription *pubsub.Subscription
+}
+
+// NewPubSubSubscriber creates a new instance of PubSubSubscriber.
+func NewPubSubSubscriber(client *pubsub.Client, subscriptionID string) *PubSubSubscriber {
+	return &PubSubSubscriber{
+		Router:       NewRouter(),
+		subscription: client.Subscription(subscriptionID),
+	}
+}
+
+// Run receives events until ctx is cancelled, retrying when the subscription
+// cannot be reached.
+func (s *PubSubSubscriber) Run(ctx context.Context) {
+	for {
+		err := s.subscription.Receive(ctx, s.receive)
+		if err != nil {
+			log.Printf("[events] failed to receive from %s: %v", s.subscription.ID(), err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func (s *PubSubSubscriber) receive(ctx context.Context, msg *pubsub.Message) {
+	event := &Event{
+		ID:          msg.Attributes[AttributeID],
+		Type:        msg.Attributes[AttributeType],
+		AggregateID: msg.Attributes[AttributeAggregateID],
+		Payload:     msg.Data,
+		TraceParent: msg.Attributes[AttributeTraceParent],
+	}
+	event.CreatedAt, _ = time.Parse(time.RFC3339Nano, msg.Attributes[AttributeCreatedAt])
+
+	if err := s.Dispatch(ctx, event); err != nil {
+		log.Printf("[events] %v", err)
+		msg.Nack()
+		return
+	}
+
+	msg.Ack()
+}
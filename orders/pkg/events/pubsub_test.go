@@ -0,0 +1,79 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+
+	"github.com/leta/order-management-system/orders/generated"
+	"github.com/leta/order-management-system/orders/pkg/events"
+)
+
+func TestPubSub(t *testing.T) {
+	srv := pstest.NewServer()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := events.NewPubSubClient(ctx, "test-project", "", srv.Addr)
+	if err != nil {
+		t.Fatalf("NewPubSubClient() error = %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	topic, err := client.CreateTopic(ctx, "payments-events")
+	if err != nil {
+		t.Fatalf("CreateTopic() error = %v", err)
+	}
+	_, err = client.CreateSubscription(ctx, "orders-payments-events", pubsub.SubscriptionConfig{
+		Topic:                 topic,
+		EnableMessageOrdering: true,
+	})
+	if err != nil {
+		t.Fatalf("CreateSubscription() error = %v", err)
+	}
+
+	received := make(chan *events.Event, 1)
+	subscriber := events.NewPubSubSubscriber(client, "orders-payments-events")
+	subscriber.Handle(events.TypeOf(&generated.PaymentSucceeded{}), func(ctx context.Context, event *events.Event) error {
+		received <- event
+		return nil
+	})
+
+	runCtx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		subscriber.Run(runCtx)
+		close(done)
+	}()
+	defer func() {
+		stop()
+		<-done
+	}()
+
+	publisher := events.NewPubSubPublisher(client, "payments-events")
+	defer func() { _ = publisher.Stop(ctx) }()
+
+	event := newEvent(t, &generated.PaymentSucceeded{PaymentId: "payment-1", OrderId: "order-1"})
+	if err := publisher.Publish(ctx, event); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	select {
+	case got := <-received:
+		if got.ID != event.ID || got.AggregateID != "payment-1" || !got.CreatedAt.Equal(event.CreatedAt) {
+			t.Errorf("received %+v, want %+v", got, event)
+		}
+
+		var payment generated.PaymentSucceeded
+		if err := got.Decode(&payment); err != nil || payment.GetOrderId() != "order-1" {
+			t.Errorf("Decode() = %v, %v, want order-1", payment.GetOrderId(), err)
+		}
+	case <-ctx.Done():
+		t.Fatalf("event was not received")
+	}
+}
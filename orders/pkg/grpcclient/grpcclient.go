@@ -0,0 +1,237 @@
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/sony/gobreaker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	_ "google.golang.org/grpc/health" // enables client-side health checking
+	"google.golang.org/grpc/status"
+
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/metrics"
+	"github.com/leta/order-management-system/orders/pkg/tracing"
+)
+
+// healthCheckMethod is always safe to retry.
+const healthCheckMethod = "/grpc.health.v1.Health/Check"
+
+// serviceConfig enables client-side health checking against the peer's
+// liveness service, so a server that is draining stops receiving new RPCs.
+// Readiness is not used since each service's readiness depends on its peer's
+// liveness, and gating on it would deadlock both at startup.
+const serviceConfig = `{
+	"loadBalancingConfig": [{"round_robin": {}}],
+	"healthCheckConfig": {"serviceName": "liveness"}
+}`
+
+// Options tune the resilience of a client connection.
+type Options struct {
+	// Timeout of each attempt of an RPC, unless the caller's deadline is sooner.
+	Timeout time.Duration `yaml:"timeout" env:"TIMEOUT"`
+
+	// Maximum number of attempts of an idempotent RPC, including the first.
+	MaxAttempts int `yaml:"max_attempts" env:"MAX_ATTEMPTS"`
+
+	// Bounds of the exponential backoff between attempts. Each wait is chosen
+	// at random up to the current bound ("full jitter").
+	InitialBackoff time.Duration `yaml:"initial_backoff" env:"INITIAL_BACKOFF"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" env:"MAX_BACKOFF"`
+
+	// Consecutive failures after which the circuit breaker opens and RPCs fail
+	// fast, and how long it stays open before letting a trial RPC through.
+	BreakerFailures    uint32        `yaml:"breaker_failures" env:"BREAKER_FAILURES"`
+	BreakerOpenTimeout time.Duration `yaml:"breaker_open_timeout" env:"BREAKER_OPEN_TIMEOUT"`
+}
+
+// DefaultOptions returns the options used when nothing else is specified.
+func DefaultOptions() Options {
+	return Options{
+		Timeout:            5 * time.Second,
+		MaxAttempts:        3,
+		InitialBackoff:     100 * time.Millisecond,
+		MaxBackoff:         2 * time.Second,
+		BreakerFailures:    5,
+		BreakerOpenTimeout: 30 * time.Second,
+	}
+}
+
+// Validate reports every invalid option. name prefixes the error messages.
+func (o *Options) Validate(name string) error {
+	var errs []error
+
+	if o.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("%s.timeout must be positive", name))
+	}
+	if o.MaxAttempts < 1 {
+		errs = append(errs, fmt.Errorf("%s.max_attempts must be at least 1", name))
+	}
+	if o.InitialBackoff <= 0 || o.MaxBackoff < o.InitialBackoff {
+		errs = append(errs, fmt.Errorf("%s.initial_backoff must be positive and not above max_backoff", name))
+	}
+	if o.BreakerFailures < 1 {
+		errs = append(errs, fmt.Errorf("%s.breaker_failures must be at least 1", name))
+	}
+	if o.BreakerOpenTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("%s.breaker_open_timeout must be positive", name))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Dial creates a client connection to target without waiting for it to be
+// established; the connection is (re)established in the background and RPCs
+// wait for it up to their deadline.
+//
+// Every RPC is traced, measured, bounded by Timeout and guarded by a circuit
+// breaker. Only the given idempotent methods, e.g. "/orders.Orders/UpdateOrderStatus",
+// and health checks are retried.
+func Dial(target string, opts Options, idempotentMethods ...string) (*grpc.ClientConn, error) {
+	idempotent := map[string]bool{healthCheckMethod: true}
+	for _, method := range idempotentMethods {
+		idempotent[method] = true
+	}
+
+	c := &client{
+		target:     target,
+		opts:       opts,
+		idempotent: idempotent,
+		breaker:    newBreaker(target, opts),
+	}
+
+	dialOpts := append(tracing.DialOptions(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           backoff.DefaultConfig,
+			MinConnectTimeout: opts.Timeout,
+		}),
+		grpc.WithChainUnaryInterceptor(metrics.UnaryClientInterceptor(), audit.UnaryClientInterceptor(), c.intercept),
+	)
+
+	conn, err := grpc.Dial(target, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %v", target, err)
+	}
+
+	return conn, nil
+}
+
+type client struct {
+	target     string
+	opts       Options
+	idempotent map[string]bool
+	breaker    *gobreaker.CircuitBreaker
+}
+
+func (c *client) intercept(
+	ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+
+	attempts := 1
+	if c.idempotent[method] {
+		attempts = c.opts.MaxAttempts
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			recordRetry(method)
+
+			if werr := c.wait(ctx, attempt); werr != nil {
+				break
+			}
+		}
+
+		err = c.invoke(ctx, method, req, reply, cc, invoker, opts...)
+		if !c.retryable(ctx, err) {
+			break
+		}
+	}
+
+	return err
+}
+
+// invoke makes a single attempt through the circuit breaker.
+func (c *client) invoke(
+	ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+
+	_, err := c.breaker.Execute(func() (interface{}, error) {
+		attemptCtx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+		defer cancel()
+
+		return nil, invoker(attemptCtx, method, req, reply, cc, opts...)
+	})
+
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		return status.Errorf(codes.Unavailable, "circuit breaker for %s is open", c.target)
+	}
+
+	return err
+}
+
+// retryable returns true if err means the peer could not serve the request,
+// and the caller is still waiting for a response.
+func (c *client) retryable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil || c.breaker.State() == gobreaker.StateOpen {
+		return false
+	}
+	return isPeerFailure(err)
+}
+
+// wait sleeps for a random duration up to the backoff bound of the attempt.
+func (c *client) wait(ctx context.Context, attempt int) error {
+	bound := c.opts.InitialBackoff << (attempt - 1)
+	if bound > c.opts.MaxBackoff || bound <= 0 {
+		bound = c.opts.MaxBackoff
+	}
+
+	// #nosec G404 - jitter does not need a cryptographic source
+	timer := time.NewTimer(time.Duration(rand.Int63n(int64(bound)) + 1))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// isPeerFailure returns true for errors caused by the peer being unreachable
+// or overloaded, as opposed to errors returned by the application.
+func isPeerFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
+
+func newBreaker(target string, opts Options) *gobreaker.CircuitBreaker {
+	setBreakerState(target, gobreaker.StateClosed)
+
+	return gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:        target,
+		MaxRequests: 1,
+		Timeout:     opts.BreakerOpenTimeout,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= opts.BreakerFailures
+		},
+		IsSuccessful: func(err error) bool {
+			return err == nil || !isPeerFailure(err)
+		},
+		OnStateChange: func(name string, from gobreaker.State, to gobreaker.State) {
+			setBreakerState(name, to)
+		},
+	})
+}
@@ -0,0 +1,132 @@
+package grpcclient_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/leta/order-management-system/orders/pkg/grpcclient"
+)
+
+const (
+	idempotentMethod = "/test.Service/Get"
+	otherMethod      = "/test.Service/Create"
+)
+
+// newPeer starts a server that fails every RPC with code and returns its
+// address along with the number of RPCs it received.
+func newPeer(t *testing.T, code codes.Code) (string, *int32) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	var calls int32
+	server := grpc.NewServer(grpc.UnknownServiceHandler(func(srv interface{}, stream grpc.ServerStream) error {
+		// Client-side health checking treats an unimplemented Watch as healthy.
+		if method, _ := grpc.MethodFromServerStream(stream); strings.HasPrefix(method, "/grpc.health.v1.") {
+			return status.Error(codes.Unimplemented, "no health service")
+		}
+		atomic.AddInt32(&calls, 1)
+		return status.Error(code, "failed")
+	}))
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	return lis.Addr().String(), &calls
+}
+
+func testOptions() grpcclient.Options {
+	opts := grpcclient.DefaultOptions()
+	opts.Timeout = time.Second
+	opts.InitialBackoff = time.Millisecond
+	opts.MaxBackoff = 5 * time.Millisecond
+	return opts
+}
+
+func dial(t *testing.T, target string, opts grpcclient.Options) *grpc.ClientConn {
+	t.Helper()
+
+	conn, err := grpcclient.Dial(target, opts, idempotentMethod)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+func TestDial_Retries(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		code      codes.Code
+		wantCalls int32
+	}{
+		{name: "idempotent method, peer unavailable", method: idempotentMethod, code: codes.Unavailable, wantCalls: 3},
+		{name: "other method, peer unavailable", method: otherMethod, code: codes.Unavailable, wantCalls: 1},
+		{name: "idempotent method, application error", method: idempotentMethod, code: codes.InvalidArgument, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, calls := newPeer(t, tt.code)
+			conn := dial(t, target, testOptions())
+
+			err := conn.Invoke(context.Background(), tt.method, &emptypb.Empty{}, &emptypb.Empty{})
+			if status.Code(err) != tt.code {
+				t.Errorf("Invoke() error = %v, want code %v", err, tt.code)
+			}
+			if got := atomic.LoadInt32(calls); got != tt.wantCalls {
+				t.Errorf("peer received %d calls, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDial_CircuitBreaker(t *testing.T) {
+	target, calls := newPeer(t, codes.Unavailable)
+
+	opts := testOptions()
+	opts.BreakerFailures = 2
+	conn := dial(t, target, opts)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_ = conn.Invoke(ctx, otherMethod, &emptypb.Empty{}, &emptypb.Empty{})
+	}
+
+	err := conn.Invoke(ctx, otherMethod, &emptypb.Empty{}, &emptypb.Empty{})
+	if status.Code(err) != codes.Unavailable || !strings.Contains(err.Error(), "circuit breaker") {
+		t.Errorf("Invoke() error = %v, want the circuit breaker to be open", err)
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Errorf("peer received %d calls, want 2", got)
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	opts := grpcclient.DefaultOptions()
+	if err := opts.Validate("client"); err != nil {
+		t.Errorf("DefaultOptions().Validate() error = %v", err)
+	}
+
+	opts.MaxAttempts = 0
+	opts.MaxBackoff = opts.InitialBackoff / 2
+	err := opts.Validate("client")
+	for _, want := range []string{"client.max_attempts", "client.initial_backoff"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, want it to mention %s", err, want)
+		}
+	}
+}
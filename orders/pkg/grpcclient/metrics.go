@@ -0,0 +1,37 @@
+package grpcclient
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sony/gobreaker"
+
+	"github.com/leta/order-management-system/orders/pkg/metrics"
+)
+
+var (
+	clientRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_client_retries_total",
+		Help: "Number of RPC attempts retried by the client, by method.",
+	}, []string{"grpc_service", "grpc_method"})
+
+	breakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "grpc_client_circuit_breaker_state",
+		Help: "State of the circuit breaker of each target: 0 closed, 1 half-open, 2 open.",
+	}, []string{"target"})
+)
+
+func recordRetry(fullMethod string) {
+	service, method := metrics.SplitMethodName(fullMethod)
+	clientRetries.WithLabelValues(service, method).Inc()
+}
+
+func setBreakerState(target string, state gobreaker.State) {
+	var value float64
+	switch state {
+	case gobreaker.StateHalfOpen:
+		value = 1
+	case gobreaker.StateOpen:
+		value = 2
+	}
+	breakerState.WithLabelValues(target).Set(value)
+}
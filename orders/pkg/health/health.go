@@ -0,0 +1,186 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	// LivenessService reports SERVING for as long as the process is up and
+	// able to answer RPCs. Orchestrators should restart the process otherwise.
+	LivenessService = "liveness"
+
+	// ReadinessService reports SERVING only when every registered dependency
+	// check passes. Orchestrators should stop routing traffic otherwise.
+	ReadinessService = "readiness"
+
+	DefaultCheckInterval = 10 * time.Second
+	DefaultCheckTimeout  = 3 * time.Second
+)
+
+// CheckFunc reports whether a dependency is available.
+type CheckFunc func(ctx context.Context) error
+
+// Service runs dependency checks and publishes the results through the
+// standard grpc.health.v1 service.
+//
+// Each dependency is exposed under its own service name, "readiness" (and the
+// empty, server-wide name) aggregate all of them, and "liveness" is always
+// SERVING until Shutdown is called.
+type Service struct {
+	server *health.Server
+
+	mu      sync.RWMutex
+	names   []string
+	checks  map[string]CheckFunc
+	results map[string]error
+
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+// NewService creates a new instance of Service.
+func NewService() *Service {
+	s := &Service{
+		server:   health.NewServer(),
+		checks:   make(map[string]CheckFunc),
+		results:  make(map[string]error),
+		Interval: DefaultCheckInterval,
+		Timeout:  DefaultCheckTimeout,
+	}
+
+	s.server.SetServingStatus(LivenessService, healthpb.HealthCheckResponse_SERVING)
+	s.server.SetServingStatus(ReadinessService, healthpb.HealthCheckResponse_NOT_SERVING)
+	s.server.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	return s
+}
+
+// AddCheck registers a dependency check under the given service name.
+func (s *Service) AddCheck(name string, check CheckFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.checks[name]; !ok {
+		s.names = append(s.names, name)
+	}
+	s.checks[name] = check
+
+	s.server.SetServingStatus(name, healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+// Register registers the grpc.health.v1 service on the given server.
+func (s *Service) Register(server *grpc.Server) {
+	healthpb.RegisterHealthServer(server, s.server)
+}
+
+// Run checks the dependencies every Interval until the context is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.CheckAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckAll runs every dependency check once, updates the published serving
+// statuses and returns the result of each check.
+func (s *Service) CheckAll(ctx context.Context) map[string]error {
+	s.mu.RLock()
+	names := append([]string(nil), s.names...)
+	checks := make(map[string]CheckFunc, len(s.checks))
+	for name, check := range s.checks {
+		checks[name] = check
+	}
+	s.mu.RUnlock()
+
+	results := make(map[string]error, len(names))
+	ready := true
+
+	for _, name := range names {
+		checkCtx, cancel := context.WithTimeout(ctx, s.Timeout)
+		err := checks[name](checkCtx)
+		cancel()
+
+		results[name] = err
+		if err != nil {
+			ready = false
+			log.Printf("[health] %s check failed: %v", name, err)
+		}
+
+		s.server.SetServingStatus(name, servingStatus(err == nil))
+	}
+
+	s.server.SetServingStatus(ReadinessService, servingStatus(ready))
+	s.server.SetServingStatus("", servingStatus(ready))
+
+	s.mu.Lock()
+	s.results = results
+	s.mu.Unlock()
+
+	return results
+}
+
+// Ready returns true if every dependency passed its most recent check.
+func (s *Service) Ready() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.results) != len(s.checks) {
+		return false
+	}
+
+	for _, err := range s.results {
+		if err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Shutdown marks every service as NOT_SERVING, including liveness, so that
+// clients stop sending new requests while the server drains.
+func (s *Service) Shutdown() {
+	s.server.Shutdown()
+}
+
+// GRPCCheck returns a CheckFunc that asks the peer at the other end of conn
+// whether the given health service is SERVING.
+func GRPCCheck(conn grpc.ClientConnInterface, service string) CheckFunc {
+	client := healthpb.NewHealthClient(conn)
+
+	return func(ctx context.Context) error {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			return err
+		}
+
+		if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			return fmt.Errorf("peer %q is %s", service, resp.GetStatus())
+		}
+
+		return nil
+	}
+}
+
+func servingStatus(ok bool) healthpb.HealthCheckResponse_ServingStatus {
+	if ok {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
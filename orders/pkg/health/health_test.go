@@ -0,0 +1,110 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/leta/order-management-system/orders/pkg/health"
+)
+
+func newTestClient(t *testing.T, s *health.Service) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	s.Register(server)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+func TestService_CheckAll(t *testing.T) {
+	dbErr := errors.New("firestore unavailable")
+	dbDown := true
+
+	s := health.NewService()
+	s.AddCheck("firestore", func(ctx context.Context) error {
+		if dbDown {
+			return dbErr
+		}
+		return nil
+	})
+	s.AddCheck("peer", func(ctx context.Context) error { return nil })
+
+	client := healthpb.NewHealthClient(newTestClient(t, s))
+	ctx := context.Background()
+
+	statusOf := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatalf("Check(%q) error = %v", service, err)
+		}
+		return resp.GetStatus()
+	}
+
+	if s.Ready() {
+		t.Errorf("Ready() = true before any check ran")
+	}
+
+	results := s.CheckAll(ctx)
+	if !errors.Is(results["firestore"], dbErr) {
+		t.Errorf("CheckAll()[firestore] = %v, want %v", results["firestore"], dbErr)
+	}
+
+	tests := map[string]healthpb.HealthCheckResponse_ServingStatus{
+		health.LivenessService:  healthpb.HealthCheckResponse_SERVING,
+		health.ReadinessService: healthpb.HealthCheckResponse_NOT_SERVING,
+		"firestore":             healthpb.HealthCheckResponse_NOT_SERVING,
+		"peer":                  healthpb.HealthCheckResponse_SERVING,
+	}
+	for service, want := range tests {
+		if got := statusOf(service); got != want {
+			t.Errorf("status(%q) = %v, want %v", service, got, want)
+		}
+	}
+
+	dbDown = false
+	s.CheckAll(ctx)
+
+	if !s.Ready() {
+		t.Errorf("Ready() = false after all checks passed")
+	}
+	if got := statusOf(health.ReadinessService); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("status(readiness) = %v, want SERVING", got)
+	}
+
+	s.Shutdown()
+	if got := statusOf(health.LivenessService); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("status(liveness) after Shutdown = %v, want NOT_SERVING", got)
+	}
+}
+
+func TestGRPCCheck(t *testing.T) {
+	peer := health.NewService()
+	conn := newTestClient(t, peer)
+	ctx := context.Background()
+
+	if err := health.GRPCCheck(conn, health.LivenessService)(ctx); err != nil {
+		t.Errorf("GRPCCheck(liveness) error = %v", err)
+	}
+
+	if err := health.GRPCCheck(conn, health.ReadinessService)(ctx); err == nil {
+		t.Errorf("GRPCCheck(readiness) expected error for a peer that is not ready")
+	}
+}
@@ -0,0 +1,172 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultGracePeriod matches the time Cloud Run gives a container between
+// SIGTERM and SIGKILL.
+const DefaultGracePeriod = 10 * time.Second
+
+// StartFunc starts a component. It may block until the component stops, in
+// which case it should return nil once StopFunc has been called. Any error
+// triggers the shutdown of the whole process.
+type StartFunc func(ctx context.Context) error
+
+// StopFunc stops a component gracefully, giving up when ctx expires.
+type StopFunc func(ctx context.Context) error
+
+// JobFunc is a background job. It must return once ctx is cancelled.
+type JobFunc func(ctx context.Context)
+
+type component struct {
+	name  string
+	start StartFunc
+	stop  StopFunc
+}
+
+type job struct {
+	name string
+	run  JobFunc
+}
+
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Manager runs the servers and background jobs of a service together and
+// shuts them down on SIGINT/SIGTERM, or as soon as one of them fails.
+//
+// On shutdown, components are stopped in the reverse order they were added so
+// that, e.g., a gateway drains before the server it forwards to. Background
+// jobs are then cancelled and waited for, and finally the shutdown hooks run
+// (closing clients, flushing exporters). All of it shares one grace period.
+type Manager struct {
+	GracePeriod time.Duration
+
+	components []component
+	jobs       []job
+	hooks      []hook
+}
+
+// NewManager creates a new instance of Manager.
+func NewManager() *Manager {
+	return &Manager{
+		GracePeriod: DefaultGracePeriod,
+	}
+}
+
+// Add registers a component, typically a server.
+func (m *Manager) Add(name string, start StartFunc, stop StopFunc) {
+	m.components = append(m.components, component{name: name, start: start, stop: stop})
+}
+
+// AddHTTPServer registers a net/http server listening on its Addr.
+func (m *Manager) AddHTTPServer(name string, server *http.Server) {
+	m.Add(name, func(ctx context.Context) error {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	}, server.Shutdown)
+}
+
+// AddJob registers a background job.
+func (m *Manager) AddJob(name string, run JobFunc) {
+	m.jobs = append(m.jobs, job{name: name, run: run})
+}
+
+// OnShutdown registers a function to run once every component and job has
+// stopped. Hooks run in the reverse order they were added.
+func (m *Manager) OnShutdown(name string, fn func(ctx context.Context) error) {
+	m.hooks = append(m.hooks, hook{name: name, fn: fn})
+}
+
+// Run starts every component and job, then blocks until ctx is cancelled, a
+// termination signal is received or a component fails, and shuts everything
+// down. It returns the error that caused the shutdown, if any, along with any
+// error encountered while shutting down.
+func (m *Manager) Run(ctx context.Context) error {
+	ctx, stopSignals := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	// Components and jobs keep running while the process drains, so they get
+	// a context of their own rather than the one cancelled by the signal.
+	runCtx, cancelRun := context.WithCancel(context.Background())
+	defer cancelRun()
+
+	errc := make(chan error, len(m.components))
+	for _, c := range m.components {
+		go func(c component) {
+			if err := c.start(runCtx); err != nil {
+				errc <- fmt.Errorf("%s: %w", c.name, err)
+			}
+		}(c)
+	}
+
+	var jobs sync.WaitGroup
+	for _, j := range m.jobs {
+		jobs.Add(1)
+		go func(j job) {
+			defer jobs.Done()
+			j.run(runCtx)
+		}(j)
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		log.Printf("[lifecycle] shutting down, grace period %s", m.GracePeriod)
+	case runErr = <-errc:
+		log.Printf("[lifecycle] %v, shutting down", runErr)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), m.GracePeriod)
+	defer cancel()
+
+	errs := []error{runErr}
+
+	for i := len(m.components) - 1; i >= 0; i-- {
+		c := m.components[i]
+		if c.stop == nil {
+			continue
+		}
+		if err := c.stop(shutdownCtx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", c.name, err))
+		}
+	}
+
+	cancelRun()
+
+	done := make(chan struct{})
+	go func() {
+		jobs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		errs = append(errs, errors.New("background jobs did not stop within the grace period"))
+	}
+
+	for i := len(m.hooks) - 1; i >= 0; i-- {
+		h := m.hooks[i]
+		if err := h.fn(shutdownCtx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to shut down %s: %w", h.name, err))
+		}
+	}
+
+	log.Printf("[lifecycle] shutdown complete")
+
+	return errors.Join(errs...)
+}
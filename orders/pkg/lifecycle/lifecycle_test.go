@@ -0,0 +1,123 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leta/order-management-system/orders/pkg/lifecycle"
+)
+
+// recorder collects the order in which components are stopped.
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+// addBlockingServer adds a server whose start blocks until it is stopped,
+// like grpc.Server.Serve.
+func addBlockingServer(m *lifecycle.Manager, r *recorder, name string) {
+	stopped := make(chan struct{})
+
+	m.Add(name, func(ctx context.Context) error {
+		<-stopped
+		return nil
+	}, func(ctx context.Context) error {
+		r.record("stop " + name)
+		close(stopped)
+		return nil
+	})
+}
+
+func TestManager_Run(t *testing.T) {
+	r := &recorder{}
+	m := lifecycle.NewManager()
+
+	addBlockingServer(m, r, "grpc")
+	addBlockingServer(m, r, "gateway")
+
+	jobStarted := make(chan struct{})
+	m.AddJob("job", func(ctx context.Context) {
+		close(jobStarted)
+		<-ctx.Done()
+		r.record("stop job")
+	})
+
+	m.OnShutdown("tracing", func(context.Context) error { r.record("close tracing"); return nil })
+	m.OnShutdown("firestore", func(context.Context) error { r.record("close firestore"); return nil })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-jobStarted
+		cancel()
+	}()
+
+	if err := m.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	want := []string{"stop gateway", "stop grpc", "stop job", "close firestore", "close tracing"}
+	if got := r.get(); !reflect.DeepEqual(got, want) {
+		t.Errorf("shutdown order = %v, want %v", got, want)
+	}
+}
+
+func TestManager_Run_ComponentFails(t *testing.T) {
+	r := &recorder{}
+	m := lifecycle.NewManager()
+
+	addBlockingServer(m, r, "grpc")
+	m.Add("gateway", func(context.Context) error {
+		return errors.New("address already in use")
+	}, nil)
+
+	err := m.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "gateway: address already in use") {
+		t.Errorf("Run() error = %v, want the gateway error", err)
+	}
+
+	if got, want := r.get(), []string{"stop grpc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("shutdown order = %v, want %v", got, want)
+	}
+}
+
+func TestManager_Run_GracePeriodExceeded(t *testing.T) {
+	m := lifecycle.NewManager()
+	m.GracePeriod = 10 * time.Millisecond
+
+	m.Add("grpc", func(ctx context.Context) error { return nil }, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	release := make(chan struct{})
+	defer close(release)
+	m.AddJob("stuck", func(ctx context.Context) { <-release })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := m.Run(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if err == nil || !strings.Contains(err.Error(), "background jobs did not stop") {
+		t.Errorf("Run() error = %v, want it to report the stuck job", err)
+	}
+}
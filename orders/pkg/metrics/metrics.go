@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	rpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_server_handling_seconds",
+		Help:    "Latency of unary RPCs handled by the server, by method and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"grpc_service", "grpc_method", "grpc_code"})
+
+	clientDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_client_handling_seconds",
+		Help:    "Latency of unary RPCs made by clients, including retries, by method and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"grpc_service", "grpc_method", "grpc_code"})
+
+	firestoreDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "firestore_request_duration_seconds",
+		Help:    "Latency of Firestore API calls, by method and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "code"})
+)
+
+// Handler returns the HTTP handler exposing metrics in the Prometheus format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// UnaryServerInterceptor records the latency and status code of every unary RPC.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		service, method := SplitMethodName(info.FullMethod)
+		rpcDuration.WithLabelValues(service, method, status.Code(err).String()).Observe(time.Since(start).Seconds())
+
+		return resp, err
+	}
+}
+
+// UnaryClientInterceptor records the latency and status code of every unary RPC
+// made by a client.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context, fullMethod string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+
+		start := time.Now()
+		err := invoker(ctx, fullMethod, req, reply, cc, opts...)
+
+		service, method := SplitMethodName(fullMethod)
+		clientDuration.WithLabelValues(service, method, status.Code(err).String()).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
+
+// FirestoreClientOptions returns client options that record the latency of
+// calls made by a Firestore client.
+func FirestoreClientOptions() []option.ClientOption {
+	return []option.ClientOption{
+		option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(firestoreUnaryInterceptor)),
+		option.WithGRPCDialOption(grpc.WithChainStreamInterceptor(firestoreStreamInterceptor)),
+	}
+}
+
+func firestoreUnaryInterceptor(
+	ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+
+	firestoreDuration.WithLabelValues(path.Base(method), status.Code(err).String()).Observe(time.Since(start).Seconds())
+
+	return err
+}
+
+// firestoreStreamInterceptor records the time taken to open a stream (queries,
+// batch gets), which includes waiting for the first response headers.
+func firestoreStreamInterceptor(
+	ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+	streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+
+	start := time.Now()
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+
+	firestoreDuration.WithLabelValues(path.Base(method), status.Code(err).String()).Observe(time.Since(start).Seconds())
+
+	return stream, err
+}
+
+// SplitMethodName splits "/package.Service/Method" into its service and method.
+func SplitMethodName(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.Index(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
@@ -0,0 +1,52 @@
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/leta/order-management-system/orders/pkg/metrics"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	interceptor := metrics.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/orders.Orders/GetOrder"}
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "ok"},
+		{name: "not found", err: status.Error(codes.NotFound, "order not found")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, tt.err
+			})
+			if err != tt.err {
+				t.Errorf("interceptor() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	for _, want := range []string{
+		`grpc_server_handling_seconds_count{grpc_code="OK",grpc_method="GetOrder",grpc_service="orders.Orders"} 1`,
+		`grpc_server_handling_seconds_count{grpc_code="NotFound",grpc_method="GetOrder",grpc_service="orders.Orders"} 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics output missing %q", want)
+		}
+	}
+}
@@ -1,11 +1,86 @@
 package models
 
+import "time"
+
 type ProductModel struct {
 	Name        string `firestore:"name"`
 	Description string `firestore:"description"`
-	Price       int    `firestore:"price"`
+	Price       int64  `firestore:"price"`
+	Currency    string `firestore:"currency"`
+	TaxCategory string `firestore:"tax_category,omitempty"`
 	CreatedAt   string `firestore:"created_at"`
 	UpdatedAt   string `firestore:"updated_at"`
+	DeletedAt   string `firestore:"deleted_at,omitempty"`
+	Version     int64  `firestore:"version"`
+
+	Sku        string `firestore:"sku,omitempty"`
+	CategoryId string `firestore:"category_id,omitempty"`
+	// Stored negated, so that products written before they could be
+	// deactivated are active.
+	Inactive bool                   `firestore:"inactive,omitempty"`
+	Variants []*ProductVariantModel `firestore:"variants,omitempty"`
+
+	Components []*ComponentModel `firestore:"components,omitempty"`
+	// The IDs of the products of the components, so that the bundles of a
+	// product can be queried.
+	ComponentIds []string `firestore:"component_ids,omitempty"`
+}
+
+// ComponentModel is a product, or one of its variants, in a bundle or in an
+// order item of a bundle.
+type ComponentModel struct {
+	ProductId string `firestore:"product_id"`
+	VariantId string `firestore:"variant_id,omitempty"`
+	Quantity  int    `firestore:"quantity"`
+}
+
+// ProductVariantModel is in the currency of its product.
+type ProductVariantModel struct {
+	Id       string            `firestore:"id"`
+	Sku      string            `firestore:"sku"`
+	Options  map[string]string `firestore:"options"`
+	Price    int64             `firestore:"price"`
+	Stock    int               `firestore:"stock"`
+	Inactive bool              `firestore:"inactive,omitempty"`
+}
+
+// SkuModel is keyed by the SKU it reserves for a product or one of its
+// variants.
+type SkuModel struct {
+	ProductId string `firestore:"product_id"`
+}
+
+// PriceListModel holds its prices in the currency of the list.
+type PriceListModel struct {
+	Name        string        `firestore:"name"`
+	Currency    string        `firestore:"currency"`
+	CustomerIds []string      `firestore:"customer_ids,omitempty"`
+	Groups      []string      `firestore:"groups,omitempty"`
+	StartsAt    string        `firestore:"starts_at,omitempty"`
+	EndsAt      string        `firestore:"ends_at,omitempty"`
+	Prices      []*PriceModel `firestore:"prices,omitempty"`
+	CreatedAt   string        `firestore:"created_at"`
+	UpdatedAt   string        `firestore:"updated_at"`
+	Version     int64         `firestore:"version"`
+}
+
+type PriceModel struct {
+	ProductId string       `firestore:"product_id"`
+	VariantId string       `firestore:"variant_id,omitempty"`
+	Tiers     []*TierModel `firestore:"tiers"`
+}
+
+type TierModel struct {
+	MinQuantity int   `firestore:"min_quantity"`
+	UnitPrice   int64 `firestore:"unit_price"`
+}
+
+type CategoryModel struct {
+	Name      string `firestore:"name"`
+	ParentId  string `firestore:"parent_id,omitempty"`
+	CreatedAt string `firestore:"created_at"`
+	UpdatedAt string `firestore:"updated_at"`
+	Version   int64  `firestore:"version"`
 }
 
 type CustomerModel struct {
@@ -15,6 +90,32 @@ type CustomerModel struct {
 	Phone     string `firestore:"phone"`
 	CreatedAt string `firestore:"created_at"`
 	UpdatedAt string `firestore:"updated_at"`
+	DeletedAt string `firestore:"deleted_at,omitempty"`
+	Version   int64  `firestore:"version"`
+
+	SmsOptOut   bool `firestore:"sms_opt_out"`
+	EmailOptOut bool `firestore:"email_opt_out"`
+	TaxExempt   bool `firestore:"tax_exempt"`
+
+	Group string `firestore:"group,omitempty"`
+
+	Addresses        []*AddressModel `firestore:"addresses,omitempty"`
+	DefaultAddressId string          `firestore:"default_address_id,omitempty"`
+}
+
+// AddressModel is an address of a customer, or the copy an order keeps of
+// the address it is shipped to.
+type AddressModel struct {
+	Id         string `firestore:"id"`
+	Label      string `firestore:"label"`
+	Recipient  string `firestore:"recipient"`
+	Phone      string `firestore:"phone"`
+	Line1      string `firestore:"line1"`
+	Line2      string `firestore:"line2"`
+	City       string `firestore:"city"`
+	Region     string `firestore:"region"`
+	PostalCode string `firestore:"postal_code"`
+	Country    string `firestore:"country"`
 }
 
 type OrderModel struct {
@@ -23,11 +124,184 @@ type OrderModel struct {
 	OrderStatus string            `firestore:"order_status"`
 	CreatedAt   string            `firestore:"created_at"`
 	UpdatedAt   string            `firestore:"updated_at"`
+	DeletedAt   string            `firestore:"deleted_at,omitempty"`
+	Version     int64             `firestore:"version"`
+	Tax         *OrderTaxModel    `firestore:"tax,omitempty"`
+	CouponCode  string            `firestore:"coupon_code,omitempty"`
+	Discount    *DiscountModel    `firestore:"discount,omitempty"`
+
+	ShippingAddress  *AddressModel `firestore:"shipping_address,omitempty"`
+	FulfilmentStatus string        `firestore:"fulfilment_status,omitempty"`
+	LatePaymentAt    string        `firestore:"late_payment_at,omitempty"`
+}
+
+// DiscountModel is in the currency of the tax of its order.
+type DiscountModel struct {
+	Code        string `firestore:"code"`
+	Description string `firestore:"description"`
+	Amount      int64  `firestore:"amount"`
+}
+
+type OrderTaxModel struct {
+	Inclusive bool   `firestore:"inclusive"`
+	Exempt    bool   `firestore:"exempt"`
+	Subtotal  int64  `firestore:"subtotal"`
+	Amount    int64  `firestore:"amount"`
+	Total     int64  `firestore:"total"`
+	Currency  string `firestore:"currency"`
 }
 
 type OrderItemModel struct {
+	ProductId string        `firestore:"product_id"`
+	VariantId string        `firestore:"variant_id,omitempty"`
+	Quantity  int           `firestore:"quantity"`
+	UnitPrice int64         `firestore:"unit_price"`
+	Currency  string        `firestore:"currency"`
+	CreatedAt string        `firestore:"created_at"`
+	UpdatedAt string        `firestore:"updated_at"`
+	Version   int64         `firestore:"version"`
+	Tax       *ItemTaxModel `firestore:"tax,omitempty"`
+	Discount  int64         `firestore:"discount,omitempty"`
+
+	Components []*ComponentModel `firestore:"components,omitempty"`
+}
+
+// ItemTaxModel is in the currency of its item.
+type ItemTaxModel struct {
+	Category string `firestore:"category"`
+	Rate     int64  `firestore:"rate"`
+	Amount   int64  `firestore:"amount"`
+	Total    int64  `firestore:"total"`
+}
+
+type PromotionModel struct {
+	Description        string `firestore:"description"`
+	Type               string `firestore:"type"`
+	PercentOff         int64  `firestore:"percent_off"`
+	AmountOff          int64  `firestore:"amount_off"`
+	AmountOffCurrency  string `firestore:"amount_off_currency"`
+	BuyProductId       string `firestore:"buy_product_id"`
+	BuyQuantity        int    `firestore:"buy_quantity"`
+	GetProductId       string `firestore:"get_product_id"`
+	GetQuantity        int    `firestore:"get_quantity"`
+	MinOrderValue      int64  `firestore:"min_order_value"`
+	MinOrderCurrency   string `firestore:"min_order_currency"`
+	MaxUses            int64  `firestore:"max_uses"`
+	MaxUsesPerCustomer int64  `firestore:"max_uses_per_customer"`
+	Uses               int64  `firestore:"uses"`
+	StartsAt           string `firestore:"starts_at"`
+	EndsAt             string `firestore:"ends_at"`
+	CreatedAt          string `firestore:"created_at"`
+	UpdatedAt          string `firestore:"updated_at"`
+	Version            int64  `firestore:"version"`
+}
+
+// PromotionRedemptionModel lists the orders a customer applied a coupon to.
+type PromotionRedemptionModel struct {
+	OrderIds []string `firestore:"order_ids"`
+}
+
+// CartModel is keyed by customer ID. Its items are a field rather than a
+// subcollection, as a cart is always read and written whole.
+type CartModel struct {
+	Items     []*CartItemModel `firestore:"items"`
+	CreatedAt string           `firestore:"created_at"`
+	UpdatedAt string           `firestore:"updated_at"`
+	ExpiresAt string           `firestore:"expires_at"`
+	Version   int64            `firestore:"version"`
+}
+
+type CartItemModel struct {
 	ProductId string `firestore:"product_id"`
+	VariantId string `firestore:"variant_id,omitempty"`
 	Quantity  int    `firestore:"quantity"`
-	CreatedAt string `firestore:"created_at"`
-	UpdatedAt string `firestore:"updated_at"`
+	AddedAt   string `firestore:"added_at"`
+}
+
+// ShipmentModel is stored in the shipments subcollection of its order.
+type ShipmentModel struct {
+	Carrier        string               `firestore:"carrier"`
+	TrackingNumber string               `firestore:"tracking_number"`
+	Items          []*ShipmentItemModel `firestore:"items"`
+	Status         string               `firestore:"status"`
+	ShippedAt      string               `firestore:"shipped_at"`
+	DeliveredAt    string               `firestore:"delivered_at,omitempty"`
+	CreatedAt      string               `firestore:"created_at"`
+	UpdatedAt      string               `firestore:"updated_at"`
+	Version        int64                `firestore:"version"`
+}
+
+type ShipmentItemModel struct {
+	OrderItemId string `firestore:"order_item_id"`
+	Quantity    int    `firestore:"quantity"`
+}
+
+// ReturnModel is stored in the returns subcollection of its order.
+type ReturnModel struct {
+	Items           []*ReturnItemModel `firestore:"items"`
+	Reason          string             `firestore:"reason"`
+	Status          string             `firestore:"status"`
+	RejectionReason string             `firestore:"rejection_reason,omitempty"`
+	Restocked       bool               `firestore:"restocked"`
+	RefundAmount    int64              `firestore:"refund_amount"`
+	RefundCurrency  string             `firestore:"refund_currency"`
+	RefundReference string             `firestore:"refund_reference,omitempty"`
+	RequestedAt     string             `firestore:"requested_at"`
+	ReceivedAt      string             `firestore:"received_at,omitempty"`
+	RefundedAt      string             `firestore:"refunded_at,omitempty"`
+	CreatedAt       string             `firestore:"created_at"`
+	UpdatedAt       string             `firestore:"updated_at"`
+	Version         int64              `firestore:"version"`
+}
+
+type ReturnItemModel struct {
+	OrderItemId string `firestore:"order_item_id"`
+	Quantity    int    `firestore:"quantity"`
+}
+
+type WebhookSubscriptionModel struct {
+	Url        string   `firestore:"url"`
+	EventTypes []string `firestore:"event_types"`
+	Secret     string   `firestore:"secret"`
+	CreatedAt  string   `firestore:"created_at"`
+	UpdatedAt  string   `firestore:"updated_at"`
+}
+
+type WebhookDeliveryModel struct {
+	SubscriptionId string    `firestore:"subscription_id"`
+	EventId        string    `firestore:"event_id"`
+	EventType      string    `firestore:"event_type"`
+	Body           []byte    `firestore:"body"`
+	Status         string    `firestore:"status"`
+	Attempts       int       `firestore:"attempts"`
+	NextAttemptAt  time.Time `firestore:"next_attempt_at"`
+	LastError      string    `firestore:"last_error"`
+	CreatedAt      time.Time `firestore:"created_at"`
+	UpdatedAt      time.Time `firestore:"updated_at"`
+}
+
+type WebhookAttemptModel struct {
+	SubscriptionId string    `firestore:"subscription_id"`
+	DeliveryId     string    `firestore:"delivery_id"`
+	Attempt        int       `firestore:"attempt"`
+	StatusCode     int       `firestore:"status_code"`
+	Error          string    `firestore:"error"`
+	DurationMs     int64     `firestore:"duration_ms"`
+	AttemptedAt    time.Time `firestore:"attempted_at"`
+}
+
+type NotificationModel struct {
+	CustomerId string    `firestore:"customer_id"`
+	OrderId    string    `firestore:"order_id"`
+	EventId    string    `firestore:"event_id"`
+	Template   string    `firestore:"template"`
+	Channel    string    `firestore:"channel"`
+	Recipient  string    `firestore:"recipient"`
+	Subject    string    `firestore:"subject"`
+	Body       string    `firestore:"body"`
+	Status     string    `firestore:"status"`
+	Attempts   int       `firestore:"attempts"`
+	Error      string    `firestore:"error"`
+	CreatedAt  time.Time `firestore:"created_at"`
+	UpdatedAt  time.Time `firestore:"updated_at"`
 }
@@ -0,0 +1,248 @@
+// Package money represents amounts of money as integers in the minor units of
+// their currency, e.g. cents, so that arithmetic on them is exact.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// DefaultCurrency is the currency of amounts that do not specify one, which
+// includes every amount stored before amounts carried a currency.
+const DefaultCurrency = "KES"
+
+var (
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrOverflow         = errors.New("amount overflows")
+)
+
+// exponents holds the number of minor units digits of the supported ISO 4217
+// currencies.
+var exponents = map[string]int{
+	"KES": 2,
+	"UGX": 0,
+	"TZS": 2,
+	"RWF": 0,
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+}
+
+// Money is an amount in the minor units of an ISO 4217 currency.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// New returns amount minor units of currency, or of DefaultCurrency if
+// currency is empty.
+func New(amount int64, currency string) Money {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// Zero returns no money in currency.
+func Zero(currency string) Money {
+	return New(0, currency)
+}
+
+// FromStored returns the money stored in a document as amount and currency.
+// Documents written before amounts carried a currency have none, and their
+// amounts are in major units of DefaultCurrency.
+func FromStored(amount int64, currency string) Money {
+	if currency != "" {
+		return New(amount, currency)
+	}
+
+	m, err := New(amount, DefaultCurrency).Mul(Scale(DefaultCurrency))
+	if err != nil {
+		// Legacy amounts are uint32s, far from overflowing.
+		panic(err)
+	}
+	return m
+}
+
+// Exponent returns the number of minor units digits of currency, e.g. 2 for
+// KES, and -1 for an unsupported currency.
+func Exponent(currency string) int {
+	if e, ok := exponents[strings.ToUpper(currency)]; ok {
+		return e
+	}
+	return -1
+}
+
+// Scale returns the number of minor units in a major unit of currency.
+func Scale(currency string) int64 {
+	scale := int64(1)
+	for i := 0; i < Exponent(currency); i++ {
+		scale *= 10
+	}
+	return scale
+}
+
+// Validate returns an error unless the currency of m is supported and its
+// amount is not negative.
+func (m Money) Validate() error {
+	if Exponent(m.Currency) < 0 {
+		return fmt.Errorf("unsupported currency %q", m.Currency)
+	}
+
+	if m.Amount < 0 {
+		return fmt.Errorf("amount %s is negative", m)
+	}
+
+	return nil
+}
+
+// IsZero reports whether m is no money.
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Add returns m+o. Both must be in the same currency.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+
+	sum := m.Amount + o.Amount
+	if (sum > m.Amount) != (o.Amount > 0) {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrOverflow, m, o)
+	}
+
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+// Sub returns m-o. Both must be in the same currency.
+func (m Money) Sub(o Money) (Money, error) {
+	if o.Amount == math.MinInt64 {
+		return Money{}, fmt.Errorf("%w: %s - %s", ErrOverflow, m, o)
+	}
+	return m.Add(Money{Amount: -o.Amount, Currency: o.Currency})
+}
+
+// Mul returns m*n.
+func (m Money) Mul(n int64) (Money, error) {
+	if m.Amount == 0 || n == 0 {
+		return Money{Amount: 0, Currency: m.Currency}, nil
+	}
+
+	product := m.Amount * n
+	if product/n != m.Amount || (n == -1 && m.Amount == math.MinInt64) {
+		return Money{}, fmt.Errorf("%w: %s * %d", ErrOverflow, m, n)
+	}
+
+	return Money{Amount: product, Currency: m.Currency}, nil
+}
+
+// Sum returns the sum of amounts, all in currency.
+func Sum(currency string, amounts ...Money) (Money, error) {
+	total := Zero(currency)
+	for _, a := range amounts {
+		var err error
+		if total, err = total.Add(a); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// Rounding is the rule applied to a result that falls between two minor
+// units.
+type Rounding int
+
+const (
+	// HalfUp rounds to the nearest minor unit, and halves away from zero.
+	HalfUp Rounding = iota
+	// HalfEven rounds to the nearest minor unit, and halves to the even one.
+	HalfEven
+	// Up rounds away from zero.
+	Up
+	// Down rounds towards zero.
+	Down
+)
+
+// MulRatio returns m*num/den rounded to a minor unit, e.g. 16% of m with
+// MulRatio(16, 100, HalfUp).
+func (m Money) MulRatio(num, den int64, rounding Rounding) (Money, error) {
+	if den == 0 {
+		return Money{}, errors.New("division by zero")
+	}
+
+	n := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(num))
+	d := big.NewInt(den)
+	if d.Sign() < 0 {
+		n.Neg(n)
+		d.Neg(d)
+	}
+
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+	if r.Sign() != 0 && roundsAway(q, r, d, rounding) {
+		q.Add(q, big.NewInt(int64(n.Sign())))
+	}
+
+	if !q.IsInt64() {
+		return Money{}, fmt.Errorf("%w: %s * %d / %d", ErrOverflow, m, num, den)
+	}
+
+	return Money{Amount: q.Int64(), Currency: m.Currency}, nil
+}
+
+// roundsAway reports whether the quotient q, truncated towards zero with the
+// remainder r of a division by d > 0, is to be rounded away from zero.
+func roundsAway(q, r, d *big.Int, rounding Rounding) bool {
+	switch rounding {
+	case Up:
+		return true
+	case Down:
+		return false
+	}
+
+	// Compare 2|r| with d to find on which side of the half the result is.
+	c := new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(d)
+	switch {
+	case c > 0:
+		return true
+	case c < 0:
+		return false
+	case rounding == HalfEven:
+		return q.Bit(0) == 1
+	default:
+		return true
+	}
+}
+
+// Major returns m in whole major units, rounded, for systems such as M-Pesa
+// that only accept whole amounts.
+func (m Money) Major(rounding Rounding) (int64, error) {
+	major, err := m.MulRatio(1, Scale(m.Currency), rounding)
+	if err != nil {
+		return 0, err
+	}
+	return major.Amount, nil
+}
+
+// String formats m in major units, e.g. "KES 12.50".
+func (m Money) String() string {
+	exp := Exponent(m.Currency)
+	if exp <= 0 {
+		return fmt.Sprintf("%s %d", m.Currency, m.Amount)
+	}
+
+	sign := ""
+	amount := new(big.Int).SetInt64(m.Amount)
+	if amount.Sign() < 0 {
+		sign = "-"
+		amount.Neg(amount)
+	}
+
+	scale := big.NewInt(Scale(m.Currency))
+	major, minor := new(big.Int).QuoRem(amount, scale, new(big.Int))
+
+	return fmt.Sprintf("%s %s%s.%0*d", m.Currency, sign, major, exp, minor.Int64())
+}
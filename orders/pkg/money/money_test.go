@@ -0,0 +1,113 @@
+package money_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/leta/order-management-system/orders/pkg/money"
+)
+
+func TestMoney_Add(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    money.Money
+		want    money.Money
+		wantErr error
+	}{
+		{
+			name: "same currency",
+			a:    money.New(1050, "KES"),
+			b:    money.New(250, "KES"),
+			want: money.New(1300, "KES"),
+		},
+		{
+			name:    "different currencies",
+			a:       money.New(1050, "KES"),
+			b:       money.New(250, "USD"),
+			wantErr: money.ErrCurrencyMismatch,
+		},
+		{
+			name:    "overflow",
+			a:       money.New(math.MaxInt64, "KES"),
+			b:       money.New(1, "KES"),
+			wantErr: money.ErrOverflow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.a.Add(tt.b)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Add() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Add() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoney_Mul(t *testing.T) {
+	if got, err := money.New(1050, "KES").Mul(3); err != nil || got != money.New(3150, "KES") {
+		t.Errorf("Mul() = %v, %v, want KES 31.50", got, err)
+	}
+
+	if _, err := money.New(math.MaxInt64/2+1, "KES").Mul(2); !errors.Is(err, money.ErrOverflow) {
+		t.Errorf("Mul() error = %v, want %v", err, money.ErrOverflow)
+	}
+}
+
+func TestMoney_MulRatio(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   int64
+		num, den int64
+		rounding money.Rounding
+		want     int64
+	}{
+		{name: "exact", amount: 10000, num: 16, den: 100, rounding: money.HalfUp, want: 1600},
+		{name: "half up", amount: 25, num: 1, den: 10, rounding: money.HalfUp, want: 3},
+		{name: "half even down", amount: 25, num: 1, den: 10, rounding: money.HalfEven, want: 2},
+		{name: "half even up", amount: 35, num: 1, den: 10, rounding: money.HalfEven, want: 4},
+		{name: "up", amount: 21, num: 1, den: 10, rounding: money.Up, want: 3},
+		{name: "down", amount: 29, num: 1, den: 10, rounding: money.Down, want: 2},
+		{name: "negative half up", amount: -25, num: 1, den: 10, rounding: money.HalfUp, want: -3},
+		{name: "no overflow in between", amount: math.MaxInt64, num: 3, den: 4, rounding: money.Down, want: 6917529027641081855},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := money.New(tt.amount, "KES").MulRatio(tt.num, tt.den, tt.rounding)
+			if err != nil {
+				t.Fatalf("MulRatio() error = %v", err)
+			}
+			if got.Amount != tt.want {
+				t.Errorf("MulRatio() = %d, want %d", got.Amount, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromStored(t *testing.T) {
+	if got := money.FromStored(100, ""); got != money.New(10000, money.DefaultCurrency) {
+		t.Errorf("FromStored(100, \"\") = %v, want KES 100.00", got)
+	}
+	if got := money.FromStored(100, "UGX"); got != money.New(100, "UGX") {
+		t.Errorf("FromStored(100, UGX) = %v, want UGX 100", got)
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	tests := []struct {
+		m    money.Money
+		want string
+	}{
+		{m: money.New(1250, "KES"), want: "KES 12.50"},
+		{m: money.New(-5, "KES"), want: "KES -0.05"},
+		{m: money.New(1500, "UGX"), want: "UGX 1500"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.String(); got != tt.want {
+			t.Errorf("String() = %s, want %s", got, tt.want)
+		}
+	}
+}
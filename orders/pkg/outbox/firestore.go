@@ -0,0 +1,218 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+
+	"github.com/leta/order-management-system/orders/pkg/events"
+)
+
+// Collection is the Firestore collection holding the outbox. Services sharing
+// a project share the collection; each relays only the events it enqueued.
+const Collection = "outbox"
+
+// DeadLetterCollection holds the events moved out of the outbox after their
+// last delivery attempt failed, under their outbox ID.
+const DeadLetterCollection = "outbox_dead_letters"
+
+var _ Store = (*FirestoreStore)(nil)
+
+// FirestoreStore keeps the outbox in the service's own Firestore database, so
+// that events are committed atomically with the documents they describe.
+//
+// Pending needs a composite index on (service ASC, published ASC,
+// created_at ASC, __name__ ASC).
+type FirestoreStore struct {
+	client  *firestore.Client
+	service string
+}
+
+// NewFirestoreStore creates a new instance of FirestoreStore holding the
+// events of service. The publishers of a service only know its own events,
+// so its relay must not claim those of another service.
+func NewFirestoreStore(client *firestore.Client, service string) *FirestoreStore {
+	return &FirestoreStore{
+		client:  client,
+		service: service,
+	}
+}
+
+func (s *FirestoreStore) CheckPreconditions() {
+	if s.client == nil {
+		panic("no Firestore client provided")
+	}
+
+	if s.service == "" {
+		panic("no service provided")
+	}
+}
+
+type eventModel struct {
+	Service     string    `firestore:"service"`
+	Type        string    `firestore:"type"`
+	AggregateID string    `firestore:"aggregate_id"`
+	Payload     []byte    `firestore:"payload"`
+	TraceParent string    `firestore:"trace_parent"`
+	CreatedAt   time.Time `firestore:"created_at"`
+	Published   bool      `firestore:"published"`
+	PublishedAt time.Time `firestore:"published_at"`
+	Attempts    int       `firestore:"attempts"`
+	LastError   string    `firestore:"last_error"`
+
+	// Set once the event is moved to DeadLetterCollection.
+	DeadLetteredAt *time.Time `firestore:"dead_lettered_at,omitempty"`
+}
+
+func (s *FirestoreStore) collection() *firestore.CollectionRef {
+	s.CheckPreconditions()
+
+	return s.client.Collection(Collection)
+}
+
+// Enqueue writes event to the outbox as part of tx and sets its ID. The event
+// is only visible to the relay once tx commits.
+func (s *FirestoreStore) Enqueue(tx *firestore.Transaction, event *events.Event) error {
+	s.CheckPreconditions()
+
+	docRef := s.collection().NewDoc()
+
+	err := tx.Create(docRef, &eventModel{
+		Service:     s.service,
+		Type:        event.Type,
+		AggregateID: event.AggregateID,
+		Payload:     event.Payload,
+		TraceParent: event.TraceParent,
+		CreatedAt:   event.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enqueue %s event: %v", event.Type, err)
+	}
+
+	event.ID = docRef.ID
+
+	return nil
+}
+
+// Pending implements Store. Events created at the same time are ordered by
+// ID, so that after is a cursor into the pending events.
+func (s *FirestoreStore) Pending(ctx context.Context, after *events.Event, limit int) ([]*events.Event, error) {
+	s.CheckPreconditions()
+
+	query := s.collection().
+		Where("service", "==", s.service).
+		Where("published", "==", false).
+		OrderBy("created_at", firestore.Asc).
+		OrderBy(firestore.DocumentID, firestore.Asc).
+		Limit(limit)
+	if after != nil {
+		query = query.StartAfter(after.CreatedAt, after.ID)
+	}
+
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending events: %v", err)
+	}
+
+	pending := make([]*events.Event, 0, len(docs))
+	for _, doc := range docs {
+		var model eventModel
+		if err := doc.DataTo(&model); err != nil {
+			return nil, fmt.Errorf("failed to decode event %s: %v", doc.Ref.ID, err)
+		}
+
+		pending = append(pending, &events.Event{
+			ID:          doc.Ref.ID,
+			Type:        model.Type,
+			AggregateID: model.AggregateID,
+			Payload:     model.Payload,
+			TraceParent: model.TraceParent,
+			CreatedAt:   model.CreatedAt,
+		})
+	}
+
+	return pending, nil
+}
+
+// MarkPublished implements Store.
+func (s *FirestoreStore) MarkPublished(ctx context.Context, id string) error {
+	s.CheckPreconditions()
+
+	_, err := s.collection().Doc(id).Update(ctx, []firestore.Update{
+		{Path: "published", Value: true},
+		{Path: "published_at", Value: time.Now().UTC()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark event %s as published: %v", id, err)
+	}
+
+	return nil
+}
+
+// MarkFailed implements Store.
+func (s *FirestoreStore) MarkFailed(ctx context.Context, id string, cause error) (int, error) {
+	s.CheckPreconditions()
+
+	docRef := s.collection().Doc(id)
+
+	var attempts int
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if err != nil {
+			return err
+		}
+
+		var model eventModel
+		if err := doc.DataTo(&model); err != nil {
+			return err
+		}
+		attempts = model.Attempts + 1
+
+		return tx.Update(docRef, []firestore.Update{
+			{Path: "attempts", Value: attempts},
+			{Path: "last_error", Value: cause.Error()},
+		})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to record delivery failure of event %s: %v", id, err)
+	}
+
+	return attempts, nil
+}
+
+// DeadLetter implements Store.
+func (s *FirestoreStore) DeadLetter(ctx context.Context, id string, cause error) error {
+	s.CheckPreconditions()
+
+	docRef := s.collection().Doc(id)
+	deadLetterRef := s.client.Collection(DeadLetterCollection).Doc(id)
+
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if err != nil {
+			return err
+		}
+
+		var model eventModel
+		if err := doc.DataTo(&model); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		model.LastError = cause.Error()
+		model.DeadLetteredAt = &now
+
+		if err := tx.Set(deadLetterRef, &model); err != nil {
+			return err
+		}
+
+		return tx.Delete(docRef)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to dead-letter event %s: %v", id, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package outbox_test
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"cloud.google.com/go/firestore"
+
+	"github.com/leta/order-management-system/orders/pkg/events"
+	"github.com/leta/order-management-system/orders/pkg/outbox"
+)
+
+// newEmulatorClient connects to the Firestore emulator, skipping the test if
+// none is running.
+func newEmulatorClient(t *testing.T) *firestore.Client {
+	t.Helper()
+
+	if os.Getenv("FIRESTORE_EMULATOR_HOST") == "" {
+		t.Skip("FIRESTORE_EMULATOR_HOST is not set")
+	}
+
+	client, err := firestore.NewClient(context.Background(), "outbox-test")
+	if err != nil {
+		t.Fatalf("failed to create firestore client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+func TestFirestoreStore_RelaysOwnEvents(t *testing.T) {
+	client := newEmulatorClient(t)
+	ctx := context.Background()
+
+	ordersStore := outbox.NewFirestoreStore(client, "orders")
+	paymentsStore := outbox.NewFirestoreStore(client, "payments")
+
+	enqueue := func(store *outbox.FirestoreStore, event *events.Event) {
+		err := client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			return store.Enqueue(tx, event)
+		})
+		if err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+
+	orderEvent := newEvent(t, "", "order-a")
+	paymentEvent := newEvent(t, "", "order-a")
+	enqueue(ordersStore, orderEvent)
+	enqueue(paymentsStore, paymentEvent)
+
+	relay := func(store *outbox.FirestoreStore) []string {
+		var delivered []string
+		publisher := events.PublisherFunc(func(ctx context.Context, event *events.Event) error {
+			delivered = append(delivered, event.ID)
+			return nil
+		})
+		if _, err := outbox.NewRelay(store, publisher).RelayPending(ctx); err != nil {
+			t.Fatalf("RelayPending() error = %v", err)
+		}
+		return delivered
+	}
+
+	if got, want := relay(ordersStore), []string{orderEvent.ID}; !reflect.DeepEqual(got, want) {
+		t.Errorf("orders relay delivered %v, want %v", got, want)
+	}
+	if got, want := relay(paymentsStore), []string{paymentEvent.ID}; !reflect.DeepEqual(got, want) {
+		t.Errorf("payments relay delivered %v, want %v", got, want)
+	}
+}
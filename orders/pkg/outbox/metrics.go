@@ -0,0 +1,31 @@
+package outbox
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	publishAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "outbox_publish_attempts_total",
+		Help: "Number of outbox event delivery attempts, by event type and result.",
+	}, []string{"type", "result"})
+
+	pendingEvents = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_pending_events",
+		Help: "Number of undelivered outbox events read by the last poll.",
+	})
+
+	deadLetters = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "outbox_dead_letters_total",
+		Help: "Number of outbox events dead-lettered after their last failed attempt, by event type.",
+	}, []string{"type"})
+)
+
+func recordPublish(eventType string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	publishAttempts.WithLabelValues(eventType, result).Inc()
+}
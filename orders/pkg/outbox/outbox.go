@@ -0,0 +1,26 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/leta/order-management-system/orders/pkg/events"
+)
+
+// Store reads pending events and records the outcome of their delivery.
+// Events are written by repositories, within the transaction that changes the
+// state they describe; see FirestoreStore.Enqueue.
+type Store interface {
+	// Pending returns up to limit undelivered events, oldest first, starting
+	// after the event after if it is not nil.
+	Pending(ctx context.Context, after *events.Event, limit int) ([]*events.Event, error)
+
+	MarkPublished(ctx context.Context, id string) error
+
+	// MarkFailed records a failed delivery attempt and returns the number of
+	// attempts of the event that failed so far.
+	MarkFailed(ctx context.Context, id string, err error) (int, error)
+
+	// DeadLetter moves an event out of the outbox, recording the error of its
+	// last attempt, so that it is no longer delivered.
+	DeadLetter(ctx context.Context, id string, err error) error
+}
@@ -0,0 +1,160 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/leta/order-management-system/orders/pkg/events"
+	"github.com/leta/order-management-system/orders/pkg/tracing"
+)
+
+const (
+	DefaultPollInterval = time.Second
+	DefaultBatchSize    = 100
+
+	// About an hour of polls, so that a peer outage does not dead-letter
+	// events.
+	DefaultMaxAttempts = int(time.Hour / DefaultPollInterval)
+)
+
+// Relay delivers the events of an outbox with at-least-once semantics: an
+// event is marked as published only once its publisher succeeded, and is
+// retried on the next poll otherwise.
+//
+// Events of the same aggregate are delivered in order; when one fails, the
+// later events of its aggregate wait for it while other aggregates proceed.
+// An event whose MaxAttempts attempts failed is dead-lettered, so that its
+// aggregate is no longer held back. Several instances of a service may relay
+// concurrently, so consumers must discard events they have already seen by
+// ID.
+type Relay struct {
+	store     Store
+	publisher events.Publisher
+
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+}
+
+// NewRelay creates a new instance of Relay.
+func NewRelay(store Store, publisher events.Publisher) *Relay {
+	return &Relay{
+		store:        store,
+		publisher:    publisher,
+		PollInterval: DefaultPollInterval,
+		BatchSize:    DefaultBatchSize,
+		MaxAttempts:  DefaultMaxAttempts,
+	}
+}
+
+// Run delivers pending events every PollInterval until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.RelayPending(ctx); err != nil {
+			log.Printf("[outbox] %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayPending makes one delivery attempt of up to BatchSize pending events
+// and returns the number delivered. The events of an aggregate waiting for a
+// failed one are skipped, reading further pages of pending events, so that an
+// aggregate with many events waiting does not hold back the others.
+func (r *Relay) RelayPending(ctx context.Context) (int, error) {
+	blocked := make(map[string]bool)
+	read, attempted, published := 0, 0, 0
+
+	var after *events.Event
+	for attempted < r.BatchSize {
+		pending, err := r.store.Pending(ctx, after, r.BatchSize)
+		if err != nil {
+			return published, err
+		}
+		read += len(pending)
+
+		for _, event := range pending {
+			if attempted == r.BatchSize {
+				break
+			}
+			if blocked[event.AggregateID] {
+				continue
+			}
+			attempted++
+
+			if err := r.publish(ctx, event); err != nil {
+				blocked[event.AggregateID] = true
+				recordPublish(event.Type, err)
+
+				log.Printf("[outbox] failed to publish %s event %s: %v", event.Type, event.ID, err)
+
+				r.fail(ctx, event, err)
+				continue
+			}
+
+			recordPublish(event.Type, nil)
+
+			// The event will be delivered again if this fails.
+			if err := r.store.MarkPublished(ctx, event.ID); err != nil {
+				log.Printf("[outbox] %v", err)
+				continue
+			}
+
+			published++
+		}
+
+		if len(pending) < r.BatchSize {
+			break
+		}
+		after = pending[len(pending)-1]
+	}
+
+	pendingEvents.Set(float64(read))
+
+	return published, nil
+}
+
+// fail records a failed attempt to deliver event, and dead-letters it once
+// MaxAttempts attempts failed. The later events of its aggregate are then
+// delivered from the next poll.
+func (r *Relay) fail(ctx context.Context, event *events.Event, cause error) {
+	attempts, err := r.store.MarkFailed(ctx, event.ID, cause)
+	if err != nil {
+		log.Printf("[outbox] %v", err)
+		return
+	}
+
+	if attempts < r.MaxAttempts {
+		return
+	}
+
+	if err := r.store.DeadLetter(ctx, event.ID, cause); err != nil {
+		log.Printf("[outbox] %v", err)
+		return
+	}
+
+	deadLetters.WithLabelValues(event.Type).Inc()
+	log.Printf("[outbox] dead-lettered %s event %s after %d attempts: %v", event.Type, event.ID, attempts, cause)
+}
+
+func (r *Relay) publish(ctx context.Context, event *events.Event) (err error) {
+	ctx, span := tracing.Resume(ctx, event.TraceParent, "outbox.Publish "+event.Type)
+	span.SetAttributes(
+		attribute.String("event.id", event.ID),
+		attribute.String("event.aggregate_id", event.AggregateID),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	return r.publisher.Publish(ctx, event)
+}
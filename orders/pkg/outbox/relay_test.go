@@ -0,0 +1,204 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/leta/order-management-system/orders/generated"
+	"github.com/leta/order-management-system/orders/pkg/events"
+	"github.com/leta/order-management-system/orders/pkg/outbox"
+)
+
+// memoryStore is an in-memory outbox.
+type memoryStore struct {
+	events      []*events.Event
+	published   map[string]bool
+	failures    map[string][]string
+	deadLetters map[string]string
+}
+
+func newMemoryStore(pending ...*events.Event) *memoryStore {
+	return &memoryStore{
+		events:      pending,
+		published:   make(map[string]bool),
+		failures:    make(map[string][]string),
+		deadLetters: make(map[string]string),
+	}
+}
+
+func (s *memoryStore) Pending(ctx context.Context, after *events.Event, limit int) ([]*events.Event, error) {
+	var pending []*events.Event
+	started := after == nil
+	for _, event := range s.events {
+		if !started {
+			started = event.ID == after.ID
+			continue
+		}
+
+		_, dead := s.deadLetters[event.ID]
+		if !s.published[event.ID] && !dead && len(pending) < limit {
+			pending = append(pending, event)
+		}
+	}
+	return pending, nil
+}
+
+func (s *memoryStore) MarkPublished(ctx context.Context, id string) error {
+	s.published[id] = true
+	return nil
+}
+
+func (s *memoryStore) MarkFailed(ctx context.Context, id string, err error) (int, error) {
+	s.failures[id] = append(s.failures[id], err.Error())
+	return len(s.failures[id]), nil
+}
+
+func (s *memoryStore) DeadLetter(ctx context.Context, id string, err error) error {
+	s.deadLetters[id] = err.Error()
+	return nil
+}
+
+func newEvent(t *testing.T, id, aggregateID string) *events.Event {
+	t.Helper()
+
+	event, err := events.New(context.Background(), aggregateID, &generated.OrderStatusChanged{
+		OrderId: aggregateID,
+		To:      generated.OrderStatus_PAID,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	event.ID = id
+
+	return event
+}
+
+func TestRelay_RelayPending(t *testing.T) {
+	store := newMemoryStore(
+		newEvent(t, "1", "order-a"),
+		newEvent(t, "2", "order-b"),
+		newEvent(t, "3", "order-a"),
+		newEvent(t, "4", "order-c"),
+	)
+
+	// The peer rejects order-b's event once, then recovers.
+	var delivered []string
+	failures := map[string]int{"2": 1}
+	publisher := events.PublisherFunc(func(ctx context.Context, event *events.Event) error {
+		if failures[event.ID] > 0 {
+			failures[event.ID]--
+			return errors.New("peer unavailable")
+		}
+		delivered = append(delivered, event.ID)
+		return nil
+	})
+
+	relay := outbox.NewRelay(store, publisher)
+	ctx := context.Background()
+
+	n, err := relay.RelayPending(ctx)
+	if err != nil {
+		t.Fatalf("RelayPending() error = %v", err)
+	}
+	if n != 3 {
+		t.Errorf("RelayPending() = %d, want 3", n)
+	}
+	if want := []string{"1", "3", "4"}; !reflect.DeepEqual(delivered, want) {
+		t.Errorf("delivered %v, want %v", delivered, want)
+	}
+	if got, want := store.failures["2"], []string{"peer unavailable"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("failures of event 2 = %v, want %v", got, want)
+	}
+
+	// The failed event is delivered on the next poll.
+	if n, err := relay.RelayPending(ctx); err != nil || n != 1 {
+		t.Errorf("RelayPending() = %d, %v, want 1, nil", n, err)
+	}
+
+	published := make([]string, 0, len(store.published))
+	for id := range store.published {
+		published = append(published, id)
+	}
+	sort.Strings(published)
+	if want := []string{"1", "2", "3", "4"}; !reflect.DeepEqual(published, want) {
+		t.Errorf("published %v, want %v", published, want)
+	}
+}
+
+func TestRelay_RelayPending_KeepsAggregateOrder(t *testing.T) {
+	store := newMemoryStore(
+		newEvent(t, "1", "order-a"),
+		newEvent(t, "2", "order-a"),
+		newEvent(t, "3", "order-b"),
+	)
+
+	var delivered []string
+	publisher := events.PublisherFunc(func(ctx context.Context, event *events.Event) error {
+		if event.ID == "1" {
+			return errors.New("peer unavailable")
+		}
+		delivered = append(delivered, event.ID)
+		return nil
+	})
+
+	if _, err := outbox.NewRelay(store, publisher).RelayPending(context.Background()); err != nil {
+		t.Fatalf("RelayPending() error = %v", err)
+	}
+
+	if want := []string{"3"}; !reflect.DeepEqual(delivered, want) {
+		t.Errorf("delivered %v, want %v: event 2 must wait for event 1", delivered, want)
+	}
+}
+
+func TestRelay_RelayPending_DeadLettersPoisonedEvents(t *testing.T) {
+	// order-a has a full batch of events waiting for an event its peer always
+	// rejects.
+	store := newMemoryStore(
+		newEvent(t, "1", "order-a"),
+		newEvent(t, "2", "order-a"),
+		newEvent(t, "3", "order-a"),
+		newEvent(t, "4", "order-b"),
+		newEvent(t, "5", "order-c"),
+	)
+
+	var delivered []string
+	publisher := events.PublisherFunc(func(ctx context.Context, event *events.Event) error {
+		if event.ID == "1" {
+			return errors.New("malformed event")
+		}
+		delivered = append(delivered, event.ID)
+		return nil
+	})
+
+	relay := outbox.NewRelay(store, publisher)
+	relay.BatchSize = 3
+	relay.MaxAttempts = 2
+	ctx := context.Background()
+
+	// The other aggregates are not held back by order-a.
+	if n, err := relay.RelayPending(ctx); err != nil || n != 2 {
+		t.Errorf("RelayPending() = %d, %v, want 2, nil", n, err)
+	}
+	if want := []string{"4", "5"}; !reflect.DeepEqual(delivered, want) {
+		t.Errorf("delivered %v, want %v", delivered, want)
+	}
+
+	// The second failed attempt dead-letters the event.
+	if n, err := relay.RelayPending(ctx); err != nil || n != 0 {
+		t.Errorf("RelayPending() = %d, %v, want 0, nil", n, err)
+	}
+	if want := map[string]string{"1": "malformed event"}; !reflect.DeepEqual(store.deadLetters, want) {
+		t.Errorf("dead letters = %v, want %v", store.deadLetters, want)
+	}
+
+	// The later events of order-a are then delivered.
+	if n, err := relay.RelayPending(ctx); err != nil || n != 2 {
+		t.Errorf("RelayPending() = %d, %v, want 2, nil", n, err)
+	}
+	if want := []string{"4", "5", "2", "3"}; !reflect.DeepEqual(delivered, want) {
+		t.Errorf("delivered %v, want %v", delivered, want)
+	}
+}
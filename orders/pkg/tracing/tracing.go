@@ -0,0 +1,165 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+// Supported span exporters.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// TraceParentHeader is the W3C Trace Context header used to carry a span
+// context across process boundaries.
+const TraceParentHeader = "traceparent"
+
+// InstrumentationName identifies the spans started by this module.
+const InstrumentationName = "github.com/leta/order-management-system"
+
+// ShutdownFunc flushes any buffered spans and releases the exporter.
+type ShutdownFunc func(ctx context.Context) error
+
+// Setup installs the global tracer provider and W3C Trace Context propagator
+// for the named service.
+//
+// The exporter is one of ExporterNone (the default when empty), ExporterStdout
+// or ExporterOTLP. The OTLP exporter is configured through the standard
+// OTEL_EXPORTER_OTLP_* environment variables, e.g. OTEL_EXPORTER_OTLP_ENDPOINT.
+func Setup(ctx context.Context, serviceName string, exporter string) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exp sdktrace.SpanExporter
+	var err error
+
+	switch exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exp, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		exp, err = otlptracegrpc.New(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported trace exporter: %q", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %v", exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL, semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %v", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer used for spans started by application code.
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// Start starts a span named name as a child of the span in ctx.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// End records err on span, if any, and ends it. Intended to be deferred with
+// a named error result:
+//
+//	ctx, span := tracing.Start(ctx, "CheckoutService.ProcessCheckout")
+//	defer func() { tracing.End(span, err) }()
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// UnaryServerInterceptor starts a server span for every unary RPC, continuing
+// the trace propagated by the caller.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return otelgrpc.UnaryServerInterceptor()
+}
+
+// DialOptions returns the dial options that start a client span for every RPC
+// and propagate the trace context to the server.
+func DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(otelgrpc.StreamClientInterceptor()),
+	}
+}
+
+// FirestoreClientOptions returns client options that trace the calls made by
+// a Firestore client, so every repository call shows up under its caller.
+func FirestoreClientOptions() []option.ClientOption {
+	opts := make([]option.ClientOption, 0, 2)
+	for _, opt := range DialOptions() {
+		opts = append(opts, option.WithGRPCDialOption(opt))
+	}
+	return opts
+}
+
+// HTTPClient returns an HTTP client that starts a client span for every
+// request and propagates the trace context in the request headers.
+func HTTPClient() *http.Client {
+	return &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+}
+
+// HTTPHandler wraps handler so that a server span is started for every request.
+func HTTPHandler(handler http.Handler, operation string) http.Handler {
+	return otelhttp.NewHandler(handler, operation)
+}
+
+// TraceParent returns the W3C traceparent of the span in ctx, or an empty
+// string if ctx carries no valid span. It is used to persist a trace context
+// alongside records that are picked up again asynchronously.
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier.Get(TraceParentHeader)
+}
+
+// Resume starts a span named name that continues the trace identified by
+// traceParent, as returned by TraceParent. The span in ctx, if any, is
+// recorded as a link so that both traces can be navigated from either side.
+//
+// If traceParent is empty or invalid the span is started as a child of ctx.
+func Resume(ctx context.Context, traceParent string, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	parent := propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{TraceParentHeader: traceParent})
+
+	if current := trace.SpanContextFromContext(ctx); current.IsValid() &&
+		!current.Equal(trace.SpanContextFromContext(parent)) {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: current}))
+	}
+
+	return Tracer().Start(parent, name, opts...)
+}
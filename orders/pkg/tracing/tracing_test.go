@@ -0,0 +1,114 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/leta/order-management-system/orders/pkg/tracing"
+)
+
+func newTestRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	return recorder
+}
+
+func TestResume(t *testing.T) {
+	recorder := newTestRecorder(t)
+	ctx := context.Background()
+
+	// Checkout: the trace context is persisted with the payment record.
+	checkoutCtx, checkout := tracing.Start(ctx, "checkout")
+	traceParent := tracing.TraceParent(checkoutCtx)
+	checkout.End()
+
+	if traceParent == "" {
+		t.Fatalf("TraceParent() is empty for a recording span")
+	}
+
+	// Callback: arrives on an unrelated request with its own trace.
+	requestCtx, request := tracing.Start(ctx, "callback request")
+	_, callback := tracing.Resume(requestCtx, traceParent, "callback")
+	callback.End()
+	request.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("recorded %d spans, want 3", len(spans))
+	}
+	checkoutSpan, callbackSpan, requestSpan := spans[0], spans[1], spans[2]
+
+	if got, want := callbackSpan.SpanContext().TraceID(), checkoutSpan.SpanContext().TraceID(); got != want {
+		t.Errorf("callback trace ID = %s, want checkout trace ID %s", got, want)
+	}
+	if got, want := callbackSpan.Parent().SpanID(), checkoutSpan.SpanContext().SpanID(); got != want {
+		t.Errorf("callback parent span ID = %s, want %s", got, want)
+	}
+
+	links := callbackSpan.Links()
+	if len(links) != 1 || !links[0].SpanContext.Equal(requestSpan.SpanContext()) {
+		t.Errorf("callback links = %v, want a link to the request span", links)
+	}
+}
+
+func TestResume_NoTraceParent(t *testing.T) {
+	recorder := newTestRecorder(t)
+
+	ctx, request := tracing.Start(context.Background(), "callback request")
+	_, callback := tracing.Resume(ctx, "", "callback")
+	callback.End()
+	request.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+
+	if got, want := spans[0].Parent().SpanID(), spans[1].SpanContext().SpanID(); got != want {
+		t.Errorf("callback parent span ID = %s, want request span ID %s", got, want)
+	}
+	if links := spans[0].Links(); len(links) != 0 {
+		t.Errorf("callback links = %v, want none", links)
+	}
+}
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		name     string
+		exporter string
+		wantErr  bool
+	}{
+		{name: "disabled", exporter: ""},
+		{name: "none", exporter: tracing.ExporterNone},
+		{name: "stdout", exporter: tracing.ExporterStdout},
+		{name: "unsupported", exporter: "jaeger", wantErr: true},
+	}
+
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shutdown, err := tracing.Setup(context.Background(), "test", tt.exporter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Setup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if err := shutdown(context.Background()); err != nil {
+					t.Errorf("shutdown() error = %v", err)
+				}
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 // Application error codes.
 const (
+	ABORTED_ERROR         = "aborted"
 	ALREADY_EXISTS_ERROR  = "already_exists"
 	INTERNAL_ERROR        = "internal"
 	INVALID_ERROR         = "invalid"
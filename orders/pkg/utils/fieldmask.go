@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// UpdatePaths returns the names of the fields of update that an update
+// request changes. With a mask, these are the paths of the mask, "*" standing
+// for every field, so that fields can be set to their zero value. Without
+// one, they are the populated fields of update, as proto3 cannot tell an
+// unset field apart from its zero value.
+//
+// A path into a message field, such as the "price.amount" the REST gateway
+// derives from {"price": {"amount": 100}}, changes the whole field. Paths
+// naming no field of update are an INVALID_ERROR.
+func UpdatePaths(mask *fieldmaskpb.FieldMask, update proto.Message) (map[string]bool, error) {
+	m := update.ProtoReflect()
+	paths := make(map[string]bool)
+
+	if len(mask.GetPaths()) == 0 {
+		m.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+			paths[string(fd.Name())] = true
+			return true
+		})
+		return paths, nil
+	}
+
+	fields := m.Descriptor().Fields()
+
+	for _, path := range mask.GetPaths() {
+		if path == "*" {
+			for i := 0; i < fields.Len(); i++ {
+				paths[string(fields.Get(i).Name())] = true
+			}
+			continue
+		}
+
+		top, rest, _ := strings.Cut(path, ".")
+		if !validPath(fields.ByName(protoreflect.Name(top)), rest) {
+			return nil, Errorf(INVALID_ERROR, "unknown field %q in update_mask", path)
+		}
+		paths[top] = true
+	}
+
+	return paths, nil
+}
+
+// validPath reports whether fd is a field and path, if any, names a field of
+// its message.
+func validPath(fd protoreflect.FieldDescriptor, path string) bool {
+	if fd == nil {
+		return false
+	} else if path == "" {
+		return true
+	} else if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+		return false
+	}
+
+	top, rest, _ := strings.Cut(path, ".")
+	return validPath(fd.Message().Fields().ByName(protoreflect.Name(top)), rest)
+}
@@ -0,0 +1,97 @@
+package utils_test
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"github.com/leta/order-management-system/orders/generated"
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+func TestUpdatePaths(t *testing.T) {
+	optOut := false
+
+	tests := []struct {
+		name    string
+		mask    []string
+		update  proto.Message
+		want    map[string]bool
+		wantErr bool
+	}{
+		{
+			name:   "no mask",
+			update: &generated.CustomerUpdate{FirstName: "Jane", SmsOptOut: &optOut},
+			want:   map[string]bool{"first_name": true, "sms_opt_out": true},
+		},
+		{
+			name:   "no mask or update",
+			update: (*generated.CustomerUpdate)(nil),
+			want:   map[string]bool{},
+		},
+		{
+			name:   "mask clears a field",
+			mask:   []string{"email"},
+			update: &generated.CustomerUpdate{FirstName: "Jane"},
+			want:   map[string]bool{"email": true},
+		},
+		{
+			name:   "wildcard",
+			mask:   []string{"*"},
+			update: &generated.CustomerUpdate{},
+			want: map[string]bool{
+				"first_name": true, "last_name": true, "email": true,
+				"phone": true, "sms_opt_out": true, "email_opt_out": true,
+				"tax_exempt": true, "default_address_id": true, "group": true,
+			},
+		},
+		{
+			name:    "unknown path",
+			mask:    []string{"first_name", "address"},
+			update:  &generated.CustomerUpdate{},
+			wantErr: true,
+		},
+		{
+			name:   "path into a message field",
+			mask:   []string{"price.amount"},
+			update: &generated.ProductUpdate{},
+			want:   map[string]bool{"price": true},
+		},
+		{
+			name:    "path into a scalar field",
+			mask:    []string{"first_name.value"},
+			update:  &generated.CustomerUpdate{},
+			wantErr: true,
+		},
+		{
+			name:    "unknown path into a message field",
+			mask:    []string{"price.value"},
+			update:  &generated.ProductUpdate{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mask *fieldmaskpb.FieldMask
+			if tt.mask != nil {
+				mask = &fieldmaskpb.FieldMask{Paths: tt.mask}
+			}
+
+			got, err := utils.UpdatePaths(mask, tt.update)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpdatePaths() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if code := utils.ErrorCode(err); code != utils.INVALID_ERROR {
+					t.Errorf("UpdatePaths() error code = %s, want %s", code, utils.INVALID_ERROR)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UpdatePaths() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,5 +1,9 @@
 package utils
 
+// SERVICE_NAME names the orders service, e.g. in its configuration, traces,
+// audit log and outbox.
+const SERVICE_NAME = "orders"
+
 type OrderStatus string
 
 const (
@@ -7,4 +11,70 @@ const (
 	OrderStatusPending    OrderStatus = "pending"
 	OrderStatusProcessing OrderStatus = "processing"
 	OrderStatusPaid       OrderStatus = "paid"
+	OrderStatusCancelled  OrderStatus = "cancelled"
+	OrderStatusFailed     OrderStatus = "failed"
+	OrderStatusExpired    OrderStatus = "expired"
+)
+
+// Open reports whether an order with the status may still be checked out or
+// paid for.
+func (s OrderStatus) Open() bool {
+	switch s {
+	case OrderStatusNew, OrderStatusPending, OrderStatusProcessing:
+		return true
+	default:
+		return false
+	}
+}
+
+// Unpaid reports whether an order with the status was neither paid nor
+// abandoned, so still holds what was reserved for it.
+func (s OrderStatus) Unpaid() bool {
+	return s.Open() || s == OrderStatusFailed
+}
+
+// Abandoned reports whether an order with the status was given up without
+// being paid, which releases what was reserved for it.
+func (s OrderStatus) Abandoned() bool {
+	return s == OrderStatusCancelled || s == OrderStatusExpired
+}
+
+// CheckTransition fails with an INVALID_ERROR unless an order with the status
+// may move to status to. Only unpaid orders may be checked out, so that a paid
+// order is not charged again, and a paid order may only be cancelled. The
+// status of an abandoned order cannot be changed.
+func (s OrderStatus) CheckTransition(to OrderStatus) error {
+	switch {
+	case s == to:
+		return nil
+	case s.Abandoned():
+		return Errorf(INVALID_ERROR, "the status of a %s order cannot be changed", s)
+	case to == OrderStatusProcessing && !s.Unpaid(),
+		s == OrderStatusPaid && to != OrderStatusCancelled:
+		return Errorf(INVALID_ERROR, "the status of a %s order cannot be changed to %s", s, to)
+	default:
+		return nil
+	}
+}
+
+// OrderStatuses lists every known order status.
+var OrderStatuses = []OrderStatus{
+	OrderStatusNew,
+	OrderStatusPending,
+	OrderStatusProcessing,
+	OrderStatusPaid,
+	OrderStatusCancelled,
+	OrderStatusFailed,
+	OrderStatusExpired,
+}
+
+// FulfilmentStatus tells how much of a paid order was shipped to the
+// customer.
+type FulfilmentStatus string
+
+const (
+	FulfilmentStatusUnfulfilled      FulfilmentStatus = "unfulfilled"
+	FulfilmentStatusPartiallyShipped FulfilmentStatus = "partially_shipped"
+	FulfilmentStatusShipped          FulfilmentStatus = "shipped"
+	FulfilmentStatusDelivered        FulfilmentStatus = "delivered"
 )
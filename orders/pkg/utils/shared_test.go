@@ -0,0 +1,44 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/leta/order-management-system/orders/pkg/utils"
+)
+
+func TestOrderStatus_CheckTransition(t *testing.T) {
+	tests := []struct {
+		from    utils.OrderStatus
+		to      utils.OrderStatus
+		wantErr bool
+	}{
+		{from: utils.OrderStatusNew, to: utils.OrderStatusProcessing},
+		{from: utils.OrderStatusPending, to: utils.OrderStatusProcessing},
+		{from: utils.OrderStatusFailed, to: utils.OrderStatusProcessing},
+		{from: utils.OrderStatusProcessing, to: utils.OrderStatusPending},
+		{from: utils.OrderStatusPending, to: utils.OrderStatusPaid},
+		{from: utils.OrderStatusProcessing, to: utils.OrderStatusFailed},
+		{from: utils.OrderStatusNew, to: utils.OrderStatusCancelled},
+		{from: utils.OrderStatusFailed, to: utils.OrderStatusExpired},
+		{from: utils.OrderStatusPaid, to: utils.OrderStatusPaid},
+		{from: utils.OrderStatusPaid, to: utils.OrderStatusCancelled},
+		{from: utils.OrderStatusPaid, to: utils.OrderStatusProcessing, wantErr: true},
+		{from: utils.OrderStatusPaid, to: utils.OrderStatusPending, wantErr: true},
+		{from: utils.OrderStatusPaid, to: utils.OrderStatusFailed, wantErr: true},
+		{from: utils.OrderStatusPaid, to: utils.OrderStatusExpired, wantErr: true},
+		{from: utils.OrderStatusCancelled, to: utils.OrderStatusProcessing, wantErr: true},
+		{from: utils.OrderStatusExpired, to: utils.OrderStatusPaid, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" to "+string(tt.to), func(t *testing.T) {
+			err := tt.from.CheckTransition(tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckTransition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && utils.ErrorCode(err) != utils.INVALID_ERROR {
+				t.Errorf("CheckTransition() error code = %s, want %s", utils.ErrorCode(err), utils.INVALID_ERROR)
+			}
+		})
+	}
+}
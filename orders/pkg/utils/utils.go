@@ -3,6 +3,7 @@ package utils
 import (
 	"os"
 	"strconv"
+	"time"
 )
 
 func MustGetEnv(key string) string {
@@ -30,3 +31,28 @@ func StringToUint(s string) (uint, error) {
 func StringPtr(s string) *string { return &s }
 
 func UintPtr(i uint) *uint { return &i }
+
+func BoolPtr(b bool) *bool { return &b }
+
+// DeletedAt formats the time of a soft deletion. Deletion times are kept in
+// UTC, so that comparing them as strings compares the times, which purging
+// deleted documents relies on.
+func DeletedAt(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ETag returns the entity tag of a version of an entity. Versions start at 1
+// and are incremented on every write.
+func ETag(version int64) string {
+	return strconv.FormatInt(version, 10)
+}
+
+// CheckETag returns an ABORTED_ERROR unless etag is empty or the tag of
+// version, so that an update based on a stale read does not overwrite a
+// concurrent one.
+func CheckETag(etag string, version int64) error {
+	if etag != "" && etag != ETag(version) {
+		return Errorf(ABORTED_ERROR, "etag %q does not match the current etag %q", etag, ETag(version))
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+// Command migrate rewrites the documents of the payments service stored in
+// earlier formats. It takes the configuration of the server.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	pkgconfig "github.com/leta/order-management-system/orders/pkg/config"
+	db "github.com/leta/order-management-system/payments/db/firebase"
+	"github.com/leta/order-management-system/payments/internal/config"
+	"github.com/leta/order-management-system/payments/internal/migrate"
+	"github.com/leta/order-management-system/payments/internal/service"
+)
+
+func main() {
+	ctx := context.Background()
+
+	conf := config.Default()
+	pkgconfig.MustLoad(service.SERVICE_NAME, conf, os.Args[1:])
+
+	firestoreClient, err := db.NewFirebaseService(conf.Firebase).GetApp().Firestore(ctx)
+	if err != nil {
+		log.Fatalf("failed to create firestore client: %v", err)
+	}
+	defer firestoreClient.Close()
+
+	n, err := migrate.Money(ctx, firestoreClient)
+	if err != nil {
+		log.Fatalf("migration failed after rewriting %d payments: %v", n, err)
+	}
+
+	log.Printf("Rewrote %d payments", n)
+}
@@ -7,53 +7,62 @@ import (
 	"os"
 
 	o "github.com/leta/order-management-system/orders/pkg/client"
+	pkgconfig "github.com/leta/order-management-system/orders/pkg/config"
+	"github.com/leta/order-management-system/orders/pkg/events"
+	"github.com/leta/order-management-system/orders/pkg/health"
+	"github.com/leta/order-management-system/orders/pkg/lifecycle"
+	"github.com/leta/order-management-system/orders/pkg/metrics"
+	"github.com/leta/order-management-system/orders/pkg/outbox"
+	"github.com/leta/order-management-system/orders/pkg/tracing"
 	db "github.com/leta/order-management-system/payments/db/firebase"
+	"github.com/leta/order-management-system/payments/internal/config"
 	"github.com/leta/order-management-system/payments/internal/handlers/grpc"
+	"github.com/leta/order-management-system/payments/internal/handlers/http"
 	"github.com/leta/order-management-system/payments/internal/mpesa"
-)
-
-const (
-	BIND_ADDRESS = "BIND_ADDRESS"
-	PORT         = "PORT"
-
-	DEFAULT_BIND_ADDRESS = "localhost"
-	DEFAULT_PORT         = "50051"
+	"github.com/leta/order-management-system/payments/internal/service"
 )
 
 func main() {
 
 	ctx := context.Background()
 
+	conf := config.Default()
+	pkgconfig.MustLoad(service.SERVICE_NAME, conf, os.Args[1:])
+
 	log.Printf("Starting server")
 
-	bindAddress := os.Getenv(BIND_ADDRESS)
-	if bindAddress == "" {
-		bindAddress = DEFAULT_BIND_ADDRESS
-	}
+	bindAddress := conf.Server.BindAddress
+	port := conf.Server.Port
+
+	manager := lifecycle.NewManager()
+	manager.GracePeriod = conf.Server.ShutdownGracePeriod
 
-	port := os.Getenv(PORT)
-	if port == "" {
-		port = DEFAULT_PORT
+	shutdownTracing, err := tracing.Setup(ctx, service.SERVICE_NAME, conf.Tracing.Exporter)
+	if err != nil {
+		log.Fatalf("failed to setup tracing: %v", err)
 	}
+	manager.OnShutdown("tracing", shutdownTracing)
 
 	s := grpc.NewGRPCServer()
 
-	mpesaService := mpesa.NewMpesaService()
+	mpesaService := mpesa.NewMpesaService(conf.Mpesa, conf.Environment)
 
 	// Setup orders service client
-	conn, err := o.ConnectToOrderService("localhost:50051")
+	conn, err := o.ConnectToOrderService(conf.Orders.Addr, conf.Orders.Client)
 	if err != nil {
 		log.Fatalf("Failed to connect to orders service: %v", err)
 	}
+	manager.OnShutdown("orders client", func(context.Context) error { return conn.Close() })
 	orderClient := o.NewGrpcOrderClient(conn)
 
 	// Setup firebase client and firestore service
-	firebase := db.NewFirebaseService()
+	firebase := db.NewFirebaseService(conf.Firebase,
+		append(metrics.FirestoreClientOptions(), tracing.FirestoreClientOptions()...)...)
 	firestoreClient, err := firebase.GetApp().Firestore(ctx)
 	if err != nil {
 		log.Fatalf("failed to create firestore client: %v", err)
 	}
-	defer firestoreClient.Close()
+	manager.OnShutdown("firestore", func(context.Context) error { return firestoreClient.Close() })
 
 	firestoreService := db.NewFirestoreService(firestoreClient)
 	paymentRepository := payments.NewPaymentsRepository(firestoreService)
@@ -63,7 +72,53 @@ func main() {
 	// Register internal services
 	s.PaymentsService = paymentService
 
-	if err := s.Run(ctx, bindAddress, port); err != nil {
-		log.Fatalf("failed to serve: %v", err)
+	// Readiness checks for the standard gRPC health service
+	healthService := health.NewService()
+	healthService.AddCheck("firestore", firestoreService.Ping)
+	healthService.AddCheck("orders", health.GRPCCheck(conn, health.LivenessService))
+	healthService.AddCheck("mpesa", mpesaService.CheckCredentials)
+	manager.AddJob("health checks", healthService.Run)
+
+	s.Health = healthService
+	s.EnableReflection = conf.Server.Reflection
+
+	// Payment events are published to Pub/Sub, where the orders service
+	// subscribes to them, or delivered to it directly without a broker.
+	publisher := mpesa.NewOrdersPublisher(orderClient)
+
+	if conf.Events.Broker == events.BrokerPubSub {
+		pubsubClient, err := events.NewPubSubClient(ctx,
+			conf.Firebase.ProjectID, conf.Firebase.CredentialsFile, conf.Events.EmulatorHost)
+		if err != nil {
+			log.Fatalf("failed to setup events broker: %v", err)
+		}
+		manager.OnShutdown("pubsub", func(context.Context) error { return pubsubClient.Close() })
+
+		pubsubPublisher := events.NewPubSubPublisher(pubsubClient, conf.Events.Topic)
+		manager.OnShutdown("pubsub publisher", pubsubPublisher.Stop)
+		publisher = pubsubPublisher
+	}
+
+	relay := outbox.NewRelay(outbox.NewFirestoreStore(firestoreClient, service.SERVICE_NAME), publisher)
+	manager.AddJob("outbox relay", relay.Run)
+
+	// Debug server exposing /metrics
+	manager.AddHTTPServer("debug server", http.NewDebugServer(bindAddress+":"+conf.Server.DebugPort))
+
+	manager.Add("grpc server", func(ctx context.Context) error {
+		return s.Run(ctx, bindAddress, port)
+	}, s.Shutdown)
+
+	// M-Pesa STK callbacks. In-flight callbacks are drained on shutdown so
+	// payment and order statuses are not left half updated.
+	callbackServer := http.NewHTTPServer()
+	callbackServer.Addr = bindAddress + ":" + conf.Server.CallbackPort
+	callbackServer.PaymentsService = paymentService
+	manager.Add("callback server", func(context.Context) error {
+		return callbackServer.Open()
+	}, callbackServer.Shutdown)
+
+	if err := manager.Run(ctx); err != nil {
+		log.Fatalf("server stopped: %v", err)
 	}
 }
@@ -2,40 +2,40 @@ package firebase
 
 import (
 	"context"
-	"github.com/leta/order-management-system/orders/pkg/utils"
 	"log"
+	"os"
+
+	"github.com/leta/order-management-system/orders/pkg/config"
 
 	firebase "firebase.google.com/go"
+	"google.golang.org/api/option"
 )
 
 const (
-	//  These are the environment variable names that need to be set in orders for the
-	//  Firebase SDK to work.
-	GOOGLE_APPLICATION_CREDENTIALS = "GOOGLE_APPLICATION_CREDENTIALS" // #nosec G101 - This is an env variable name
-	GOOGLE_CLOUD_PROJECT           = "GOOGLE_CLOUD_PROJECT"           // #nosec G101 - This is an env variable name
-	FIRESTORE_EMULATOR_HOST        = "FIRESTORE_EMULATOR_HOST"
-	ENVIRONMENT                    = "ENVIRONMENT"
+	// Environment variable read by the Firestore client to connect to the emulator.
+	FIRESTORE_EMULATOR_HOST = "FIRESTORE_EMULATOR_HOST"
 )
 
 type FirebaseService struct {
 	app *firebase.App
 }
 
-func NewFirebaseService() *FirebaseService {
-	// Check preconditions
+// NewFirebaseService initializes the Firebase app described by conf, which is
+// expected to have been validated.
+func NewFirebaseService(conf config.Firebase, opts ...option.ClientOption) *FirebaseService {
 	// https://firebase.google.com/docs/admin/setup/#initialize_the_sdk_in_non-google_environments
-	c := utils.MustGetEnv(GOOGLE_APPLICATION_CREDENTIALS)
-	log.Println(c)
-
-	env := utils.MustGetEnv(ENVIRONMENT)
-	if env == "dev" || env == "test" {
-		utils.MustGetEnv(FIRESTORE_EMULATOR_HOST)
+	if conf.CredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(conf.CredentialsFile))
 	}
 
-	projectID := utils.MustGetEnv(GOOGLE_CLOUD_PROJECT)
+	// The Firestore client only reads the emulator address from the environment.
+	if conf.EmulatorHost != "" {
+		if err := os.Setenv(FIRESTORE_EMULATOR_HOST, conf.EmulatorHost); err != nil {
+			log.Fatalf("error setting %s: %v\n", FIRESTORE_EMULATOR_HOST, err)
+		}
+	}
 
-	conf := &firebase.Config{ProjectID: projectID}
-	app, err := firebase.NewApp(context.Background(), conf)
+	app, err := firebase.NewApp(context.Background(), &firebase.Config{ProjectID: conf.ProjectID}, opts...)
 	if err != nil {
 		log.Fatalf("error initializing app: %v\n", err)
 	}
@@ -1,7 +1,10 @@
 package firebase
 
 import (
+	"context"
+
 	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
 )
 
 type FirestoreService struct {
@@ -13,3 +16,13 @@ func NewFirestoreService(client *firestore.Client) *FirestoreService {
 		Client: client,
 	}
 }
+
+// Ping checks that Firestore is reachable by listing the root collections.
+func (s *FirestoreService) Ping(ctx context.Context) error {
+	_, err := s.Client.Collections(ctx).Next()
+	if err != nil && err != iterator.Done {
+		return err
+	}
+
+	return nil
+}
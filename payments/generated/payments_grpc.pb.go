@@ -24,6 +24,10 @@ const _ = grpc.SupportPackageIsVersion7
 type PaymentsClient interface {
 	HealthCheck(ctx context.Context, in *HealthCheckRequest, opts ...grpc.CallOption) (*HealthCheckResponse, error)
 	ProcessMpesaPayment(ctx context.Context, in *MpesaPaymentRequest, opts ...grpc.CallOption) (*MpesaPaymentResponse, error)
+	// Sends money back to the phone that paid for an order, as an M-Pesa B2C
+	// payment. Refunds are identified by refundId: requesting a refund again
+	// returns the first one instead of paying twice.
+	RefundMpesaPayment(ctx context.Context, in *MpesaRefundRequest, opts ...grpc.CallOption) (*MpesaRefundResponse, error)
 }
 
 type paymentsClient struct {
@@ -52,12 +56,25 @@ func (c *paymentsClient) ProcessMpesaPayment(ctx context.Context, in *MpesaPayme
 	return out, nil
 }
 
+func (c *paymentsClient) RefundMpesaPayment(ctx context.Context, in *MpesaRefundRequest, opts ...grpc.CallOption) (*MpesaRefundResponse, error) {
+	out := new(MpesaRefundResponse)
+	err := c.cc.Invoke(ctx, "/payments.Payments/RefundMpesaPayment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PaymentsServer is the server API for Payments service.
 // All implementations must embed UnimplementedPaymentsServer
 // for forward compatibility
 type PaymentsServer interface {
 	HealthCheck(context.Context, *HealthCheckRequest) (*HealthCheckResponse, error)
 	ProcessMpesaPayment(context.Context, *MpesaPaymentRequest) (*MpesaPaymentResponse, error)
+	// Sends money back to the phone that paid for an order, as an M-Pesa B2C
+	// payment. Refunds are identified by refundId: requesting a refund again
+	// returns the first one instead of paying twice.
+	RefundMpesaPayment(context.Context, *MpesaRefundRequest) (*MpesaRefundResponse, error)
 	mustEmbedUnimplementedPaymentsServer()
 }
 
@@ -71,6 +88,9 @@ func (UnimplementedPaymentsServer) HealthCheck(context.Context, *HealthCheckRequ
 func (UnimplementedPaymentsServer) ProcessMpesaPayment(context.Context, *MpesaPaymentRequest) (*MpesaPaymentResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ProcessMpesaPayment not implemented")
 }
+func (UnimplementedPaymentsServer) RefundMpesaPayment(context.Context, *MpesaRefundRequest) (*MpesaRefundResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefundMpesaPayment not implemented")
+}
 func (UnimplementedPaymentsServer) mustEmbedUnimplementedPaymentsServer() {}
 
 // UnsafePaymentsServer may be embedded to opt out of forward compatibility for this service.
@@ -120,6 +140,24 @@ func _Payments_ProcessMpesaPayment_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Payments_RefundMpesaPayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MpesaRefundRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentsServer).RefundMpesaPayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/payments.Payments/RefundMpesaPayment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentsServer).RefundMpesaPayment(ctx, req.(*MpesaRefundRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Payments_ServiceDesc is the grpc.ServiceDesc for Payments service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -135,6 +173,10 @@ var Payments_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ProcessMpesaPayment",
 			Handler:    _Payments_ProcessMpesaPayment_Handler,
 		},
+		{
+			MethodName: "RefundMpesaPayment",
+			Handler:    _Payments_RefundMpesaPayment_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "payments.proto",
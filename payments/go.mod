@@ -3,6 +3,7 @@ module github.com/leta/order-management-system/payments
 go 1.21.0
 
 require (
+	github.com/prometheus/client_golang v1.16.0
 	google.golang.org/grpc v1.57.0
 	google.golang.org/protobuf v1.31.0
 )
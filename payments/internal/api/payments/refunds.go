@@ -0,0 +1,282 @@
+package payments
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/payments/internal/repository"
+	"github.com/leta/order-management-system/payments/internal/service"
+	"github.com/leta/order-management-system/payments/pkg/models"
+)
+
+func (r *PaymentsRepository) refundsCollection() *firestore.CollectionRef {
+	r.CheckPreconditions()
+
+	return r.db.Client.Collection("refunds")
+}
+
+// recordRefund adds the audit event of a change to a refund to tx.
+func (r *PaymentsRepository) recordRefund(
+	ctx context.Context, tx *firestore.Transaction, action string, before, after *repository.Refund) error {
+
+	event, err := audit.New(ctx, audit.EntityRefund, after.Id, action, before, after)
+	if err != nil {
+		return err
+	}
+
+	return audit.NewFirestoreLog(r.db.Client, service.SERVICE_NAME).Record(tx, event)
+}
+
+func (r *PaymentsRepository) GetPaidPaymentByOrderID(ctx context.Context, orderID string) (*repository.Payment, error) {
+	r.CheckPreconditions()
+
+	if orderID == "" {
+		return nil, service.Errorf(service.INVALID_ERROR, "invalid order ID provided")
+	}
+
+	query := r.paymentsCollection().
+		Where("orderId", "==", orderID).
+		Where("status", "==", string(repository.PaymentStatusPaid)).
+		Limit(1)
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, service.Errorf(service.INTERNAL_ERROR, "failed to get payment: %v", err)
+	}
+
+	if len(docs) == 0 {
+		return nil, service.Errorf(service.NOT_FOUND_ERROR, "order %s has no paid payment", orderID)
+	}
+
+	var paymentModel models.PaymentModel
+	err = docs[0].DataTo(&paymentModel)
+	if err != nil {
+		return nil, service.Errorf(service.INTERNAL_ERROR, "failed to decode payment: %v", err)
+	}
+
+	payment := r.unmarshallPayment(&paymentModel)
+	payment.Id = docs[0].Ref.ID
+
+	return payment, nil
+}
+
+// CreateRefund checks the refunds of payment that have not failed in the same
+// transaction, so that concurrent refunds cannot add up to more than was paid.
+// A failed refund is replaced, so that it can be retried with the same id.
+func (r *PaymentsRepository) CreateRefund(
+	ctx context.Context, refund *repository.Refund, payment *repository.Payment) error {
+	r.CheckPreconditions()
+
+	if refund.Id == "" {
+		return service.Errorf(service.INVALID_ERROR, "invalid refund ID provided")
+	}
+
+	if refund.Amount.Amount <= 0 {
+		return service.Errorf(service.INVALID_ERROR, "invalid refund amount %s", refund.Amount)
+	}
+
+	currentTime := time.Now().Format(time.RFC3339)
+	refund.PaymentID = payment.Id
+	refund.OrderID = payment.OrderID
+	refund.Phone = payment.Phone
+	refund.Status = repository.RefundStatusPending
+	refund.CreatedAt = currentTime
+	refund.UpdatedAt = currentTime
+
+	refundRef := r.refundsCollection().Doc(refund.Id)
+	query := r.refundsCollection().Where("paymentId", "==", payment.Id)
+
+	return r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		var failed *repository.Refund
+		doc, err := tx.Get(refundRef)
+		if err == nil {
+			failed, err = r.unmarshallRefundDoc(doc)
+			if err != nil {
+				return err
+			}
+			if failed.Status != repository.RefundStatusFailed {
+				return service.Errorf(service.ALREADY_EXISTS_ERROR, "refund %s already exists", refund.Id)
+			}
+		} else if status.Code(err) != codes.NotFound {
+			return service.Errorf(service.INTERNAL_ERROR, "failed to get refund: %v", err)
+		}
+
+		docs, err := tx.Documents(query).GetAll()
+		if err != nil {
+			return service.Errorf(service.INTERNAL_ERROR, "failed to get refunds: %v", err)
+		}
+
+		refunded := money.Zero(payment.Amount.Currency)
+		for _, doc := range docs {
+			var refundModel models.RefundModel
+			if err := doc.DataTo(&refundModel); err != nil {
+				return service.Errorf(service.INTERNAL_ERROR, "failed to decode refund: %v", err)
+			}
+
+			if repository.RefundStatus(refundModel.Status) == repository.RefundStatusFailed {
+				continue
+			}
+
+			refunded, err = refunded.Add(money.FromStored(refundModel.Amount, refundModel.Currency))
+			if err != nil {
+				return service.Errorf(service.INTERNAL_ERROR, "%v", err)
+			}
+		}
+
+		total, err := refunded.Add(refund.Amount)
+		if err != nil {
+			return service.Errorf(service.INVALID_ERROR, "%v", err)
+		}
+
+		if total.Amount > payment.Amount.Amount {
+			return service.Errorf(service.INVALID_ERROR,
+				"cannot refund %s, %s of the %s paid was already refunded", refund.Amount, refunded, payment.Amount)
+		}
+
+		if failed != nil {
+			refund.CreatedAt = failed.CreatedAt
+			if err := tx.Set(refundRef, r.marshallRefund(refund)); err != nil {
+				return service.Errorf(service.INTERNAL_ERROR, "failed to retry refund: %v", err)
+			}
+
+			return r.recordRefund(ctx, tx, audit.ActionUpdate, failed, refund)
+		}
+
+		if err := tx.Create(refundRef, r.marshallRefund(refund)); err != nil {
+			return service.Errorf(service.INTERNAL_ERROR, "failed to create refund: %v", err)
+		}
+
+		return r.recordRefund(ctx, tx, audit.ActionCreate, nil, refund)
+	})
+}
+
+func (r *PaymentsRepository) GetRefundByID(ctx context.Context, refundID string) (*repository.Refund, error) {
+	r.CheckPreconditions()
+
+	if refundID == "" {
+		return nil, service.Errorf(service.INVALID_ERROR, "invalid refund ID provided")
+	}
+
+	doc, err := r.refundsCollection().Doc(refundID).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, service.Errorf(service.NOT_FOUND_ERROR, "refund not found")
+	} else if err != nil {
+		return nil, service.Errorf(service.INTERNAL_ERROR, "failed to get refund: %v", err)
+	}
+
+	return r.unmarshallRefundDoc(doc)
+}
+
+func (r *PaymentsRepository) GetRefundByConversationID(
+	ctx context.Context, conversationID string) (*repository.Refund, error) {
+	r.CheckPreconditions()
+
+	if conversationID == "" {
+		return nil, service.Errorf(service.INVALID_ERROR, "invalid conversation ID provided")
+	}
+
+	query := r.refundsCollection().Where("conversationId", "==", conversationID).Limit(1)
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, service.Errorf(service.INTERNAL_ERROR, "failed to get refund: %v", err)
+	}
+
+	if len(docs) == 0 {
+		return nil, service.Errorf(service.NOT_FOUND_ERROR, "refund not found")
+	}
+
+	return r.unmarshallRefundDoc(docs[0])
+}
+
+// UpdateRefund writes the M-Pesa state of refund: its status, conversation,
+// response code and transaction. The amount and phone never change.
+func (r *PaymentsRepository) UpdateRefund(ctx context.Context, refund *repository.Refund) error {
+	r.CheckPreconditions()
+
+	if refund.Id == "" {
+		return service.Errorf(service.INVALID_ERROR, "invalid refund ID provided")
+	}
+
+	refundRef := r.refundsCollection().Doc(refund.Id)
+
+	return r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(refundRef)
+		if status.Code(err) == codes.NotFound {
+			return service.Errorf(service.NOT_FOUND_ERROR, "refund not found")
+		} else if err != nil {
+			return service.Errorf(service.INTERNAL_ERROR, "failed to get refund: %v", err)
+		}
+
+		before, err := r.unmarshallRefundDoc(doc)
+		if err != nil {
+			return err
+		}
+
+		after := *before
+		after.Status = refund.Status
+		after.ConversationID = refund.ConversationID
+		after.ResponseCode = refund.ResponseCode
+		after.TransactionID = refund.TransactionID
+		after.UpdatedAt = time.Now().Format(time.RFC3339)
+
+		if err := tx.Set(refundRef, r.marshallRefund(&after)); err != nil {
+			return service.Errorf(service.INTERNAL_ERROR, "failed to update refund: %v", err)
+		}
+
+		*refund = after
+
+		return r.recordRefund(ctx, tx, audit.ActionUpdate, before, &after)
+	})
+}
+
+func (r *PaymentsRepository) unmarshallRefundDoc(doc *firestore.DocumentSnapshot) (*repository.Refund, error) {
+	var refundModel models.RefundModel
+	if err := doc.DataTo(&refundModel); err != nil {
+		return nil, service.Errorf(service.INTERNAL_ERROR, "failed to decode refund: %v", err)
+	}
+
+	refund := r.unmarshallRefund(&refundModel)
+	refund.Id = doc.Ref.ID
+
+	return refund, nil
+}
+
+func (r *PaymentsRepository) marshallRefund(refund *repository.Refund) *models.RefundModel {
+	return &models.RefundModel{
+		ID:             refund.Id,
+		PaymentID:      refund.PaymentID,
+		OrderID:        refund.OrderID,
+		Amount:         refund.Amount.Amount,
+		Currency:       refund.Amount.Currency,
+		Phone:          refund.Phone,
+		Reason:         refund.Reason,
+		Status:         string(refund.Status),
+		ConversationID: refund.ConversationID,
+		ResponseCode:   refund.ResponseCode,
+		TransactionID:  refund.TransactionID,
+		CreatedAt:      refund.CreatedAt,
+		UpdatedAt:      refund.UpdatedAt,
+	}
+}
+
+func (r *PaymentsRepository) unmarshallRefund(refundModel *models.RefundModel) *repository.Refund {
+	return &repository.Refund{
+		Id:             refundModel.ID,
+		PaymentID:      refundModel.PaymentID,
+		OrderID:        refundModel.OrderID,
+		Amount:         money.FromStored(refundModel.Amount, refundModel.Currency),
+		Phone:          refundModel.Phone,
+		Reason:         refundModel.Reason,
+		Status:         repository.RefundStatus(refundModel.Status),
+		ConversationID: refundModel.ConversationID,
+		ResponseCode:   refundModel.ResponseCode,
+		TransactionID:  refundModel.TransactionID,
+		CreatedAt:      refundModel.CreatedAt,
+		UpdatedAt:      refundModel.UpdatedAt,
+	}
+}
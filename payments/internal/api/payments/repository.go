@@ -8,6 +8,14 @@ import (
 	"github.com/leta/order-management-system/payments/internal/service"
 	"github.com/leta/order-management-system/payments/pkg/models"
 	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	orders "github.com/leta/order-management-system/orders/pkg/client"
+	"github.com/leta/order-management-system/orders/pkg/events"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/outbox"
 )
 
 type PaymentsRepository struct {
@@ -32,6 +40,24 @@ func (r *PaymentsRepository) paymentsCollection() *firestore.CollectionRef {
 	return r.db.Client.Collection("payments")
 }
 
+func (r *PaymentsRepository) outbox() *outbox.FirestoreStore {
+	r.CheckPreconditions()
+
+	return outbox.NewFirestoreStore(r.db.Client, service.SERVICE_NAME)
+}
+
+// record adds the audit event of a change to a payment to tx.
+func (r *PaymentsRepository) record(
+	ctx context.Context, tx *firestore.Transaction, id string, action string, before, after *repository.Payment) error {
+
+	event, err := audit.New(ctx, audit.EntityPayment, id, action, before, after)
+	if err != nil {
+		return err
+	}
+
+	return audit.NewFirestoreLog(r.db.Client, service.SERVICE_NAME).Record(tx, event)
+}
+
 func (r *PaymentsRepository) CreatePayment(ctx context.Context, payment *repository.Payment) (string, error) {
 	r.CheckPreconditions()
 
@@ -45,13 +71,21 @@ func (r *PaymentsRepository) CreatePayment(ctx context.Context, payment *reposit
 	}
 	paymentModel := r.marshallPayment(payment)
 
-	docRef, _, err := r.paymentsCollection().Add(ctx, paymentModel)
+	docRef := r.paymentsCollection().NewDoc()
+
+	err = r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if err := tx.Create(docRef, paymentModel); err != nil {
+			return err
+		}
+
+		payment.Id = docRef.ID
+
+		return r.record(ctx, tx, docRef.ID, audit.ActionCreate, nil, payment)
+	})
 	if err != nil {
 		return "", service.Errorf(service.INTERNAL_ERROR, "failed to create payment: %v", err)
 	}
 
-	payment.Id = docRef.ID
-
 	return payment.Id, nil
 }
 
@@ -81,10 +115,15 @@ func (r *PaymentsRepository) getPaymentByID(ctx context.Context, paymentID strin
 	}
 
 	payment := r.unmarshallPayment(&paymentModel)
+	payment.Id = doc.Ref.ID
 
 	return payment, nil
 }
 
+// UpdatePaymentStatus sets the status of a payment and, when it becomes paid or
+// failed, records a PaymentSucceeded or PaymentFailed event in the same
+// transaction. Setting the current status again is a no-op, so a callback
+// delivered twice does not emit duplicate events.
 func (r *PaymentsRepository) UpdatePaymentStatus(
 	ctx context.Context, paymentID string, status repository.PaymentStatus) error {
 	r.CheckPreconditions()
@@ -93,20 +132,85 @@ func (r *PaymentsRepository) UpdatePaymentStatus(
 		return service.Errorf(service.INVALID_ERROR, "invalid payment ID provided")
 	}
 
-	payment, err := r.getPaymentByID(ctx, paymentID)
-	if err != nil {
-		return err
-	}
+	paymentRef := r.paymentsCollection().Doc(paymentID)
+
+	return r.db.Client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(paymentRef)
+		if err != nil {
+			return service.Errorf(service.INTERNAL_ERROR, "failed to get payment: %v", err)
+		}
+
+		var paymentModel models.PaymentModel
+		err = doc.DataTo(&paymentModel)
+		if err != nil {
+			return service.Errorf(service.INTERNAL_ERROR, "failed to decode payment: %v", err)
+		}
+
+		payment := r.unmarshallPayment(&paymentModel)
+		payment.Id = paymentID
+
+		if payment.Status == status {
+			return nil
+		}
+
+		before := *payment
+
+		payment.Status = status
+		payment.UpdatedAt = time.Now().Format(time.RFC3339)
+
+		err = tx.Set(paymentRef, r.marshallPayment(payment))
+		if err != nil {
+			return service.Errorf(service.INTERNAL_ERROR, "failed to update payment status: %v", err)
+		}
 
-	payment.Status = status
-	payment.UpdatedAt = time.Now().Format(time.RFC3339)
+		err = r.record(ctx, tx, paymentID, audit.ActionUpdate, &before, payment)
+		if err != nil {
+			return service.Errorf(service.INTERNAL_ERROR, "%v", err)
+		}
 
-	opaymentModel := r.marshallPayment(payment)
-	_, err = r.paymentsCollection().Doc(paymentID).Set(ctx, opaymentModel)
+		event, err := r.paymentEvent(ctx, payment)
+		if err != nil || event == nil {
+			return err
+		}
+
+		err = r.outbox().Enqueue(tx, event)
+		if err != nil {
+			return service.Errorf(service.INTERNAL_ERROR, "%v", err)
+		}
+
+		return nil
+	})
+}
+
+// paymentEvent returns the event describing the status of payment, or nil if
+// the status is not final.
+func (r *PaymentsRepository) paymentEvent(ctx context.Context, payment *repository.Payment) (*events.Event, error) {
+	var msg proto.Message
+	switch payment.Status {
+	case repository.PaymentStatusPaid:
+		msg = &orders.PaymentSucceeded{
+			PaymentId:         payment.Id,
+			OrderId:           payment.OrderID,
+			MerchantRequestId: payment.MerchantRequestID,
+			Amount:            &orders.Money{Amount: payment.Amount.Amount, Currency: payment.Amount.Currency},
+		}
+	case repository.PaymentStatusFailed:
+		msg = &orders.PaymentFailed{
+			PaymentId:         payment.Id,
+			OrderId:           payment.OrderID,
+			MerchantRequestId: payment.MerchantRequestID,
+			Amount:            &orders.Money{Amount: payment.Amount.Amount, Currency: payment.Amount.Currency},
+		}
+	default:
+		return nil, nil
+	}
+
+	event, err := events.New(ctx, payment.Id, msg)
 	if err != nil {
-		return service.Errorf(service.INTERNAL_ERROR, "failed to update orders status: %v", err)
+		return nil, service.Errorf(service.INTERNAL_ERROR, "%v", err)
 	}
-	return nil
+
+	return event, nil
 }
 
 func (r *PaymentsRepository) GetPaymentByMerchantRequestID(
@@ -117,7 +221,7 @@ func (r *PaymentsRepository) GetPaymentByMerchantRequestID(
 		return nil, service.Errorf(service.INVALID_ERROR, "invalid merchant request ID provided")
 	}
 
-	query := r.paymentsCollection().Where("reference", "==", merchantRequestID).Limit(1)
+	query := r.paymentsCollection().Where("merchantRequestId", "==", merchantRequestID).Limit(1)
 	docs, err := query.Documents(ctx).GetAll()
 	if err != nil {
 		return nil, service.Errorf(service.INTERNAL_ERROR, "failed to get payment: %v", err)
@@ -134,13 +238,15 @@ func (r *PaymentsRepository) GetPaymentByMerchantRequestID(
 	}
 
 	payment := r.unmarshallPayment(&paymentModel)
+	payment.Id = docs[0].Ref.ID
 
 	return payment, nil
 }
 
 func (r *PaymentsRepository) marshallPayment(payment *repository.Payment) *models.PaymentModel {
 	return &models.PaymentModel{
-		Amount:      payment.Amount,
+		Amount:      payment.Amount.Amount,
+		Currency:    payment.Amount.Currency,
 		Status:      string(payment.Status),
 		OrderID:     payment.OrderID,
 		Phone:       payment.Phone,
@@ -148,13 +254,16 @@ func (r *PaymentsRepository) marshallPayment(payment *repository.Payment) *model
 		Description: payment.Description,
 		CreatedAt:   payment.CreatedAt,
 		UpdatedAt:   payment.UpdatedAt,
+		TraceParent: payment.TraceParent,
+
+		MerchantRequestID: payment.MerchantRequestID,
 	}
 }
 
 func (r *PaymentsRepository) unmarshallPayment(paymentModel *models.PaymentModel) *repository.Payment {
 	return &repository.Payment{
 		Id:          paymentModel.ID,
-		Amount:      paymentModel.Amount,
+		Amount:      money.FromStored(paymentModel.Amount, paymentModel.Currency),
 		Status:      repository.PaymentStatus(paymentModel.Status),
 		OrderID:     paymentModel.OrderID,
 		Phone:       paymentModel.Phone,
@@ -162,5 +271,8 @@ func (r *PaymentsRepository) unmarshallPayment(paymentModel *models.PaymentModel
 		Description: paymentModel.Description,
 		CreatedAt:   paymentModel.CreatedAt,
 		UpdatedAt:   paymentModel.UpdatedAt,
+		TraceParent: paymentModel.TraceParent,
+
+		MerchantRequestID: paymentModel.MerchantRequestID,
 	}
 }
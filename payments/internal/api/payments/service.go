@@ -4,6 +4,8 @@ import (
 	"context"
 	"github.com/leta/order-management-system/payments/generated"
 	"github.com/leta/order-management-system/payments/internal/interfaces/api/payment"
+
+	"github.com/leta/order-management-system/orders/pkg/money"
 )
 
 type PaymentService struct {
@@ -16,7 +18,7 @@ func (s *PaymentService) ProcessPayment(ctx context.Context, in *generated.Mpesa
 	//callBackURL := "https://order-management-system.herokuapp.com/payments/callback"
 
 	p, err := s.paymentSvc.ProcessPayment(ctx, &payment.Payment{
-		Amount:      uint(in.GetAmount()),
+		Amount:      money.New(in.GetAmount().GetAmount(), in.GetAmount().GetCurrency()),
 		PhoneNumber: uint(in.GetPhoneNumber()),
 		CallbackURL: s.callBackURL,
 		Reference:   in.GetReference(),
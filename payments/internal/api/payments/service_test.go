@@ -48,7 +48,7 @@ func TestGRPCServer_ProcessPayment(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				in: &generated.MpesaPaymentRequest{
-					Amount:      100,
+					Amount:      &generated.Money{Amount: 10000, Currency: "KES"},
 					PhoneNumber: 254700000000,
 					Reference:   "reference",
 					Description: "description",
@@ -67,7 +67,7 @@ func TestGRPCServer_ProcessPayment(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				in: &generated.MpesaPaymentRequest{
-					Amount:      100,
+					Amount:      &generated.Money{Amount: 10000, Currency: "KES"},
 					PhoneNumber: 254700000000,
 					Reference:   ERROR_PAYMENT_TRIGGER,
 					Description: "description",
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"time"
+
+	"github.com/leta/order-management-system/orders/pkg/config"
+	"github.com/leta/order-management-system/orders/pkg/events"
+	"github.com/leta/order-management-system/orders/pkg/grpcclient"
+	"github.com/leta/order-management-system/orders/pkg/lifecycle"
+)
+
+// Config is the configuration of the payments service.
+type Config struct {
+	// Deployment environment, e.g. "dev", "test" or "prod". M-Pesa requests go
+	// to the production Daraja API only in "prod".
+	Environment string `yaml:"environment" env:"ENVIRONMENT"`
+
+	Server   Server          `yaml:"server"`
+	Orders   Orders          `yaml:"orders"`
+	Mpesa    Mpesa           `yaml:"mpesa"`
+	Events   config.Events   `yaml:"events"`
+	Firebase config.Firebase `yaml:"firebase"`
+	Tracing  config.Tracing  `yaml:"tracing"`
+}
+
+// Server configures the listeners of the payments service.
+type Server struct {
+	BindAddress string `yaml:"bind_address" env:"BIND_ADDRESS"`
+	Port        string `yaml:"port" env:"PORT"`
+	DebugPort   string `yaml:"debug_port" env:"DEBUG_PORT"`
+
+	// Port of the HTTP server receiving M-Pesa STK callbacks.
+	CallbackPort string `yaml:"callback_port" env:"CALLBACK_PORT"`
+
+	// Registers the gRPC reflection service so tools like grpcurl work.
+	Reflection bool `yaml:"reflection" env:"GRPC_REFLECTION"`
+
+	// Time given to in-flight RPCs, requests and background jobs to finish
+	// after SIGTERM before they are cancelled.
+	ShutdownGracePeriod time.Duration `yaml:"shutdown_grace_period" env:"SHUTDOWN_GRACE_PERIOD"`
+}
+
+// Orders configures the connection to the orders service.
+type Orders struct {
+	Addr string `yaml:"addr" env:"ORDERS_ADDR"`
+
+	// Retries, timeouts and circuit breaking of the client, e.g.
+	// ORDERS_CLIENT_TIMEOUT.
+	Client grpcclient.Options `yaml:"client" env:"ORDERS_CLIENT_"`
+}
+
+// Mpesa holds the Daraja API credentials. The system handles orders for a
+// single business, so there is one short code and pass key.
+type Mpesa struct {
+	ConsumerKey       config.Secret `yaml:"consumer_key" env:"MPESA_CONSUMER_KEY"`
+	ConsumerSecret    config.Secret `yaml:"consumer_secret" env:"MPESA_CONSUMER_SECRET"`
+	PassKey           config.Secret `yaml:"passkey" env:"MPESA_PASSKEY"`
+	BusinessShortCode uint          `yaml:"business_short_code" env:"MPESA_BUSINESS_SHORT_CODE"`
+
+	// B2C operator refunds are sent by. Refunds are disabled if not set.
+	InitiatorName     string        `yaml:"initiator_name" env:"MPESA_INITIATOR_NAME"`
+	InitiatorPassword config.Secret `yaml:"initiator_password" env:"MPESA_INITIATOR_PASSWORD"`
+
+	// URLs M-Pesa posts the results and queue timeouts of refunds to.
+	RefundResultURL  string `yaml:"refund_result_url" env:"MPESA_REFUND_RESULT_URL"`
+	RefundTimeoutURL string `yaml:"refund_timeout_url" env:"MPESA_REFUND_TIMEOUT_URL"`
+}
+
+// Default returns the configuration used when nothing else is specified.
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			BindAddress:  "localhost",
+			Port:         "50052",
+			DebugPort:    "6061",
+			CallbackPort: "8081",
+
+			ShutdownGracePeriod: lifecycle.DefaultGracePeriod,
+		},
+		Orders: Orders{
+			Addr:   "localhost:50051",
+			Client: grpcclient.DefaultOptions(),
+		},
+		Events: config.Events{
+			Broker: events.BrokerInProcess,
+			Topic:  "payments-events",
+		},
+	}
+}
+
+// RegisterFlags implements config.Config. Secrets have no flags so they do
+// not show up in the process list.
+func (c *Config) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.Environment, "environment", c.Environment, "deployment environment (dev, test, prod)")
+	fs.StringVar(&c.Server.BindAddress, "bind-address", c.Server.BindAddress, "address the servers listen on")
+	fs.StringVar(&c.Server.Port, "port", c.Server.Port, "gRPC server port")
+	fs.StringVar(&c.Server.DebugPort, "debug-port", c.Server.DebugPort, "debug server (/metrics) port")
+	fs.StringVar(&c.Server.CallbackPort, "callback-port", c.Server.CallbackPort, "M-Pesa callback server port")
+	fs.BoolVar(&c.Server.Reflection, "grpc-reflection", c.Server.Reflection, "register the gRPC reflection service")
+	fs.DurationVar(&c.Server.ShutdownGracePeriod, "shutdown-grace-period", c.Server.ShutdownGracePeriod,
+		"time given to in-flight work to finish on shutdown")
+	fs.StringVar(&c.Orders.Addr, "orders-addr", c.Orders.Addr, "host:port of the orders gRPC server")
+	fs.UintVar(&c.Mpesa.BusinessShortCode, "mpesa-short-code", c.Mpesa.BusinessShortCode, "M-Pesa business short code")
+	fs.StringVar(&c.Events.Broker, "events-broker", c.Events.Broker, "event broker (inprocess, pubsub)")
+	fs.StringVar(&c.Firebase.ProjectID, "firebase-project", c.Firebase.ProjectID, "Google Cloud project ID")
+	fs.StringVar(&c.Tracing.Exporter, "trace-exporter", c.Tracing.Exporter, "span exporter (none, stdout, otlp)")
+}
+
+// Validate implements config.Config.
+func (c *Config) Validate() error {
+	errs := []error{
+		config.ValidatePort("server.port (PORT)", c.Server.Port),
+		config.ValidatePort("server.debug_port (DEBUG_PORT)", c.Server.DebugPort),
+		config.ValidatePort("server.callback_port (CALLBACK_PORT)", c.Server.CallbackPort),
+		config.ValidateDistinctPorts(
+			"server.port", c.Server.Port,
+			"server.debug_port", c.Server.DebugPort,
+			"server.callback_port", c.Server.CallbackPort),
+		config.ValidateAddress("orders.addr (ORDERS_ADDR)", c.Orders.Addr),
+		c.Mpesa.Validate(),
+		c.Orders.Client.Validate("orders.client"),
+		c.Events.Validate(),
+		c.Firebase.Validate(c.Environment),
+		c.Tracing.Validate(),
+	}
+
+	if c.Server.ShutdownGracePeriod <= 0 {
+		errs = append(errs, errors.New("server.shutdown_grace_period (SHUTDOWN_GRACE_PERIOD) must be positive"))
+	}
+
+	if c.Environment == "" {
+		errs = append(errs, errors.New("environment (ENVIRONMENT) is required"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validate checks that every Daraja credential is set.
+func (c *Mpesa) Validate() error {
+	var errs []error
+
+	if c.ConsumerKey == "" {
+		errs = append(errs, errors.New("mpesa.consumer_key (MPESA_CONSUMER_KEY) is required"))
+	}
+	if c.ConsumerSecret == "" {
+		errs = append(errs, errors.New("mpesa.consumer_secret (MPESA_CONSUMER_SECRET) is required"))
+	}
+	if c.PassKey == "" {
+		errs = append(errs, errors.New("mpesa.passkey (MPESA_PASSKEY) is required"))
+	}
+	if c.BusinessShortCode == 0 {
+		errs = append(errs, errors.New("mpesa.business_short_code (MPESA_BUSINESS_SHORT_CODE) is required"))
+	}
+
+	if c.RefundsEnabled() {
+		if c.InitiatorPassword == "" {
+			errs = append(errs, errors.New("mpesa.initiator_password (MPESA_INITIATOR_PASSWORD) is required for refunds"))
+		}
+		if c.RefundResultURL == "" {
+			errs = append(errs, errors.New("mpesa.refund_result_url (MPESA_REFUND_RESULT_URL) is required for refunds"))
+		}
+		if c.RefundTimeoutURL == "" {
+			errs = append(errs, errors.New("mpesa.refund_timeout_url (MPESA_REFUND_TIMEOUT_URL) is required for refunds"))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// RefundsEnabled reports whether a B2C operator is configured to send refunds.
+func (c *Mpesa) RefundsEnabled() bool {
+	return c.InitiatorName != ""
+}
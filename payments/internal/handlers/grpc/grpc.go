@@ -24,6 +24,8 @@ func GRPCErrorStatusCode(err error) error {
 		return status.Error(codes.AlreadyExists, service.ErrorMessage(err))
 	case service.INTERNAL_ERROR:
 		return status.Error(codes.Internal, service.ErrorMessage(err))
+	case service.NOT_IMPLEMENTED_ERROR:
+		return status.Error(codes.Unimplemented, service.ErrorMessage(err))
 	default:
 		return status.Error(codes.Unknown, service.ErrorMessage(err))
 	}
@@ -6,11 +6,22 @@ import (
 	"log"
 )
 
+const (
+	HealthStatusOK       = "OK"
+	HealthStatusNotReady = "NOT_READY"
+)
+
 func (s *GRPCServer) HealthCheck(ctx context.Context, in *generated.HealthCheckRequest) (*generated.HealthCheckResponse, error) {
 
 	log.Printf("Received: Health check request")
 
+	if s.Health != nil && !s.Health.Ready() {
+		return &generated.HealthCheckResponse{
+			Status: HealthStatusNotReady,
+		}, nil
+	}
+
 	return &generated.HealthCheckResponse{
-		Status: "OK",
+		Status: HealthStatusOK,
 	}, nil
 }
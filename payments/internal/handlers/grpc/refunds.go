@@ -0,0 +1,31 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/payments/generated"
+	"github.com/leta/order-management-system/payments/internal/service"
+)
+
+func (s *GRPCServer) RefundMpesaPayment(
+	ctx context.Context, in *generated.MpesaRefundRequest) (*generated.MpesaRefundResponse, error) {
+
+	res, err := s.PaymentsService.RefundPayment(ctx, &service.Refund{
+		Id:      in.GetRefundId(),
+		OrderId: in.GetOrderId(),
+		Amount:  money.New(in.GetAmount().GetAmount(), in.GetAmount().GetCurrency()),
+		Reason:  in.GetReason(),
+	})
+	if err != nil {
+		LogError(err)
+		return nil, GRPCErrorStatusCode(err)
+	}
+
+	return &generated.MpesaRefundResponse{
+		RefundId:       res.RefundID,
+		ConversationId: res.ConversationID,
+		ResponseCode:   res.ResponseCode,
+		Status:         res.Status,
+	}, nil
+}
@@ -8,9 +8,14 @@ import (
 	"net"
 	"sync"
 
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/health"
+	"github.com/leta/order-management-system/orders/pkg/metrics"
+	"github.com/leta/order-management-system/orders/pkg/tracing"
 	"github.com/leta/order-management-system/payments/internal/service"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 )
 
 type GRPCServer struct {
@@ -18,6 +23,13 @@ type GRPCServer struct {
 
 	grpcServer *grpc.Server
 	mu         sync.Mutex // synchronizes access to the grpcServer
+	closed     bool       // set once Shutdown has been called
+
+	// Standard grpc.health.v1 service. Not registered if nil.
+	Health *health.Service
+
+	// Registers the gRPC reflection service so tools like grpcurl work.
+	EnableReflection bool
 
 	// Internal servicesx
 	PaymentsService service.PaymentsService
@@ -36,22 +48,63 @@ func (s *GRPCServer) Run(ctx context.Context, bindAddress string, port string) e
 	}
 
 	s.mu.Lock()
-	s.grpcServer = grpc.NewServer()
+	if s.closed {
+		s.mu.Unlock()
+		return lis.Close()
+	}
+	s.grpcServer = grpc.NewServer(grpc.ChainUnaryInterceptor(
+		tracing.UnaryServerInterceptor(), metrics.UnaryServerInterceptor(), audit.UnaryServerInterceptor()))
 	s.mu.Unlock()
 
 	generated.RegisterPaymentsServer(s.grpcServer, s)
 
+	if s.Health != nil {
+		s.Health.Register(s.grpcServer)
+	}
+
+	if s.EnableReflection {
+		reflection.Register(s.grpcServer)
+	}
+
 	log.Printf("Starting server on port %v", lis.Addr())
 
 	return s.grpcServer.Serve(lis)
 }
 
-// Stop gracefully stops the GRPC server.
+// Stop gracefully stops the GRPC server, waiting for in-flight RPCs to finish.
 func (s *GRPCServer) Stop() {
+	_ = s.Shutdown(context.Background())
+}
+
+// Shutdown marks the server as NOT_SERVING, stops accepting new RPCs and waits
+// for in-flight RPCs to finish. If ctx expires first, the remaining RPCs are
+// cancelled and ctx's error is returned.
+func (s *GRPCServer) Shutdown(ctx context.Context) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.closed = true
+	server := s.grpcServer
+	s.mu.Unlock()
+
+	if s.Health != nil {
+		s.Health.Shutdown()
+	}
+
+	if server == nil {
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(done)
+	}()
 
-	if s.grpcServer != nil {
-		s.grpcServer.GracefulStop()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		server.Stop()
+		<-done
+		return ctx.Err()
 	}
 }
@@ -9,6 +9,8 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/leta/order-management-system/orders/pkg/metrics"
+	"github.com/leta/order-management-system/orders/pkg/tracing"
 	"github.com/leta/order-management-system/payments/internal/service"
 
 	"golang.org/x/crypto/acme/autocert"
@@ -52,6 +54,8 @@ func NewHTTPServer() *HTTPServer {
 
 	s.registerCallbackRoutes(s.router)
 
+	s.server.Handler = tracing.HTTPHandler(s.router, "payments.callback")
+
 	return s
 }
 
@@ -119,10 +123,17 @@ func (s *HTTPServer) Open() (err error) {
 	return nil
 }
 
-// Close gracefully shuts down the server.
+// Close gracefully shuts down the server, giving outstanding requests
+// ShutdownTimeout to finish.
 func (s *HTTPServer) Close() error {
 	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
 	defer cancel()
+	return s.Shutdown(ctx)
+}
+
+// Shutdown stops accepting new requests and waits for outstanding requests,
+// such as M-Pesa callbacks being processed, to finish or ctx to expire.
+func (s *HTTPServer) Shutdown(ctx context.Context) error {
 	return s.server.Shutdown(ctx)
 }
 
@@ -146,17 +157,17 @@ func redirectTLS(domain string) func(http.ResponseWriter, *http.Request) {
 	}
 }
 
-// ListenAndServeDebug runs an HTTP server with /debug endpoints (e.g. pprof, vars).
-func ListenAndServeDebug() error {
+// NewDebugServer returns an HTTP server with /debug endpoints (e.g. pprof, vars)
+// and the Prometheus /metrics endpoint.
+func NewDebugServer(addr string) *http.Server {
 	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
 
-	server := &http.Server{
-		Addr:         ":6060",
+	return &http.Server{
+		Addr:         addr,
 		Handler:      mux,
 		ReadTimeout:  ReadTimeout,
 		WriteTimeout: WriteTimeout,
 		IdleTimeout:  IdleTimeout,
 	}
-
-	return server.ListenAndServe()
 }
@@ -10,6 +10,10 @@ import (
 
 func (s *HTTPServer) registerCallbackRoutes(r *chi.Mux) {
 	r.Get("/callback", s.handleMpesaCallback)
+
+	// Results of refunds, and of refunds that timed out in the M-Pesa queue.
+	r.Post("/refunds/result", s.handleMpesaRefundResult)
+	r.Post("/refunds/timeout", s.handleMpesaRefundResult)
 }
 
 func (s *HTTPServer) handleMpesaCallback(w http.ResponseWriter, r *http.Request) {
@@ -25,3 +29,20 @@ func (s *HTTPServer) handleMpesaCallback(w http.ResponseWriter, r *http.Request)
 
 	log.Printf("%+v", callback)
 }
+
+func (s *HTTPServer) handleMpesaRefundResult(w http.ResponseWriter, r *http.Request) {
+	callback, err := mpesa.UnmarshalB2CCallback(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = s.PaymentsService.HandleMpesaRefundResult(r.Context(), &callback.Result)
+	if err != nil {
+		log.Printf("failed to handle refund result %s: %v", callback.Result.ConversationID, err)
+		http.Error(w, "failed to handle refund result", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -1,17 +1,21 @@
 package payment
 
-import "github.com/jwambugu/mpesa-golang-sdk"
+import (
+	"github.com/jwambugu/mpesa-golang-sdk"
+
+	"github.com/leta/order-management-system/orders/pkg/money"
+)
 
 type Payment struct {
-	Id          string `json:"id"`
-	OrderId     string `json:"orderId"`
-	PhoneNumber uint   `json:"phoneNumber"`
-	Amount      uint   `json:"amount"`
-	Reference   string `json:"reference"`
-	Description string `json:"description"`
-	CallbackURL string `json:"callbackUrl"`
-	CreatedAt   string `json:"createdAt"`
-	UpdatedAt   string `json:"updatedAt"`
+	Id          string      `json:"id"`
+	OrderId     string      `json:"orderId"`
+	PhoneNumber uint        `json:"phoneNumber"`
+	Amount      money.Money `json:"amount"`
+	Reference   string      `json:"reference"`
+	Description string      `json:"description"`
+	CallbackURL string      `json:"callbackUrl"`
+	CreatedAt   string      `json:"createdAt"`
+	UpdatedAt   string      `json:"updatedAt"`
 }
 
 type PaymentResponse struct {
@@ -0,0 +1,95 @@
+// Package migrate rewrites documents stored in earlier formats.
+package migrate
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/payments/internal/service"
+	"github.com/leta/order-management-system/payments/pkg/models"
+)
+
+// Actor is recorded in the audit log as the author of migrations.
+const Actor = "system:migrate"
+
+// Money converts the amounts of the payments stored before amounts carried a
+// currency, in major units of money.DefaultCurrency, to minor units, and
+// returns the number of payments rewritten.
+//
+// Payments are read the same way before and after, so the service can run
+// during the migration. Each payment is rewritten in its own transaction that
+// checks it still needs it, which makes the migration safe to rerun.
+func Money(ctx context.Context, client *firestore.Client) (int, error) {
+	ctx = audit.WithActor(ctx, Actor)
+	ctx = audit.WithRequestID(ctx, audit.NewRequestID())
+
+	iter := client.Collection("payments").Documents(ctx)
+	defer iter.Stop()
+
+	migrated := 0
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			return migrated, nil
+		} else if err != nil {
+			return migrated, err
+		}
+
+		var rewritten bool
+		err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) (err error) {
+			rewritten, err = migratePayment(ctx, client, tx, doc.Ref)
+			return err
+		})
+		if err != nil {
+			return migrated, fmt.Errorf("%s: %w", doc.Ref.Path, err)
+		}
+
+		if rewritten {
+			migrated++
+		}
+	}
+}
+
+func migratePayment(
+	ctx context.Context, client *firestore.Client, tx *firestore.Transaction, ref *firestore.DocumentRef) (bool, error) {
+
+	doc, err := tx.Get(ref)
+	if err != nil {
+		return false, err
+	}
+
+	var payment models.PaymentModel
+	if err := doc.DataTo(&payment); err != nil {
+		return false, err
+	}
+
+	if payment.Currency != "" {
+		return false, nil
+	}
+
+	amount := money.FromStored(payment.Amount, payment.Currency)
+
+	migrated := payment
+	migrated.Amount = amount.Amount
+	migrated.Currency = amount.Currency
+
+	err = tx.Update(ref, []firestore.Update{
+		{Path: "amount", Value: migrated.Amount},
+		{Path: "currency", Value: migrated.Currency},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	event, err := audit.New(ctx, audit.EntityPayment, ref.ID, audit.ActionUpdate, &payment, &migrated)
+	if err != nil {
+		return false, err
+	}
+
+	return true, audit.NewFirestoreLog(client, service.SERVICE_NAME).Record(tx, event)
+}
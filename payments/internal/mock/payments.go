@@ -11,6 +11,9 @@ var _ service.PaymentsService = (*PaymentsService)(nil)
 type PaymentsService struct {
 	ProcessPaymentFunc      func(ctx context.Context, p *service.Payment) (*service.PaymentResponse, error)
 	HandleMpesaCallbackFunc func(ctx context.Context, p *service.PaymentCallback) error
+
+	RefundPaymentFunc           func(ctx context.Context, r *service.Refund) (*service.RefundResponse, error)
+	HandleMpesaRefundResultFunc func(ctx context.Context, r *service.RefundCallback) error
 }
 
 func (m *PaymentsService) ProcessPayment(ctx context.Context, p *service.Payment) (*service.PaymentResponse, error) {
@@ -20,3 +23,11 @@ func (m *PaymentsService) ProcessPayment(ctx context.Context, p *service.Payment
 func (m *PaymentsService) HandleMpesaCallback(ctx context.Context, p *service.PaymentCallback) error {
 	return m.HandleMpesaCallbackFunc(ctx, p)
 }
+
+func (m *PaymentsService) RefundPayment(ctx context.Context, r *service.Refund) (*service.RefundResponse, error) {
+	return m.RefundPaymentFunc(ctx, r)
+}
+
+func (m *PaymentsService) HandleMpesaRefundResult(ctx context.Context, r *service.RefundCallback) error {
+	return m.HandleMpesaRefundResultFunc(ctx, r)
+}
@@ -33,6 +33,9 @@ func findCodeInError(err error, code string) (string, bool) {
 
 	re := regexp.MustCompile(`(?m)` + quotedPattern + `: (.*)`)
 	match := re.FindStringSubmatch(err.Error())
+	if len(match) == 0 {
+		return "", false
+	}
 
-	return match[0], len(match) > 0
+	return match[0], true
 }
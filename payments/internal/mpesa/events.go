@@ -0,0 +1,58 @@
+package mpesa
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	orders "github.com/leta/order-management-system/orders/pkg/client"
+	"github.com/leta/order-management-system/orders/pkg/events"
+)
+
+// NewOrdersPublisher returns a publisher delivering payment events straight to
+// the orders service, by updating the status of the order, for deployments
+// without a broker. Setting a status is idempotent, so redelivered events are
+// harmless. Like the orders service's own subscriber, updates the order
+// refuses are logged and dropped, as retrying them would not change that.
+func NewOrdersPublisher(ordersClient orders.OrdersClient) events.Publisher {
+	updateOrderStatus := func(ctx context.Context, orderID string, orderStatus orders.OrderStatus) error {
+		_, err := ordersClient.UpdateOrderStatus(ctx, &orders.UpdateOrderStatusRequest{
+			Id:     orderID,
+			Status: orderStatus,
+		})
+		if status.Code(err) == codes.InvalidArgument {
+			log.Printf("[payment events] dropped %s status of order %s: %v", orderStatus, orderID, err)
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to update status of order %s: %v", orderID, err)
+		}
+		return nil
+	}
+
+	return events.PublisherFunc(func(ctx context.Context, event *events.Event) error {
+		ctx = audit.WithActor(ctx, "system:payment-events")
+
+		switch event.Type {
+		case events.TypeOf(&orders.PaymentSucceeded{}):
+			var payment orders.PaymentSucceeded
+			if err := event.Decode(&payment); err != nil {
+				return err
+			}
+			return updateOrderStatus(ctx, payment.GetOrderId(), orders.OrderStatusPaid)
+
+		case events.TypeOf(&orders.PaymentFailed{}):
+			var payment orders.PaymentFailed
+			if err := event.Decode(&payment); err != nil {
+				return err
+			}
+			return updateOrderStatus(ctx, payment.GetOrderId(), orders.OrderStatusFailed)
+
+		default:
+			return nil
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package mpesa
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const stkPushErrorCode = "error"
+
+var (
+	stkPushTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mpesa_stk_push_total",
+		Help: "Number of STK push requests sent to Daraja, by response code.",
+	}, []string{"response_code"})
+
+	stkCallbackTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mpesa_stk_callback_total",
+		Help: "Number of STK push callbacks received, by result code.",
+	}, []string{"result_code"})
+
+	b2cTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mpesa_b2c_total",
+		Help: "Number of B2C refund requests sent to Daraja, by response code.",
+	}, []string{"response_code"})
+
+	b2cResultTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mpesa_b2c_result_total",
+		Help: "Number of B2C refund results received, by result code.",
+	}, []string{"result_code"})
+
+	callbackLag = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "mpesa_callback_lag_seconds",
+		Help:    "Time between a payment being initiated and its STK callback being processed.",
+		Buckets: []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600},
+	})
+)
+
+func recordSTKPush(responseCode string, err error) {
+	if err != nil {
+		responseCode = stkPushErrorCode
+	}
+	stkPushTotal.WithLabelValues(responseCode).Inc()
+}
+
+func recordSTKCallback(resultCode int, paymentCreatedAt string) {
+	stkCallbackTotal.WithLabelValues(strconv.Itoa(resultCode)).Inc()
+
+	createdAt, err := time.Parse(time.RFC3339, paymentCreatedAt)
+	if err != nil {
+		return
+	}
+	callbackLag.Observe(time.Since(createdAt).Seconds())
+}
+
+func recordB2C(responseCode string, err error) {
+	if err != nil {
+		responseCode = stkPushErrorCode
+	}
+	b2cTotal.WithLabelValues(responseCode).Inc()
+}
+
+func recordB2CResult(resultCode int) {
+	b2cResultTotal.WithLabelValues(strconv.Itoa(resultCode)).Inc()
+}
@@ -1,39 +1,46 @@
 package mpesa
 
 import (
-	"github.com/leta/order-management-system/payments/pkg/utils"
-	"net/http"
+	"context"
+	"fmt"
 
-	"github.com/jwambugu/mpesa-golang-sdk"
-)
+	"github.com/leta/order-management-system/orders/pkg/tracing"
+	"github.com/leta/order-management-system/payments/internal/config"
 
-const (
-	MPESA_CONSUMER_KEY    = "MPESA_CONSUMER_KEY"    // #nosec G101 - This is an env variable name
-	MPESA_CONSUMER_SECRET = "MPESA_CONSUMER_SECRET" // #nosec G101 - This is an env variable name
-	ENVIRONMENT           = "ENVIRONMENT"
+	"github.com/jwambugu/mpesa-golang-sdk"
 )
 
 type Mpesa struct {
-	app *mpesa.Mpesa
+	app  *mpesa.Mpesa
+	conf config.Mpesa
 }
 
-func NewMpesaService() *Mpesa {
-
-	consumerKey := utils.MustGetEnv(MPESA_CONSUMER_KEY)
-	consumerSecret := utils.MustGetEnv(MPESA_CONSUMER_SECRET)
-
-	env := utils.MustGetEnv(ENVIRONMENT)
+// NewMpesaService creates a Daraja API client for the given environment.
+// Requests go to the production API only if environment is "prod".
+func NewMpesaService(conf config.Mpesa, environment string) *Mpesa {
 
 	var mpesaEnv mpesa.Environment
-	if env == "prod" {
+	if environment == "prod" {
 		mpesaEnv = mpesa.Production
 	} else {
 		mpesaEnv = mpesa.Sandbox
 	}
 
-	mpesaApp := mpesa.NewApp(http.DefaultClient, consumerKey, consumerSecret, mpesaEnv)
+	mpesaApp := mpesa.NewApp(
+		tracing.HTTPClient(), conf.ConsumerKey.Value(), conf.ConsumerSecret.Value(), mpesaEnv)
 
 	return &Mpesa{
-		app: mpesaApp,
+		app:  mpesaApp,
+		conf: conf,
 	}
 }
+
+// CheckCredentials reports whether the M-Pesa credentials needed to initiate
+// STK pushes are loaded.
+func (m *Mpesa) CheckCredentials(ctx context.Context) error {
+	if m.app == nil {
+		return fmt.Errorf("mpesa app is not initialized")
+	}
+
+	return m.conf.Validate()
+}
@@ -6,14 +6,11 @@ import (
 	"github.com/jwambugu/mpesa-golang-sdk"
 	"github.com/leta/order-management-system/payments/internal/repository"
 	"github.com/leta/order-management-system/payments/internal/service"
-	"github.com/leta/order-management-system/payments/pkg/utils"
 
+	"github.com/leta/order-management-system/orders/pkg/audit"
 	orders "github.com/leta/order-management-system/orders/pkg/client"
-)
-
-const (
-	MPESA_BUSINESS_SHORT_CODE = "MPESA_BUSINESS_SHORT_CODE" // #nosec G101 - This is an env variable name
-	MPESA_PASSKEY             = "MPESA_PASSKEY"             // #nosec G101 - This is an env variable name
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/tracing"
 )
 
 var _ service.PaymentsService = (*PaymentsService)(nil)
@@ -28,6 +25,7 @@ func NewPaymentsService(
 	mpesa *Mpesa, orderClient orders.OrdersClient, db repository.PaymentsRepository) *PaymentsService {
 	return &PaymentsService{
 		mpesa:        mpesa,
+		db:           db,
 		ordersClient: orderClient,
 	}
 }
@@ -38,29 +36,39 @@ func (s *PaymentsService) CheckPreconditions() {
 	}
 }
 
-func (s *PaymentsService) ProcessPayment(ctx context.Context, payment *service.Payment) (*service.PaymentResponse, error) {
+func (s *PaymentsService) ProcessPayment(
+	ctx context.Context, payment *service.Payment) (_ *service.PaymentResponse, err error) {
 	s.CheckPreconditions()
 
-	// stored in an environemnt variable for now:- assumption is that the system handles orders for a single business
-	businessShortCode, err := utils.StringToUint(utils.MustGetEnv(MPESA_BUSINESS_SHORT_CODE))
-	if err != nil {
-		return nil, fmt.Errorf("failed to convert business short code to uint: %v", err)
-	}
+	ctx, span := tracing.Start(ctx, "PaymentsService.ProcessPayment")
+	defer func() { tracing.End(span, err) }()
 
-	passKey := utils.MustGetEnv(MPESA_PASSKEY)
+	// assumption is that the system handles orders for a single business
+	businessShortCode := s.mpesa.conf.BusinessShortCode
+	passKey := s.mpesa.conf.PassKey.Value()
 
 	_, err = s.ordersClient.UpdateOrderStatus(ctx, &orders.UpdateOrderStatusRequest{
-		Id:     "1",
+		Id:     payment.OrderId,
 		Status: orders.OrderStatusPending,
 	})
 	if err != nil {
 		return nil, service.Errorf(service.INTERNAL_ERROR, "failed to update orders status: %v", err)
 	}
 
+	if payment.Amount.Currency != "KES" {
+		return nil, service.Errorf(service.INVALID_ERROR, "M-Pesa only takes KES, got %s", payment.Amount.Currency)
+	}
+
+	// M-Pesa takes whole shillings; the customer pays any fraction in full.
+	amount, err := payment.Amount.Major(money.Up)
+	if err != nil || amount <= 0 {
+		return nil, service.Errorf(service.INVALID_ERROR, "invalid amount %s", payment.Amount)
+	}
+
 	stkPushRes, err := s.mpesa.app.STKPush(ctx, passKey, mpesa.STKPushRequest{
 		BusinessShortCode: businessShortCode,
 		TransactionType:   "CustomerBuyGoodsOnline",
-		Amount:            payment.Amount,
+		Amount:            uint(amount),
 		PartyA:            payment.PhoneNumber,
 		PartyB:            businessShortCode,
 		PhoneNumber:       uint64(payment.PhoneNumber),
@@ -69,16 +77,21 @@ func (s *PaymentsService) ProcessPayment(ctx context.Context, payment *service.P
 		TransactionDesc:   payment.Description,
 	})
 	if err != nil {
+		recordSTKPush("", err)
 		return nil, fmt.Errorf("failed to process payment: %v", MpesaErrorToInternalError(err))
 	}
 
+	recordSTKPush(stkPushRes.ResponseCode, nil)
+
 	_, err = s.db.CreatePayment(ctx, &repository.Payment{
 		Amount:            payment.Amount,
+		OrderID:           payment.OrderId,
 		Phone:             fmt.Sprint(payment.PhoneNumber),
 		Reference:         payment.Reference,
 		Description:       payment.Description,
 		MerchantRequestID: stkPushRes.MerchantRequestID,
 		Status:            repository.PaymentStatusPending,
+		TraceParent:       tracing.TraceParent(ctx),
 	})
 	if err != nil {
 		return nil, service.Errorf(service.INTERNAL_ERROR, "failed to store payment record: %v", err)
@@ -93,23 +106,27 @@ func (s *PaymentsService) ProcessPayment(ctx context.Context, payment *service.P
 
 }
 
-func (s *PaymentsService) HandleMpesaCallback(ctx context.Context, callback *service.PaymentCallback) error {
+func (s *PaymentsService) HandleMpesaCallback(ctx context.Context, callback *service.PaymentCallback) (err error) {
 	s.CheckPreconditions()
 
+	// The callback is sent by M-Pesa rather than on behalf of a user.
+	ctx = audit.WithActor(ctx, "system:mpesa-callback")
+	ctx = audit.WithRequestID(ctx, audit.NewRequestID())
+
 	payment, err := s.db.GetPaymentByMerchantRequestID(ctx, callback.MerchantRequestID)
 	if err != nil {
 		return service.Errorf(service.INTERNAL_ERROR, "failed to get payment: %v", err)
 	}
 
-	if callback.ResultCode != 0 {
-		_, err := s.ordersClient.UpdateOrderStatus(ctx, &orders.UpdateOrderStatusRequest{
-			Id:     payment.OrderID,
-			Status: orders.OrderStatusFailed,
-		})
-		if err != nil {
-			return service.Errorf(service.INTERNAL_ERROR, "failed to update orders status(Failed): %v", err)
-		}
+	// The callback arrives on a new request, continue the checkout's trace.
+	ctx, span := tracing.Resume(ctx, payment.TraceParent, "PaymentsService.HandleMpesaCallback")
+	defer func() { tracing.End(span, err) }()
 
+	defer recordSTKCallback(callback.ResultCode, payment.CreatedAt)
+
+	// The order is updated by the outbox relay, from the event recorded along
+	// with the payment status, so the two cannot disagree.
+	if callback.ResultCode != 0 {
 		err = s.db.UpdatePaymentStatus(ctx, payment.Id, repository.PaymentStatusFailed)
 		if err != nil {
 			return service.Errorf(service.INTERNAL_ERROR, "failed to update payment status(Failed): %v", err)
@@ -118,12 +135,9 @@ func (s *PaymentsService) HandleMpesaCallback(ctx context.Context, callback *ser
 		return service.Errorf(service.NOT_IMPLEMENTED_ERROR, "invalid request: %s", callback.ResultDesc)
 	}
 
-	_, err = s.ordersClient.UpdateOrderStatus(ctx, &orders.UpdateOrderStatusRequest{
-		Id:     payment.OrderID,
-		Status: orders.OrderStatusPaid,
-	})
+	err = s.db.UpdatePaymentStatus(ctx, payment.Id, repository.PaymentStatusPaid)
 	if err != nil {
-		return service.Errorf(service.INTERNAL_ERROR, "failed to update orders status(Paid): %v", err)
+		return service.Errorf(service.INTERNAL_ERROR, "failed to update payment status(Paid): %v", err)
 	}
 
 	return nil
@@ -0,0 +1,51 @@
+package mpesa_test
+
+import (
+	"context"
+	"testing"
+
+	orders "github.com/leta/order-management-system/orders/pkg/client"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/payments/internal/repository"
+	"github.com/leta/order-management-system/payments/internal/service"
+)
+
+// ordersClient records the status updates requested.
+type ordersClient struct {
+	updates []*orders.UpdateOrderStatusRequest
+}
+
+func (c *ordersClient) UpdateOrderStatus(
+	ctx context.Context, req *orders.UpdateOrderStatusRequest) (*orders.UpdateOrderStatusResponse, error) {
+	c.updates = append(c.updates, req)
+	return &orders.UpdateOrderStatusResponse{}, nil
+}
+
+func TestPaymentsService_ProcessPayment(t *testing.T) {
+	client := &ordersClient{}
+	repo := &paymentsRepository{}
+
+	s, _ := newPaymentsService(t, client, repo)
+
+	_, err := s.ProcessPayment(context.Background(), &service.Payment{
+		OrderId:     "order-1",
+		PhoneNumber: 254700000000,
+		Amount:      money.New(10000, "KES"),
+		CallbackURL: "https://example.com/payments/callback",
+	})
+	if err != nil {
+		t.Fatalf("ProcessPayment() error = %v", err)
+	}
+
+	if len(client.updates) != 1 {
+		t.Fatalf("requested %d status updates, want 1", len(client.updates))
+	}
+	if got := client.updates[0]; got.GetId() != "order-1" || got.GetStatus() != orders.OrderStatusPending {
+		t.Errorf("updated order %q to %v, want order-1 to %v", got.GetId(), got.GetStatus(), orders.OrderStatusPending)
+	}
+
+	if len(repo.payments) != 1 || repo.payments[0].OrderID != "order-1" ||
+		repo.payments[0].Status != repository.PaymentStatusPending {
+		t.Errorf("stored payments %+v, want a pending payment of order-1", repo.payments)
+	}
+}
@@ -0,0 +1,154 @@
+package mpesa
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/jwambugu/mpesa-golang-sdk"
+
+	"github.com/leta/order-management-system/orders/pkg/audit"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/orders/pkg/tracing"
+	"github.com/leta/order-management-system/payments/internal/repository"
+	"github.com/leta/order-management-system/payments/internal/service"
+)
+
+// RefundPayment sends refund.Amount, in whole shillings rounded down, to the
+// phone that paid for the order as a B2C payment. The refund is recorded
+// before M-Pesa is asked to pay it, so a retried request returns the recorded
+// refund rather than paying again. A refund that failed, because M-Pesa
+// rejected the request or reported that the payment did not go through, is
+// sent again.
+func (s *PaymentsService) RefundPayment(
+	ctx context.Context, refund *service.Refund) (_ *service.RefundResponse, err error) {
+	s.CheckPreconditions()
+
+	ctx, span := tracing.Start(ctx, "PaymentsService.RefundPayment")
+	defer func() { tracing.End(span, err) }()
+
+	if !s.mpesa.conf.RefundsEnabled() {
+		return nil, service.Errorf(service.NOT_IMPLEMENTED_ERROR, "refunds are not configured")
+	}
+
+	if refund.Id == "" {
+		return nil, service.Errorf(service.INVALID_ERROR, "refund id is required")
+	}
+
+	existing, err := s.db.GetRefundByID(ctx, refund.Id)
+	if err == nil && existing.Status != repository.RefundStatusFailed {
+		return refundResponse(existing), nil
+	} else if err != nil && service.ErrorCode(err) != service.NOT_FOUND_ERROR {
+		return nil, err
+	}
+
+	if refund.Amount.Currency != "KES" {
+		return nil, service.Errorf(service.INVALID_ERROR, "M-Pesa only takes KES, got %s", refund.Amount.Currency)
+	}
+
+	// M-Pesa pays whole shillings. Fractions are dropped rather than rounded
+	// up, so that partial refunds cannot add up to more than was charged.
+	amount, err := refund.Amount.Major(money.Down)
+	if err != nil || amount <= 0 {
+		return nil, service.Errorf(service.INVALID_ERROR, "invalid amount %s", refund.Amount)
+	}
+
+	payment, err := s.db.GetPaidPaymentByOrderID(ctx, refund.OrderId)
+	if err != nil {
+		return nil, err
+	}
+
+	phone, err := strconv.ParseUint(payment.Phone, 10, 64)
+	if err != nil {
+		return nil, service.Errorf(service.INTERNAL_ERROR,
+			"invalid phone number %q of payment %s", payment.Phone, payment.Id)
+	}
+
+	record := &repository.Refund{
+		Id:     refund.Id,
+		Amount: refund.Amount,
+		Reason: refund.Reason,
+	}
+	err = s.db.CreateRefund(ctx, record, payment)
+	if service.ErrorCode(err) == service.ALREADY_EXISTS_ERROR {
+		// Lost a race with a retry of the same refund.
+		existing, err := s.db.GetRefundByID(ctx, refund.Id)
+		if err != nil {
+			return nil, err
+		}
+		return refundResponse(existing), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	res, err := s.mpesa.app.B2C(ctx, s.mpesa.conf.InitiatorPassword.Value(), mpesa.B2CRequest{
+		InitiatorName:   s.mpesa.conf.InitiatorName,
+		CommandID:       "BusinessPayment",
+		Amount:          uint(amount),
+		PartyA:          s.mpesa.conf.BusinessShortCode,
+		PartyB:          phone,
+		Remarks:         "Refund of order " + refund.OrderId,
+		QueueTimeOutURL: s.mpesa.conf.RefundTimeoutURL,
+		ResultURL:       s.mpesa.conf.RefundResultURL,
+		Occasion:        refund.Reason,
+	})
+	if err != nil {
+		recordB2C("", err)
+
+		record.Status = repository.RefundStatusFailed
+		if err := s.db.UpdateRefund(ctx, record); err != nil {
+			return nil, err
+		}
+
+		return nil, MpesaErrorToInternalError(err)
+	}
+
+	recordB2C(res.ResponseCode, nil)
+
+	record.ConversationID = res.ConversationID
+	record.ResponseCode = res.ResponseCode
+	if err := s.db.UpdateRefund(ctx, record); err != nil {
+		return nil, err
+	}
+
+	return refundResponse(record), nil
+}
+
+// HandleMpesaRefundResult completes or fails the refund M-Pesa reports on.
+func (s *PaymentsService) HandleMpesaRefundResult(ctx context.Context, result *service.RefundCallback) (err error) {
+	s.CheckPreconditions()
+
+	ctx = audit.WithActor(ctx, "system:mpesa-callback")
+	ctx = audit.WithRequestID(ctx, audit.NewRequestID())
+
+	ctx, span := tracing.Start(ctx, "PaymentsService.HandleMpesaRefundResult")
+	defer func() { tracing.End(span, err) }()
+
+	recordB2CResult(result.ResultCode)
+
+	refund, err := s.db.GetRefundByConversationID(ctx, result.ConversationID)
+	if err != nil {
+		return err
+	}
+
+	if refund.Status != repository.RefundStatusPending {
+		return nil
+	}
+
+	if result.ResultCode != 0 {
+		refund.Status = repository.RefundStatusFailed
+	} else {
+		refund.Status = repository.RefundStatusCompleted
+		refund.TransactionID = result.TransactionID
+	}
+
+	return s.db.UpdateRefund(ctx, refund)
+}
+
+func refundResponse(refund *repository.Refund) *service.RefundResponse {
+	return &service.RefundResponse{
+		RefundID:       refund.Id,
+		ConversationID: refund.ConversationID,
+		ResponseCode:   refund.ResponseCode,
+		Status:         string(refund.Status),
+	}
+}
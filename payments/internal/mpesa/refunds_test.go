@@ -0,0 +1,166 @@
+package mpesa_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	orders "github.com/leta/order-management-system/orders/pkg/client"
+	"github.com/leta/order-management-system/orders/pkg/money"
+	"github.com/leta/order-management-system/payments/internal/config"
+	"github.com/leta/order-management-system/payments/internal/mpesa"
+	"github.com/leta/order-management-system/payments/internal/repository"
+	"github.com/leta/order-management-system/payments/internal/service"
+)
+
+// paymentsRepository holds a paid payment and the payments and refunds
+// created. Other methods are not used.
+type paymentsRepository struct {
+	repository.PaymentsRepository
+
+	payment  *repository.Payment
+	payments []*repository.Payment
+	refunds  map[string]*repository.Refund
+}
+
+func (r *paymentsRepository) CreatePayment(ctx context.Context, payment *repository.Payment) (string, error) {
+	r.payments = append(r.payments, payment)
+	return fmt.Sprintf("payment-%d", len(r.payments)), nil
+}
+
+func (r *paymentsRepository) GetPaidPaymentByOrderID(ctx context.Context, orderID string) (*repository.Payment, error) {
+	return r.payment, nil
+}
+
+func (r *paymentsRepository) GetRefundByID(ctx context.Context, refundID string) (*repository.Refund, error) {
+	refund, ok := r.refunds[refundID]
+	if !ok {
+		return nil, service.Errorf(service.NOT_FOUND_ERROR, "refund %s not found", refundID)
+	}
+	return refund, nil
+}
+
+func (r *paymentsRepository) CreateRefund(
+	ctx context.Context, refund *repository.Refund, payment *repository.Payment) error {
+	refund.PaymentID = payment.Id
+	refund.Status = repository.RefundStatusPending
+	r.refunds[refund.Id] = refund
+	return nil
+}
+
+func (r *paymentsRepository) UpdateRefund(ctx context.Context, refund *repository.Refund) error {
+	r.refunds[refund.Id] = refund
+	return nil
+}
+
+// daraja answers the requests of the M-Pesa client, recording the amounts of
+// the STK pushes and B2C payments it is asked for.
+type daraja struct {
+	amounts []uint
+}
+
+func (d *daraja) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body any = map[string]string{"access_token": "token", "expires_in": "3599"}
+
+	if req.Method == http.MethodPost {
+		var request struct {
+			Amount uint `json:"Amount"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+			return nil, err
+		}
+		d.amounts = append(d.amounts, request.Amount)
+
+		body = map[string]string{
+			"ConversationID":    fmt.Sprintf("conversation-%d", len(d.amounts)),
+			"MerchantRequestID": fmt.Sprintf("merchant-request-%d", len(d.amounts)),
+			"ResponseCode":      "0",
+		}
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(string(b))),
+		Request:    req,
+	}, nil
+}
+
+// newPaymentsService returns a PaymentsService sending its M-Pesa requests to
+// the daraja returned.
+func newPaymentsService(
+	t *testing.T, ordersClient orders.OrdersClient, repo repository.PaymentsRepository) (*mpesa.PaymentsService, *daraja) {
+	t.Helper()
+
+	d := &daraja{}
+	transport := http.DefaultTransport
+	http.DefaultTransport = d
+	t.Cleanup(func() { http.DefaultTransport = transport })
+
+	conf := config.Mpesa{
+		ConsumerKey:       "key",
+		ConsumerSecret:    "secret",
+		PassKey:           "passkey",
+		BusinessShortCode: 174379,
+		InitiatorName:     "operator",
+		InitiatorPassword: "password",
+		RefundResultURL:   "https://example.com/refunds/result",
+		RefundTimeoutURL:  "https://example.com/refunds/timeout",
+	}
+
+	return mpesa.NewPaymentsService(mpesa.NewMpesaService(conf, "sandbox"), ordersClient, repo), d
+}
+
+func TestPaymentsService_RefundPayment_PartialRefunds(t *testing.T) {
+	// KES 100.01 was charged as 101 shillings.
+	payment := &repository.Payment{
+		Id:      "payment-1",
+		Amount:  money.New(10001, "KES"),
+		OrderID: "order-1",
+		Phone:   "254700000000",
+		Status:  repository.PaymentStatusPaid,
+	}
+	repo := &paymentsRepository{payment: payment, refunds: make(map[string]*repository.Refund)}
+
+	s, d := newPaymentsService(t, nil, repo)
+
+	// Refunds adding up to exactly what was paid, each with a fraction of a
+	// shilling.
+	for i, amount := range []int64{3334, 3334, 3333} {
+		_, err := s.RefundPayment(context.Background(), &service.Refund{
+			Id:      fmt.Sprintf("refund-%d", i+1),
+			OrderId: "order-1",
+			Amount:  money.New(amount, "KES"),
+		})
+		if err != nil {
+			t.Fatalf("RefundPayment() error = %v", err)
+		}
+	}
+
+	want := []uint{33, 33, 33}
+	if fmt.Sprint(d.amounts) != fmt.Sprint(want) {
+		t.Errorf("payouts = %v, want %v", d.amounts, want)
+	}
+
+	charged, err := payment.Amount.Major(money.Up)
+	if err != nil {
+		t.Fatalf("Major() error = %v", err)
+	}
+
+	var paid uint
+	for _, payout := range d.amounts {
+		paid += payout
+	}
+	if int64(paid) > charged {
+		t.Errorf("refunded %d shillings, more than the %d charged", paid, charged)
+	}
+}
@@ -1,6 +1,10 @@
 package repository
 
-import "context"
+import (
+	"context"
+
+	"github.com/leta/order-management-system/orders/pkg/money"
+)
 
 type PaymentStatus string
 
@@ -12,7 +16,7 @@ const (
 
 type Payment struct {
 	Id                string
-	Amount            uint
+	Amount            money.Money
 	MerchantRequestID string
 	Status            PaymentStatus
 	OrderID           string
@@ -21,15 +25,61 @@ type Payment struct {
 	Description       string
 	CreatedAt         string
 	UpdatedAt         string
+
+	// W3C traceparent of the checkout that initiated the payment, used to
+	// continue the trace when the asynchronous STK callback arrives.
+	TraceParent string
 }
 
 func (p *Payment) Validate() error {
 	return nil
 }
 
+type RefundStatus string
+
+const (
+	RefundStatusPending   RefundStatus = "pending"
+	RefundStatusCompleted RefundStatus = "completed"
+	RefundStatusFailed    RefundStatus = "failed"
+)
+
+// Refund is money sent back to the phone that made a payment. Its id is
+// chosen by the caller, so that a refund requested twice is only paid once.
+type Refund struct {
+	Id        string
+	PaymentID string
+	OrderID   string
+	Amount    money.Money
+	Phone     string
+	Reason    string
+	Status    RefundStatus
+
+	// Set once the B2C request has been accepted by M-Pesa.
+	ConversationID string
+	ResponseCode   string
+
+	// M-Pesa transaction ID of a completed refund.
+	TransactionID string
+
+	CreatedAt string
+	UpdatedAt string
+}
+
 type PaymentsRepository interface {
 	CreatePayment(ctx context.Context, payment *Payment) (string, error)
 	GetPaymentByID(ctx context.Context, paymentID string) (*Payment, error)
 	GetPaymentByMerchantRequestID(ctx context.Context, merchantRequestID string) (*Payment, error)
 	UpdatePaymentStatus(ctx context.Context, paymentID string, status PaymentStatus) error
+
+	// GetPaidPaymentByOrderID returns the payment that paid for an order.
+	GetPaidPaymentByOrderID(ctx context.Context, orderID string) (*Payment, error)
+
+	// CreateRefund records a pending refund of payment. It fails with
+	// ALREADY_EXISTS if a refund with the same id exists and has not failed,
+	// and with INVALID if the refunds of the payment would add up to more
+	// than was paid.
+	CreateRefund(ctx context.Context, refund *Refund, payment *Payment) error
+	GetRefundByID(ctx context.Context, refundID string) (*Refund, error)
+	GetRefundByConversationID(ctx context.Context, conversationID string) (*Refund, error)
+	UpdateRefund(ctx context.Context, refund *Refund) error
 }
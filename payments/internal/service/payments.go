@@ -4,18 +4,24 @@ import (
 	"context"
 
 	"github.com/jwambugu/mpesa-golang-sdk"
+
+	"github.com/leta/order-management-system/orders/pkg/money"
 )
 
+// SERVICE_NAME names the payments service, e.g. in its configuration, traces,
+// audit log and outbox.
+const SERVICE_NAME = "payments"
+
 type Payment struct {
-	Id          string `json:"id"`
-	OrderId     string `json:"orderId"`
-	PhoneNumber uint   `json:"phoneNumber"`
-	Amount      uint   `json:"amount"`
-	Reference   string `json:"reference"`
-	Description string `json:"description"`
-	CallbackURL string `json:"callbackUrl"`
-	CreatedAt   string `json:"createdAt"`
-	UpdatedAt   string `json:"updatedAt"`
+	Id          string      `json:"id"`
+	OrderId     string      `json:"orderId"`
+	PhoneNumber uint        `json:"phoneNumber"`
+	Amount      money.Money `json:"amount"`
+	Reference   string      `json:"reference"`
+	Description string      `json:"description"`
+	CallbackURL string      `json:"callbackUrl"`
+	CreatedAt   string      `json:"createdAt"`
+	UpdatedAt   string      `json:"updatedAt"`
 }
 
 type PaymentResponse struct {
@@ -29,7 +35,29 @@ type PaymentResponse struct {
 // I know I know, this is a bit of a hack but it works for now
 type PaymentCallback = mpesa.STKCallback
 
+// Refund asks for Amount of the payment of an order to be sent back. Id is
+// chosen by the caller and makes retries safe.
+type Refund struct {
+	Id      string      `json:"id"`
+	OrderId string      `json:"orderId"`
+	Amount  money.Money `json:"amount"`
+	Reason  string      `json:"reason"`
+}
+
+type RefundResponse struct {
+	RefundID       string `json:"refundId"`
+	ConversationID string `json:"conversationId"`
+	ResponseCode   string `json:"responseCode"`
+	Status         string `json:"status"`
+}
+
+// RefundCallback is the result of a B2C payment posted by M-Pesa.
+type RefundCallback = mpesa.B2CCallbackResult
+
 type PaymentsService interface {
 	ProcessPayment(ctx context.Context, payment *Payment) (*PaymentResponse, error)
 	HandleMpesaCallback(ctx context.Context, callback *PaymentCallback) error
+
+	RefundPayment(ctx context.Context, refund *Refund) (*RefundResponse, error)
+	HandleMpesaRefundResult(ctx context.Context, result *RefundCallback) error
 }
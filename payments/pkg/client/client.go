@@ -3,16 +3,17 @@ package client
 import (
 	"context"
 	"github.com/leta/order-management-system/payments/generated"
-	"log"
+
+	"github.com/leta/order-management-system/orders/pkg/grpcclient"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 var _ PaymentsClient = (*GrpcPaymentsClient)(nil)
 
 type PaymentsClient interface {
 	ProcessMpesaPayment(ctx context.Context, req *ProcessMpesaPaymentRequest) (*ProcessMpesaPaymentResponse, error)
+	RefundMpesaPayment(ctx context.Context, req *RefundMpesaPaymentRequest) (*RefundMpesaPaymentResponse, error)
 }
 
 type GrpcPaymentsClient struct {
@@ -30,12 +31,10 @@ func NewGrpcPaymentsClient(conn *grpc.ClientConn) *GrpcPaymentsClient {
 	}
 }
 
-func ConnectToPaymentService(address string) (*grpc.ClientConn, error) {
-
-	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
-	if err != nil {
-		log.Fatalf("Failed to connect: %v", err)
-	}
-
-	return conn, nil
+// ConnectToPaymentService dials the payments service without waiting for it to
+// be up. A retried ProcessMpesaPayment could prompt the customer twice, so no
+// method is retried. RefundMpesaPayment is safe to retry with the same refund
+// id.
+func ConnectToPaymentService(address string, opts grpcclient.Options) (*grpc.ClientConn, error) {
+	return grpcclient.Dial(address, opts)
 }
@@ -4,3 +4,9 @@ import "github.com/leta/order-management-system/payments/generated"
 
 type ProcessMpesaPaymentRequest = generated.MpesaPaymentRequest
 type ProcessMpesaPaymentResponse = generated.MpesaPaymentResponse
+
+// Money is an amount in the minor units of its currency, see orders/pkg/money.
+type Money = generated.Money
+
+type RefundMpesaPaymentRequest = generated.MpesaRefundRequest
+type RefundMpesaPaymentResponse = generated.MpesaRefundResponse
@@ -5,3 +5,7 @@ import "context"
 func (c *GrpcPaymentsClient) ProcessMpesaPayment(ctx context.Context, req *ProcessMpesaPaymentRequest) (*ProcessMpesaPaymentResponse, error) {
 	return c.client.ProcessMpesaPayment(ctx, req)
 }
+
+func (c *GrpcPaymentsClient) RefundMpesaPayment(ctx context.Context, req *RefundMpesaPaymentRequest) (*RefundMpesaPaymentResponse, error) {
+	return c.client.RefundMpesaPayment(ctx, req)
+}
@@ -1,13 +1,32 @@
 package models
 
 type PaymentModel struct {
-	ID          string `firestore:"id"`
-	Amount      uint   `firestore:"amount"`
-	Status      string `firestore:"status"`
-	OrderID     string `firestore:"orderId"`
-	Phone       string `firestore:"phone"`
-	Reference   string `firestore:"reference"`
-	Description string `firestore:"description"`
-	CreatedAt   string `firestore:"createdAt"`
-	UpdatedAt   string `firestore:"updatedAt"`
+	ID                string `firestore:"id"`
+	Amount            int64  `firestore:"amount"`
+	Currency          string `firestore:"currency"`
+	Status            string `firestore:"status"`
+	OrderID           string `firestore:"orderId"`
+	Phone             string `firestore:"phone"`
+	Reference         string `firestore:"reference"`
+	Description       string `firestore:"description"`
+	MerchantRequestID string `firestore:"merchantRequestId"`
+	CreatedAt         string `firestore:"createdAt"`
+	UpdatedAt         string `firestore:"updatedAt"`
+	TraceParent       string `firestore:"traceParent"`
+}
+
+type RefundModel struct {
+	ID             string `firestore:"id"`
+	PaymentID      string `firestore:"paymentId"`
+	OrderID        string `firestore:"orderId"`
+	Amount         int64  `firestore:"amount"`
+	Currency       string `firestore:"currency"`
+	Phone          string `firestore:"phone"`
+	Reason         string `firestore:"reason"`
+	Status         string `firestore:"status"`
+	ConversationID string `firestore:"conversationId"`
+	ResponseCode   string `firestore:"responseCode"`
+	TransactionID  string `firestore:"transactionId"`
+	CreatedAt      string `firestore:"createdAt"`
+	UpdatedAt      string `firestore:"updatedAt"`
 }